@@ -0,0 +1,439 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// maximum number of items of each kind read from the server for an advanced
+// search, so that very selective client-side matching can't scan the whole library
+const advancedSearchMaxScanned = 5000
+
+// fields supported in advanced search queries
+const (
+	searchFieldText   = "" // free text, matched against titles, albums and artists
+	searchFieldArtist = "artist"
+	searchFieldAlbum  = "album"
+	searchFieldTitle  = "title"
+	searchFieldGenre  = "genre"
+	searchFieldYear   = "year"
+	searchFieldRating = "rating"
+	searchFieldFav    = "fav"
+)
+
+// AdvancedSearchSyntax is a summary of the advanced search query language.
+const AdvancedSearchSyntax = `Words match the title, album or artist. Quote phrases: "dark side".
+artist:name  album:name  title:name  genre:name
+year:1990  year:1990..1999  year:>=2000
+rating:4  rating:>=4  rating:1..3
+fav:yes  fav:no
+Prefix any term with - to exclude it: -live  -genre:jazz`
+
+// SearchQuery is a parsed advanced search query.
+// All terms must match for an item to match the query.
+type SearchQuery struct {
+	terms []searchTerm
+}
+
+type searchTerm struct {
+	field  string
+	text   string // lowercased, for text fields
+	min    int    // inclusive range, for numeric fields
+	max    int
+	negate bool
+}
+
+// AdvancedSearchResults holds the results of an advanced search
+// of each kind of item.
+type AdvancedSearchResults struct {
+	Tracks  []*mediaprovider.Track
+	Albums  []*mediaprovider.Album
+	Artists []*mediaprovider.Artist
+}
+
+// ParseSearchQuery parses an advanced search query, such as
+// `artist:"pink floyd" year:1970..1979 rating:>=4 -live`.
+// See AdvancedSearchSyntax for the supported syntax.
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	q := &SearchQuery{}
+	for _, tok := range tokenizeSearchQuery(query) {
+		term := searchTerm{}
+		if len(tok) > 1 && tok[0] == '-' {
+			term.negate = true
+			tok = tok[1:]
+		}
+		value := tok
+		if field, v, ok := strings.Cut(tok, ":"); ok && isSearchField(strings.ToLower(field)) {
+			term.field = strings.ToLower(field)
+			value = v
+		}
+		value = strings.Trim(value, `"`)
+		if value == "" {
+			continue
+		}
+		switch term.field {
+		case searchFieldYear, searchFieldRating:
+			min, max, err := parseSearchRange(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", term.field, value, err)
+			}
+			term.min, term.max = min, max
+		case searchFieldFav:
+			switch strings.ToLower(value) {
+			case "yes", "true", "1":
+				term.min, term.max = 1, 1
+			case "no", "false", "0":
+				term.min, term.max = 0, 0
+			default:
+				return nil, fmt.Errorf("invalid fav %q: must be yes or no", value)
+			}
+		default:
+			term.text = strings.ToLower(value)
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// IsEmpty returns true if the query has no terms.
+func (q *SearchQuery) IsEmpty() bool {
+	return len(q.terms) == 0
+}
+
+// splits the query on whitespace, keeping quoted phrases (which may
+// follow a field name or negation, e.g. -album:"greatest hits") together
+func tokenizeSearchQuery(query string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuotes := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+func isSearchField(f string) bool {
+	switch f {
+	case searchFieldArtist, searchFieldAlbum, searchFieldTitle, searchFieldGenre,
+		searchFieldYear, searchFieldRating, searchFieldFav:
+		return true
+	}
+	return false
+}
+
+// parses N, >N, >=N, <N, <=N, N..M, N.., ..M into an inclusive range
+func parseSearchRange(s string) (int, int, error) {
+	min, max := math.MinInt, math.MaxInt
+	atoi := func(s string) (int, error) {
+		return strconv.Atoi(strings.TrimSpace(s))
+	}
+	var err error
+	switch {
+	case strings.Contains(s, ".."):
+		lo, hi, _ := strings.Cut(s, "..")
+		if lo != "" {
+			if min, err = atoi(lo); err != nil {
+				return 0, 0, err
+			}
+		}
+		if hi != "" {
+			if max, err = atoi(hi); err != nil {
+				return 0, 0, err
+			}
+		}
+	case strings.HasPrefix(s, ">="):
+		min, err = atoi(s[2:])
+	case strings.HasPrefix(s, "<="):
+		max, err = atoi(s[2:])
+	case strings.HasPrefix(s, ">"):
+		min, err = atoi(s[1:])
+		min++
+	case strings.HasPrefix(s, "<"):
+		max, err = atoi(s[1:])
+		max--
+	default:
+		min, err = atoi(strings.TrimPrefix(s, "="))
+		max = min
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("empty range")
+	}
+	return min, max, nil
+}
+
+// searchItem holds the searchable fields of a track, album or artist.
+// Fields that don't apply to the kind of item are nil.
+type searchItem struct {
+	title   *string
+	album   *string
+	artists []string
+	genres  []string
+	year    *int
+	rating  *int
+	fav     bool
+}
+
+func trackSearchItem(tr *mediaprovider.Track) searchItem {
+	return searchItem{title: &tr.Name, album: &tr.Album, artists: tr.ArtistNames,
+		genres: tr.Genres, year: &tr.Year, rating: &tr.Rating, fav: tr.Favorite}
+}
+
+func albumSearchItem(al *mediaprovider.Album) searchItem {
+	return searchItem{album: &al.Name, artists: al.ArtistNames,
+		genres: al.Genres, year: &al.Year, fav: al.Favorite}
+}
+
+func artistSearchItem(ar *mediaprovider.Artist) searchItem {
+	return searchItem{artists: []string{ar.Name}, genres: ar.Genres, fav: ar.Favorite}
+}
+
+func (q *SearchQuery) MatchesTrack(tr *mediaprovider.Track) bool {
+	return q.matches(trackSearchItem(tr))
+}
+
+func (q *SearchQuery) MatchesAlbum(al *mediaprovider.Album) bool {
+	return q.matches(albumSearchItem(al))
+}
+
+func (q *SearchQuery) MatchesArtist(ar *mediaprovider.Artist) bool {
+	return q.matches(artistSearchItem(ar))
+}
+
+func (q *SearchQuery) matches(item searchItem) bool {
+	for _, t := range q.terms {
+		if t.matches(item) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// returns whether the item matches the term, ignoring negation.
+// Terms on fields that don't apply to the kind of item never match.
+func (t searchTerm) matches(item searchItem) bool {
+	contains := func(s *string) bool {
+		return s != nil && strings.Contains(strings.ToLower(*s), t.text)
+	}
+	anyContains := func(ss []string) bool {
+		for _, s := range ss {
+			if strings.Contains(strings.ToLower(s), t.text) {
+				return true
+			}
+		}
+		return false
+	}
+	inRange := func(n *int) bool {
+		return n != nil && *n >= t.min && *n <= t.max
+	}
+	switch t.field {
+	case searchFieldArtist:
+		return anyContains(item.artists)
+	case searchFieldAlbum:
+		return contains(item.album)
+	case searchFieldTitle:
+		return contains(item.title)
+	case searchFieldGenre:
+		for _, g := range item.genres {
+			if strings.EqualFold(g, t.text) {
+				return true
+			}
+		}
+		return false
+	case searchFieldYear:
+		return inRange(item.year)
+	case searchFieldRating:
+		return inRange(item.rating)
+	case searchFieldFav:
+		return item.fav == (t.min == 1)
+	default:
+		return contains(item.title) || contains(item.album) || anyContains(item.artists)
+	}
+}
+
+// the text sent to the server's search, which is the longest positive
+// text term, since it is likely to be the most selective. The full query
+// is then matched client-side against the server's results.
+func (q *SearchQuery) serverQuery() string {
+	var best string
+	for _, t := range q.terms {
+		switch t.field {
+		case searchFieldText, searchFieldArtist, searchFieldAlbum, searchFieldTitle:
+			if !t.negate && len(t.text) > len(best) {
+				best = t.text
+			}
+		}
+	}
+	return best
+}
+
+// filter options that the server can apply while iterating or searching
+func (q *SearchQuery) tagFilterOptions() (minYear, maxYear int, genres, excludeGenres []string, excludeFav, excludeUnfav bool) {
+	for _, t := range q.terms {
+		switch t.field {
+		case searchFieldGenre:
+			if t.negate {
+				excludeGenres = append(excludeGenres, t.text)
+			} else {
+				genres = append(genres, t.text)
+			}
+		case searchFieldYear:
+			if !t.negate {
+				if t.min > 0 {
+					minYear = max(minYear, t.min)
+				}
+				if t.max < math.MaxInt {
+					maxYear = t.max
+				}
+			}
+		case searchFieldFav:
+			if (t.min == 1) != t.negate {
+				excludeUnfav = true
+			} else {
+				excludeFav = true
+			}
+		}
+	}
+	return
+}
+
+func (q *SearchQuery) trackFilter() mediaprovider.TrackFilter {
+	minYear, maxYear, genres, exclGenres, exclFav, exclUnfav := q.tagFilterOptions()
+	return mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{
+		MinYear: minYear, MaxYear: maxYear,
+		Genres: genres, GenreMatch: mediaprovider.TagMatchAll, ExcludeGenres: exclGenres,
+		ExcludeFavorited: exclFav, ExcludeUnfavorited: exclUnfav,
+	})
+}
+
+func (q *SearchQuery) albumFilter() mediaprovider.AlbumFilter {
+	minYear, maxYear, genres, exclGenres, exclFav, exclUnfav := q.tagFilterOptions()
+	return mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{
+		MinYear: minYear, MaxYear: maxYear,
+		Genres: genres, GenreMatch: mediaprovider.TagMatchAll, ExcludeGenres: exclGenres,
+		ExcludeFavorited: exclFav, ExcludeUnfavorited: exclUnfav,
+	})
+}
+
+func (q *SearchQuery) artistFilter() mediaprovider.ArtistFilter {
+	_, _, genres, exclGenres, exclFav, exclUnfav := q.tagFilterOptions()
+	return mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{
+		Genres: genres, GenreMatch: mediaprovider.TagMatchAll, ExcludeGenres: exclGenres,
+		ExcludeFavorited: exclFav, ExcludeUnfavorited: exclUnfav,
+	})
+}
+
+// appliesTo returns false if the query has a positive term on a field
+// that the kind of item doesn't have, so no items of that kind can match.
+func (q *SearchQuery) appliesTo(item searchItem) bool {
+	for _, t := range q.terms {
+		if t.negate {
+			continue
+		}
+		switch {
+		case t.field == searchFieldTitle && item.title == nil,
+			t.field == searchFieldAlbum && item.album == nil,
+			t.field == searchFieldYear && item.year == nil,
+			t.field == searchFieldRating && item.rating == nil:
+			return false
+		}
+	}
+	return true
+}
+
+// AdvancedSearch runs the query against the media provider, returning up to
+// maxResults items of each kind. The server's search (or library iteration,
+// if the query has no text terms) is combined with filtering on the client.
+func AdvancedSearch(mp mediaprovider.MediaProvider, q *SearchQuery, maxResults int) *AdvancedSearchResults {
+	results := &AdvancedSearchResults{}
+	if q.IsEmpty() {
+		return results
+	}
+	serverQuery := q.serverQuery()
+	var wg sync.WaitGroup
+
+	// the server's results are iterated unfiltered and the filters applied by
+	// collectMatches, so that advancedSearchMaxScanned bounds the items read
+	if q.appliesTo(trackSearchItem(&mediaprovider.Track{})) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			iter := mp.IterateTracks(serverQuery, mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+			results.Tracks = collectMatches(iter, q.trackFilter(), q.MatchesTrack, maxResults)
+		}()
+	}
+	if q.appliesTo(albumSearchItem(&mediaprovider.Album{})) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			noFilter := mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{})
+			var iter mediaprovider.AlbumIterator
+			if serverQuery == "" {
+				iter = mp.IterateAlbums("", noFilter)
+			} else {
+				iter = mp.SearchAlbums(serverQuery, noFilter)
+			}
+			results.Albums = collectMatches(iter, q.albumFilter(), q.MatchesAlbum, maxResults)
+		}()
+	}
+	if q.appliesTo(artistSearchItem(&mediaprovider.Artist{})) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// artists only have genres when the provider looks them up for
+			// a genre filter, so genre terms must be passed to the provider
+			filter := q.artistFilter()
+			opts := filter.Options()
+			genreFilter := mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{
+				Genres: opts.Genres, GenreMatch: opts.GenreMatch, ExcludeGenres: opts.ExcludeGenres,
+			})
+			var iter mediaprovider.ArtistIterator
+			if serverQuery == "" {
+				iter = mp.IterateArtists("", genreFilter)
+			} else {
+				iter = mp.SearchArtists(serverQuery, genreFilter)
+			}
+			results.Artists = collectMatches(iter, filter, q.MatchesArtist, maxResults)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// collectMatches returns up to maxResults items from iter matching both the filter
+// and the query, reading at most advancedSearchMaxScanned items from iter.
+func collectMatches[M, F any](iter mediaprovider.MediaIterator[M], filter mediaprovider.MediaFilter[M, F], matches func(*M) bool, maxResults int) []*M {
+	var results []*M
+	for scanned := 0; scanned < advancedSearchMaxScanned && len(results) < maxResults; scanned++ {
+		item := iter.Next()
+		if item == nil {
+			break
+		}
+		if filter.Matches(item) && matches(item) {
+			results = append(results, item)
+		}
+	}
+	return results
+}
@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_ParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []searchTerm
+		wantErr bool
+	}{
+		{query: "", want: nil},
+		{query: "  Dark   Side ", want: []searchTerm{{text: "dark"}, {text: "side"}}},
+		{query: `"Dark Side" moon`, want: []searchTerm{{text: "dark side"}, {text: "moon"}}},
+		{query: `artist:"Pink Floyd"`, want: []searchTerm{{field: "artist", text: "pink floyd"}}},
+		{query: `-album:"Greatest Hits"`, want: []searchTerm{{field: "album", text: "greatest hits", negate: true}}},
+		{query: "Genre:Jazz -genre:fusion", want: []searchTerm{{field: "genre", text: "jazz"}, {field: "genre", text: "fusion", negate: true}}},
+		{query: "-live", want: []searchTerm{{text: "live", negate: true}}},
+		{query: "-", want: []searchTerm{{text: "-"}}},
+		{query: "year:1970..1979", want: []searchTerm{{field: "year", min: 1970, max: 1979}}},
+		{query: "-rating:<=2", want: []searchTerm{{field: "rating", min: math.MinInt, max: 2, negate: true}}},
+		{query: "fav:yes fav:No", want: []searchTerm{{field: "fav", min: 1, max: 1}, {field: "fav"}}},
+		{query: "mood:happy", want: []searchTerm{{text: "mood:happy"}}},
+		{query: "artist: title:", want: nil},
+		{query: "year:soon", wantErr: true},
+		{query: "rating:5..1", wantErr: true},
+		{query: "fav:maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		q, err := ParseSearchQuery(tt.query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSearchQuery(%q): got error %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(q.terms, tt.want) {
+			t.Errorf("ParseSearchQuery(%q): got %+v, want %+v", tt.query, q.terms, tt.want)
+		}
+	}
+}
+
+func Test_ParseSearchRange(t *testing.T) {
+	tests := []struct {
+		s        string
+		min, max int
+		wantErr  bool
+	}{
+		{s: "1990", min: 1990, max: 1990},
+		{s: "=4", min: 4, max: 4},
+		{s: ">3", min: 4, max: math.MaxInt},
+		{s: ">=3", min: 3, max: math.MaxInt},
+		{s: "<3", min: math.MinInt, max: 2},
+		{s: "<=3", min: math.MinInt, max: 3},
+		{s: "1990..1999", min: 1990, max: 1999},
+		{s: "1990..", min: 1990, max: math.MaxInt},
+		{s: "..1999", min: math.MinInt, max: 1999},
+		{s: "3..3", min: 3, max: 3},
+		{s: "5..1", wantErr: true},
+		{s: "a..b", wantErr: true},
+		{s: ">=", wantErr: true},
+		{s: "1.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		min, max, err := parseSearchRange(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSearchRange(%q): got error %v, want error %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (min != tt.min || max != tt.max) {
+			t.Errorf("parseSearchRange(%q): got %d..%d, want %d..%d", tt.s, min, max, tt.min, tt.max)
+		}
+	}
+}
+
+func Test_SearchQueryMatches(t *testing.T) {
+	track := &mediaprovider.Track{Name: "Time", Album: "The Dark Side of the Moon",
+		ArtistNames: []string{"Pink Floyd"}, Genres: []string{"Rock"}, Year: 1973, Rating: 5}
+	album := &mediaprovider.Album{Name: "The Dark Side of the Moon", ArtistNames: []string{"Pink Floyd"}, Year: 1973}
+	tests := []struct {
+		query      string
+		track, alb bool
+	}{
+		{"floyd", true, true},
+		{"time", true, false},
+		{`album:"dark side" year:1970..1979`, true, true},
+		{"genre:rock -live", true, false},
+		{"rating:>=4", true, false},
+		{"-rating:>=4", false, true},
+		{"fav:yes", false, false},
+		{"-artist:floyd", false, false},
+	}
+	for _, tt := range tests {
+		q, err := ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseSearchQuery(%q): %v", tt.query, err)
+		}
+		if got := q.MatchesTrack(track); got != tt.track {
+			t.Errorf("%q: track match got %v, want %v", tt.query, got, tt.track)
+		}
+		if got := q.MatchesAlbum(album); got != tt.alb {
+			t.Errorf("%q: album match got %v, want %v", tt.query, got, tt.alb)
+		}
+	}
+}
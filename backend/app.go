@@ -18,7 +18,6 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/20after4/configdir"
-	"github.com/zalando/go-keyring"
 )
 
 const (
@@ -26,6 +25,7 @@ const (
 	sessionLockFile     = ".lock"
 	sessionActivateFile = ".activate"
 	savedQueueFile      = "saved_queue.json"
+	libraryIndexDir     = "library_index"
 )
 
 var (
@@ -34,13 +34,19 @@ var (
 )
 
 type App struct {
-	Config          *Config
-	ServerManager   *ServerManager
-	ImageManager    *ImageManager
-	PlaybackManager *PlaybackManager
-	LocalPlayer     *mpv.Player
-	UpdateChecker   UpdateChecker
-	MPRISHandler    *MPRISHandler
+	Config               *Config
+	CredentialStore      CredentialStore
+	prevCredentialStore  CredentialStore // to migrate credentials from, if any
+	ServerManager        *ServerManager
+	ImageManager         *ImageManager
+	PlaybackManager      *PlaybackManager
+	SmartPlaylistManager *SmartPlaylistManager
+	LibraryIndexManager  *LibraryIndexManager
+	ListeningHistory     *ListeningHistory
+	ScrobbleManager      *ScrobbleManager
+	LocalPlayer          *mpv.Player
+	UpdateChecker        UpdateChecker
+	MPRISHandler         *MPRISHandler
 
 	// UI callbacks to be set in main
 	OnReactivate func()
@@ -108,11 +114,22 @@ func StartupApp(appName, displayAppName, appVersionTag, configFile, latestReleas
 		return nil, err
 	}
 
-	a.ServerManager = NewServerManager(appName, a.Config)
+	a.initCredentialStore()
+	a.ServerManager = NewServerManager(a.Config, a.CredentialStore)
 	a.PlaybackManager = NewPlaybackManager(a.bgrndCtx, a.ServerManager, a.LocalPlayer, &a.Config.Scrobbling, &a.Config.Transcoding)
+	a.SmartPlaylistManager = NewSmartPlaylistManager(a.ServerManager, configdir.LocalConfig(a.appName, smartPlaylistsFile))
+	a.PlaybackManager.smartPlaylists = a.SmartPlaylistManager
+	a.ListeningHistory = NewListeningHistory(configdir.LocalConfig(a.appName, listeningHistoryFile))
+	a.PlaybackManager.SetListeningHistory(a.ListeningHistory)
+	a.ScrobbleManager = NewScrobbleManager(a.bgrndCtx, a.CredentialStore, &a.Config.Scrobbling, configdir.LocalConfig(a.appName, scrobbleQueueFile))
+	a.PlaybackManager.SetScrobbleManager(a.ScrobbleManager)
+	a.migrateCredentials()
+	a.LibraryIndexManager = NewLibraryIndexManager(a.bgrndCtx, a.ServerManager, &a.Config.LibraryIndex, configdir.LocalCache(a.appName, libraryIndexDir))
 	a.ImageManager = NewImageManager(a.bgrndCtx, a.ServerManager, configdir.LocalCache(a.appName))
 	a.Config.Application.MaxImageCacheSizeMB = clamp(a.Config.Application.MaxImageCacheSizeMB, 1, 500)
 	a.ImageManager.SetMaxOnDiskCacheSizeBytes(int64(a.Config.Application.MaxImageCacheSizeMB) * 1_048_576)
+	a.ImageManager.SetOfflineMode(a.Config.Application.OfflineImageCache)
+	a.ImageManager.SetLocalArtworkDir(a.Config.Application.LocalArtworkDir)
 	a.ServerManager.SetPrefetchAlbumCoverCallback(func(coverID string) {
 		_, _ = a.ImageManager.GetCoverThumbnail(coverID)
 	})
@@ -288,13 +305,95 @@ func (a *App) LoginToDefaultServer(string) error {
 	if serverCfg == nil {
 		return ErrNoServers
 	}
-	pass, err := keyring.Get(a.appName, serverCfg.ID.String())
+	pass, err := a.CredentialStore.Get(serverCfg.ID.String())
 	if err != nil {
-		return fmt.Errorf("error reading keyring credentials: %v", err)
+		return fmt.Errorf("error reading saved credentials: %v", err)
 	}
 	return a.ServerManager.ConnectToServer(serverCfg, pass)
 }
 
+// initCredentialStore creates the credential store selected by the config,
+// and the store the credentials were previously saved in, if it differs.
+func (a *App) initCredentialStore() {
+	cfg := &a.Config.Credentials
+	encryptedFile := configdir.LocalConfig(a.appName, encryptedCredentialsFile)
+	a.CredentialStore = NewCredentialStore(a.appName, cfg, encryptedFile)
+	kind := credentialStoreKind(a.CredentialStore)
+	if cfg.InUse == "" || cfg.InUse == kind {
+		cfg.InUse = kind
+		return
+	}
+	prev := newCredentialStoreOfKind(cfg.InUse, a.appName, cfg, encryptedFile)
+	if enc, ok := prev.(*EncryptedCredentialStore); ok && !enc.Exists() {
+		// no credentials were saved
+		cfg.InUse = kind
+		return
+	}
+	log.Printf("Credential store changed from %s to %s", cfg.InUse, kind)
+	a.prevCredentialStore = prev
+}
+
+// migrateCredentials moves the credentials from the previous credential store
+// to the current one, once both are unlocked.
+func (a *App) migrateCredentials() {
+	if a.prevCredentialStore == nil || a.lockedCredentialStore() != nil {
+		return
+	}
+	var keys []string
+	for _, s := range a.Config.Servers {
+		keys = append(keys, s.ID.String())
+	}
+	keys = append(keys, scrobbleCredentialKeys...)
+	if err := migrateCredentials(a.prevCredentialStore, a.CredentialStore, keys); err != nil {
+		log.Printf("error migrating credentials: %v", err)
+		return
+	}
+	log.Println("Migrated saved credentials")
+	a.prevCredentialStore = nil
+	a.Config.Credentials.InUse = credentialStoreKind(a.CredentialStore)
+	a.ScrobbleManager.LoadCredentials()
+}
+
+// lockedCredentialStore returns the encrypted credentials file
+// which must be unlocked before use, if any. This may be the file
+// the credentials are to be migrated from.
+func (a *App) lockedCredentialStore() *EncryptedCredentialStore {
+	for _, store := range []CredentialStore{a.CredentialStore, a.prevCredentialStore} {
+		if enc, ok := store.(*EncryptedCredentialStore); ok && enc.Locked() {
+			return enc
+		}
+	}
+	return nil
+}
+
+// CredentialsLocked returns true if the saved credentials are
+// in an encrypted file which has not yet been unlocked.
+func (a *App) CredentialsLocked() bool {
+	return a.lockedCredentialStore() != nil
+}
+
+// CredentialsFileExists returns true if the encrypted credentials file
+// to be unlocked exists, and false if it will be created when unlocked.
+func (a *App) CredentialsFileExists() bool {
+	enc := a.lockedCredentialStore()
+	return enc == nil || enc.Exists()
+}
+
+// UnlockCredentials unlocks the encrypted credentials file
+// with the master passphrase, or creates it if there is none.
+func (a *App) UnlockCredentials(passphrase string) error {
+	enc := a.lockedCredentialStore()
+	if enc == nil {
+		return nil
+	}
+	if err := enc.Unlock([]byte(passphrase)); err != nil {
+		return err
+	}
+	a.ScrobbleManager.LoadCredentials()
+	a.migrateCredentials()
+	return nil
+}
+
 func (a *App) DeleteServerCacheDir(serverID uuid.UUID) error {
 	path := path.Join(configdir.LocalCache(a.appName), serverID.String())
 	log.Printf("Deleting server cache dir: %s", path)
@@ -308,6 +407,7 @@ func (a *App) Shutdown() {
 		SavePlayQueue(a.ServerManager.ServerID.String(), a.PlaybackManager, configdir.LocalConfig(a.appName, savedQueueFile))
 	}
 	a.PlaybackManager.Stop() // will trigger scrobble check
+	a.ScrobbleManager.Shutdown()
 	a.Config.LocalPlayback.Volume = a.LocalPlayer.GetVolume()
 	a.cancel()
 	a.LocalPlayer.Destroy()
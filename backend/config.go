@@ -4,6 +4,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/dweymouth/supersonic/backend/scrobbler"
 	"github.com/google/uuid"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -15,12 +16,42 @@ const (
 	ServerTypeJellyfin ServerType = "Jellyfin"
 )
 
+// AuthMode is how the client authenticates to a server.
+type AuthMode string
+
+const (
+	// AuthModePassword authenticates with the username and password.
+	AuthModePassword AuthMode = "Password"
+	// AuthModeAPIKey authenticates with an OpenSubsonic API key, or a Jellyfin
+	// API key or access token (e.g. obtained with Quick Connect), which is
+	// stored in place of the password.
+	AuthModeAPIKey AuthMode = "APIKey"
+)
+
 type ServerConnection struct {
 	ServerType  ServerType
 	Hostname    string
 	AltHostname string
 	Username    string
 	LegacyAuth  bool
+	AuthMode    AuthMode
+	HTTP        HTTPOptions
+}
+
+// HTTPOptions are advanced options for the HTTP connection to a server,
+// e.g. for servers behind an authenticating reverse proxy.
+type HTTPOptions struct {
+	Headers        map[string]string // extra headers sent with every request
+	ClientCertFile string            // PEM certificate for mutual TLS
+	ClientKeyFile  string            // PEM private key of the client certificate
+	CACertFile     string            // PEM bundle of additional trusted CAs
+	ProxyURL       string            // http://, https:// or socks5:// proxy
+}
+
+// IsZero returns true if none of the options are set.
+func (h HTTPOptions) IsZero() bool {
+	return len(h.Headers) == 0 && h.ClientCertFile == "" && h.ClientKeyFile == "" &&
+		h.CACertFile == "" && h.ProxyURL == ""
 }
 
 type ServerConfig struct {
@@ -28,6 +59,23 @@ type ServerConfig struct {
 	ID       uuid.UUID
 	Nickname string
 	Default  bool
+
+	// MergeLibrary connects to the server alongside whichever
+	// other server is connected, merging their libraries.
+	MergeLibrary bool
+
+	PlaylistOrganization PlaylistOrganizationConfig
+}
+
+// PlaylistOrganizationConfig is the client-side organization of a server's playlists.
+type PlaylistOrganizationConfig struct {
+	Folders map[string]string // playlist ID -> folder name
+	Pinned  []PinnedPlaylist  // playlists pinned to the navigation sidebar, in order
+}
+
+type PinnedPlaylist struct {
+	ID   string
+	Name string
 }
 
 type AppConfig struct {
@@ -41,9 +89,12 @@ type AppConfig struct {
 	SettingsTab                 string
 	AllowMultiInstance          bool
 	MaxImageCacheSizeMB         int
+	OfflineImageCache           bool
+	LocalArtworkDir             string
 	SavePlayQueue               bool
 	DefaultPlaylistID           string
 	ShowTrackChangeNotification bool
+	WarnOnDuplicatePlaylistAdd  bool
 
 	// Experimental - may be removed in future
 	FontNormalTTF string
@@ -79,6 +130,7 @@ type PlaylistPageConfig struct {
 
 type PlaylistsPageConfig struct {
 	InitialView string
+	SortOrder   string
 }
 
 type TracksPageConfig struct {
@@ -103,6 +155,26 @@ type ScrobbleConfig struct {
 	Enabled              bool
 	ThresholdTimeSeconds int
 	ThresholdPercent     int
+	LastFM               LastFMConfig
+	ListenBrainz         ListenBrainzConfig
+}
+
+// LastFMConfig configures direct scrobbling to Last.fm.
+// The API secret and session key are stored in the keyring.
+type LastFMConfig struct {
+	Enabled  bool
+	Username string
+	APIKey   string
+	APIURL   string
+	AuthURL  string
+}
+
+// ListenBrainzConfig configures direct scrobbling to ListenBrainz.
+// The user token is stored in the keyring.
+type ListenBrainzConfig struct {
+	Enabled  bool
+	Username string
+	APIURL   string
 }
 
 type ReplayGainConfig struct {
@@ -114,12 +186,42 @@ type ReplayGainConfig struct {
 type ThemeConfig struct {
 	ThemeFile  string
 	Appearance string
+
+	// DynamicAccentColor derives the Primary, PageHeader and Hyperlink
+	// colors from the cover art of the playing track.
+	DynamicAccentColor bool
 }
 
 type TranscodingConfig struct {
 	ForceRawFile bool
 }
 
+type LibraryIndexConfig struct {
+	Enabled             bool
+	SyncIntervalMinutes int
+	MaxAgeHours         int // index is used only if synced within this time
+	FullResyncDays      int // incremental syncs can't detect deletions
+}
+
+const (
+	CredentialStoreAuto          = "Auto" // the OS keyring if available, else the encrypted file
+	CredentialStoreKeyring       = "Keyring"
+	CredentialStoreEncryptedFile = "EncryptedFile"
+)
+
+// CredentialsConfig selects where server passwords and other secrets are saved.
+type CredentialsConfig struct {
+	Store string
+
+	// If set, the encrypted file's key is derived from the contents
+	// of this file, rather than from a passphrase entered at startup.
+	KeyFile string
+
+	// The store the credentials were saved in (Keyring or EncryptedFile).
+	// If the store chosen at startup differs, the credentials are migrated.
+	InUse string
+}
+
 type Config struct {
 	Application      AppConfig
 	Servers          []*ServerConfig
@@ -136,6 +238,8 @@ type Config struct {
 	Scrobbling       ScrobbleConfig
 	ReplayGain       ReplayGainConfig
 	Transcoding      TranscodingConfig
+	LibraryIndex     LibraryIndexConfig
+	Credentials      CredentialsConfig
 	Theme            ThemeConfig
 }
 
@@ -157,6 +261,7 @@ func DefaultConfig(appVersionTag string) *Config {
 			UIScaleSize:                 "Normal",
 			SavePlayQueue:               false,
 			ShowTrackChangeNotification: false,
+			WarnOnDuplicatePlaylistAdd:  true,
 		},
 		AlbumPage: AlbumPageConfig{
 			TracklistColumns: []string{"Artist", "Time", "Plays", "Favorite", "Rating"},
@@ -180,6 +285,7 @@ func DefaultConfig(appVersionTag string) *Config {
 		},
 		PlaylistsPage: PlaylistsPageConfig{
 			InitialView: "List",
+			SortOrder:   PlaylistSortName,
 		},
 		NowPlayingConfig: NowPlayingPageConfig{
 			InitialView: "Play Queue",
@@ -201,6 +307,13 @@ func DefaultConfig(appVersionTag string) *Config {
 			Enabled:              true,
 			ThresholdTimeSeconds: 240,
 			ThresholdPercent:     50,
+			LastFM: LastFMConfig{
+				APIURL:  scrobbler.DefaultLastFMAPIURL,
+				AuthURL: scrobbler.DefaultLastFMAuthURL,
+			},
+			ListenBrainz: ListenBrainzConfig{
+				APIURL: scrobbler.DefaultListenBrainzAPIURL,
+			},
 		},
 		ReplayGain: ReplayGainConfig{
 			Mode:            ReplayGainNone,
@@ -210,6 +323,15 @@ func DefaultConfig(appVersionTag string) *Config {
 		Transcoding: TranscodingConfig{
 			ForceRawFile: false,
 		},
+		LibraryIndex: LibraryIndexConfig{
+			Enabled:             false,
+			SyncIntervalMinutes: 60,
+			MaxAgeHours:         24,
+			FullResyncDays:      7,
+		},
+		Credentials: CredentialsConfig{
+			Store: CredentialStoreAuto,
+		},
 		Theme: ThemeConfig{
 			Appearance: "Dark",
 		},
@@ -234,6 +356,9 @@ func ReadConfigFile(filepath, appVersionTag string) (*Config, error) {
 		if s.ServerType == "" {
 			s.ServerType = ServerTypeSubsonic
 		}
+		if s.AuthMode == "" {
+			s.AuthMode = AuthModePassword
+		}
 	}
 
 	return c, nil
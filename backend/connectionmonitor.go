@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// minimum time between re-probes of the hostnames after request failures
+	minReprobeInterval = 10 * time.Second
+
+	probeTimeout = 5 * time.Second
+)
+
+// connectionMonitor is the HTTP transport of a server's clients. Requests
+// are sent to whichever of the server's primary and alternate hostnames
+// was last found to be reachable; when a request fails with a network
+// error, both hostnames are re-probed, and if the other one is reachable,
+// subsequent requests are transparently redirected to it.
+type connectionMonitor struct {
+	base      http.RoundTripper
+	hostnames []*url.URL // primary, then alternate if set
+
+	// index into hostnames of the active hostname,
+	// or -1 to send requests to their URL unchanged
+	active atomic.Int32
+
+	// authorizeURL, if set, adds the authentication to
+	// URLs resolved for use outside of the server's clients
+	authorizeURL func(*url.URL) *url.URL
+
+	probing           atomic.Bool
+	mutex             sync.Mutex
+	lastProbe         time.Time
+	onHostnameChanged func()
+}
+
+var _ http.RoundTripper = (*connectionMonitor)(nil)
+
+func newConnectionMonitor(base http.RoundTripper, hostname, altHostname string) *connectionMonitor {
+	m := &connectionMonitor{base: base}
+	m.active.Store(-1)
+	for _, h := range []string{hostname, altHostname} {
+		if h == "" {
+			continue
+		}
+		if u, err := url.Parse(h); err == nil {
+			m.hostnames = append(m.hostnames, u)
+		} else {
+			log.Printf("error parsing server hostname: %v", err)
+		}
+	}
+	return m
+}
+
+// setOnHostnameChanged sets a callback invoked (on a background
+// goroutine) when requests are switched to the other hostname.
+func (m *connectionMonitor) setOnHostnameChanged(cb func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.onHostnameChanged = cb
+}
+
+// setActive sets whether the primary or alternate hostname is active.
+func (m *connectionMonitor) setActive(alternate bool) {
+	if alternate && len(m.hostnames) > 1 {
+		m.active.Store(1)
+	} else {
+		m.active.Store(0)
+	}
+}
+
+func (m *connectionMonitor) RoundTrip(req *http.Request) (*http.Response, error) {
+	if u, ok := m.rebase(req.URL); ok {
+		req = req.Clone(req.Context())
+		req.URL = u
+		req.Host = u.Host
+	}
+	resp, err := m.base.RoundTrip(req)
+	if err != nil && !isCanceled(req, err) && m.active.Load() >= 0 {
+		go m.reprobe()
+	}
+	return resp, err
+}
+
+// isCanceled returns true if the request failed because it was canceled by
+// the client, rather than due to a problem reaching the host. Requests which
+// timed out (exceeded their context deadline) count as host failures.
+func isCanceled(req *http.Request, err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// ResolveURL returns the URL, rewritten to the active
+// hostname if it was for one of the server's other hostnames,
+// and with the authentication added if needed.
+func (m *connectionMonitor) ResolveURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || !m.owns(u) {
+		return rawURL
+	}
+	rebased, ok := m.rebase(u)
+	if !ok && m.authorizeURL == nil {
+		return rawURL
+	}
+	if ok {
+		u = rebased
+	}
+	if m.authorizeURL != nil {
+		u = m.authorizeURL(u)
+	}
+	return u.String()
+}
+
+// rebase returns the URL rewritten to the active hostname,
+// and false if it needn't be rewritten.
+func (m *connectionMonitor) rebase(u *url.URL) (*url.URL, bool) {
+	active := int(m.active.Load())
+	if active < 0 {
+		return nil, false
+	}
+	for i, h := range m.hostnames {
+		basePath := strings.TrimSuffix(h.Path, "/")
+		if i == active || u.Scheme != h.Scheme || u.Host != h.Host || !hasPathPrefix(u.Path, basePath) {
+			continue
+		}
+		to := m.hostnames[active]
+		rebased := *u
+		rebased.Scheme = to.Scheme
+		rebased.Host = to.Host
+		rebased.Path = strings.TrimSuffix(to.Path, "/") + strings.TrimPrefix(u.Path, basePath)
+		rebased.RawPath = ""
+		return &rebased, true
+	}
+	return nil, false
+}
+
+// Owns returns true if the URL is for one of the server's hostnames.
+func (m *connectionMonitor) Owns(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && m.owns(u)
+}
+
+func (m *connectionMonitor) owns(u *url.URL) bool {
+	for _, h := range m.hostnames {
+		if u.Scheme == h.Scheme && u.Host == h.Host && hasPathPrefix(u.Path, strings.TrimSuffix(h.Path, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	return strings.HasPrefix(path, prefix) &&
+		(len(path) == len(prefix) || path[len(prefix)] == '/')
+}
+
+// reprobe probes the hostnames, if not done recently,
+// and switches to the other hostname if only it is reachable.
+func (m *connectionMonitor) reprobe() {
+	if len(m.hostnames) < 2 || !m.probing.CompareAndSwap(false, true) {
+		return
+	}
+	defer m.probing.Store(false)
+	m.mutex.Lock()
+	if time.Since(m.lastProbe) < minReprobeInterval {
+		m.mutex.Unlock()
+		return
+	}
+	m.lastProbe = time.Now()
+	m.mutex.Unlock()
+
+	idx := m.probe()
+	if idx < 0 {
+		log.Println("Server is unreachable at all hostnames")
+		return
+	}
+	if prev := m.active.Swap(int32(idx)); int(prev) != idx {
+		log.Printf("Switched server hostname to %s", m.hostnames[idx].Host)
+		m.mutex.Lock()
+		cb := m.onHostnameChanged
+		m.mutex.Unlock()
+		if cb != nil {
+			cb()
+		}
+	}
+}
+
+// probe returns the index of the first hostname found to be reachable,
+// giving the primary hostname a head start, or -1 if none are.
+func (m *connectionMonitor) probe() int {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	cli := &http.Client{Transport: m.base}
+	reachable := make(chan int, len(m.hostnames))
+	for i, h := range m.hostnames {
+		go func(i int, h *url.URL) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(i) * 333 * time.Millisecond):
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.String(), nil)
+			if err != nil {
+				return
+			}
+			// any response at all means the server is reachable
+			if resp, err := cli.Do(req); err == nil {
+				resp.Body.Close()
+				reachable <- i
+			}
+		}(i, h)
+	}
+	select {
+	case <-ctx.Done():
+		return -1
+	case i := <-reachable:
+		return i
+	}
+}
@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_ConnectionMonitorRebase(t *testing.T) {
+	m := newConnectionMonitor(http.DefaultTransport, "http://home.lan:4533/music/", "https://example.com/navidrome")
+	m.authorizeURL = func(u *url.URL) *url.URL {
+		q := u.Query()
+		q.Set("t", "token")
+		u.RawQuery = q.Encode()
+		return u
+	}
+	tests := []struct {
+		alternate bool
+		url       string
+		want      string
+	}{
+		{false, "http://home.lan:4533/music/rest/stream?id=1", "http://home.lan:4533/music/rest/stream?id=1&t=token"},
+		{true, "http://home.lan:4533/music/rest/stream?id=1", "https://example.com/navidrome/rest/stream?id=1&t=token"},
+		{false, "https://example.com/navidrome/rest/stream?id=1", "http://home.lan:4533/music/rest/stream?id=1&t=token"},
+		// path must match on a segment boundary
+		{true, "http://home.lan:4533/musicbox/rest/stream", "http://home.lan:4533/musicbox/rest/stream"},
+		// not one of the server's URLs
+		{true, "https://other.com/music/rest/stream", "https://other.com/music/rest/stream"},
+	}
+	for _, tt := range tests {
+		m.setActive(tt.alternate)
+		if got := m.ResolveURL(tt.url); got != tt.want {
+			t.Errorf("ResolveURL(%q) with alternate %v: got %q, want %q", tt.url, tt.alternate, got, tt.want)
+		}
+	}
+}
+
+func Test_ConnectionMonitorSwitchesHost(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	alt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer alt.Close()
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	primaryURL := primary.URL
+	primary.Close() // primary is now unreachable
+
+	m := newConnectionMonitor(http.DefaultTransport, primaryURL+"/music", alt.URL)
+	m.setActive(false)
+	changed := make(chan struct{})
+	m.setOnHostnameChanged(func() { close(changed) })
+	cli := &http.Client{Transport: m}
+
+	if _, err := cli.Get(primaryURL + "/music/rest/ping"); err == nil {
+		t.Fatal("expected request to unreachable primary to fail")
+	}
+	select {
+	case <-changed:
+	case <-time.After(probeTimeout + time.Second):
+		t.Fatal("hostname was not switched")
+	}
+	if m.active.Load() != 1 {
+		t.Errorf("got active hostname %d, want 1", m.active.Load())
+	}
+
+	// requests for the primary are now sent to the alternate
+	resp, err := cli.Get(primaryURL + "/music/rest/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	last := requests[len(requests)-1]
+	mu.Unlock()
+	if last != "/rest/ping" {
+		t.Errorf("got request for %q at alternate, want /rest/ping", last)
+	}
+}
+
+func Test_ConnectionMonitorIsCanceled(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancel2 := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel2()
+	tests := []struct {
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{context.Background(), errors.New("connection refused"), false},
+		{context.Background(), context.DeadlineExceeded, false},
+		{expiredCtx, context.DeadlineExceeded, false},
+		{context.Background(), context.Canceled, true},
+		{canceledCtx, errors.New("request canceled"), true},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+		if got := isCanceled(req, tt.err); got != tt.want {
+			t.Errorf("isCanceled(%v, ctx err %v): got %v, want %v", tt.err, tt.ctx.Err(), got, tt.want)
+		}
+	}
+}
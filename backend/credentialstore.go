@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	encryptedCredentialsFile = "credentials.enc"
+
+	// PBKDF2-HMAC-SHA256 iterations for new credential files
+	credentialKDFIterations = 600_000
+
+	// the range of iterations accepted when reading a credentials file,
+	// so a corrupt or tampered file can neither weaken the key derivation
+	// nor make unlocking hang
+	minCredentialKDFIterations = 100_000
+	maxCredentialKDFIterations = 10_000_000
+
+	credentialSaltLen = 16
+)
+
+var (
+	ErrCredentialNotFound    = errors.New("credential not found")
+	ErrCredentialStoreLocked = errors.New("credential store is locked")
+	ErrWrongPassphrase       = errors.New("wrong passphrase")
+)
+
+// CredentialStore saves server passwords and other secrets.
+type CredentialStore interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+// NewCredentialStore returns the credential store selected by the config.
+// In auto mode, this is the OS keyring if it is available, and otherwise an
+// encrypted file in the config directory, which must be unlocked with
+// the master passphrase, unless a key file is configured.
+func NewCredentialStore(appName string, cfg *CredentialsConfig, encryptedFilePath string) CredentialStore {
+	kind := cfg.Store
+	if kind != CredentialStoreKeyring && kind != CredentialStoreEncryptedFile {
+		kind = CredentialStoreKeyring
+		if !keyringAvailable(appName) {
+			log.Println("OS keyring is unavailable, using encrypted credentials file")
+			kind = CredentialStoreEncryptedFile
+		}
+	}
+	return newCredentialStoreOfKind(kind, appName, cfg, encryptedFilePath)
+}
+
+func newCredentialStoreOfKind(kind, appName string, cfg *CredentialsConfig, encryptedFilePath string) CredentialStore {
+	if kind == CredentialStoreKeyring {
+		return &keyringCredentialStore{service: appName}
+	}
+
+	store := NewEncryptedCredentialStore(encryptedFilePath)
+	if cfg.KeyFile != "" {
+		if key, err := os.ReadFile(cfg.KeyFile); err != nil {
+			log.Printf("error reading credentials key file: %v", err)
+		} else if err := store.Unlock(key); err != nil {
+			log.Printf("error unlocking credentials with key file: %v", err)
+		}
+	}
+	return store
+}
+
+// credentialStoreKind returns the kind of the credential store,
+// CredentialStoreKeyring or CredentialStoreEncryptedFile.
+func credentialStoreKind(store CredentialStore) string {
+	if _, ok := store.(*EncryptedCredentialStore); ok {
+		return CredentialStoreEncryptedFile
+	}
+	return CredentialStoreKeyring
+}
+
+// migrateCredentials moves the credentials with the given keys from one store
+// to the other. Credentials are deleted from the old store only once all have
+// been copied, so that none are lost if the migration fails part way.
+func migrateCredentials(from, to CredentialStore, keys []string) error {
+	var migrated []string
+	for _, key := range keys {
+		secret, err := from.Get(key)
+		if errors.Is(err, ErrCredentialNotFound) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error reading credential %s: %w", key, err)
+		}
+		if err := to.Set(key, secret); err != nil {
+			return fmt.Errorf("error saving credential %s: %w", key, err)
+		}
+		migrated = append(migrated, key)
+	}
+	for _, key := range migrated {
+		if err := from.Delete(key); err != nil {
+			log.Printf("error deleting migrated credential %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// keyringAvailable returns true if the OS keyring can be used.
+func keyringAvailable(service string) bool {
+	_, err := keyring.Get(service, "keyring-probe")
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+type keyringCredentialStore struct {
+	service string
+}
+
+func (k *keyringCredentialStore) Get(key string) (string, error) {
+	secret, err := keyring.Get(k.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrCredentialNotFound
+	}
+	return secret, err
+}
+
+func (k *keyringCredentialStore) Set(key, secret string) error {
+	return keyring.Set(k.service, key, secret)
+}
+
+func (k *keyringCredentialStore) Delete(key string) error {
+	err := keyring.Delete(k.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// EncryptedCredentialStore saves credentials in a file encrypted with
+// AES-GCM, with the key derived from the master passphrase by PBKDF2.
+// It must be unlocked with the passphrase before use.
+type EncryptedCredentialStore struct {
+	path string
+
+	mutex      sync.Mutex
+	key        []byte // nil while locked
+	salt       []byte
+	iterations int
+	creds      map[string]string
+}
+
+// the on-disk format of the encrypted credentials file
+type encryptedCredentialsData struct {
+	Version    int
+	Iterations int
+	Salt       []byte
+	Nonce      []byte
+	Data       []byte // encrypted JSON map of key to secret
+}
+
+func NewEncryptedCredentialStore(path string) *EncryptedCredentialStore {
+	return &EncryptedCredentialStore{path: path}
+}
+
+// Exists returns true if the credentials file has been created,
+// and so the store must be unlocked with the existing passphrase.
+func (e *EncryptedCredentialStore) Exists() bool {
+	_, err := os.Stat(e.path)
+	return err == nil
+}
+
+// Locked returns true if the store has not yet been unlocked.
+func (e *EncryptedCredentialStore) Locked() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.key == nil
+}
+
+// Unlock decrypts the credentials file with the passphrase,
+// or creates a new file encrypted with it if there is none.
+func (e *EncryptedCredentialStore) Unlock(passphrase []byte) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	b, err := os.ReadFile(e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		e.salt = make([]byte, credentialSaltLen)
+		if _, err := rand.Read(e.salt); err != nil {
+			return err
+		}
+		e.iterations = credentialKDFIterations
+		e.key = deriveCredentialKey(passphrase, e.salt, e.iterations)
+		e.creds = make(map[string]string)
+		return e.save()
+	} else if err != nil {
+		return err
+	}
+
+	var data encryptedCredentialsData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return fmt.Errorf("invalid credentials file: %w", err)
+	}
+	if data.Iterations < minCredentialKDFIterations || data.Iterations > maxCredentialKDFIterations {
+		return fmt.Errorf("invalid credentials file: unsupported iteration count %d", data.Iterations)
+	}
+	if len(data.Salt) < credentialSaltLen {
+		return errors.New("invalid credentials file: salt is too short")
+	}
+	key := deriveCredentialKey(passphrase, data.Salt, data.Iterations)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	if len(data.Nonce) != gcm.NonceSize() {
+		return errors.New("invalid credentials file: bad nonce")
+	}
+	plain, err := gcm.Open(nil, data.Nonce, data.Data, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	var creds map[string]string
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return fmt.Errorf("invalid credentials file: %w", err)
+	}
+	if creds == nil {
+		creds = make(map[string]string)
+	}
+	e.key, e.salt, e.iterations, e.creds = key, data.Salt, data.Iterations, creds
+	return nil
+}
+
+func (e *EncryptedCredentialStore) Get(key string) (string, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.key == nil {
+		return "", ErrCredentialStoreLocked
+	}
+	secret, ok := e.creds[key]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return secret, nil
+}
+
+func (e *EncryptedCredentialStore) Set(key, secret string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.key == nil {
+		return ErrCredentialStoreLocked
+	}
+	e.creds[key] = secret
+	return e.save()
+}
+
+func (e *EncryptedCredentialStore) Delete(key string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.key == nil {
+		return ErrCredentialStoreLocked
+	}
+	if _, ok := e.creds[key]; !ok {
+		return nil
+	}
+	delete(e.creds, key)
+	return e.save()
+}
+
+// save encrypts and writes the credentials. Must be called with the mutex held.
+func (e *EncryptedCredentialStore) save() error {
+	plain, err := json.Marshal(e.creds)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(e.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	b, err := json.Marshal(encryptedCredentialsData{
+		Version:    1,
+		Iterations: e.iterations,
+		Salt:       e.salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first so the credentials are never left half-written
+	if err := os.MkdirAll(filepath.Dir(e.path), 0700); err != nil {
+		return err
+	}
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.path)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveCredentialKey derives the AES-256 key from the passphrase.
+func deriveCredentialKey(passphrase, salt []byte, iterations int) []byte {
+	return pbkdf2.Key(passphrase, salt, iterations, 32, sha256.New)
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_EncryptedCredentialStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), encryptedCredentialsFile)
+	store := NewEncryptedCredentialStore(path)
+	if store.Exists() || !store.Locked() {
+		t.Fatal("new store should not exist and should be locked")
+	}
+	if _, err := store.Get("server"); !errors.Is(err, ErrCredentialStoreLocked) {
+		t.Errorf("Get on locked store: got err %v", err)
+	}
+	if err := store.Unlock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("server", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("other", "secret2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data encryptedCredentialsData
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Iterations != credentialKDFIterations || len(data.Salt) != credentialSaltLen {
+		t.Errorf("got iterations %d and salt length %d", data.Iterations, len(data.Salt))
+	}
+
+	reopened := NewEncryptedCredentialStore(path)
+	if err := reopened.Unlock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := reopened.Get("server"); err != nil || secret != "secret" {
+		t.Errorf("got secret %q, err %v", secret, err)
+	}
+	if _, err := reopened.Get("other"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("deleted credential: got err %v", err)
+	}
+}
+
+func Test_EncryptedCredentialStoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), encryptedCredentialsFile)
+	store := NewEncryptedCredentialStore(path)
+	if err := store.Unlock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	store.Set("server", "secret")
+
+	reopened := NewEncryptedCredentialStore(path)
+	if err := reopened.Unlock([]byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("got err %v, want ErrWrongPassphrase", err)
+	}
+	if !reopened.Locked() {
+		t.Error("store was unlocked with the wrong passphrase")
+	}
+}
+
+func Test_EncryptedCredentialStoreTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), encryptedCredentialsFile)
+	store := NewEncryptedCredentialStore(path)
+	if err := store.Unlock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	store.Set("server", "secret")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orig encryptedCredentialsData
+	if err := json.Unmarshal(b, &orig); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(d *encryptedCredentialsData)
+		wantErr error
+	}{
+		{"data", func(d *encryptedCredentialsData) { d.Data[0] ^= 1 }, ErrWrongPassphrase},
+		{"nonce", func(d *encryptedCredentialsData) { d.Nonce[0] ^= 1 }, ErrWrongPassphrase},
+		{"salt", func(d *encryptedCredentialsData) { d.Salt[0] ^= 1 }, ErrWrongPassphrase},
+		{"truncated nonce", func(d *encryptedCredentialsData) { d.Nonce = d.Nonce[:4] }, nil},
+		{"short salt", func(d *encryptedCredentialsData) { d.Salt = d.Salt[:4] }, nil},
+		{"too few iterations", func(d *encryptedCredentialsData) { d.Iterations = 1 }, nil},
+		{"too many iterations", func(d *encryptedCredentialsData) { d.Iterations = 1 << 40 }, nil},
+	}
+	for _, tt := range tests {
+		d := orig
+		d.Data = append([]byte(nil), orig.Data...)
+		d.Nonce = append([]byte(nil), orig.Nonce...)
+		d.Salt = append([]byte(nil), orig.Salt...)
+		tt.tamper(&d)
+		b, _ := json.Marshal(d)
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+		err := NewEncryptedCredentialStore(path).Unlock([]byte("passphrase"))
+		if err == nil {
+			t.Errorf("%s: tampered file was unlocked", tt.name)
+		} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got err %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func Test_MigrateCredentials(t *testing.T) {
+	dir := t.TempDir()
+	from := NewEncryptedCredentialStore(filepath.Join(dir, "from.enc"))
+	to := NewEncryptedCredentialStore(filepath.Join(dir, "to.enc"))
+	for _, s := range []*EncryptedCredentialStore{from, to} {
+		if err := s.Unlock([]byte("passphrase")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from.Set("server", "secret")
+	from.Set(lastFMSessionKeyringUser, "session")
+	from.Set("unlisted", "kept")
+
+	if err := migrateCredentials(from, to, append([]string{"server", "missing"}, scrobbleCredentialKeys...)); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"server": "secret", lastFMSessionKeyringUser: "session"} {
+		if got, err := to.Get(key); got != want || err != nil {
+			t.Errorf("migrated %s: got %q, err %v", key, got, err)
+		}
+		if _, err := from.Get(key); !errors.Is(err, ErrCredentialNotFound) {
+			t.Errorf("migrated %s was not deleted from the old store", key)
+		}
+	}
+	if _, err := to.Get("missing"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("missing credential: got err %v", err)
+	}
+	if got, _ := from.Get("unlisted"); got != "kept" {
+		t.Error("credential not being migrated was deleted")
+	}
+
+	// nothing is deleted if the migration fails
+	locked := NewEncryptedCredentialStore(filepath.Join(dir, "locked.enc"))
+	from.Set("server", "secret")
+	if err := migrateCredentials(from, locked, []string{"server"}); err == nil {
+		t.Error("expected error migrating to a locked store")
+	}
+	if got, _ := from.Get("server"); got != "secret" {
+		t.Error("credential was deleted after a failed migration")
+	}
+}
@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var ErrInvalidHTTPOptions = errors.New("invalid connection options")
+
+// newHTTPTransport returns the transport for requests to a server,
+// applying its HTTP options. The server's monitor determines which
+// requests are to the server, to which the extra headers are added.
+func newHTTPTransport(opts HTTPOptions, server *connectionMonitor) (http.RoundTripper, error) {
+	if opts.IsZero() {
+		return http.DefaultTransport, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.ClientCertFile != "" || opts.CACertFile != "" {
+		tlsConfig := &tls.Config{}
+		if opts.ClientCertFile != "" {
+			keyFile := opts.ClientKeyFile
+			if keyFile == "" {
+				// the key may be in the same PEM file as the certificate
+				keyFile = opts.ClientCertFile
+			}
+			cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("%w: client certificate: %v", ErrInvalidHTTPOptions, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if opts.CACertFile != "" {
+			pem, err := os.ReadFile(opts.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("%w: CA certificates: %v", ErrInvalidHTTPOptions, err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("%w: no CA certificates found in %s", ErrInvalidHTTPOptions, opts.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: invalid proxy URL %q", ErrInvalidHTTPOptions, opts.ProxyURL)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if len(opts.Headers) == 0 {
+		return t, nil
+	}
+	return &headerTransport{base: t, headers: opts.Headers, server: server}, nil
+}
+
+// headerTransport adds extra headers to every request to the server's
+// hostnames. The headers may hold secrets, such as access tokens for an
+// authenticating reverse proxy, so they aren't sent to other hosts,
+// such as those the server redirects to.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+	server  *connectionMonitor
+}
+
+func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !h.server.owns(req.URL) {
+		return h.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.base.RoundTrip(req)
+}
+
+// streamProxy is a local HTTP server through which mpv streams tracks
+// from a server whose HTTP options mpv can't apply itself, such as
+// extra headers, client certificates or a SOCKS proxy.
+// Requests are forwarded to the server through its transport,
+// and only for URLs of the server.
+type streamProxy struct {
+	monitor *connectionMonitor
+	client  *http.Client
+	server  *http.Server
+	addr    string
+	token   string // required path prefix, so other local processes can't use the proxy
+}
+
+func newStreamProxy(monitor *connectionMonitor) (*streamProxy, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		l.Close()
+		return nil, err
+	}
+	// no timeout, since streams may be long
+	p := &streamProxy{
+		monitor: monitor,
+		client:  &http.Client{Transport: monitor},
+		addr:    l.Addr().String(),
+		token:   hex.EncodeToString(b),
+	}
+	p.server = &http.Server{Handler: p}
+	go func() {
+		if err := p.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("stream proxy stopped: %v", err)
+		}
+	}()
+	return p, nil
+}
+
+// URL returns the URL at which the proxy serves the target URL.
+func (p *streamProxy) URL(target string) string {
+	return fmt.Sprintf("http://%s/%s/stream?url=%s", p.addr, p.token, url.QueryEscape(target))
+}
+
+func (p *streamProxy) Close() {
+	p.server.Shutdown(context.Background())
+}
+
+// headers forwarded from mpv to the server, to support seeking
+var streamProxyRequestHeaders = []string{"Range", "If-Range", "Accept"}
+
+func (p *streamProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/"+p.token+"/stream" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.Query().Get("url"), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !p.monitor.owns(req.URL) {
+		http.Error(w, "not a URL of the server", http.StatusForbidden)
+		return
+	}
+	for _, h := range streamProxyRequestHeaders {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
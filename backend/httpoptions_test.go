@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_HeaderTransportOnlyToServer(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Token")
+	}))
+	defer srv.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Token")
+	}))
+	defer other.Close()
+
+	monitor := newConnectionMonitor(nil, srv.URL+"/music", "")
+	transport, err := newHTTPTransport(HTTPOptions{Headers: map[string]string{"X-Token": "secret"}}, monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &http.Client{Transport: transport}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{srv.URL + "/music/rest/ping", "secret"},
+		{srv.URL + "/other/rest/ping", ""},
+		{other.URL + "/music/rest/ping", ""},
+	}
+	for _, tt := range tests {
+		gotHeader = ""
+		resp, err := cli.Get(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if gotHeader != tt.want {
+			t.Errorf("request to %s: got header %q, want %q", tt.url, gotHeader, tt.want)
+		}
+	}
+}
+
+func Test_StreamProxyOnlyToServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+
+	monitor := newConnectionMonitor(http.DefaultTransport, srv.URL+"/music", "")
+	proxy, err := newStreamProxy(monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{srv.URL + "/music/rest/stream?id=1", http.StatusOK},
+		{other.URL + "/music/rest/stream?id=1", http.StatusForbidden},
+		{"file:///etc/passwd", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(proxy.URL(tt.target))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("proxying %s: got status %d, want %d", tt.target, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -14,10 +14,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"github.com/20after4/configdir"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/aggregate"
 	"github.com/google/uuid"
 )
 
@@ -33,11 +35,25 @@ const (
 // The ImageManager is responsible for retrieving and serving images to the UI layer.
 // It maintains an in-memory cache of recently used images for immediate future access,
 // and a larger on-disc cache of images that is periodically re-requested from the server.
+// Each server's images are cached in its own directory.
 type ImageManager struct {
+	// OnCoverArtPrefetchProgress is called (on a background goroutine)
+	// as the cover art prefetch job progresses, and when it ends.
+	OnCoverArtPrefetchProgress func()
+
+	ctx            context.Context
 	s              *ServerManager
 	baseCacheDir   string
 	thumbnailCache ImageCache
 
+	// in offline mode, cached images never expire
+	// and are not pruned to the max cache size
+	offlineMode bool
+
+	localArtworkMutex   sync.Mutex
+	localArtworkDir     string
+	localArtworkMissing map[string]bool // cache keys of artwork not found locally
+
 	cachedFullSizeCover           image.Image
 	cachedFullSizeCoverID         string
 	cachedFullSizeCoverAccessedAt int64 // unixMillis
@@ -46,6 +62,10 @@ type ImageManager struct {
 	filesWrittenSinceLastPrune bool
 
 	serverFetchSema chan interface{}
+
+	prefetchMutex  sync.Mutex
+	prefetch       CoverArtPrefetchStatus
+	prefetchCancel context.CancelFunc
 }
 
 // NewImageManager returns a new ImageManager.
@@ -55,6 +75,7 @@ func NewImageManager(ctx context.Context, s *ServerManager, baseCacheDir string)
 		baseCacheDir = ""
 	}
 	i := &ImageManager{
+		ctx:          ctx,
 		s:            s,
 		baseCacheDir: baseCacheDir,
 		thumbnailCache: ImageCache{
@@ -64,8 +85,10 @@ func NewImageManager(ctx context.Context, s *ServerManager, baseCacheDir string)
 		},
 		maxOnDiskCacheSizeBytes: defaultDiskCacheSizeBytes,
 		serverFetchSema:         make(chan interface{}, maxConcurrentServerFetches),
+		localArtworkMissing:     make(map[string]bool),
 	}
 	s.OnLogout(func() {
+		i.CancelCoverArtPrefetch()
 		i.thumbnailCache.Clear()
 		i.clearFullSizeCover()
 	})
@@ -83,6 +106,13 @@ func (i *ImageManager) SetMaxOnDiskCacheSizeBytes(size int64) {
 	i.maxOnDiskCacheSizeBytes = size
 }
 
+// SetOfflineMode sets whether the on-disc image cache is kept for offline use.
+// In offline mode, cached images are never re-requested from the server
+// nor deleted to keep the cache under the maximum size.
+func (i *ImageManager) SetOfflineMode(offline bool) {
+	i.offlineMode = offline
+}
+
 // GetCoverThumbnailFromCache returns the cover thumbnail for the given ID if it exists
 // in the in-memory cache. Returns quickly, safe to call in UI threads.
 func (i *ImageManager) GetCoverThumbnailFromCache(coverID string) (image.Image, bool) {
@@ -181,34 +211,37 @@ func (i *ImageManager) FetchAndCacheArtistImage(artistID string, imgURL string)
 	if err != nil {
 		return nil, err
 	}
-	_ = i.writeJpeg(im, i.filePathForArtistImage(artistID))
+	if path := i.filePathForArtistImage(artistID); path != "" {
+		_ = i.writeJpeg(im, path)
+	}
 	return im, nil
 }
 
 // RefreshCachedArtistImageIfExpired re-fetches the artist image from the server if expired.
 func (i *ImageManager) RefreshCachedArtistImageIfExpired(artistID string, imgURL string) error {
 	stat, err := os.Stat(i.filePathForArtistImage(artistID))
-	if err == nil && time.Since(stat.ModTime()) > cachedImageValidTime {
+	if err == nil && !i.offlineMode && time.Since(stat.ModTime()) > cachedImageValidTime {
 		_, err = i.FetchAndCacheArtistImage(artistID, imgURL)
 	}
 	return err
 }
 
-func (i *ImageManager) ensureCoverCacheDir() string {
-	// if user logged out with pending fetches in progress,
-	// make sure we don't write to nil (00000000-*0) cache directory
-	if i.s.ServerID == uuid.Nil {
-		return ""
+// ensureItemCacheDir returns the given cache subdirectory of the server
+// the item belongs to, creating it if needed, and the server's own ID
+// for the item. The directory is empty if no server is connected.
+func (i *ImageManager) ensureItemCacheDir(itemID, subdir string) (dir, id string) {
+	serverID, id := aggregate.SplitID(itemID)
+	if serverID == "" {
+		// if user logged out with pending fetches in progress,
+		// make sure we don't write to nil (00000000-*0) cache directory
+		if i.s.ServerID == uuid.Nil {
+			return "", id
+		}
+		serverID = i.s.ServerID.String()
 	}
-	path := path.Join(i.baseCacheDir, i.s.ServerID.String(), "covers")
-	configdir.MakePath(path)
-	return path
-}
-
-func (i *ImageManager) ensureArtistCoverCacheDir() string {
-	path := path.Join(i.baseCacheDir, i.s.ServerID.String(), "artistimages")
-	configdir.MakePath(path)
-	return path
+	dir = path.Join(i.baseCacheDir, serverID, subdir)
+	configdir.MakePath(dir)
+	return dir, id
 }
 
 func (i *ImageManager) fetchRemoteArtistImage(url string) (image.Image, error) {
@@ -228,8 +261,7 @@ func (i *ImageManager) fetchRemoteArtistImage(url string) (image.Image, error) {
 
 func (i *ImageManager) fetchAndCacheCoverFromDiskOrServer(ctx context.Context, coverID string, ttl time.Duration, cb func(image.Image, error)) (image.Image, error) {
 	// on disc cache
-	if i.ensureCoverCacheDir() != "" {
-		path := i.filePathForCover(coverID)
+	if path := i.filePathForCover(coverID); path != "" {
 		if s, err := os.Stat(path); err == nil {
 			go i.checkRefreshLocalCover(s, coverID, ttl)
 			if img, ok := i.loadLocalImage(path); ok {
@@ -261,8 +293,8 @@ func (i *ImageManager) fetchAndCacheCoverFromServer(ctx context.Context, coverID
 		img, err := i.s.Server.GetCoverArt(coverID, coverArtThumbnailSize)
 		<-i.serverFetchSema // release
 		if err == nil {
-			if i.ensureCoverCacheDir() != "" {
-				_ = i.writeJpeg(img, i.filePathForCover(coverID))
+			if path := i.filePathForCover(coverID); path != "" {
+				_ = i.writeJpeg(img, path)
 			}
 			i.thumbnailCache.SetWithTTL(coverID, img, ttl)
 		}
@@ -301,17 +333,27 @@ func (i *ImageManager) getFullSizeCoverArtFromServer(ctx context.Context, coverI
 }
 
 func (i *ImageManager) checkRefreshLocalCover(stat os.FileInfo, coverID string, ttl time.Duration) {
-	if time.Since(stat.ModTime()) > cachedImageValidTime {
+	if !i.offlineMode && time.Since(stat.ModTime()) > cachedImageValidTime {
 		i.fetchAndCacheCoverFromServer(context.Background(), coverID, ttl, nil)
 	}
 }
 
+// filePathForCover returns the path of the cached cover,
+// or the empty string if no server is connected.
 func (i *ImageManager) filePathForCover(coverID string) string {
-	return filepath.Join(i.ensureCoverCacheDir(), fmt.Sprintf("%s.jpg", coverID))
+	dir, id := i.ensureItemCacheDir(coverID, "covers")
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.jpg", id))
 }
 
-func (i *ImageManager) filePathForArtistImage(id string) string {
-	return filepath.Join(i.ensureArtistCoverCacheDir(), fmt.Sprintf("%s.jpg", id))
+func (i *ImageManager) filePathForArtistImage(artistID string) string {
+	dir, id := i.ensureItemCacheDir(artistID, "artistimages")
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.jpg", id))
 }
 
 func (i *ImageManager) writeJpeg(img image.Image, path string) error {
@@ -350,7 +392,7 @@ func (i *ImageManager) clearFullSizeCover() {
 }
 
 func (im *ImageManager) pruneOnDiskCache() {
-	if !im.filesWrittenSinceLastPrune {
+	if !im.filesWrittenSinceLastPrune || im.offlineMode {
 		return // no new covers cached since last run, no need to walk dir
 	}
 
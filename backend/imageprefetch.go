@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+var imageCacheSubdirs = []string{"covers", "artistimages"}
+
+// CoverArtPrefetchStatus describes the progress of the cover art prefetch job.
+type CoverArtPrefetchStatus struct {
+	Running bool
+	Done    int // number of covers checked so far, including failures
+	Total   int // total number of covers, or 0 if still being determined
+	Failed  int
+}
+
+// CoverArtPrefetchStatus returns the status of the current or last cover art prefetch job.
+func (i *ImageManager) CoverArtPrefetchStatus() CoverArtPrefetchStatus {
+	i.prefetchMutex.Lock()
+	defer i.prefetchMutex.Unlock()
+	return i.prefetch
+}
+
+// StartCoverArtPrefetch starts a background job which downloads the cover art
+// of every album in the library to the on-disc cache, so that it is available offline.
+// Covers which are already cached are not re-downloaded.
+// It does nothing if the job is already running or no server is connected.
+func (i *ImageManager) StartCoverArtPrefetch() {
+	i.prefetchMutex.Lock()
+	mp := i.s.Server
+	if i.prefetch.Running || mp == nil {
+		i.prefetchMutex.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(i.ctx)
+	i.prefetch = CoverArtPrefetchStatus{Running: true}
+	i.prefetchCancel = cancel
+	i.prefetchMutex.Unlock()
+	i.notifyPrefetchProgress()
+
+	go func() {
+		defer cancel()
+		i.prefetchCoverArt(ctx, mp)
+		i.prefetchMutex.Lock()
+		i.prefetch.Running = false
+		i.prefetchCancel = nil
+		i.prefetchMutex.Unlock()
+		i.notifyPrefetchProgress()
+	}()
+}
+
+// CancelCoverArtPrefetch stops the cover art prefetch job, if running.
+func (i *ImageManager) CancelCoverArtPrefetch() {
+	i.prefetchMutex.Lock()
+	defer i.prefetchMutex.Unlock()
+	if i.prefetchCancel != nil {
+		i.prefetchCancel()
+	}
+}
+
+func (i *ImageManager) prefetchCoverArt(ctx context.Context, mp mediaprovider.MediaProvider) {
+	var coverIDs []string
+	seen := make(map[string]bool)
+	iter := mp.IterateAlbums("", mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+	for al := iter.Next(); al != nil; al = iter.Next() {
+		if ctx.Err() != nil {
+			return
+		}
+		if al.CoverArtID != "" && !seen[al.CoverArtID] {
+			seen[al.CoverArtID] = true
+			coverIDs = append(coverIDs, al.CoverArtID)
+		}
+	}
+	i.prefetchMutex.Lock()
+	i.prefetch.Total = len(coverIDs)
+	i.prefetchMutex.Unlock()
+	i.notifyPrefetchProgress()
+
+	// wait for the fetches in progress on every return,
+	// so none outlive the prefetch run
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, id := range coverIDs {
+		path := i.filePathForCover(id)
+		if path == "" {
+			return // logged out
+		}
+		if _, err := os.Stat(path); err == nil {
+			i.prefetchCoverDone(false)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case i.serverFetchSema <- struct{}{}: // acquire
+		}
+		wg.Add(1)
+		go func(id, path string) {
+			defer wg.Done()
+			img, err := mp.GetCoverArt(id, coverArtThumbnailSize)
+			<-i.serverFetchSema // release
+			if err == nil {
+				err = i.writeJpeg(img, path)
+			}
+			if err != nil {
+				log.Printf("failed to prefetch cover %s: %v", id, err)
+			}
+			i.prefetchCoverDone(err != nil)
+		}(id, path)
+	}
+}
+
+func (i *ImageManager) prefetchCoverDone(failed bool) {
+	i.prefetchMutex.Lock()
+	i.prefetch.Done++
+	if failed {
+		i.prefetch.Failed++
+	}
+	i.prefetchMutex.Unlock()
+	i.notifyPrefetchProgress()
+}
+
+func (i *ImageManager) notifyPrefetchProgress() {
+	if i.OnCoverArtPrefetchProgress != nil {
+		i.OnCoverArtPrefetchProgress()
+	}
+}
+
+// ServerCacheSize returns the size in bytes of the images cached on disc for the server.
+func (i *ImageManager) ServerCacheSize(serverID uuid.UUID) int64 {
+	var size int64
+	for _, subdir := range imageCacheSubdirs {
+		dir := path.Join(i.baseCacheDir, serverID.String(), subdir)
+		filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return size
+}
+
+// ClearServerCache deletes the images cached on disc for the server.
+func (i *ImageManager) ClearServerCache(serverID uuid.UUID) error {
+	for _, subdir := range imageCacheSubdirs {
+		dir := path.Join(i.baseCacheDir, serverID.String(), subdir)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	if serverID == i.s.ServerID {
+		i.thumbnailCache.Clear()
+		i.clearFullSizeCover()
+	}
+	return nil
+}
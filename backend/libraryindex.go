@@ -0,0 +1,370 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+const (
+	// the album sort order, supported by all providers, used to find newly added albums
+	indexAlbumSortOrder = "Recently Added"
+
+	// an incremental sync stops looking for new albums after
+	// encountering this many already indexed albums in a row
+	indexIncrementalStopAfterKnown = 50
+
+	// number of albums whose tracks are fetched concurrently while syncing
+	indexSyncConcurrency = 4
+)
+
+var errSyncCanceled = errors.New("sync canceled")
+
+// LibraryIndexManager keeps a local index of the connected server's
+// library in sync in the background, and attaches it to the server's
+// media provider so that browsing, filtering and search requests can be
+// answered locally. Each server's index is saved in the cache directory.
+type LibraryIndexManager struct {
+	// OnSyncStatusChanged is called (on a background goroutine)
+	// when a sync begins or ends.
+	OnSyncStatusChanged func()
+
+	ctx      context.Context
+	sm       *ServerManager
+	cfg      *LibraryIndexConfig
+	cacheDir string
+
+	mutex   sync.Mutex
+	index   *mediaprovider.LibraryIndex
+	cancel  context.CancelFunc
+	syncNow chan bool // true for a full sync
+	syncing bool
+	lastErr error
+}
+
+func NewLibraryIndexManager(ctx context.Context, sm *ServerManager, cfg *LibraryIndexConfig, cacheDir string) *LibraryIndexManager {
+	m := &LibraryIndexManager{ctx: ctx, sm: sm, cfg: cfg, cacheDir: cacheDir}
+	sm.OnServerConnected(m.start)
+	sm.OnLogout(m.stop)
+	return m
+}
+
+// SetEnabled enables or disables the library index for the connected server.
+func (m *LibraryIndexManager) SetEnabled(enabled bool) {
+	if enabled == m.cfg.Enabled {
+		return
+	}
+	m.cfg.Enabled = enabled
+	if m.sm.Server == nil {
+		return
+	}
+	if enabled {
+		m.start()
+	} else {
+		m.stop()
+		if indexer, ok := m.sm.Server.(mediaprovider.LibraryIndexer); ok {
+			indexer.SetLibraryIndex(nil)
+		}
+	}
+}
+
+// Resync requests an immediate full sync of the index.
+func (m *LibraryIndexManager) Resync() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.syncNow != nil {
+		select {
+		case m.syncNow <- true:
+		default: // a sync was already requested
+		}
+	}
+}
+
+// Stats returns the statistics of the indexed library,
+// and false if there is no index for the connected server.
+func (m *LibraryIndexManager) Stats() (mediaprovider.LibraryIndexStats, bool) {
+	m.mutex.Lock()
+	idx := m.index
+	m.mutex.Unlock()
+	if idx == nil {
+		return mediaprovider.LibraryIndexStats{}, false
+	}
+	return idx.Stats(), true
+}
+
+// SyncStatus returns whether a sync is in progress, and the error
+// from the last sync, if it failed.
+func (m *LibraryIndexManager) SyncStatus() (syncing bool, lastErr error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.syncing, m.lastErr
+}
+
+func (m *LibraryIndexManager) start() {
+	if !m.cfg.Enabled {
+		return
+	}
+	indexer, ok := m.sm.Server.(mediaprovider.LibraryIndexer)
+	if !ok {
+		return
+	}
+	mp := m.sm.newUnindexedProvider()
+	if mp == nil {
+		return
+	}
+	m.stop()
+	ctx, cancel := context.WithCancel(m.ctx)
+	idx := mediaprovider.NewLibraryIndex(time.Duration(max(m.cfg.MaxAgeHours, 1)) * time.Hour)
+	syncNow := make(chan bool, 1)
+
+	m.mutex.Lock()
+	m.index = idx
+	m.cancel = cancel
+	m.syncNow = syncNow
+	m.lastErr = nil
+	m.mutex.Unlock()
+
+	indexer.SetLibraryIndex(idx)
+	go m.run(ctx, idx, mp, m.indexFilePath(m.sm.ServerID), syncNow)
+}
+
+func (m *LibraryIndexManager) stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.index = nil
+	m.syncNow = nil
+}
+
+func (m *LibraryIndexManager) indexFilePath(serverID uuid.UUID) string {
+	return filepath.Join(m.cacheDir, serverID.String()+".gob")
+}
+
+func (m *LibraryIndexManager) run(ctx context.Context, idx *mediaprovider.LibraryIndex, mp mediaprovider.MediaProvider, path string, syncNow chan bool) {
+	if f, err := os.Open(path); err == nil {
+		if err := idx.Load(bufio.NewReader(f)); err != nil {
+			log.Printf("error reading library index: %v", err)
+		}
+		f.Close()
+	}
+
+	interval := time.Duration(max(m.cfg.SyncIntervalMinutes, 5)) * time.Minute
+	fullResyncAge := time.Duration(max(m.cfg.FullResyncDays, 1)) * 24 * time.Hour
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	forceFull := false
+	for {
+		full := forceFull || time.Since(idx.LastFullSync()) > fullResyncAge
+		m.setSyncStatus(true, nil)
+		err := m.sync(ctx, idx, mp, full)
+		if errors.Is(err, errSyncCanceled) {
+			return
+		}
+		if err != nil {
+			log.Printf("error syncing library index: %v", err)
+		} else if err = m.save(idx, path); err != nil {
+			log.Printf("error saving library index: %v", err)
+		}
+		m.setSyncStatus(false, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			forceFull = false
+		case forceFull = <-syncNow:
+		}
+	}
+}
+
+func (m *LibraryIndexManager) setSyncStatus(syncing bool, err error) {
+	m.mutex.Lock()
+	m.syncing = syncing
+	m.lastErr = err
+	m.mutex.Unlock()
+	if m.OnSyncStatusChanged != nil {
+		m.OnSyncStatusChanged()
+	}
+}
+
+// sync fetches the library from the server into the index. An incremental
+// sync adds only newly added albums and refreshes artists and favorites;
+// a full sync is needed to pick up removed and edited items. If any part
+// of the library fails to load, the sync fails and the index is unchanged.
+func (m *LibraryIndexManager) sync(ctx context.Context, idx *mediaprovider.LibraryIndex, mp mediaprovider.MediaProvider, full bool) error {
+	syncTime := time.Now()
+	if !slices.Contains(mp.AlbumSortOrders(), indexAlbumSortOrder) {
+		// can't find newly added albums
+		full = true
+	}
+
+	known := make(map[string]bool)
+	if !full {
+		for _, al := range idx.Albums() {
+			known[al.ID] = true
+		}
+	}
+	var newAlbums []*mediaprovider.Album
+	knownInARow := 0
+	iter := mp.IterateAlbums(indexAlbumSortOrder, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+	for al := iter.Next(); al != nil; al = iter.Next() {
+		if ctx.Err() != nil {
+			return errSyncCanceled
+		}
+		if known[al.ID] {
+			if knownInARow++; knownInARow >= indexIncrementalStopAfterKnown {
+				break
+			}
+			continue
+		}
+		knownInARow = 0
+		newAlbums = append(newAlbums, al)
+	}
+	if err := mediaprovider.IteratorErr(iter); err != nil {
+		// a partial album list would drop the rest of the library from a full sync
+		return fmt.Errorf("error fetching albums: %w", err)
+	}
+
+	newTracks, failed, err := fetchAlbumTracks(ctx, mp, newAlbums)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		// keep the current index rather than replacing it with an incomplete one,
+		// and since the albums would not be fetched again by the next incremental sync
+		return fmt.Errorf("failed to fetch the tracks of %d albums", failed)
+	}
+
+	var artists []*mediaprovider.Artist
+	artistIter := mp.IterateArtists("", mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+	for ar := artistIter.Next(); ar != nil; ar = artistIter.Next() {
+		artists = append(artists, ar)
+	}
+	if ctx.Err() != nil {
+		return errSyncCanceled
+	}
+	if err := mediaprovider.IteratorErr(artistIter); err != nil {
+		return fmt.Errorf("error fetching artists: %w", err)
+	}
+
+	albums, tracks := newAlbums, newTracks
+	if !full {
+		albums, tracks = mergeNewAlbums(idx.Albums(), idx.Tracks(), newAlbums, newTracks)
+	}
+	idx.Replace(albums, artists, tracks, syncTime, full)
+
+	if !full {
+		// favorites may have been changed from other clients
+		favs, err := mp.GetFavorites()
+		if err != nil {
+			return err
+		}
+		idx.ApplyFavorites(favs)
+	}
+	log.Printf("Synced library index: %d new albums, %d albums total (full sync: %t)",
+		len(newAlbums), len(albums), full)
+	return nil
+}
+
+// mergeNewAlbums adds the newly added albums and their tracks from an
+// incremental sync in front of the indexed ones, replacing any indexed
+// album with the same ID, so that albums stay most recently added first.
+func mergeNewAlbums(albums []*mediaprovider.Album, tracks []*mediaprovider.Track, newAlbums []*mediaprovider.Album, newTracks []*mediaprovider.Track) ([]*mediaprovider.Album, []*mediaprovider.Track) {
+	newIDs := make(map[string]bool, len(newAlbums))
+	for _, al := range newAlbums {
+		newIDs[al.ID] = true
+	}
+	mergedAlbums := slices.Clone(newAlbums)
+	for _, al := range albums {
+		if !newIDs[al.ID] {
+			mergedAlbums = append(mergedAlbums, al)
+		}
+	}
+	mergedTracks := slices.Clone(newTracks)
+	for _, tr := range tracks {
+		if !newIDs[tr.AlbumID] {
+			mergedTracks = append(mergedTracks, tr)
+		}
+	}
+	return mergedAlbums, mergedTracks
+}
+
+// fetchAlbumTracks fetches the tracks of the albums, in album order.
+// Albums whose tracks fail to load are logged and counted.
+func fetchAlbumTracks(ctx context.Context, mp mediaprovider.MediaProvider, albums []*mediaprovider.Album) ([]*mediaprovider.Track, int, error) {
+	albumTracks := make([][]*mediaprovider.Track, len(albums))
+	var failed atomic.Int32
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < indexSyncConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				al, err := mp.GetAlbum(albums[i].ID)
+				if err != nil {
+					log.Printf("Library index sync: failed to get album %s: %s", albums[i].ID, err.Error())
+					failed.Add(1)
+					continue
+				}
+				albumTracks[i] = al.Tracks
+			}
+		}()
+	}
+	for i := range albums {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, 0, errSyncCanceled
+	}
+	var tracks []*mediaprovider.Track
+	for _, trs := range albumTracks {
+		tracks = append(tracks, trs...)
+	}
+	return tracks, int(failed.Load()), nil
+}
+
+// save writes the index to a temporary file which then replaces the
+// index file, so an interrupted write can't corrupt the saved index.
+func (m *LibraryIndexManager) save(idx *mediaprovider.LibraryIndex, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = idx.Save(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
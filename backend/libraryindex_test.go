@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+)
+
+func Test_MergeNewAlbums(t *testing.T) {
+	albums := []*mediaprovider.Album{{ID: "b"}, {ID: "a"}}
+	tracks := []*mediaprovider.Track{{ID: "b1", AlbumID: "b"}, {ID: "a1", AlbumID: "a"}, {ID: "a2", AlbumID: "a"}}
+	// album a was re-added with one track removed
+	newAlbums := []*mediaprovider.Album{{ID: "c"}, {ID: "a"}}
+	newTracks := []*mediaprovider.Track{{ID: "c1", AlbumID: "c"}, {ID: "a1", AlbumID: "a"}}
+
+	mergedAlbums, mergedTracks := mergeNewAlbums(albums, tracks, newAlbums, newTracks)
+	var albumIDs, trackIDs string
+	for _, al := range mergedAlbums {
+		albumIDs += al.ID
+	}
+	for _, tr := range mergedTracks {
+		trackIDs += tr.ID + " "
+	}
+	if albumIDs != "cab" {
+		t.Errorf("got albums %q, want %q", albumIDs, "cab")
+	}
+	if trackIDs != "c1 a1 b1 " {
+		t.Errorf("got tracks %q, want %q", trackIDs, "c1 a1 b1 ")
+	}
+	if len(newAlbums) != 2 || len(newTracks) != 2 {
+		t.Error("new albums or tracks were modified")
+	}
+}
+
+// syncTestProvider serves a library of numAlbums albums, one track each,
+// most recently added (highest ID) first, failing the requests selected
+// by the fail fields.
+type syncTestProvider struct {
+	mediaprovider.MediaProvider
+
+	numAlbums      int
+	failAlbumsPage bool   // fail fetching the second page of albums
+	failAlbum      string // fail fetching this album's tracks
+	failArtists    bool
+}
+
+func (p *syncTestProvider) AlbumSortOrders() []string { return []string{indexAlbumSortOrder} }
+
+func (p *syncTestProvider) IterateAlbums(string, mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	return helpers.NewAlbumIterator(func(offset, limit int) ([]*mediaprovider.Album, error) {
+		if offset > 0 && p.failAlbumsPage {
+			return nil, errors.New("connection reset")
+		}
+		var albums []*mediaprovider.Album
+		for i := offset; i < min(offset+limit, p.numAlbums); i++ {
+			albums = append(albums, &mediaprovider.Album{ID: fmt.Sprint(p.numAlbums - 1 - i)})
+		}
+		return albums, nil
+	}, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}), func(string) {})
+}
+
+func (p *syncTestProvider) GetAlbum(id string) (*mediaprovider.AlbumWithTracks, error) {
+	if id == p.failAlbum {
+		return nil, errors.New("server error")
+	}
+	return &mediaprovider.AlbumWithTracks{
+		Album:  mediaprovider.Album{ID: id},
+		Tracks: []*mediaprovider.Track{{ID: "t" + id, AlbumID: id}},
+	}, nil
+}
+
+func (p *syncTestProvider) GetFavorites() (mediaprovider.Favorites, error) {
+	return mediaprovider.Favorites{}, nil
+}
+
+func (p *syncTestProvider) IterateArtists(string, mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
+	return helpers.NewArtistIterator(func(offset, limit int) ([]*mediaprovider.Artist, error) {
+		if p.failArtists {
+			return nil, errors.New("timeout")
+		}
+		if offset > 0 {
+			return nil, nil
+		}
+		return []*mediaprovider.Artist{{ID: "ar", Name: "Artist"}}, nil
+	}, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}), func(string) {})
+}
+
+func Test_LibraryIndexSyncKeepsIndexOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		mp   *syncTestProvider
+	}{
+		{"album list", &syncTestProvider{numAlbums: 150, failAlbumsPage: true}},
+		{"album tracks", &syncTestProvider{numAlbums: 150, failAlbum: "120"}},
+		{"artists", &syncTestProvider{numAlbums: 150, failArtists: true}},
+	}
+	m := &LibraryIndexManager{}
+	for _, tt := range tests {
+		idx := mediaprovider.NewLibraryIndex(time.Hour)
+		if err := m.sync(context.Background(), idx, &syncTestProvider{numAlbums: 100}, true); err != nil {
+			t.Fatalf("%s: initial sync: %v", tt.name, err)
+		}
+		lastFullSync := idx.LastFullSync()
+		// albums 100-149 are new to the incremental sync
+		for _, full := range []bool{true, false} {
+			if err := m.sync(context.Background(), idx, tt.mp, full); err == nil {
+				t.Errorf("%s: sync (full: %t) succeeded despite failure", tt.name, full)
+			}
+			if n := len(idx.Albums()); n != 100 {
+				t.Errorf("%s: index has %d albums after failed sync (full: %t), want 100", tt.name, n, full)
+			}
+			if !idx.LastFullSync().Equal(lastFullSync) {
+				t.Errorf("%s: failed sync updated the last full sync time", tt.name)
+			}
+		}
+	}
+}
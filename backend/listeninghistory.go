@@ -0,0 +1,318 @@
+package backend
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const listeningHistoryFile = "listening_history.jsonl"
+
+// PlayRecord is a record of one play of a track, whether or not it
+// was played long enough to be scrobbled.
+type PlayRecord struct {
+	ServerID     string    `json:"serverID"`
+	TrackID      string    `json:"trackID"`
+	Title        string    `json:"title"`
+	ArtistIDs    []string  `json:"artistIDs,omitempty"`
+	Artists      []string  `json:"artists,omitempty"`
+	AlbumID      string    `json:"albumID,omitempty"`
+	Album        string    `json:"album,omitempty"`
+	Genres       []string  `json:"genres,omitempty"`
+	Time         time.Time `json:"time"` // when the play ended
+	ListenedSecs int       `json:"listenedSecs"`
+	DurationSecs int       `json:"durationSecs"`
+
+	// Completed is true if the track was played past the scrobble
+	// threshold, and false if it was skipped before then.
+	Completed bool `json:"completed"`
+}
+
+// ListeningHistory is a local record of every track played, kept in an
+// append-only file in the config directory, from which listening
+// statistics are computed.
+type ListeningHistory struct {
+	filepath  string
+	fileMutex sync.Mutex
+
+	mutex   sync.RWMutex
+	records []PlayRecord // in order of time
+}
+
+func NewListeningHistory(filepath string) *ListeningHistory {
+	h := &ListeningHistory{filepath: filepath}
+	f, err := os.Open(filepath)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec PlayRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// skip a line that may have been partially written
+			continue
+		}
+		h.records = append(h.records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("error reading listening history: %v", err)
+	}
+	return h
+}
+
+// RecordPlay adds a play of the track to the history.
+func (h *ListeningHistory) RecordPlay(serverID string, track *mediaprovider.Track, listened time.Duration, completed bool) {
+	rec := PlayRecord{
+		ServerID:     serverID,
+		TrackID:      track.ID,
+		Title:        track.Name,
+		ArtistIDs:    track.ArtistIDs,
+		Artists:      track.ArtistNames,
+		AlbumID:      track.AlbumID,
+		Album:        track.Album,
+		Genres:       track.Genres,
+		Time:         time.Now(),
+		ListenedSecs: int(listened.Seconds()),
+		DurationSecs: track.Duration,
+		Completed:    completed,
+	}
+	h.mutex.Lock()
+	h.records = append(h.records, rec)
+	h.mutex.Unlock()
+	go h.appendToFile(rec)
+}
+
+func (h *ListeningHistory) appendToFile(rec PlayRecord) {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("error encoding play record: %v", err)
+		return
+	}
+	h.fileMutex.Lock()
+	defer h.fileMutex.Unlock()
+	f, err := os.OpenFile(h.filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("error writing listening history: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		log.Printf("error writing listening history: %v", err)
+	}
+}
+
+// Records returns the plays on the given server between from (inclusive)
+// and to (exclusive), in order of time. A zero time is unbounded.
+func (h *ListeningHistory) Records(serverID string, from, to time.Time) []PlayRecord {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var recs []PlayRecord
+	for _, r := range h.records {
+		if r.ServerID == serverID && !r.Time.Before(from) && (to.IsZero() || r.Time.Before(to)) {
+			recs = append(recs, r)
+		}
+	}
+	return recs
+}
+
+// Years returns the years, most recent first, in which there
+// were any plays on the given server.
+func (h *ListeningHistory) Years(serverID string) []int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var years []int
+	for _, r := range h.records {
+		if y := r.Time.Local().Year(); r.ServerID == serverID && !slices.Contains(years, y) {
+			years = append(years, y)
+		}
+	}
+	slices.SortFunc(years, func(a, b int) int { return b - a })
+	return years
+}
+
+// StatsEntry is an artist, album, track or genre ranked by its plays.
+type StatsEntry struct {
+	ID        string // empty for genres
+	Name      string
+	Secondary string // artist name, for albums and tracks
+	Plays     int    // completed plays
+	Listened  time.Duration
+}
+
+// DailyListening is the listening time on one (local) day.
+type DailyListening struct {
+	Date     time.Time // the local date, at midnight UTC
+	Listened time.Duration
+}
+
+// ListeningStats are the statistics of the plays in a period of time.
+type ListeningStats struct {
+	Plays    int // completed plays
+	Skips    int
+	Listened time.Duration
+
+	TopArtists []StatsEntry
+	TopAlbums  []StatsEntry
+	TopTracks  []StatsEntry
+	TopGenres  []StatsEntry
+
+	// listening time on each day of the period that had any listening
+	Daily []DailyListening
+
+	// streaks of consecutive days with listening. The current
+	// streak is counted over the whole history, up to today.
+	LongestStreak int
+	CurrentStreak int
+}
+
+// Stats computes the listening statistics of the given server for plays
+// between from (inclusive) and to (exclusive), with up to topN entries
+// in each ranking. A zero time is unbounded.
+func (h *ListeningHistory) Stats(serverID string, from, to time.Time, topN int) *ListeningStats {
+	recs := h.Records(serverID, from, to)
+	stats := &ListeningStats{}
+
+	artists := newStatsCounter()
+	albums := newStatsCounter()
+	tracks := newStatsCounter()
+	genres := newStatsCounter()
+	daily := make(map[time.Time]time.Duration)
+	for _, r := range recs {
+		listened := time.Duration(r.ListenedSecs) * time.Second
+		stats.Listened += listened
+		if r.Completed {
+			stats.Plays++
+		} else {
+			stats.Skips++
+		}
+		daily[dateOf(r.Time)] += listened
+
+		for i, name := range r.Artists {
+			id := name
+			if i < len(r.ArtistIDs) {
+				id = r.ArtistIDs[i]
+			}
+			artists.add(id, name, "", r.Completed, listened)
+		}
+		artistNames := strings.Join(r.Artists, ", ")
+		if r.AlbumID != "" {
+			albums.add(r.AlbumID, r.Album, artistNames, r.Completed, listened)
+		}
+		tracks.add(r.TrackID, r.Title, artistNames, r.Completed, listened)
+		for _, g := range r.Genres {
+			genres.add(strings.ToLower(g), g, "", r.Completed, listened)
+		}
+	}
+	stats.TopArtists = artists.top(topN)
+	stats.TopAlbums = albums.top(topN)
+	stats.TopTracks = tracks.top(topN)
+	stats.TopGenres = genres.top(topN)
+	for i := range stats.TopGenres {
+		stats.TopGenres[i].ID = ""
+	}
+
+	for day, listened := range daily {
+		stats.Daily = append(stats.Daily, DailyListening{Date: day, Listened: listened})
+	}
+	slices.SortFunc(stats.Daily, func(a, b DailyListening) int { return a.Date.Compare(b.Date) })
+	stats.LongestStreak = longestStreak(stats.Daily)
+	stats.CurrentStreak = h.currentStreak(serverID, time.Now())
+	return stats
+}
+
+// the number of consecutive days, ending today or yesterday, with any listening
+func (h *ListeningHistory) currentStreak(serverID string, now time.Time) int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	days := make(map[time.Time]bool)
+	for _, r := range h.records {
+		if r.ServerID == serverID {
+			days[dateOf(r.Time)] = true
+		}
+	}
+	day := dateOf(now)
+	if !days[day] {
+		// today's streak isn't broken until the day is over
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for days[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func longestStreak(daily []DailyListening) int {
+	longest, cur := 0, 0
+	for i, d := range daily {
+		if i > 0 && daily[i-1].Date.AddDate(0, 0, 1).Equal(d.Date) {
+			cur++
+		} else {
+			cur = 1
+		}
+		longest = max(longest, cur)
+	}
+	return longest
+}
+
+// dateOf returns the local date of t, at midnight UTC. Days are counted in
+// UTC, where every day starts at midnight and is 24 hours long, since local
+// days may be shorter or longer, or not start at midnight, due to DST changes.
+func dateOf(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+type statsCounter struct {
+	entries map[string]*StatsEntry
+}
+
+func newStatsCounter() *statsCounter {
+	return &statsCounter{entries: make(map[string]*StatsEntry)}
+}
+
+func (s *statsCounter) add(id, name, secondary string, completed bool, listened time.Duration) {
+	e, ok := s.entries[id]
+	if !ok {
+		e = &StatsEntry{ID: id, Name: name, Secondary: secondary}
+		s.entries[id] = e
+	}
+	if completed {
+		e.Plays++
+	}
+	e.Listened += listened
+}
+
+// top returns the n entries with the most plays, then the most listening time
+func (s *statsCounter) top(n int) []StatsEntry {
+	entries := make([]StatsEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if e.Plays > 0 || e.Listened > 0 {
+			entries = append(entries, *e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b StatsEntry) int {
+		if a.Plays != b.Plays {
+			return b.Plays - a.Plays
+		}
+		if a.Listened != b.Listened {
+			return cmp.Compare(b.Listened, a.Listened)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// sets the local time zone for the duration of the test
+func setLocalTimeZone(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = saved })
+	return loc
+}
+
+func newTestHistory(t *testing.T, recs ...PlayRecord) *ListeningHistory {
+	h := NewListeningHistory(filepath.Join(t.TempDir(), listeningHistoryFile))
+	h.records = recs
+	return h
+}
+
+func play(at time.Time, trackID string, listenedSecs int, completed bool) PlayRecord {
+	return PlayRecord{ServerID: "srv", TrackID: trackID, Title: trackID, Time: at, ListenedSecs: listenedSecs, Completed: completed}
+}
+
+func Test_ListeningStreaks(t *testing.T) {
+	loc := setLocalTimeZone(t, "America/New_York")
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, loc)
+	}
+	h := newTestHistory(t,
+		play(at(3, 1, 12, 0), "a", 60, true),
+		// 23:59 and 00:01 are on consecutive days
+		play(at(3, 1, 23, 59), "a", 60, true),
+		play(at(3, 2, 0, 1), "a", 60, true),
+		// spans the start of daylight saving time on March 10
+		play(at(3, 9, 22, 0), "a", 60, true),
+		play(at(3, 10, 0, 30), "a", 60, true),
+		play(at(3, 10, 23, 30), "a", 60, true),
+		play(at(3, 11, 8, 0), "a", 60, true),
+		// recorded in UTC, but 21:00 on March 12 in New York
+		play(time.Date(2024, 3, 13, 1, 0, 0, 0, time.UTC), "a", 60, true),
+		// a play on another server doesn't extend the streak
+		PlayRecord{ServerID: "other", TrackID: "b", Time: at(3, 13, 12, 0), ListenedSecs: 60},
+	)
+
+	stats := h.Stats("srv", time.Time{}, time.Time{}, 10)
+	if stats.LongestStreak != 4 {
+		t.Errorf("got longest streak %d, want 4", stats.LongestStreak)
+	}
+	if len(stats.Daily) != 6 {
+		t.Errorf("got %d days of listening, want 6", len(stats.Daily))
+	}
+
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{at(3, 12, 22, 0), 4},
+		// the streak isn't broken until the day after the last play is over
+		{at(3, 13, 23, 59), 4},
+		{at(3, 14, 0, 1), 0},
+		{at(3, 2, 9, 0), 2},
+		{at(3, 5, 9, 0), 0},
+	}
+	for _, tt := range tests {
+		if got := h.currentStreak("srv", tt.now); got != tt.want {
+			t.Errorf("currentStreak at %v: got %d, want %d", tt.now, got, tt.want)
+		}
+	}
+}
+
+func Test_ListeningStreakAcrossSkippedMidnight(t *testing.T) {
+	// on November 4, 2018, clocks in São Paulo went from 23:59:59 to 01:00,
+	// so that day didn't start at midnight
+	loc := setLocalTimeZone(t, "America/Sao_Paulo")
+	h := newTestHistory(t,
+		play(time.Date(2018, 11, 3, 20, 0, 0, 0, loc), "a", 60, true),
+		play(time.Date(2018, 11, 4, 12, 0, 0, 0, loc), "a", 60, true),
+		play(time.Date(2018, 11, 5, 12, 0, 0, 0, loc), "a", 60, true),
+	)
+	if got := h.Stats("srv", time.Time{}, time.Time{}, 10).LongestStreak; got != 3 {
+		t.Errorf("got longest streak %d, want 3", got)
+	}
+	if got := h.currentStreak("srv", time.Date(2018, 11, 5, 20, 0, 0, 0, loc)); got != 3 {
+		t.Errorf("got current streak %d, want 3", got)
+	}
+}
+
+func Test_ListeningStatsTopN(t *testing.T) {
+	setLocalTimeZone(t, "UTC")
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := func(trackID string, artists []string, album string, genres []string, listened int, completed bool) PlayRecord {
+		r := play(base, trackID, listened, completed)
+		r.Artists, r.ArtistIDs = artists, artists
+		r.AlbumID, r.Album = album, album
+		r.Genres = genres
+		return r
+	}
+	h := newTestHistory(t,
+		rec("t1", []string{"A"}, "X", []string{"Rock"}, 200, true),
+		rec("t1", []string{"A"}, "X", []string{"rock"}, 200, true),
+		rec("t2", []string{"A", "B"}, "Y", []string{"Pop"}, 100, true),
+		rec("t3", []string{"B"}, "Y", []string{"Pop"}, 350, true),
+		rec("t4", []string{"C"}, "", nil, 30, false),
+		rec("t5", []string{"D"}, "Z", nil, 10, false),
+		// outside the period
+		PlayRecord{ServerID: "srv", TrackID: "t6", Time: base.AddDate(0, 0, 2), ListenedSecs: 1000, Completed: true},
+	)
+
+	stats := h.Stats("srv", base, base.AddDate(0, 0, 1), 2)
+	if stats.Plays != 4 || stats.Skips != 2 || stats.Listened != 890*time.Second {
+		t.Errorf("got %d plays, %d skips, %v listened", stats.Plays, stats.Skips, stats.Listened)
+	}
+
+	names := func(entries []StatsEntry) string {
+		var s string
+		for _, e := range entries {
+			s += e.Name + " "
+		}
+		return s
+	}
+	tests := []struct {
+		name    string
+		entries []StatsEntry
+		want    string
+	}{
+		{"artists", stats.TopArtists, "A B "},
+		// X and Y both have 2 plays; Y has more listening time
+		{"albums", stats.TopAlbums, "Y X "},
+		{"tracks", stats.TopTracks, "t1 t3 "},
+		// genres are counted case-insensitively; Pop has more listening time
+		{"genres", stats.TopGenres, "Pop Rock "},
+	}
+	for _, tt := range tests {
+		if got := names(tt.entries); got != tt.want {
+			t.Errorf("top %s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if e := stats.TopTracks[0]; e.Plays != 2 || e.Listened != 400*time.Second || e.Secondary != "A" {
+		t.Errorf("got top track %+v", e)
+	}
+	if stats.TopGenres[0].ID != "" {
+		t.Error("genre entries should not have IDs")
+	}
+
+	// entries are ranked by listening time when no play was completed
+	skipped := h.Stats("srv", time.Time{}, time.Time{}, 10)
+	if got := skipped.TopArtists[len(skipped.TopArtists)-1].Name; got != "D" {
+		t.Errorf("got least played artist %q, want D", got)
+	}
+}
@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"image"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/util"
+)
+
+// File names (without extension) of the artwork looked up in the
+// local artwork directory, in order of preference. The directory is laid
+// out as <dir>/<artist>/artist.jpg, <dir>/<artist>/fanart.jpg and
+// <dir>/<artist>/<album>/folder.jpg, as used by many media centers.
+var (
+	localArtistImageNames = []string{"artist", "folder"}
+	localAlbumCoverNames  = []string{"folder", "cover"}
+	localBackdropNames    = []string{"fanart", "backdrop"}
+	localArtworkExts      = []string{".jpg", ".jpeg", ".png"}
+)
+
+var errNoArtwork = errors.New("no artwork")
+
+var fileNameReplacer = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// SetLocalArtworkDir sets the directory of local artwork which is consulted
+// before the server for artist images and album covers, or "" to disable it.
+func (i *ImageManager) SetLocalArtworkDir(dir string) {
+	i.localArtworkMutex.Lock()
+	defer i.localArtworkMutex.Unlock()
+	i.localArtworkDir = dir
+	i.localArtworkMissing = make(map[string]bool)
+	// drop artwork loaded from the previous directory
+	i.thumbnailCache.Clear()
+}
+
+// GetLocalArtistImage returns the image for the artist from the local artwork directory, if any.
+func (i *ImageManager) GetLocalArtistImage(artistName string) (image.Image, bool) {
+	return i.loadLocalArtwork(localArtistImageNames, artistName)
+}
+
+// GetArtworkThumbnailFromCache returns the artwork thumbnail for the album
+// (or artist, if album is empty) if it is in the in-memory cache, preferring
+// local artwork. Returns quickly, safe to call in UI threads.
+func (i *ImageManager) GetArtworkThumbnailFromCache(coverID, artist, album string) (image.Image, bool) {
+	if key, ok := i.localArtworkKey(artist, album); ok {
+		if img, ok := i.GetCoverThumbnailFromCache(key); ok {
+			return img, true
+		}
+		i.localArtworkMutex.Lock()
+		missing := i.localArtworkMissing[key]
+		i.localArtworkMutex.Unlock()
+		if !missing {
+			return nil, false // not yet looked up
+		}
+	}
+	if coverID == "" {
+		return nil, false
+	}
+	return i.GetCoverThumbnailFromCache(coverID)
+}
+
+// GetArtworkThumbnailAsync asynchronously fetches the artwork thumbnail for the album
+// (or artist, if album is empty) from the local artwork directory, falling back to the
+// cover art with the given ID. Cancellation is as for GetCoverThumbnailAsync.
+func (i *ImageManager) GetArtworkThumbnailAsync(coverID, artist, album string, cb func(image.Image, error)) context.CancelFunc {
+	key, ok := i.localArtworkKey(artist, album)
+	if !ok {
+		return i.GetCoverThumbnailAsync(coverID, cb)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if img, ok := i.getLocalArtworkThumbnail(key, artist, album); ok {
+			if ctx.Err() == nil {
+				cb(img, nil)
+			}
+		} else if coverID == "" {
+			if ctx.Err() == nil {
+				cb(nil, errNoArtwork)
+			}
+		} else if img, ok := i.GetCoverThumbnailFromCache(coverID); ok {
+			if ctx.Err() == nil {
+				cb(img, nil)
+			}
+		} else {
+			i.fetchAndCacheCoverFromDiskOrServer(ctx, coverID, i.thumbnailCache.DefaultTTL, cb)
+		}
+	}()
+	return cancel
+}
+
+// GetArtistBackdrop returns a blurred backdrop image for the artist page.
+// It uses the artist's fanart from the local artwork directory if present,
+// and otherwise the artist image. Blocks until the image is loaded.
+func (i *ImageManager) GetArtistBackdrop(artistID, artistName string) (image.Image, bool) {
+	if img, ok := i.loadLocalArtwork(localBackdropNames, artistName); ok {
+		return util.BlurImage(img), true
+	}
+	if img, ok := i.GetLocalArtistImage(artistName); ok {
+		return util.BlurImage(img), true
+	}
+	if img, ok := i.GetCachedArtistImage(artistID); ok {
+		return util.BlurImage(img), true
+	}
+	return nil, false
+}
+
+// GetCoverBackdrop returns a blurred backdrop image for the track with the
+// given cover and artist. It uses the artist's fanart from the local artwork
+// directory if present, and otherwise the cover. Blocks until the image is loaded.
+func (i *ImageManager) GetCoverBackdrop(coverID, artistName string) (image.Image, bool) {
+	if img, ok := i.loadLocalArtwork(localBackdropNames, artistName); ok {
+		return util.BlurImage(img), true
+	}
+	if coverID == "" {
+		return nil, false
+	}
+	if img, err := i.GetCoverThumbnail(coverID); err == nil {
+		return util.BlurImage(img), true
+	}
+	return nil, false
+}
+
+// localArtworkKey returns the in-memory cache key of the local artwork
+// for the artist or album, or false if no local artwork directory is set.
+func (i *ImageManager) localArtworkKey(artist, album string) (string, bool) {
+	i.localArtworkMutex.Lock()
+	dir := i.localArtworkDir
+	i.localArtworkMutex.Unlock()
+	if dir == "" || artist == "" {
+		return "", false
+	}
+	return "local:" + artist + "/" + album, true
+}
+
+func (i *ImageManager) getLocalArtworkThumbnail(key, artist, album string) (image.Image, bool) {
+	if img, ok := i.GetCoverThumbnailFromCache(key); ok {
+		return img, true
+	}
+	var img image.Image
+	var ok bool
+	if album == "" {
+		img, ok = i.GetLocalArtistImage(artist)
+	} else {
+		img, ok = i.loadLocalArtwork(localAlbumCoverNames, artist, album)
+	}
+	if !ok {
+		i.localArtworkMutex.Lock()
+		i.localArtworkMissing[key] = true
+		i.localArtworkMutex.Unlock()
+		return nil, false
+	}
+	img = util.ResizeImage(img, coverArtThumbnailSize)
+	i.thumbnailCache.Set(key, img)
+	return img, true
+}
+
+// loadLocalArtwork loads the first of the named artwork files
+// found in the subdirectory of the local artwork directory.
+func (i *ImageManager) loadLocalArtwork(names []string, subdirs ...string) (image.Image, bool) {
+	i.localArtworkMutex.Lock()
+	dir := i.localArtworkDir
+	i.localArtworkMutex.Unlock()
+	if dir == "" || len(subdirs) == 0 || subdirs[0] == "" {
+		return nil, false
+	}
+	for _, s := range subdirs {
+		dir = filepath.Join(dir, fileNameReplacer.Replace(s))
+	}
+	for _, name := range names {
+		for _, ext := range localArtworkExts {
+			path := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return i.loadLocalImage(path)
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,758 @@
+// Package aggregate implements a media provider which presents the
+// libraries of several servers, connected simultaneously, as one.
+package aggregate
+
+import (
+	"errors"
+	"image"
+	"io"
+	"math/rand"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/deluan/sanitize"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var (
+	ErrMixedServers = errors.New("the items are from different servers")
+	ErrNotSupported = errors.New("not supported by the item's server")
+)
+
+// Member is a server connected alongside the primary server.
+type Member struct {
+	ServerID string // the UUID string of the server's config
+	Provider mediaprovider.MediaProvider
+}
+
+type aggregateMediaProvider struct {
+	primary mediaprovider.MediaProvider
+	members []Member
+	byID    map[string]mediaprovider.MediaProvider
+}
+
+var (
+	_ mediaprovider.MediaProvider   = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.SupportsRating  = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.SupportsSharing = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.LyricsProvider  = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.LibraryIndexer  = (*aggregateMediaProvider)(nil)
+
+	_ mediaprovider.ComposerProvider     = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.JukeboxProvider      = (*aggregateMediaProvider)(nil)
+	_ mediaprovider.TrackScrobbleDecider = (*aggregateMediaProvider)(nil)
+)
+
+// NewAggregateMediaProvider returns a media provider for the merged libraries of
+// the primary server and the secondary servers. Requests for an item are sent to
+// the server the item came from, as identified by its ID (see SplitID).
+// Server-wide settings, such as the sort orders and scrobbling behavior,
+// are those of the primary server.
+func NewAggregateMediaProvider(primary mediaprovider.MediaProvider, secondaries []Member) mediaprovider.MediaProvider {
+	a := &aggregateMediaProvider{
+		primary: primary,
+		members: secondaries,
+		byID:    make(map[string]mediaprovider.MediaProvider, len(secondaries)),
+	}
+	for _, m := range secondaries {
+		a.byID[m.ServerID] = m.Provider
+	}
+	return a
+}
+
+// route returns the provider for the item ID, the ID prefix
+// of its server, and the server's own ID for the item.
+func (a *aggregateMediaProvider) route(id string) (mediaprovider.MediaProvider, string, string) {
+	serverID, itemID := SplitID(id)
+	if mp, ok := a.byID[serverID]; ok {
+		return mp, serverID, itemID
+	}
+	return a.primary, "", id
+}
+
+// all returns the providers of all servers, and their ID prefixes.
+func (a *aggregateMediaProvider) all() ([]mediaprovider.MediaProvider, []string) {
+	mps := []mediaprovider.MediaProvider{a.primary}
+	prefixes := []string{""}
+	for _, m := range a.members {
+		mps = append(mps, m.Provider)
+		prefixes = append(prefixes, m.ServerID)
+	}
+	return mps, prefixes
+}
+
+// groupIDs groups the item IDs by server, returning
+// the servers' own IDs for the items, keyed by ID prefix.
+func (a *aggregateMediaProvider) groupIDs(ids []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, id := range ids {
+		_, prefix, itemID := a.route(id)
+		groups[prefix] = append(groups[prefix], itemID)
+	}
+	return groups
+}
+
+func (a *aggregateMediaProvider) provider(prefix string) mediaprovider.MediaProvider {
+	if prefix == "" {
+		return a.primary
+	}
+	return a.byID[prefix]
+}
+
+// forEach calls f concurrently for each server, returning the joined errors.
+func (a *aggregateMediaProvider) forEach(f func(i int, mp mediaprovider.MediaProvider, prefix string) error) error {
+	mps, prefixes := a.all()
+	errs := make([]error, len(mps))
+	var wg sync.WaitGroup
+	for i := range mps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f(i, mps[i], prefixes[i])
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (a *aggregateMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID string)) {
+	a.primary.SetPrefetchCoverCallback(cb)
+	for _, m := range a.members {
+		prefix := m.ServerID
+		if cb == nil {
+			m.Provider.SetPrefetchCoverCallback(nil)
+			continue
+		}
+		m.Provider.SetPrefetchCoverCallback(func(id string) { cb(prefixID(prefix, id)) })
+	}
+}
+
+func (a *aggregateMediaProvider) SetLibraryIndex(index *mediaprovider.LibraryIndex) {
+	// the library index is of the primary server only
+	if indexer, ok := a.primary.(mediaprovider.LibraryIndexer); ok {
+		indexer.SetLibraryIndex(index)
+	}
+}
+
+func (a *aggregateMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
+	mp, prefix, id := a.route(trackID)
+	tr, err := mp.GetTrack(id)
+	if err != nil {
+		return nil, err
+	}
+	return prefixTrack(prefix, tr), nil
+}
+
+func (a *aggregateMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
+	mp, prefix, id := a.route(albumID)
+	al, err := mp.GetAlbum(id)
+	if err != nil || prefix == "" {
+		return al, err
+	}
+	return &mediaprovider.AlbumWithTracks{
+		Album:      *prefixAlbum(prefix, &al.Album),
+		Tracks:     prefixTracks(prefix, al.Tracks),
+		DiscTitles: al.DiscTitles,
+	}, nil
+}
+
+func (a *aggregateMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.AlbumInfo, error) {
+	mp, prefix, id := a.route(albumID)
+	info, err := mp.GetAlbumInfo(id)
+	if err != nil || prefix == "" {
+		return info, err
+	}
+	c := *info
+	c.TrackCredits = sharedutil.MapSlice(info.TrackCredits, func(tc *mediaprovider.TrackCredits) *mediaprovider.TrackCredits {
+		c := *tc
+		c.TrackID = prefixID(prefix, tc.TrackID)
+		c.Performers = prefixContributors(prefix, tc.Performers)
+		return &c
+	})
+	return &c, nil
+}
+
+func (a *aggregateMediaProvider) GetArtist(artistID string) (*mediaprovider.ArtistWithAlbums, error) {
+	mp, prefix, id := a.route(artistID)
+	ar, err := mp.GetArtist(id)
+	if err != nil || prefix == "" {
+		return ar, err
+	}
+	return &mediaprovider.ArtistWithAlbums{
+		Artist: *prefixArtist(prefix, &ar.Artist),
+		Albums: prefixAlbums(prefix, ar.Albums),
+	}, nil
+}
+
+func (a *aggregateMediaProvider) GetArtistInfo(artistID string) (*mediaprovider.ArtistInfo, error) {
+	mp, prefix, id := a.route(artistID)
+	info, err := mp.GetArtistInfo(id)
+	if err != nil || prefix == "" {
+		return info, err
+	}
+	c := *info
+	c.SimilarArtists = prefixArtists(prefix, info.SimilarArtists)
+	return &c, nil
+}
+
+func (a *aggregateMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
+	mp, prefix, id := a.route(playlistID)
+	pl, err := mp.GetPlaylist(id)
+	if err != nil || prefix == "" {
+		return pl, err
+	}
+	return &mediaprovider.PlaylistWithTracks{
+		Playlist: *prefixPlaylist(prefix, &pl.Playlist),
+		Tracks:   prefixTracks(prefix, pl.Tracks),
+	}, nil
+}
+
+func (a *aggregateMediaProvider) GetCoverArt(coverArtID string, size int) (image.Image, error) {
+	mp, _, id := a.route(coverArtID)
+	return mp.GetCoverArt(id, size)
+}
+
+func (a *aggregateMediaProvider) AlbumSortOrders() []string {
+	return a.primary.AlbumSortOrders()
+}
+
+// sortOrderFor returns the sort order to use with the provider,
+// which may not support all of the primary server's sort orders.
+func sortOrderFor(sortOrder string, supported []string) string {
+	if len(supported) == 0 || slices.Contains(supported, sortOrder) {
+		return sortOrder
+	}
+	return supported[0]
+}
+
+func (a *aggregateMediaProvider) IterateAlbums(sortOrder string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	mps, prefixes := a.all()
+	iters := sharedutil.MapSlice(mps, func(mp mediaprovider.MediaProvider) mediaprovider.AlbumIterator {
+		return mp.IterateAlbums(sortOrderFor(sortOrder, mp.AlbumSortOrders()), cloneFilter(filter))
+	})
+	return newMergedIter(iters, prefixes, prefixAlbum, albumKeys, albumLess(sortOrder))
+}
+
+func (a *aggregateMediaProvider) IterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	mps, prefixes := a.all()
+	iters := sharedutil.MapSlice(mps, func(mp mediaprovider.MediaProvider) mediaprovider.TrackIterator {
+		return mp.IterateTracks(searchQuery, cloneFilter(filter))
+	})
+	return newMergedIter(iters, prefixes, prefixTrack, trackKeys, nil)
+}
+
+func (a *aggregateMediaProvider) SearchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	mps, prefixes := a.all()
+	iters := sharedutil.MapSlice(mps, func(mp mediaprovider.MediaProvider) mediaprovider.AlbumIterator {
+		return mp.SearchAlbums(searchQuery, cloneFilter(filter))
+	})
+	return newMergedIter(iters, prefixes, prefixAlbum, albumKeys, nil)
+}
+
+func (a *aggregateMediaProvider) SearchAll(searchQuery string, maxResults int) ([]*mediaprovider.SearchResult, error) {
+	mps, _ := a.all()
+	results := make([][]*mediaprovider.SearchResult, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, prefix string) error {
+		r, err := mp.SearchAll(searchQuery, maxResults)
+		results[i] = sharedutil.MapSlice(r, func(r *mediaprovider.SearchResult) *mediaprovider.SearchResult {
+			return prefixSearchResult(prefix, r)
+		})
+		return err
+	})
+	if results[0] == nil && err != nil {
+		return nil, err
+	}
+
+	seen := make(dedupSet)
+	var merged []*mediaprovider.SearchResult
+	for i, rs := range results {
+		for _, r := range rs {
+			if seen.add(i, searchResultKeys(r)) {
+				merged = append(merged, r)
+			}
+		}
+	}
+	querySanitized := strings.ToLower(sanitize.Accents(searchQuery))
+	helpers.RankSearchResults(merged, querySanitized, strings.Fields(querySanitized))
+	if len(merged) > maxResults {
+		merged = merged[:maxResults]
+	}
+	return merged, nil
+}
+
+func (a *aggregateMediaProvider) GetRandomTracks(genre string, count int) ([]*mediaprovider.Track, error) {
+	mps, _ := a.all()
+	results := make([][]*mediaprovider.Track, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, prefix string) error {
+		tr, err := mp.GetRandomTracks(genre, count)
+		results[i] = prefixTracks(prefix, tr)
+		return err
+	})
+	var tracks []*mediaprovider.Track
+	for _, tr := range results {
+		tracks = append(tracks, tr...)
+	}
+	if len(tracks) == 0 && err != nil {
+		return nil, err
+	}
+	rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+	if len(tracks) > count {
+		tracks = tracks[:count]
+	}
+	return tracks, nil
+}
+
+func (a *aggregateMediaProvider) GetSimilarTracks(artistID string, count int) ([]*mediaprovider.Track, error) {
+	mp, prefix, id := a.route(artistID)
+	tr, err := mp.GetSimilarTracks(id, count)
+	return prefixTracks(prefix, tr), err
+}
+
+func (a *aggregateMediaProvider) GetSongRadio(trackID string, count int) ([]*mediaprovider.Track, error) {
+	mp, prefix, id := a.route(trackID)
+	tr, err := mp.GetSongRadio(id, count)
+	return prefixTracks(prefix, tr), err
+}
+
+func (a *aggregateMediaProvider) ArtistSortOrders() []string {
+	return a.primary.ArtistSortOrders()
+}
+
+func (a *aggregateMediaProvider) IterateArtists(sortOrder string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
+	mps, prefixes := a.all()
+	iters := sharedutil.MapSlice(mps, func(mp mediaprovider.MediaProvider) mediaprovider.ArtistIterator {
+		return mp.IterateArtists(sortOrderFor(sortOrder, mp.ArtistSortOrders()), cloneFilter(filter))
+	})
+	return newMergedIter(iters, prefixes, prefixArtist, artistKeys, artistLess(sortOrder))
+}
+
+func (a *aggregateMediaProvider) SearchArtists(searchQuery string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
+	mps, prefixes := a.all()
+	iters := sharedutil.MapSlice(mps, func(mp mediaprovider.MediaProvider) mediaprovider.ArtistIterator {
+		return mp.SearchArtists(searchQuery, cloneFilter(filter))
+	})
+	return newMergedIter(iters, prefixes, prefixArtist, artistKeys, nil)
+}
+
+func (a *aggregateMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
+	mps, _ := a.all()
+	results := make([][]*mediaprovider.Genre, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, _ string) error {
+		g, err := mp.GetGenres()
+		results[i] = g
+		return err
+	})
+	if results[0] == nil && err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*mediaprovider.Genre)
+	var genres []*mediaprovider.Genre
+	for _, gs := range results {
+		for _, g := range gs {
+			key := normalize(g.Name)
+			if merged, ok := byName[key]; ok {
+				merged.AlbumCount += g.AlbumCount
+				merged.TrackCount += g.TrackCount
+				continue
+			}
+			c := *g
+			byName[key] = &c
+			genres = append(genres, &c)
+		}
+	}
+	sort.SliceStable(genres, func(i, j int) bool { return compareFold(genres[i].Name, genres[j].Name) < 0 })
+	return genres, nil
+}
+
+func (a *aggregateMediaProvider) GetFavorites() (mediaprovider.Favorites, error) {
+	mps, _ := a.all()
+	results := make([]mediaprovider.Favorites, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, prefix string) error {
+		fav, err := mp.GetFavorites()
+		results[i] = mediaprovider.Favorites{
+			Albums:  prefixAlbums(prefix, fav.Albums),
+			Artists: prefixArtists(prefix, fav.Artists),
+			Tracks:  prefixTracks(prefix, fav.Tracks),
+		}
+		return err
+	})
+	var favs mediaprovider.Favorites
+	albums, artists, tracks := make(dedupSet), make(dedupSet), make(dedupSet)
+	for i, fav := range results {
+		for _, al := range fav.Albums {
+			if albums.add(i, albumKeys(al)) {
+				favs.Albums = append(favs.Albums, al)
+			}
+		}
+		for _, ar := range fav.Artists {
+			if artists.add(i, artistKeys(ar)) {
+				favs.Artists = append(favs.Artists, ar)
+			}
+		}
+		for _, tr := range fav.Tracks {
+			if tracks.add(i, trackKeys(tr)) {
+				favs.Tracks = append(favs.Tracks, tr)
+			}
+		}
+	}
+	if err != nil && len(favs.Albums)+len(favs.Artists)+len(favs.Tracks) == 0 {
+		return favs, err
+	}
+	return favs, nil
+}
+
+func (a *aggregateMediaProvider) GetStreamURL(trackID string, forceRaw bool) (string, error) {
+	mp, _, id := a.route(trackID)
+	return mp.GetStreamURL(id, forceRaw)
+}
+
+func (a *aggregateMediaProvider) GetTopTracks(artist mediaprovider.Artist, count int) ([]*mediaprovider.Track, error) {
+	mp, prefix, id := a.route(artist.ID)
+	artist.ID = id
+	tr, err := mp.GetTopTracks(artist, count)
+	return prefixTracks(prefix, tr), err
+}
+
+func (a *aggregateMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteParameters, favorite bool) error {
+	var errs []error
+	for prefix, p := range a.groupParams(params) {
+		errs = append(errs, a.provider(prefix).SetFavorite(p, favorite))
+	}
+	return errors.Join(errs...)
+}
+
+// groupParams splits the parameters by the server of the items.
+func (a *aggregateMediaProvider) groupParams(params mediaprovider.RatingFavoriteParameters) map[string]mediaprovider.RatingFavoriteParameters {
+	grouped := make(map[string]mediaprovider.RatingFavoriteParameters)
+	for prefix, ids := range a.groupIDs(params.AlbumIDs) {
+		p := grouped[prefix]
+		p.AlbumIDs = ids
+		grouped[prefix] = p
+	}
+	for prefix, ids := range a.groupIDs(params.ArtistIDs) {
+		p := grouped[prefix]
+		p.ArtistIDs = ids
+		grouped[prefix] = p
+	}
+	for prefix, ids := range a.groupIDs(params.TrackIDs) {
+		p := grouped[prefix]
+		p.TrackIDs = ids
+		grouped[prefix] = p
+	}
+	return grouped
+}
+
+func (a *aggregateMediaProvider) GetPlaylists() ([]*mediaprovider.Playlist, error) {
+	mps, _ := a.all()
+	results := make([][]*mediaprovider.Playlist, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, prefix string) error {
+		pls, err := mp.GetPlaylists()
+		results[i] = sharedutil.MapSlice(pls, func(p *mediaprovider.Playlist) *mediaprovider.Playlist {
+			return prefixPlaylist(prefix, p)
+		})
+		return err
+	})
+	if results[0] == nil && err != nil {
+		return nil, err
+	}
+	var playlists []*mediaprovider.Playlist
+	for _, pls := range results {
+		playlists = append(playlists, pls...)
+	}
+	return playlists, nil
+}
+
+// singleServerIDs returns the provider and server's own IDs for the item IDs,
+// which must all be from the same server. If there are no IDs,
+// defaultID determines the server.
+func (a *aggregateMediaProvider) singleServerIDs(defaultID string, ids []string) (mediaprovider.MediaProvider, []string, error) {
+	groups := a.groupIDs(ids)
+	if len(groups) > 1 {
+		return nil, nil, ErrMixedServers
+	}
+	mp, prefix, _ := a.route(defaultID)
+	for p, ids := range groups {
+		if defaultID != "" && p != prefix {
+			return nil, nil, ErrMixedServers
+		}
+		return a.provider(p), ids, nil
+	}
+	return mp, nil, nil
+}
+
+func (a *aggregateMediaProvider) CreatePlaylist(name string, trackIDs []string) error {
+	// the playlist is created on the server of the tracks
+	mp, ids, err := a.singleServerIDs("", trackIDs)
+	if err != nil {
+		return err
+	}
+	return mp.CreatePlaylist(name, ids)
+}
+
+func (a *aggregateMediaProvider) CanMakePublicPlaylist() bool {
+	return a.primary.CanMakePublicPlaylist()
+}
+
+func (a *aggregateMediaProvider) EditPlaylist(id, name, description string, public bool) error {
+	mp, _, plID := a.route(id)
+	return mp.EditPlaylist(plID, name, description, public)
+}
+
+func (a *aggregateMediaProvider) AddPlaylistTracks(id string, trackIDsToAdd []string) error {
+	mp, ids, err := a.singleServerIDs(id, trackIDsToAdd)
+	if err != nil {
+		return err
+	}
+	_, _, plID := a.route(id)
+	return mp.AddPlaylistTracks(plID, ids)
+}
+
+func (a *aggregateMediaProvider) RemovePlaylistTracks(id string, trackIdxsToRemove []int) error {
+	mp, _, plID := a.route(id)
+	return mp.RemovePlaylistTracks(plID, trackIdxsToRemove)
+}
+
+func (a *aggregateMediaProvider) ReplacePlaylistTracks(id string, trackIDs []string) error {
+	mp, ids, err := a.singleServerIDs(id, trackIDs)
+	if err != nil {
+		return err
+	}
+	_, _, plID := a.route(id)
+	return mp.ReplacePlaylistTracks(plID, ids)
+}
+
+func (a *aggregateMediaProvider) DeletePlaylist(id string) error {
+	mp, _, plID := a.route(id)
+	return mp.DeletePlaylist(plID)
+}
+
+// ClientDecidesScrobble returns true if the client decides
+// when a track is scrobbled for any of the servers.
+func (a *aggregateMediaProvider) ClientDecidesScrobble() bool {
+	mps, _ := a.all()
+	return slices.ContainsFunc(mps, mediaprovider.MediaProvider.ClientDecidesScrobble)
+}
+
+func (a *aggregateMediaProvider) ClientDecidesTrackScrobble(trackID string) bool {
+	mp, _, _ := a.route(trackID)
+	return mp.ClientDecidesScrobble()
+}
+
+func (a *aggregateMediaProvider) TrackBeganPlayback(trackID string) error {
+	mp, _, id := a.route(trackID)
+	return mp.TrackBeganPlayback(id)
+}
+
+func (a *aggregateMediaProvider) TrackEndedPlayback(trackID string, positionSecs int, submission bool) error {
+	mp, _, id := a.route(trackID)
+	return mp.TrackEndedPlayback(id, positionSecs, submission)
+}
+
+func (a *aggregateMediaProvider) DownloadTrack(trackID string) (io.Reader, error) {
+	mp, _, id := a.route(trackID)
+	return mp.DownloadTrack(id)
+}
+
+func (a *aggregateMediaProvider) RescanLibrary() error {
+	return a.forEach(func(_ int, mp mediaprovider.MediaProvider, _ string) error {
+		return mp.RescanLibrary()
+	})
+}
+
+func (a *aggregateMediaProvider) SetRating(params mediaprovider.RatingFavoriteParameters, rating int) error {
+	var errs []error
+	for prefix, p := range a.groupParams(params) {
+		r, ok := a.provider(prefix).(mediaprovider.SupportsRating)
+		if !ok {
+			errs = append(errs, ErrNotSupported)
+			continue
+		}
+		errs = append(errs, r.SetRating(p, rating))
+	}
+	return errors.Join(errs...)
+}
+
+func (a *aggregateMediaProvider) CreateShareURL(id string) (*url.URL, error) {
+	mp, _, itemID := a.route(id)
+	s, ok := mp.(mediaprovider.SupportsSharing)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return s.CreateShareURL(itemID)
+}
+
+func (a *aggregateMediaProvider) CanShareArtists() bool {
+	s, ok := a.primary.(mediaprovider.SupportsSharing)
+	return ok && s.CanShareArtists()
+}
+
+func (a *aggregateMediaProvider) GetLyrics(track *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
+	mp, prefix, id := a.route(track.ID)
+	lp, ok := mp.(mediaprovider.LyricsProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	if prefix != "" {
+		c := *track
+		c.ID = id
+		_, _, c.AlbumID = a.route(track.AlbumID)
+		track = &c
+	}
+	return lp.GetLyrics(track)
+}
+
+// GetComposers returns the composers of all servers which support
+// browsing by composer, merged by name, since IterateComposerTracks
+// identifies the composer by name.
+func (a *aggregateMediaProvider) GetComposers() ([]*mediaprovider.Composer, error) {
+	mps, _ := a.all()
+	results := make([][]*mediaprovider.Composer, len(mps))
+	err := a.forEach(func(i int, mp mediaprovider.MediaProvider, prefix string) error {
+		cp, ok := mp.(mediaprovider.ComposerProvider)
+		if !ok {
+			return nil
+		}
+		c, err := cp.GetComposers()
+		results[i] = sharedutil.MapSlice(c, func(c *mediaprovider.Composer) *mediaprovider.Composer {
+			return prefixComposer(prefix, c)
+		})
+		return err
+	})
+	byName := make(map[string]*mediaprovider.Composer)
+	var composers []*mediaprovider.Composer
+	for _, cs := range results {
+		for _, c := range cs {
+			key := normalize(c.Name)
+			if merged, ok := byName[key]; ok {
+				merged.AlbumCount = addCounts(merged.AlbumCount, c.AlbumCount)
+				merged.TrackCount = addCounts(merged.TrackCount, c.TrackCount)
+				continue
+			}
+			c := *c
+			byName[key] = &c
+			composers = append(composers, &c)
+		}
+	}
+	if len(composers) == 0 && err != nil {
+		return nil, err
+	}
+	return composers, nil
+}
+
+// addCounts adds two counts, either of which may be -1 if unknown.
+func addCounts(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return a + b
+}
+
+func (a *aggregateMediaProvider) IterateComposerTracks(composer string) mediaprovider.TrackIterator {
+	mps, allPrefixes := a.all()
+	var iters []mediaprovider.TrackIterator
+	var prefixes []string
+	for i, mp := range mps {
+		if cp, ok := mp.(mediaprovider.ComposerProvider); ok {
+			iters = append(iters, cp.IterateComposerTracks(composer))
+			prefixes = append(prefixes, allPrefixes[i])
+		}
+	}
+	return newMergedIter(iters, prefixes, prefixTrack, trackKeys, nil)
+}
+
+// The jukebox is that of the primary server, which can
+// only play the primary server's tracks.
+
+func (a *aggregateMediaProvider) jukebox() (mediaprovider.JukeboxProvider, error) {
+	if jp, ok := a.primary.(mediaprovider.JukeboxProvider); ok {
+		return jp, nil
+	}
+	return nil, ErrNotSupported
+}
+
+// jukeboxTrack returns the primary server's jukebox and its own ID for the track.
+func (a *aggregateMediaProvider) jukeboxTrack(trackID string) (mediaprovider.JukeboxProvider, string, error) {
+	_, prefix, id := a.route(trackID)
+	if prefix != "" {
+		return nil, "", ErrNotSupported
+	}
+	jp, err := a.jukebox()
+	return jp, id, err
+}
+
+func (a *aggregateMediaProvider) JukeboxStart() error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxStart()
+}
+
+func (a *aggregateMediaProvider) JukeboxStop() error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxStop()
+}
+
+func (a *aggregateMediaProvider) JukeboxSeek(idx, seconds int) error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxSeek(idx, seconds)
+}
+
+func (a *aggregateMediaProvider) JukeboxClear() error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxClear()
+}
+
+func (a *aggregateMediaProvider) JukeboxSet(trackID string) error {
+	jp, id, err := a.jukeboxTrack(trackID)
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxSet(id)
+}
+
+func (a *aggregateMediaProvider) JukeboxAdd(trackID string) error {
+	jp, id, err := a.jukeboxTrack(trackID)
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxAdd(id)
+}
+
+func (a *aggregateMediaProvider) JukeboxRemove(idx int) error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxRemove(idx)
+}
+
+func (a *aggregateMediaProvider) JukeboxSetVolume(vol int) error {
+	jp, err := a.jukebox()
+	if err != nil {
+		return err
+	}
+	return jp.JukeboxSetVolume(vol)
+}
+
+func (a *aggregateMediaProvider) JukeboxGetStatus() (*mediaprovider.JukeboxStatus, error) {
+	jp, err := a.jukebox()
+	if err != nil {
+		return nil, err
+	}
+	return jp.JukeboxGetStatus()
+}
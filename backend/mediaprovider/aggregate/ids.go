@@ -0,0 +1,210 @@
+package aggregate
+
+import (
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// Items from the secondary servers have IDs of the form "<server ID>:<item ID>",
+// so that requests for them can be routed back to the server they came from.
+// Items from the primary server keep their IDs unchanged, so that data saved
+// with them, such as the play queue, remains valid whether or not other
+// servers are connected alongside it.
+
+const idSeparator = ":"
+
+// SplitID returns the ID of the secondary server the item ID belongs to,
+// and the server's own ID for the item. The server ID is empty for items
+// of the primary server.
+func SplitID(id string) (serverID, itemID string) {
+	if len(id) > serverIDLen && id[serverIDLen:serverIDLen+len(idSeparator)] == idSeparator {
+		return id[:serverIDLen], id[serverIDLen+len(idSeparator):]
+	}
+	return "", id
+}
+
+// server IDs are UUID strings
+const serverIDLen = 36
+
+func prefixID(prefix, id string) string {
+	if prefix == "" || id == "" {
+		return id
+	}
+	return prefix + idSeparator + id
+}
+
+func prefixIDs(prefix string, ids []string) []string {
+	if prefix == "" {
+		return ids
+	}
+	return sharedutil.MapSlice(ids, func(id string) string { return prefixID(prefix, id) })
+}
+
+// The prefix functions return copies of the items with their IDs prefixed,
+// since the items may be shared, such as with a provider's library index.
+
+func prefixAlbum(prefix string, a *mediaprovider.Album) *mediaprovider.Album {
+	if prefix == "" || a == nil {
+		return a
+	}
+	c := *a
+	c.ID = prefixID(prefix, a.ID)
+	c.CoverArtID = prefixID(prefix, a.CoverArtID)
+	c.ArtistIDs = prefixIDs(prefix, a.ArtistIDs)
+	return &c
+}
+
+func prefixArtist(prefix string, a *mediaprovider.Artist) *mediaprovider.Artist {
+	if prefix == "" || a == nil {
+		return a
+	}
+	c := *a
+	c.ID = prefixID(prefix, a.ID)
+	c.CoverArtID = prefixID(prefix, a.CoverArtID)
+	return &c
+}
+
+func prefixTrack(prefix string, t *mediaprovider.Track) *mediaprovider.Track {
+	if prefix == "" || t == nil {
+		return t
+	}
+	c := *t
+	c.ID = prefixID(prefix, t.ID)
+	c.CoverArtID = prefixID(prefix, t.CoverArtID)
+	c.ParentID = prefixID(prefix, t.ParentID)
+	c.AlbumID = prefixID(prefix, t.AlbumID)
+	c.ArtistIDs = prefixIDs(prefix, t.ArtistIDs)
+	c.ComposerIDs = prefixIDs(prefix, t.ComposerIDs)
+	c.Contributors = prefixContributors(prefix, t.Contributors)
+	return &c
+}
+
+func prefixContributors(prefix string, contribs []mediaprovider.Contributor) []mediaprovider.Contributor {
+	if prefix == "" || contribs == nil {
+		return contribs
+	}
+	return sharedutil.MapSlice(contribs, func(c mediaprovider.Contributor) mediaprovider.Contributor {
+		c.ArtistID = prefixID(prefix, c.ArtistID)
+		return c
+	})
+}
+
+func prefixPlaylist(prefix string, p *mediaprovider.Playlist) *mediaprovider.Playlist {
+	if prefix == "" || p == nil {
+		return p
+	}
+	c := *p
+	c.ID = prefixID(prefix, p.ID)
+	c.CoverArtID = prefixID(prefix, p.CoverArtID)
+	return &c
+}
+
+func prefixComposer(prefix string, c *mediaprovider.Composer) *mediaprovider.Composer {
+	if prefix == "" || c == nil {
+		return c
+	}
+	cp := *c
+	cp.ID = prefixID(prefix, c.ID)
+	return &cp
+}
+
+func prefixSearchResult(prefix string, r *mediaprovider.SearchResult) *mediaprovider.SearchResult {
+	// genres are identified by name and are merged across servers
+	if prefix == "" || r == nil || r.Type == mediaprovider.ContentTypeGenre {
+		return r
+	}
+	c := *r
+	c.ID = prefixID(prefix, r.ID)
+	c.CoverID = prefixID(prefix, r.CoverID)
+	return &c
+}
+
+func prefixTracks(prefix string, tracks []*mediaprovider.Track) []*mediaprovider.Track {
+	if prefix == "" {
+		return tracks
+	}
+	return sharedutil.MapSlice(tracks, func(t *mediaprovider.Track) *mediaprovider.Track { return prefixTrack(prefix, t) })
+}
+
+func prefixAlbums(prefix string, albums []*mediaprovider.Album) []*mediaprovider.Album {
+	if prefix == "" {
+		return albums
+	}
+	return sharedutil.MapSlice(albums, func(a *mediaprovider.Album) *mediaprovider.Album { return prefixAlbum(prefix, a) })
+}
+
+func prefixArtists(prefix string, artists []*mediaprovider.Artist) []*mediaprovider.Artist {
+	if prefix == "" {
+		return artists
+	}
+	return sharedutil.MapSlice(artists, func(a *mediaprovider.Artist) *mediaprovider.Artist { return prefixArtist(prefix, a) })
+}
+
+// Items from different servers are considered the same if they have the
+// same MusicBrainz ID, or failing that, the same name (and artist).
+// The dedup keys of an item are compared against those of the items
+// already returned from the other servers; an item is a duplicate
+// if any of its keys match.
+
+func albumKeys(a *mediaprovider.Album) []string {
+	keys := []string{"n:" + normalize(strings.Join(a.ArtistNames, ", ")) + "\x00" + normalize(a.Name)}
+	if a.MusicBrainzID != "" {
+		keys = append(keys, "m:"+a.MusicBrainzID)
+	}
+	return keys
+}
+
+func artistKeys(a *mediaprovider.Artist) []string {
+	keys := []string{"n:" + normalize(a.Name)}
+	if a.MusicBrainzID != "" {
+		keys = append(keys, "m:"+a.MusicBrainzID)
+	}
+	return keys
+}
+
+func trackKeys(t *mediaprovider.Track) []string {
+	keys := []string{"n:" + normalize(strings.Join(t.ArtistNames, ", ")) + "\x00" + normalize(t.Album) + "\x00" + normalize(t.Name)}
+	if t.MusicBrainzID != "" {
+		keys = append(keys, "m:"+t.MusicBrainzID)
+	}
+	return keys
+}
+
+func searchResultKeys(r *mediaprovider.SearchResult) []string {
+	switch r.Type {
+	case mediaprovider.ContentTypeAlbum, mediaprovider.ContentTypeTrack:
+		return []string{r.Type.String() + normalize(r.ArtistName) + "\x00" + normalize(r.Name)}
+	case mediaprovider.ContentTypeArtist, mediaprovider.ContentTypeGenre:
+		return []string{r.Type.String() + normalize(r.Name)}
+	default:
+		// playlists are never merged
+		return nil
+	}
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// dedupSet tracks the keys of the items returned so far,
+// and the index of the server each key was first seen from.
+type dedupSet map[string]int
+
+// add adds the keys of an item from the server and returns false if the item
+// is a duplicate of one from another server. Items from the same server are
+// never considered duplicates, since they are distinct items on that server.
+func (d dedupSet) add(server int, keys []string) bool {
+	for _, k := range keys {
+		if s, ok := d[k]; ok && s != server {
+			return false
+		}
+	}
+	for _, k := range keys {
+		if _, ok := d[k]; !ok {
+			d[k] = server
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const testServerID = "0b8a2b6e-4c7d-4f5e-9a3b-2c1d0e9f8a7b"
+
+func Test_SplitID(t *testing.T) {
+	tests := []struct {
+		id         string
+		wantServer string
+		wantItem   string
+	}{
+		{"al-123", "", "al-123"},
+		{testServerID + ":al-123", testServerID, "al-123"},
+		// item IDs may themselves contain the separator
+		{testServerID + ":a:b", testServerID, "a:b"},
+		{"short:al-123", "", "short:al-123"},
+		{testServerID, "", testServerID},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		server, item := SplitID(tt.id)
+		if server != tt.wantServer || item != tt.wantItem {
+			t.Errorf("SplitID(%q): got (%q, %q), want (%q, %q)", tt.id, server, item, tt.wantServer, tt.wantItem)
+		}
+	}
+}
+
+func Test_PrefixID(t *testing.T) {
+	if got := prefixID("", "al-123"); got != "al-123" {
+		t.Errorf("primary server ID was prefixed: %q", got)
+	}
+	if got := prefixID(testServerID, ""); got != "" {
+		t.Errorf("empty ID was prefixed: %q", got)
+	}
+	server, item := SplitID(prefixID(testServerID, "al-123"))
+	if server != testServerID || item != "al-123" {
+		t.Errorf("prefixed ID did not round trip: got (%q, %q)", server, item)
+	}
+}
+
+func Test_PrefixTrackCopies(t *testing.T) {
+	tr := &mediaprovider.Track{ID: "1", AlbumID: "2", ArtistIDs: []string{"3"}, CoverArtID: "4"}
+	c := prefixTrack(testServerID, tr)
+	if tr.ID != "1" || tr.ArtistIDs[0] != "3" {
+		t.Error("prefixing modified the original track")
+	}
+	want := []string{testServerID + ":1", testServerID + ":2", testServerID + ":3", testServerID + ":4"}
+	if got := []string{c.ID, c.AlbumID, c.ArtistIDs[0], c.CoverArtID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got IDs %v, want %v", got, want)
+	}
+}
+
+func Test_DedupSet(t *testing.T) {
+	d := make(dedupSet)
+	tests := []struct {
+		server int
+		keys   []string
+		want   bool
+	}{
+		{0, []string{"n:a", "m:1"}, true},
+		// same item again from the same server is a distinct item
+		{0, []string{"n:a"}, true},
+		{1, []string{"n:a"}, false},
+		{1, []string{"n:b", "m:1"}, false},
+		{1, []string{"n:c"}, true},
+		{1, []string{"n:c"}, true},
+		{2, []string{"n:c"}, false},
+		{2, nil, true},
+	}
+	for i, tt := range tests {
+		if got := d.add(tt.server, tt.keys); got != tt.want {
+			t.Errorf("%d: add(%d, %v): got %v, want %v", i, tt.server, tt.keys, got, tt.want)
+		}
+	}
+}
+
+type sliceIter[M any] struct {
+	items []*M
+}
+
+func (s *sliceIter[M]) Next() *M {
+	if len(s.items) == 0 {
+		return nil
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item
+}
+
+func Test_MergedIter(t *testing.T) {
+	primary := []*mediaprovider.Album{
+		{ID: "1", Name: "Alpha", ArtistNames: []string{"A"}},
+		{ID: "2", Name: "Gamma", ArtistNames: []string{"A"}},
+		// another edition on the same server
+		{ID: "3", Name: "Gamma", ArtistNames: []string{"A"}},
+	}
+	secondary := []*mediaprovider.Album{
+		{ID: "1", Name: "Beta", ArtistNames: []string{"B"}},
+		{ID: "2", Name: "gamma ", ArtistNames: []string{"a"}},
+		{ID: "3", Name: "Other", ArtistNames: []string{"B"}, MusicBrainzID: "mb"},
+	}
+	mk := func(sortOrder string) *mergedIter[mediaprovider.Album] {
+		iters := []mediaprovider.AlbumIterator{
+			&sliceIter[mediaprovider.Album]{items: primary},
+			&sliceIter[mediaprovider.Album]{items: secondary},
+		}
+		return newMergedIter(iters, []string{"", testServerID}, prefixAlbum, albumKeys, albumLess(sortOrder))
+	}
+
+	ids := func(iter mediaprovider.AlbumIterator) []string {
+		var ids []string
+		for al := iter.Next(); al != nil; al = iter.Next() {
+			ids = append(ids, al.ID)
+		}
+		return ids
+	}
+	s := testServerID + ":"
+	if got, want := ids(mk(albumSortTitleAZ)), []string{"1", s + "1", "2", "3", s + "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted: got %v, want %v", got, want)
+	}
+	if got, want := ids(mk("")), []string{"1", s + "1", "2", "3", s + "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("interleaved: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package aggregate
+
+import (
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// the sort orders, common to the providers, by which
+// the items from multiple servers can be merged in order
+const (
+	albumSortTitleAZ        = "Title (A-Z)"
+	albumSortArtistAZ       = "Artist (A-Z)"
+	albumSortYearAscending  = "Year (ascending)"
+	albumSortYearDescending = "Year (descending)"
+
+	artistSortNameAZ = "Name (A-Z)"
+)
+
+// mergedIter merges the results of an iterator from each server, skipping
+// duplicates of items from other servers. If the items are sorted in an order that can be compared
+// locally, they are merged in that order; otherwise the servers' results
+// are interleaved.
+type mergedIter[M any] struct {
+	iters    []mediaprovider.MediaIterator[M]
+	prefixes []string
+	prefix   func(string, *M) *M
+	keys     func(*M) []string
+	less     func(a, b *M) bool // nil to interleave
+
+	heads     []*M
+	exhausted []bool
+	nextIter  int
+	seen      dedupSet
+}
+
+func newMergedIter[M any](
+	iters []mediaprovider.MediaIterator[M],
+	prefixes []string,
+	prefix func(string, *M) *M,
+	keys func(*M) []string,
+	less func(a, b *M) bool,
+) *mergedIter[M] {
+	return &mergedIter[M]{
+		iters:     iters,
+		prefixes:  prefixes,
+		prefix:    prefix,
+		keys:      keys,
+		less:      less,
+		heads:     make([]*M, len(iters)),
+		exhausted: make([]bool, len(iters)),
+		seen:      make(dedupSet),
+	}
+}
+
+func (m *mergedIter[M]) Next() *M {
+	for {
+		var item *M
+		var server int
+		if m.less == nil {
+			item, server = m.nextInterleaved()
+		} else {
+			item, server = m.nextSorted()
+		}
+		if item == nil {
+			return nil
+		}
+		if m.seen.add(server, m.keys(item)) {
+			return item
+		}
+	}
+}
+
+// nextInterleaved returns the next item, and the index of its server.
+func (m *mergedIter[M]) nextInterleaved() (*M, int) {
+	for range m.iters {
+		i := m.nextIter
+		m.nextIter = (m.nextIter + 1) % len(m.iters)
+		if m.exhausted[i] {
+			continue
+		}
+		if item := m.iters[i].Next(); item != nil {
+			return m.prefix(m.prefixes[i], item), i
+		}
+		m.exhausted[i] = true
+	}
+	return nil, -1
+}
+
+// nextSorted returns the next item in sort order, and the index of its server.
+func (m *mergedIter[M]) nextSorted() (*M, int) {
+	min := -1
+	for i := range m.iters {
+		if m.heads[i] == nil && !m.exhausted[i] {
+			if m.heads[i] = m.iters[i].Next(); m.heads[i] == nil {
+				m.exhausted[i] = true
+			}
+		}
+		if m.heads[i] != nil && (min < 0 || m.less(m.heads[i], m.heads[min])) {
+			min = i
+		}
+	}
+	if min < 0 {
+		return nil, -1
+	}
+	item := m.heads[min]
+	m.heads[min] = nil
+	return m.prefix(m.prefixes[min], item), min
+}
+
+func albumLess(sortOrder string) func(a, b *mediaprovider.Album) bool {
+	switch sortOrder {
+	case albumSortTitleAZ:
+		return func(a, b *mediaprovider.Album) bool {
+			return compareFold(a.Name, b.Name) < 0
+		}
+	case albumSortArtistAZ:
+		return func(a, b *mediaprovider.Album) bool {
+			if c := compareFold(firstOrEmpty(a.ArtistNames), firstOrEmpty(b.ArtistNames)); c != 0 {
+				return c < 0
+			}
+			return compareFold(a.Name, b.Name) < 0
+		}
+	case albumSortYearAscending:
+		return func(a, b *mediaprovider.Album) bool { return a.Year < b.Year }
+	case albumSortYearDescending:
+		return func(a, b *mediaprovider.Album) bool { return a.Year > b.Year }
+	default:
+		return nil
+	}
+}
+
+func artistLess(sortOrder string) func(a, b *mediaprovider.Artist) bool {
+	if sortOrder == artistSortNameAZ {
+		return func(a, b *mediaprovider.Artist) bool {
+			return compareFold(a.Name, b.Name) < 0
+		}
+	}
+	return nil
+}
+
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func firstOrEmpty(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+// cloneFilter returns a copy of the filter for each server's iterator.
+func cloneFilter[M, F any](filter mediaprovider.MediaFilter[M, F]) mediaprovider.MediaFilter[M, F] {
+	if filter == nil {
+		return nil
+	}
+	return filter.Clone()
+}
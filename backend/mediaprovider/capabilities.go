@@ -0,0 +1,105 @@
+package mediaprovider
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Capabilities describes the server and which of the optional
+// features it supports for the logged-in user. It is fetched once
+// when connecting to the server, rather than checked per call.
+type Capabilities struct {
+	ServerType    string // e.g. "Navidrome", "Jellyfin"
+	ServerVersion string // version of the server software, if reported
+	APIVersion    string // Subsonic API level, if applicable
+
+	// OpenSubsonic is true if the server implements the OpenSubsonic API,
+	// and Extensions lists the OpenSubsonic extensions it supports.
+	OpenSubsonic bool
+	Extensions   []Extension
+
+	Rating          bool
+	Sharing         bool
+	ShareArtists    bool
+	Lyrics          bool
+	SyncedLyrics    bool
+	Composers       bool
+	Moods           bool
+	Jukebox         bool
+	Podcasts        bool
+	Transcoding     bool
+	PublicPlaylists bool
+
+	// ArtistImages is true if artists reliably report whether they have an image.
+	ArtistImages bool
+
+	// Admin is true if the logged-in user administers the server,
+	// and the provider implements AdminProvider.
+	Admin bool
+}
+
+// Extension is an OpenSubsonic API extension supported by the server.
+type Extension struct {
+	Name     string
+	Versions []int
+}
+
+// HasExtension returns true if the server supports the OpenSubsonic extension.
+func (c *Capabilities) HasExtension(name string) bool {
+	return slices.ContainsFunc(c.Extensions, func(e Extension) bool {
+		return e.Name == name
+	})
+}
+
+// ServerVersionAtLeast returns true if the server reports a version of at
+// least min, comparing the dot-separated numbers, such as "0.55.0", which
+// begin the version. An unreported or unrecognized version is never at least min.
+func (c *Capabilities) ServerVersionAtLeast(min string) bool {
+	v, ok := parseVersion(c.ServerVersion)
+	m, _ := parseVersion(min)
+	return ok && slices.Compare(v, m) >= 0
+}
+
+// parseVersion parses the numbers beginning a version such as "v0.55.2 (d2d0ab2)".
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		s = s[:i]
+	}
+	var v []int
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		v = append(v, n)
+	}
+	// so that e.g. 1.2 compares equal to 1.2.0
+	for len(v) > 1 && v[len(v)-1] == 0 {
+		v = v[:len(v)-1]
+	}
+	return v, true
+}
+
+// CapabilityProvider is implemented by media providers which
+// can query the server for its capabilities.
+type CapabilityProvider interface {
+	GetCapabilities() (*Capabilities, error)
+}
+
+// DefaultCapabilities returns the capabilities of the media provider
+// as determined by the optional interfaces it implements, for providers
+// which cannot query the server, or if the query fails.
+func DefaultCapabilities(mp MediaProvider) *Capabilities {
+	c := &Capabilities{PublicPlaylists: mp.CanMakePublicPlaylist()}
+	_, c.Rating = mp.(SupportsRating)
+	if s, ok := mp.(SupportsSharing); ok {
+		c.Sharing = true
+		c.ShareArtists = s.CanShareArtists()
+	}
+	_, c.Lyrics = mp.(LyricsProvider)
+	_, c.Composers = mp.(ComposerProvider)
+	_, c.Jukebox = mp.(JukeboxProvider)
+	return c
+}
@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// how long the artists of a genre are cached
+const artistGenreCacheTTL = 5 * time.Minute
+
+// ArtistGenreCache derives the genres of artists from the genres of their albums,
+// since neither Subsonic nor Jellyfin artists have genres of their own.
+// The artists of each genre are looked up lazily the first time a filter
+// references the genre, and cached for subsequent artist queries.
+type ArtistGenreCache struct {
+	iterateAlbums func(string, mediaprovider.AlbumFilter) mediaprovider.AlbumIterator
+
+	mutex  sync.Mutex
+	genres map[string]*genreArtists // keyed by lowercased genre
+}
+
+type genreArtists struct {
+	once      sync.Once
+	fetchedAt time.Time
+	artistIDs map[string]bool
+}
+
+func NewArtistGenreCache(iterateAlbums func(string, mediaprovider.AlbumFilter) mediaprovider.AlbumIterator) *ArtistGenreCache {
+	return &ArtistGenreCache{
+		iterateAlbums: iterateAlbums,
+		genres:        make(map[string]*genreArtists),
+	}
+}
+
+// Lookup returns a lookup for the genres referenced by the given filter,
+// or nil if the filter has no genre criteria.
+func (c *ArtistGenreCache) Lookup(filter mediaprovider.ArtistFilter) *ArtistGenreLookup {
+	opts := filter.Options()
+	if !opts.HasGenreFilter() {
+		return nil
+	}
+	genres := append(slices.Clone(opts.Genres), opts.ExcludeGenres...)
+	return &ArtistGenreLookup{cache: c, genres: genres}
+}
+
+// artistsOf returns the IDs of the artists with albums of the genre.
+func (c *ArtistGenreCache) artistsOf(genre string) map[string]bool {
+	key := strings.ToLower(genre)
+	c.mutex.Lock()
+	entry, ok := c.genres[key]
+	if !ok || (!entry.fetchedAt.IsZero() && time.Since(entry.fetchedAt) > artistGenreCacheTTL) {
+		entry = &genreArtists{}
+		c.genres[key] = entry
+	}
+	c.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.artistIDs = make(map[string]bool)
+		iter := c.iterateAlbums("", mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{
+			Genres: []string{genre},
+		}))
+		if iter != nil {
+			for al := iter.Next(); al != nil; al = iter.Next() {
+				for _, id := range al.ArtistIDs {
+					entry.artistIDs[id] = true
+				}
+			}
+		}
+		c.mutex.Lock()
+		entry.fetchedAt = time.Now()
+		c.mutex.Unlock()
+	})
+	return entry.artistIDs
+}
+
+// ArtistGenreLookup fills in the genres referenced by an artist filter
+// from an ArtistGenreCache.
+type ArtistGenreLookup struct {
+	cache  *ArtistGenreCache
+	genres []string
+}
+
+// FillGenres sets the Genres field of each artist to the looked-up genres.
+func (l *ArtistGenreLookup) FillGenres(artists []*mediaprovider.Artist) {
+	if l == nil {
+		return
+	}
+	for _, a := range artists {
+		a.Genres = nil
+	}
+	for _, genre := range l.genres {
+		artistIDs := l.cache.artistsOf(genre)
+		for _, a := range artists {
+			if artistIDs[a.ID] && !slices.Contains(a.Genres, genre) {
+				a.Genres = append(a.Genres, genre)
+			}
+		}
+	}
+}
+
+// WrapFetchFn returns a fetch function which fills in the genres
+// of the artists returned by fetchFn.
+func (l *ArtistGenreLookup) WrapFetchFn(fetchFn ArtistFetchFn) ArtistFetchFn {
+	if l == nil {
+		return fetchFn
+	}
+	return func(offset, limit int) ([]*mediaprovider.Artist, error) {
+		artists, err := fetchFn(offset, limit)
+		if err == nil {
+			l.FillGenres(artists)
+		}
+		return artists, err
+	}
+}
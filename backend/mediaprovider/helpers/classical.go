@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"strings"
+)
+
+// SplitWorkMovement splits a classical track title of the form
+// "Symphony No. 5 in C minor, Op. 67: I. Allegro con brio"
+// into its work and movement. If the title is not of this form,
+// the empty string is returned for both.
+func SplitWorkMovement(title string) (work, movement string) {
+	idx := strings.LastIndex(title, ": ")
+	if idx <= 0 {
+		return "", ""
+	}
+	work, movement = title[:idx], title[idx+2:]
+	numeral, _, found := strings.Cut(movement, ". ")
+	if !found || !isRomanNumeral(numeral) {
+		return "", ""
+	}
+	return work, movement
+}
+
+func isRomanNumeral(s string) bool {
+	if s == "" || len(s) > 7 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("IVXLC", r) {
+			return false
+		}
+	}
+	return true
+}
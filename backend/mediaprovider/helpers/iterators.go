@@ -15,6 +15,7 @@ type baseIter[M, F any] struct {
 	prefetched    []*M
 	prefetchedPos int
 	done          bool
+	err           error // the fetch error which ended the iteration, if any
 }
 
 type AlbumFetchFn func(offset, limit int) ([]*mediaprovider.Album, error)
@@ -39,14 +40,40 @@ func NewArtistIterator(fetchFn ArtistFetchFn, filter mediaprovider.ArtistFilter,
 
 type TrackFetchFn func(offset, limit int) ([]*mediaprovider.Track, error)
 
-func NewTrackIterator(fetchFn TrackFetchFn, cb func(string)) mediaprovider.TrackIterator {
-	return &baseIter[mediaprovider.Track, nilFilterOptions]{
+func NewTrackIterator(fetchFn TrackFetchFn, filter mediaprovider.TrackFilter, cb func(string)) mediaprovider.TrackIterator {
+	return &baseIter[mediaprovider.Track, mediaprovider.TrackFilterOptions]{
 		prefetchCB: func(a *mediaprovider.Track) { cb(a.CoverArtID) },
-		filter:     nilFilter[mediaprovider.Track]{},
+		filter:     filter,
 		fetcher:    fetchFn,
 	}
 }
 
+// NewFilteredIterator wraps an iterator, returning only the items matched by the filter.
+func NewFilteredIterator[M, F any](iter mediaprovider.MediaIterator[M], filter mediaprovider.MediaFilter[M, F]) mediaprovider.MediaIterator[M] {
+	if filter == nil || filter.IsNil() {
+		return iter
+	}
+	return &filteredIter[M, F]{iter: iter, filter: filter}
+}
+
+type filteredIter[M, F any] struct {
+	iter   mediaprovider.MediaIterator[M]
+	filter mediaprovider.MediaFilter[M, F]
+}
+
+func (f *filteredIter[M, F]) Next() *M {
+	for item := f.iter.Next(); item != nil; item = f.iter.Next() {
+		if f.filter.Matches(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+func (f *filteredIter[M, F]) Err() error {
+	return mediaprovider.IteratorErr(f.iter)
+}
+
 func (r *baseIter[M, F]) Next() *M {
 	if r.done {
 		return nil
@@ -61,6 +88,7 @@ func (r *baseIter[M, F]) Next() *M {
 		items, err := r.fetcher(r.serverPos, 20)
 		if err != nil {
 			log.Printf("error fetching items: %s", err.Error())
+			r.err = err
 			items = nil
 		}
 		if len(items) == 0 {
@@ -85,6 +113,11 @@ func (r *baseIter[M, F]) Next() *M {
 	return r.prefetched[0]
 }
 
+// Err returns the error which ended the iteration early, if any.
+func (r *baseIter[M, F]) Err() error {
+	return r.err
+}
+
 type randomAlbumIter struct {
 	filter        mediaprovider.AlbumFilter
 	prefetchCB    func(coverArtID string)
@@ -187,17 +220,3 @@ func (r *randomAlbumIter) Next() *mediaprovider.Album {
 
 	return nil
 }
-
-type nilFilterOptions struct{}
-
-type nilFilter[M any] struct{}
-
-func (n nilFilter[M]) IsNil() bool { return true }
-
-func (n nilFilter[M]) Matches(*M) bool { return true }
-
-func (n nilFilter[M]) Clone() mediaprovider.MediaFilter[M, nilFilterOptions] { return n }
-
-func (n nilFilter[M]) Options() nilFilterOptions { return nilFilterOptions{} }
-
-func (n nilFilter[M]) SetOptions(options nilFilterOptions) {}
@@ -0,0 +1,27 @@
+package jellyfin
+
+import (
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+var _ mediaprovider.CapabilityProvider = (*jellyfinMediaProvider)(nil)
+
+func (j *jellyfinMediaProvider) GetCapabilities() (*mediaprovider.Capabilities, error) {
+	info, err := j.client.Ping()
+	if err != nil {
+		return nil, err
+	}
+	serverType := info.ProductName
+	if serverType == "" {
+		serverType = "Jellyfin"
+	}
+	// tracks are always streamed in their original format,
+	// and ratings, sharing and lyrics are not yet implemented
+	return &mediaprovider.Capabilities{
+		ServerType:      serverType,
+		ServerVersion:   info.Version,
+		Composers:       true,
+		ArtistImages:    true,
+		PublicPlaylists: j.CanMakePublicPlaylist(),
+	}, nil
+}
@@ -1,6 +1,9 @@
 package jellyfin
 
 import (
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
@@ -38,6 +41,9 @@ func (j *jellyfinMediaProvider) ArtistSortOrders() []string {
 }
 
 func (j *jellyfinMediaProvider) IterateAlbums(sortOrder string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	if iter := j.iterateIndexedAlbums(sortOrder, filter); iter != nil {
+		return iter
+	}
 	var jfSort jellyfin.Sort
 	switch sortOrder {
 	case AlbumSortRecentlyAdded:
@@ -89,7 +95,32 @@ func (j *jellyfinMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 	return helpers.NewAlbumIterator(fetcher, modifiedFilter, j.prefetchCoverCB)
 }
 
+// iterateIndexedAlbums returns an iterator over the albums from the library index,
+// or nil if the index is not fresh or can't answer for the sort order.
+func (j *jellyfinMediaProvider) iterateIndexedAlbums(sortOrder string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	var cmp func(a, b *mediaprovider.Album) int
+	switch sortOrder {
+	case AlbumSortRecentlyAdded:
+		// index order
+	case "", AlbumSortTitleAZ:
+		cmp = mediaprovider.CompareAlbumsByTitle
+	case AlbumSortArtistAZ:
+		cmp = mediaprovider.CompareAlbumsByArtist
+	case AlbumSortYearAscending:
+		cmp = mediaprovider.CompareAlbumsByYear
+	case AlbumSortYearDescending:
+		cmp = func(a, b *mediaprovider.Album) int { return mediaprovider.CompareAlbumsByYear(b, a) }
+	default:
+		// random order comes from the server
+		return nil
+	}
+	return j.index.Load().IterateAlbums(filter, cmp)
+}
+
 func (j *jellyfinMediaProvider) SearchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	if iter := j.index.Load().SearchAlbums(searchQuery, filter); iter != nil {
+		return iter
+	}
 	fetcher := func(offs, limit int) ([]*mediaprovider.Album, error) {
 		sr, err := j.client.Search(searchQuery, jellyfin.TypeAlbum, jellyfin.Paging{StartIndex: offs, Limit: limit})
 		if err != nil {
@@ -100,17 +131,25 @@ func (j *jellyfinMediaProvider) SearchAlbums(searchQuery string, filter mediapro
 	return helpers.NewAlbumIterator(fetcher, filter, j.prefetchCoverCB)
 }
 
-func (j *jellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.TrackIterator {
+func (j *jellyfinMediaProvider) IterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if iter := j.index.Load().IterateTracks(searchQuery, filter); iter != nil {
+		return iter
+	}
 	var fetcher helpers.TrackFetchFn
 	if searchQuery == "" {
+		filterOptions := filter.Options()
 		fetcher = func(offs, limit int) ([]*mediaprovider.Track, error) {
-			var opts jellyfin.QueryOpts
-			opts.Paging = jellyfin.Paging{StartIndex: offs, Limit: limit}
-			s, err := j.client.GetSongs(opts)
-			if err != nil {
-				return nil, err
+			q := url.Values{}
+			// narrow down server-side where possible; the full filter is still applied client-side
+			if len(filterOptions.Genres) > 0 && filterOptions.GenreMatch == mediaprovider.TagMatchAny {
+				q.Set("Genres", strings.Join(filterOptions.Genres, "|"))
+			}
+			if filterOptions.ExcludeUnfavorited {
+				q.Set("IsFavorite", "true")
 			}
-			return sharedutil.MapSlice(s, toTrack), nil
+			q.Set("StartIndex", strconv.Itoa(offs))
+			q.Set("Limit", strconv.Itoa(limit))
+			return j.getSongsWithExtras(q)
 		}
 	} else {
 		fetcher = func(offs, limit int) ([]*mediaprovider.Track, error) {
@@ -121,7 +160,20 @@ func (j *jellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 			return sharedutil.MapSlice(sr.Songs, toTrack), nil
 		}
 	}
-	return helpers.NewTrackIterator(fetcher, j.prefetchCoverCB)
+	return helpers.NewTrackIterator(fetcher, filter, j.prefetchCoverCB)
+}
+
+func (j *jellyfinMediaProvider) IterateComposerTracks(composer string) mediaprovider.TrackIterator {
+	fetcher := func(offs, limit int) ([]*mediaprovider.Track, error) {
+		q := url.Values{}
+		q.Set("Person", composer)
+		q.Set("PersonTypes", "Composer")
+		q.Set("StartIndex", strconv.Itoa(offs))
+		q.Set("Limit", strconv.Itoa(limit))
+		return j.getSongsWithExtras(q)
+	}
+	return helpers.NewTrackIterator(fetcher,
+		mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}), j.prefetchCoverCB)
 }
 
 func (j *jellyfinMediaProvider) IterateArtists(sortOrder string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
@@ -132,13 +184,21 @@ func (j *jellyfinMediaProvider) IterateArtists(sortOrder string, filter mediapro
 	}
 	switch sortOrder {
 	case ArtistSortNameAZ:
+		if iter := j.index.Load().IterateArtists(filter); iter != nil {
+			return iter
+		}
 		jfSort.Field = jellyfin.SortByName
 		jfSort.Mode = jellyfin.SortAsc
 	}
 
+	var jfFilt jellyfin.Filter
+	if filter.Options().ExcludeUnfavorited {
+		jfFilt.Favorite = true
+	}
 	fetcher := func(offs, limit int) ([]*mediaprovider.Artist, error) {
 		ar, err := j.client.GetAlbumArtists(jellyfin.QueryOpts{
 			Sort:   jfSort,
+			Filter: jfFilt,
 			Paging: jellyfin.Paging{StartIndex: offs, Limit: limit},
 		})
 		if err != nil {
@@ -147,10 +207,14 @@ func (j *jellyfinMediaProvider) IterateArtists(sortOrder string, filter mediapro
 		return sharedutil.MapSlice(ar, toArtist), nil
 	}
 
-	return helpers.NewArtistIterator(fetcher, filter, j.prefetchCoverCB)
+	genreLookup := j.artistGenres.Lookup(filter)
+	return helpers.NewArtistIterator(genreLookup.WrapFetchFn(fetcher), filter, j.prefetchCoverCB)
 }
 
 func (j *jellyfinMediaProvider) SearchArtists(searchQuery string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
+	if iter := j.index.Load().SearchArtists(searchQuery, filter); iter != nil {
+		return iter
+	}
 	fetcher := func(offs, limit int) ([]*mediaprovider.Artist, error) {
 		sr, err := j.client.Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{StartIndex: offs, Limit: limit})
 		if err != nil {
@@ -158,7 +222,8 @@ func (j *jellyfinMediaProvider) SearchArtists(searchQuery string, filter mediapr
 		}
 		return sharedutil.MapSlice(sr.Artists, toArtist), nil
 	}
-	return helpers.NewArtistIterator(fetcher, filter, j.prefetchCoverCB)
+	genreLookup := j.artistGenres.Lookup(filter)
+	return helpers.NewArtistIterator(genreLookup.WrapFetchFn(fetcher), filter, j.prefetchCoverCB)
 }
 
 // Creates the Jellyfin filter to implement the given mediaprovider filter,
@@ -187,8 +252,12 @@ func jfFilterFromFilter(filter mediaprovider.AlbumFilter) (jellyfin.Filter, medi
 		jfFilt.YearRange = [2]int{1900, filterOptions.MaxYear}
 		filterOptions.MinYear, filterOptions.MaxYear = 0, 0
 	}
-	jfFilt.Genres = filterOptions.Genres
-	filterOptions.Genres = nil
+	// Jellyfin matches albums with any of the given genres,
+	// so match all of the genres client-side
+	if filterOptions.GenreMatch == mediaprovider.TagMatchAny {
+		jfFilt.Genres = filterOptions.Genres
+		filterOptions.Genres = nil
+	}
 
 	modifiedFilter.SetOptions(filterOptions)
 	return jfFilt, modifiedFilter
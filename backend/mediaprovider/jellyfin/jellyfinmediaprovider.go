@@ -3,13 +3,18 @@ package jellyfin
 import (
 	"image"
 	"io"
+	"log"
 	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
 )
 
@@ -20,12 +25,22 @@ const (
 
 type JellyfinServer struct {
 	jellyfin.Client
+
+	login *loginRecorder
 }
 
 func (j *JellyfinServer) Login(user, pass string) mediaprovider.LoginResponse {
 	if _, err := j.Ping(); err != nil {
 		return mediaprovider.LoginResponse{Error: err}
 	}
+	if j.login == nil {
+		base := j.Client.HTTPClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		j.login = &loginRecorder{base: base}
+		j.Client.HTTPClient.Transport = j.login
+	}
 	err := j.Client.Login(user, pass)
 	return mediaprovider.LoginResponse{
 		Error:       err,
@@ -34,30 +49,43 @@ func (j *JellyfinServer) Login(user, pass string) mediaprovider.LoginResponse {
 }
 
 func (j *JellyfinServer) MediaProvider() mediaprovider.MediaProvider {
-	return newJellyfinMediaProvider(&j.Client)
+	return newJellyfinMediaProvider(&j.Client, j.login)
 }
 
 var _ mediaprovider.MediaProvider = (*jellyfinMediaProvider)(nil)
 
 type jellyfinMediaProvider struct {
 	client          *jellyfin.Client
+	login           *loginRecorder
 	prefetchCoverCB func(coverArtID string)
 
 	genresCached   []*mediaprovider.Genre
 	genresCachedAt int64 // unix
+
+	index        atomic.Pointer[mediaprovider.LibraryIndex]
+	artistGenres *helpers.ArtistGenreCache
 }
 
-func newJellyfinMediaProvider(cli *jellyfin.Client) mediaprovider.MediaProvider {
-	return &jellyfinMediaProvider{
+func newJellyfinMediaProvider(cli *jellyfin.Client, login *loginRecorder) mediaprovider.MediaProvider {
+	j := &jellyfinMediaProvider{
 		client:       cli,
+		login:        login,
 		genresCached: make([]*mediaprovider.Genre, 0),
 	}
+	j.artistGenres = helpers.NewArtistGenreCache(j.IterateAlbums)
+	return j
 }
 
 func (j *jellyfinMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID string)) {
 	j.prefetchCoverCB = cb
 }
 
+var _ mediaprovider.LibraryIndexer = (*jellyfinMediaProvider)(nil)
+
+func (j *jellyfinMediaProvider) SetLibraryIndex(index *mediaprovider.LibraryIndex) {
+	j.index.Store(index)
+}
+
 func (j *jellyfinMediaProvider) CreatePlaylist(name string, trackIDs []string) error {
 	return j.client.CreatePlaylist(name, trackIDs)
 }
@@ -102,16 +130,17 @@ func (j *jellyfinMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWi
 	if err != nil {
 		return nil, err
 	}
-	var opts jellyfin.QueryOpts
-	opts.Filter.ParentID = albumID
-	tr, err := j.client.GetSongs(opts)
+	q := url.Values{}
+	q.Set("ParentId", albumID)
+	q.Set("SortBy", "ParentIndexNumber,IndexNumber,SortName")
+	tr, err := j.getSongsWithExtras(q)
 	if err != nil {
 		return nil, err
 	}
 
 	album := &mediaprovider.AlbumWithTracks{}
 	fillAlbum(al, &album.Album)
-	album.Tracks = sharedutil.MapSlice(tr, toTrack)
+	album.Tracks = tr
 	return album, nil
 }
 
@@ -120,9 +149,51 @@ func (j *jellyfinMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.Alb
 	if err != nil {
 		return nil, err
 	}
-	return &mediaprovider.AlbumInfo{
+	// Jellyfin does not model release types, so leave them unset
+	info := &mediaprovider.AlbumInfo{
 		Notes: al.Overview,
-	}, nil
+	}
+
+	extras, err := j.getItemExtras(albumID, "ProviderIds", "Studios", "ExternalUrls", "PremiereDate")
+	if err != nil {
+		// still return the basic album info
+		log.Printf("error fetching extended album info: %v", err)
+		return info, nil
+	}
+	if len(extras.PremiereDate) >= 10 {
+		info.ReleaseDate = extras.PremiereDate[:10] // strip time component
+	} else if extras.ProductionYear > 0 {
+		info.ReleaseDate = strconv.Itoa(extras.ProductionYear)
+	}
+	for _, s := range extras.Studios {
+		info.RecordLabels = append(info.RecordLabels, s.Name)
+	}
+	info.MusicBrainzID = extras.ProviderIds["MusicBrainzAlbum"]
+	if len(extras.ProviderIds) > 0 {
+		info.ExternalIDs = extras.ProviderIds
+	}
+	for _, u := range extras.ExternalUrls {
+		info.ExternalURLs = append(info.ExternalURLs, mediaprovider.ExternalURL{Name: u.Name, URL: u.URL})
+	}
+
+	tracks, err := j.getChildItemsExtras(albumID, "People")
+	if err != nil {
+		log.Printf("error fetching album track credits: %v", err)
+		return info, nil
+	}
+	for _, item := range tracks {
+		tr := &mediaprovider.Track{
+			ID:          item.ID,
+			Name:        item.Name,
+			TrackNumber: item.IndexNumber,
+			DiscNumber:  item.DiscNumber,
+		}
+		fillTrackExtras(tr, item)
+		if credits := mediaprovider.NewTrackCredits(tr); credits != nil {
+			info.TrackCredits = append(info.TrackCredits, credits)
+		}
+	}
+	return info, nil
 }
 
 func (j *jellyfinMediaProvider) GetArtist(artistID string) (*mediaprovider.ArtistWithAlbums, error) {
@@ -321,6 +392,9 @@ func (j *jellyfinMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteP
 		wg.Wait()
 	}
 
+	if err == nil {
+		j.index.Load().UpdateFavorite(params, favorite)
+	}
 	return err
 }
 
@@ -333,7 +407,7 @@ func (j *jellyfinMediaProvider) DownloadTrack(trackID string) (io.Reader, error)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.Get(url)
+	resp, err := j.client.HTTPClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -369,6 +443,7 @@ func toTrack(ch *jellyfin.Song) *mediaprovider.Track {
 		coverArtID = ch.Id
 	}
 
+	work, movement := helpers.SplitWorkMovement(ch.Name)
 	t := &mediaprovider.Track{
 		ID:          ch.Id,
 		CoverArtID:  coverArtID,
@@ -377,7 +452,6 @@ func toTrack(ch *jellyfin.Song) *mediaprovider.Track {
 		Duration:    int(ch.RunTimeTicks / runTimeTicksPerSecond),
 		TrackNumber: ch.IndexNumber,
 		DiscNumber:  ch.DiscNumber,
-		//Genre:       ch.Genres,
 		ArtistIDs:   artistIDs,
 		ArtistNames: artistNames,
 		Album:       ch.Album,
@@ -386,6 +460,11 @@ func toTrack(ch *jellyfin.Song) *mediaprovider.Track {
 		Rating:      ch.UserData.Rating,
 		Favorite:    ch.UserData.IsFavorite,
 		PlayCount:   ch.UserData.PlayCount,
+		Work:        work,
+		Movement:    movement,
+	}
+	if lastPlayed, err := time.Parse(time.RFC3339Nano, ch.UserData.LastPlayedDate); err == nil {
+		t.LastPlayed = lastPlayed
 	}
 	if len(ch.MediaSources) > 0 {
 		t.FilePath = ch.MediaSources[0].Path
@@ -407,6 +486,7 @@ func fillArtist(a *jellyfin.Artist, artist *mediaprovider.Artist) {
 	artist.ID = a.ID
 	artist.Name = a.Name
 	artist.CoverArtID = a.ID
+	artist.HasImage = a.ImageTags.Primary != ""
 }
 
 func toAlbum(a *jellyfin.Album) *mediaprovider.Album {
@@ -448,6 +528,12 @@ func (j *jellyfinMediaProvider) fillPlaylist(p *jellyfin.Playlist, pl *mediaprov
 	pl.Description = p.Overview
 	pl.TrackCount = p.SongCount
 	pl.Duration = int(p.RunTimeTicks / runTimeTicksPerSecond)
+	for _, date := range []string{p.DateLastMediaAdded, p.DateCreated} {
+		if t, err := time.Parse(time.RFC3339Nano, date); err == nil {
+			pl.Changed = t
+			break
+		}
+	}
 	// Jellyfin does not have public playlists
 	pl.Owner = j.client.LoggedInUser()
 	pl.Public = false
@@ -0,0 +1,251 @@
+package jellyfin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// Jellyfin item fields which are not (yet) modeled by go-jellyfin.
+
+type jfItemExtras struct {
+	ID             string            `json:"Id"`
+	Name           string            `json:"Name"`
+	IndexNumber    int               `json:"IndexNumber"`
+	DiscNumber     int               `json:"ParentIndexNumber"`
+	PremiereDate   string            `json:"PremiereDate"`
+	ProductionYear int               `json:"ProductionYear"`
+	Genres         []string          `json:"Genres"`
+	Studios        []jfNameID        `json:"Studios"`
+	ProviderIds    map[string]string `json:"ProviderIds"`
+	ExternalUrls   []jfExternalURL   `json:"ExternalUrls"`
+	People         []jfPerson        `json:"People"`
+}
+
+type jfNameID struct {
+	Name string `json:"Name"`
+	ID   string `json:"Id"`
+}
+
+type jfExternalURL struct {
+	Name string `json:"Name"`
+	URL  string `json:"Url"`
+}
+
+type jfPerson struct {
+	Name string `json:"Name"`
+	ID   string `json:"Id"`
+	Role string `json:"Role"`
+	Type string `json:"Type"`
+}
+
+type jfItemsResponse struct {
+	Items      []*jfItemExtras `json:"Items"`
+	TotalCount int             `json:"TotalRecordCount"`
+}
+
+// loginRecorder records the user ID and access token from the
+// client's login response, since go-jellyfin does not expose them.
+type loginRecorder struct {
+	base http.RoundTripper
+
+	mutex  sync.Mutex
+	userID string
+	token  string
+}
+
+func (l *loginRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := l.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || req.Method != http.MethodPost ||
+		!strings.HasSuffix(strings.ToLower(req.URL.Path), "/users/authenticatebyname") {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	var auth struct {
+		User struct {
+			ID string `json:"Id"`
+		} `json:"User"`
+		Token string `json:"AccessToken"`
+	}
+	if err := json.Unmarshal(body, &auth); err == nil {
+		l.mutex.Lock()
+		l.userID, l.token = auth.User.ID, auth.Token
+		l.mutex.Unlock()
+	}
+	return resp, nil
+}
+
+// rawCredentials returns the user ID and access token for the logged-in session.
+func (j *jellyfinMediaProvider) rawCredentials() (userID, token string, err error) {
+	if j.login == nil {
+		return "", "", errors.New("not logged in")
+	}
+	j.login.mutex.Lock()
+	defer j.login.mutex.Unlock()
+	if j.login.userID == "" || j.login.token == "" {
+		return "", "", errors.New("not logged in")
+	}
+	return j.login.userID, j.login.token, nil
+}
+
+// rawGet performs an authenticated GET request against the given path
+// (which may contain a "{userId}" placeholder) and decodes the JSON response into dest.
+func (j *jellyfinMediaProvider) rawGet(path string, query url.Values, dest any) error {
+	body, err := j.rawGetBody(path, query)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, dest)
+}
+
+// rawGetBody performs an authenticated GET request against the given path
+// (which may contain a "{userId}" placeholder) and returns the response body.
+func (j *jellyfinMediaProvider) rawGetBody(path string, query url.Values) ([]byte, error) {
+	userID, token, err := j.rawCredentials()
+	if err != nil {
+		return nil, err
+	}
+	u := j.client.BaseURL().JoinPath(strings.ReplaceAll(path, "{userId}", userID))
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("UserId", userID)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Emby-Token", token)
+	resp, err := j.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// getItemExtras fetches a single item, requesting the given additional fields
+func (j *jellyfinMediaProvider) getItemExtras(itemID string, fields ...string) (*jfItemExtras, error) {
+	var item jfItemExtras
+	q := url.Values{}
+	q.Set("Fields", strings.Join(fields, ","))
+	if err := j.rawGet("/Users/{userId}/Items/"+itemID, q, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// getChildItemsExtras fetches the (audio) children of an item such as an album,
+// requesting the given additional fields
+func (j *jellyfinMediaProvider) getChildItemsExtras(parentID string, fields ...string) ([]*jfItemExtras, error) {
+	var items jfItemsResponse
+	q := url.Values{}
+	q.Set("ParentId", parentID)
+	q.Set("IncludeItemTypes", "Audio")
+	q.Set("Recursive", "true")
+	q.Set("SortBy", "ParentIndexNumber,IndexNumber,SortName")
+	q.Set("Fields", strings.Join(fields, ","))
+	if err := j.rawGet("/Users/{userId}/Items", q, &items); err != nil {
+		return nil, err
+	}
+	return items.Items, nil
+}
+
+// getSongsWithExtras fetches songs matching the given query, decoding
+// both the go-jellyfin Song model and the extra fields (genres, people)
+// from the same response.
+func (j *jellyfinMediaProvider) getSongsWithExtras(query url.Values) ([]*mediaprovider.Track, error) {
+	query.Set("IncludeItemTypes", "Audio")
+	query.Set("Recursive", "true")
+	query.Set("Fields", "Genres,DateCreated,MediaSources,UserData,ParentId,People,ProviderIds")
+	if query.Get("SortBy") == "" {
+		query.Set("SortBy", "SortName")
+	}
+	body, err := j.rawGetBody("/Users/{userId}/Items", query)
+	if err != nil {
+		return nil, err
+	}
+	var songs struct {
+		Items []*jellyfin.Song `json:"Items"`
+	}
+	if err := json.Unmarshal(body, &songs); err != nil {
+		return nil, err
+	}
+	var extras jfItemsResponse
+	if err := json.Unmarshal(body, &extras); err != nil {
+		return nil, err
+	}
+	tracks := make([]*mediaprovider.Track, 0, len(songs.Items))
+	for i, song := range songs.Items {
+		tr := toTrack(song)
+		if i < len(extras.Items) {
+			fillTrackExtras(tr, extras.Items[i])
+		}
+		tracks = append(tracks, tr)
+	}
+	return tracks, nil
+}
+
+// fillTrackExtras fills in the genres, MusicBrainz ID, composers and other contributors of the track.
+func fillTrackExtras(tr *mediaprovider.Track, item *jfItemExtras) {
+	if item.ID != tr.ID {
+		return
+	}
+	tr.Genres = item.Genres
+	tr.MusicBrainzID = item.ProviderIds["MusicBrainzRecording"]
+	if tr.MusicBrainzID == "" {
+		tr.MusicBrainzID = item.ProviderIds["MusicBrainzTrack"]
+	}
+	for _, p := range item.People {
+		switch p.Type {
+		case "Composer":
+			tr.ComposerIDs = append(tr.ComposerIDs, p.ID)
+			tr.ComposerNames = append(tr.ComposerNames, p.Name)
+		case "AlbumArtist", "Artist":
+			// already modeled as the track artist(s)
+		default:
+			tr.Contributors = append(tr.Contributors, mediaprovider.Contributor{
+				ArtistID: p.ID,
+				Name:     p.Name,
+				Role:     strings.ToLower(p.Type),
+				SubRole:  p.Role,
+			})
+		}
+	}
+}
+
+func (j *jellyfinMediaProvider) GetComposers() ([]*mediaprovider.Composer, error) {
+	var persons jfItemsResponse
+	q := url.Values{}
+	q.Set("PersonTypes", "Composer")
+	if err := j.rawGet("/Persons", q, &persons); err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(persons.Items, func(p *jfItemExtras) *mediaprovider.Composer {
+		return &mediaprovider.Composer{
+			ID:         p.ID,
+			Name:       p.Name,
+			AlbumCount: -1, // unsupported by Jellyfin
+			TrackCount: -1, // unsupported by Jellyfin
+		}
+	}), nil
+}
@@ -20,24 +20,28 @@ func (s *jellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 	var genres []jellyfin.NameID
 	var playlists []*jellyfin.Playlist
 
-	wg.Add(1)
-	go func() {
-		albumResult, _ := s.client.Search(searchQuery, jellyfin.TypeAlbum, jellyfin.Paging{Limit: limit})
-		albums = albumResult.Albums
-		wg.Done()
-	}()
-	wg.Add(1)
-	go func() {
-		artistResult, _ := s.client.Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{Limit: limit})
-		artists = artistResult.Artists
-		wg.Done()
-	}()
-	wg.Add(1)
-	go func() {
-		songResult, _ := s.client.Search(searchQuery, jellyfin.TypeSong, jellyfin.Paging{Limit: limit})
-		songs = songResult.Songs
-		wg.Done()
-	}()
+	// albums, artists and tracks come from the library index if fresh
+	indexed, haveIndexed := s.index.Load().SearchAll(searchQuery, maxResults)
+	if !haveIndexed {
+		wg.Add(1)
+		go func() {
+			albumResult, _ := s.client.Search(searchQuery, jellyfin.TypeAlbum, jellyfin.Paging{Limit: limit})
+			albums = albumResult.Albums
+			wg.Done()
+		}()
+		wg.Add(1)
+		go func() {
+			artistResult, _ := s.client.Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{Limit: limit})
+			artists = artistResult.Artists
+			wg.Done()
+		}()
+		wg.Add(1)
+		go func() {
+			songResult, _ := s.client.Search(searchQuery, jellyfin.TypeSong, jellyfin.Paging{Limit: limit})
+			songs = songResult.Songs
+			wg.Done()
+		}()
+	}
 
 	querySanitized := strings.ToLower(sanitize.Accents(searchQuery))
 	queryLowerWords := strings.Fields(querySanitized)
@@ -66,7 +70,7 @@ func (s *jellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 
 	wg.Wait()
 
-	results := mergeResults(albums, artists, songs, playlists, genres)
+	results := append(indexed, mergeResults(albums, artists, songs, playlists, genres)...)
 	helpers.RankSearchResults(results, searchQuery, queryLowerWords)
 
 	return results, nil
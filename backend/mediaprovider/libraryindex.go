@@ -0,0 +1,468 @@
+package mediaprovider
+
+import (
+	"encoding/gob"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deluan/sanitize"
+)
+
+// LibraryIndexer is implemented by media providers which can answer
+// iteration, filter and search requests from a local LibraryIndex.
+type LibraryIndexer interface {
+	SetLibraryIndex(index *LibraryIndex)
+}
+
+// LibraryIndex is a local copy of the album, artist and track metadata
+// of a server's library. While it is fresh, media providers answer
+// requests from it instead of paging through results from the server.
+//
+// The index is held in memory and saved as a single gob file, rather than
+// in a database such as SQLite: every query scans or sorts the whole list
+// of items anyway (filters combine arbitrary fields, and search matches
+// substrings), which is fast in memory even for libraries of several
+// hundred thousand tracks, and it avoids a cgo or large pure-Go
+// database dependency for a cache that can always be rebuilt.
+type LibraryIndex struct {
+	// MaxAge is how long after the last sync the index is considered fresh.
+	MaxAge time.Duration
+
+	mutex        sync.RWMutex
+	lastSync     time.Time
+	lastFullSync time.Time
+	albums       []*Album  // most recently added first
+	artists      []*Artist // sorted by name
+	tracks       []*Track
+
+	// lowercased, accent-stripped text to match search
+	// queries against, at the same indexes as the items
+	albumKeys  []string
+	artistKeys []string
+	trackKeys  []string
+}
+
+// LibraryIndexStats are summary statistics of the indexed library.
+type LibraryIndexStats struct {
+	Albums        int
+	Artists       int
+	Tracks        int
+	TotalDuration time.Duration
+	TotalSize     int64
+	LastSync      time.Time
+}
+
+// the on-disk format of the index
+type libraryIndexData struct {
+	LastSync     time.Time
+	LastFullSync time.Time
+	Albums       []*Album
+	Artists      []*Artist
+	Tracks       []*Track
+}
+
+func NewLibraryIndex(maxAge time.Duration) *LibraryIndex {
+	return &LibraryIndex{MaxAge: maxAge}
+}
+
+// IsFresh returns true if the index has been synced within MaxAge.
+func (l *LibraryIndex) IsFresh() bool {
+	if l == nil {
+		return false
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return !l.lastSync.IsZero() && time.Since(l.lastSync) < l.MaxAge
+}
+
+// LastSync returns the time of the last sync, or the zero time if never synced.
+func (l *LibraryIndex) LastSync() time.Time {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.lastSync
+}
+
+// LastFullSync returns the time of the last full (not incremental) sync.
+func (l *LibraryIndex) LastFullSync() time.Time {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.lastFullSync
+}
+
+func (l *LibraryIndex) Stats() LibraryIndexStats {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	stats := LibraryIndexStats{
+		Albums:   len(l.albums),
+		Artists:  len(l.artists),
+		Tracks:   len(l.tracks),
+		LastSync: l.lastSync,
+	}
+	for _, tr := range l.tracks {
+		stats.TotalDuration += time.Duration(tr.Duration) * time.Second
+		stats.TotalSize += tr.Size
+	}
+	return stats
+}
+
+// Albums returns the indexed albums, most recently added first.
+// The returned albums must not be modified.
+func (l *LibraryIndex) Albums() []*Album {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.albums
+}
+
+// Tracks returns the indexed tracks. The returned tracks must not be modified.
+func (l *LibraryIndex) Tracks() []*Track {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.tracks
+}
+
+// Replace replaces the contents of the index with the results of a sync.
+// Albums must be ordered most recently added first.
+func (l *LibraryIndex) Replace(albums []*Album, artists []*Artist, tracks []*Track, syncTime time.Time, fullSync bool) {
+	artists = slices.Clone(artists)
+	slices.SortStableFunc(artists, func(a, b *Artist) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	fillArtistGenres(artists, albums)
+
+	albumKeys := make([]string, len(albums))
+	for i, al := range albums {
+		albumKeys[i] = searchKey(al.Name, strings.Join(al.ArtistNames, " "))
+	}
+	artistKeys := make([]string, len(artists))
+	for i, ar := range artists {
+		artistKeys[i] = searchKey(ar.Name)
+	}
+	trackKeys := make([]string, len(tracks))
+	for i, tr := range tracks {
+		trackKeys[i] = searchKey(tr.Name, tr.Album, strings.Join(tr.ArtistNames, " "))
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.albums, l.artists, l.tracks = albums, artists, tracks
+	l.albumKeys, l.artistKeys, l.trackKeys = albumKeys, artistKeys, trackKeys
+	l.lastSync = syncTime
+	if fullSync {
+		l.lastFullSync = syncTime
+	}
+}
+
+// ApplyFavorites updates the favorite status of all indexed items
+// to match the given favorites, as returned by the server.
+func (l *LibraryIndex) ApplyFavorites(favs Favorites) {
+	idSet := func(ids []string) map[string]bool {
+		m := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	albums := idSet(mapIDs(favs.Albums, func(a *Album) string { return a.ID }))
+	artists := idSet(mapIDs(favs.Artists, func(a *Artist) string { return a.ID }))
+	tracks := idSet(mapIDs(favs.Tracks, func(t *Track) string { return t.ID }))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, al := range l.albums {
+		al.Favorite = albums[al.ID]
+	}
+	for _, ar := range l.artists {
+		ar.Favorite = artists[ar.ID]
+	}
+	for _, tr := range l.tracks {
+		tr.Favorite = tracks[tr.ID]
+	}
+}
+
+// UpdateFavorite updates the favorite status of indexed items after it
+// has been successfully changed on the server.
+func (l *LibraryIndex) UpdateFavorite(params RatingFavoriteParameters, favorite bool) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, al := range l.albums {
+		if slices.Contains(params.AlbumIDs, al.ID) {
+			al.Favorite = favorite
+		}
+	}
+	for _, ar := range l.artists {
+		if slices.Contains(params.ArtistIDs, ar.ID) {
+			ar.Favorite = favorite
+		}
+	}
+	for _, tr := range l.tracks {
+		if slices.Contains(params.TrackIDs, tr.ID) {
+			tr.Favorite = favorite
+		}
+	}
+}
+
+// UpdateRating updates the rating of indexed tracks after it
+// has been successfully changed on the server.
+func (l *LibraryIndex) UpdateRating(params RatingFavoriteParameters, rating int) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, tr := range l.tracks {
+		if slices.Contains(params.TrackIDs, tr.ID) {
+			tr.Rating = rating
+		}
+	}
+}
+
+// Save writes the index to w.
+func (l *LibraryIndex) Save(w io.Writer) error {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return gob.NewEncoder(w).Encode(libraryIndexData{
+		LastSync:     l.lastSync,
+		LastFullSync: l.lastFullSync,
+		Albums:       l.albums,
+		Artists:      l.artists,
+		Tracks:       l.tracks,
+	})
+}
+
+// Load replaces the contents of the index with an index previously saved to r.
+func (l *LibraryIndex) Load(r io.Reader) error {
+	var data libraryIndexData
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	l.Replace(data.Albums, data.Artists, data.Tracks, data.LastSync, false)
+	l.mutex.Lock()
+	l.lastFullSync = data.LastFullSync
+	l.mutex.Unlock()
+	return nil
+}
+
+// IterateAlbums returns an iterator over the indexed albums matching the
+// filter, sorted with cmp, or most recently added first if cmp is nil.
+// Returns nil if the index is not fresh.
+func (l *LibraryIndex) IterateAlbums(filter AlbumFilter, cmp func(a, b *Album) int) AlbumIterator {
+	if !l.IsFresh() {
+		return nil
+	}
+	return l.newAlbumIterator("", filter, cmp)
+}
+
+// SearchAlbums returns an iterator over the indexed albums matching the
+// search query and filter. Returns nil if the index is not fresh.
+func (l *LibraryIndex) SearchAlbums(query string, filter AlbumFilter) AlbumIterator {
+	if !l.IsFresh() {
+		return nil
+	}
+	return l.newAlbumIterator(query, filter, nil)
+}
+
+// IterateArtists returns an iterator over the indexed artists matching
+// the filter, sorted by name. Returns nil if the index is not fresh.
+func (l *LibraryIndex) IterateArtists(filter ArtistFilter) ArtistIterator {
+	if !l.IsFresh() {
+		return nil
+	}
+	return l.newArtistIterator("", filter)
+}
+
+// SearchArtists returns an iterator over the indexed artists matching the
+// search query and filter. Returns nil if the index is not fresh.
+func (l *LibraryIndex) SearchArtists(query string, filter ArtistFilter) ArtistIterator {
+	if !l.IsFresh() {
+		return nil
+	}
+	return l.newArtistIterator(query, filter)
+}
+
+// IterateTracks returns an iterator over the indexed tracks matching the
+// search query, if not empty, and the filter. Returns nil if the index is not fresh.
+func (l *LibraryIndex) IterateTracks(query string, filter TrackFilter) TrackIterator {
+	if !l.IsFresh() {
+		return nil
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return &indexIterator[Track]{
+		mutex: &l.mutex,
+		items: matchingItems(l.tracks, l.trackKeys, query, filter.Matches),
+	}
+}
+
+// SearchAll returns the indexed albums, artists and tracks matching the
+// query, up to maxResults/3 of each. Playlists and genres are not indexed.
+// Returns false if the index is not fresh.
+func (l *LibraryIndex) SearchAll(query string, maxResults int) ([]*SearchResult, bool) {
+	if !l.IsFresh() || strings.TrimSpace(query) == "" {
+		return nil, false
+	}
+	limit := maxResults / 3
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	var results []*SearchResult
+	for _, al := range firstN(matchingItems(l.albums, l.albumKeys, query, nil), limit) {
+		results = append(results, &SearchResult{
+			Type:       ContentTypeAlbum,
+			ID:         al.ID,
+			CoverID:    al.CoverArtID,
+			Name:       al.Name,
+			ArtistName: strings.Join(al.ArtistNames, ", "),
+			Size:       al.TrackCount,
+		})
+	}
+	for _, ar := range firstN(matchingItems(l.artists, l.artistKeys, query, nil), limit) {
+		results = append(results, &SearchResult{
+			Type:    ContentTypeArtist,
+			ID:      ar.ID,
+			CoverID: ar.CoverArtID,
+			Name:    ar.Name,
+			Size:    ar.AlbumCount,
+		})
+	}
+	for _, tr := range firstN(matchingItems(l.tracks, l.trackKeys, query, nil), limit) {
+		results = append(results, &SearchResult{
+			Type:       ContentTypeTrack,
+			ID:         tr.ID,
+			CoverID:    tr.CoverArtID,
+			Name:       tr.Name,
+			ArtistName: strings.Join(tr.ArtistNames, ", "),
+			Size:       tr.Duration,
+		})
+	}
+	return results, true
+}
+
+// CompareAlbumsByTitle compares albums by name, case-insensitively.
+func CompareAlbumsByTitle(a, b *Album) int {
+	return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+}
+
+// CompareAlbumsByArtist compares albums by artist name, then album name.
+func CompareAlbumsByArtist(a, b *Album) int {
+	if c := strings.Compare(strings.ToLower(strings.Join(a.ArtistNames, ", ")),
+		strings.ToLower(strings.Join(b.ArtistNames, ", "))); c != 0 {
+		return c
+	}
+	return CompareAlbumsByTitle(a, b)
+}
+
+// CompareAlbumsByYear compares albums by year, then album name.
+func CompareAlbumsByYear(a, b *Album) int {
+	if a.Year != b.Year {
+		return a.Year - b.Year
+	}
+	return CompareAlbumsByTitle(a, b)
+}
+
+func (l *LibraryIndex) newAlbumIterator(query string, filter AlbumFilter, cmp func(a, b *Album) int) AlbumIterator {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	albums := matchingItems(l.albums, l.albumKeys, query, filter.Matches)
+	if cmp != nil {
+		slices.SortStableFunc(albums, cmp)
+	}
+	return &indexIterator[Album]{mutex: &l.mutex, items: albums}
+}
+
+func (l *LibraryIndex) newArtistIterator(query string, filter ArtistFilter) ArtistIterator {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return &indexIterator[Artist]{
+		mutex: &l.mutex,
+		items: matchingItems(l.artists, l.artistKeys, query, filter.Matches),
+	}
+}
+
+// indexIterator iterates over copies of matching indexed items,
+// so that callers may modify them without affecting the index.
+type indexIterator[M any] struct {
+	mutex *sync.RWMutex
+	items []*M
+	pos   int
+}
+
+func (i *indexIterator[M]) Next() *M {
+	if i.pos >= len(i.items) {
+		return nil
+	}
+	i.mutex.RLock()
+	c := *i.items[i.pos]
+	i.mutex.RUnlock()
+	i.pos++
+	return &c
+}
+
+// returns the items whose search keys contain all the words of the query,
+// if not empty, and which match the filter, if not nil.
+// Must be called with the index's mutex held.
+func matchingItems[M any](items []*M, keys []string, query string, matches func(*M) bool) []*M {
+	terms := strings.Fields(searchKey(query))
+	var result []*M
+	for i, item := range items {
+		if len(terms) > 0 && !allTermsMatch(keys[i], terms) {
+			continue
+		}
+		if matches != nil && !matches(item) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func allTermsMatch(key string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(key, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func searchKey(fields ...string) string {
+	return strings.ToLower(sanitize.Accents(strings.Join(fields, " ")))
+}
+
+// fills in the genres of artists without genres from the genres of their albums
+func fillArtistGenres(artists []*Artist, albums []*Album) {
+	genres := make(map[string][]string)
+	for _, al := range albums {
+		for _, id := range al.ArtistIDs {
+			for _, g := range al.Genres {
+				if !slices.Contains(genres[id], g) {
+					genres[id] = append(genres[id], g)
+				}
+			}
+		}
+	}
+	for _, ar := range artists {
+		if len(ar.Genres) == 0 {
+			ar.Genres = genres[ar.ID]
+		}
+	}
+}
+
+func mapIDs[M any](items []*M, id func(*M) string) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = id(item)
+	}
+	return ids
+}
+
+func firstN[M any](items []*M, n int) []*M {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
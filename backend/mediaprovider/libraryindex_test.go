@@ -0,0 +1,118 @@
+package mediaprovider
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testLibraryIndex(syncTime time.Time) *LibraryIndex {
+	l := NewLibraryIndex(time.Hour)
+	albums := []*Album{
+		{ID: "al1", Name: "Señorita Sessions", ArtistIDs: []string{"ar1"}, ArtistNames: []string{"Björk"}, Genres: []string{"Electronic"}, Year: 1997},
+		{ID: "al2", Name: "Blue Train", ArtistIDs: []string{"ar2"}, ArtistNames: []string{"John Coltrane"}, Genres: []string{"Jazz"}, Year: 1957},
+	}
+	artists := []*Artist{{ID: "ar2", Name: "John Coltrane"}, {ID: "ar1", Name: "björk"}}
+	tracks := []*Track{
+		{ID: "t1", AlbumID: "al1", Name: "Hunter", Album: "Señorita Sessions", ArtistNames: []string{"Björk"}},
+		{ID: "t2", AlbumID: "al2", Name: "Blue Train", Album: "Blue Train", ArtistNames: []string{"John Coltrane"}},
+		{ID: "t3", AlbumID: "al2", Name: "Moment's Notice", Album: "Blue Train", ArtistNames: []string{"John Coltrane"}},
+	}
+	l.Replace(albums, artists, tracks, syncTime, true)
+	return l
+}
+
+func Test_LibraryIndexFreshness(t *testing.T) {
+	var nilIndex *LibraryIndex
+	if nilIndex.IsFresh() || nilIndex.IterateTracks("", NewTrackFilter(TrackFilterOptions{})) != nil {
+		t.Error("nil index should not be fresh")
+	}
+	if NewLibraryIndex(time.Hour).IsFresh() {
+		t.Error("never synced index should not be fresh")
+	}
+	stale := testLibraryIndex(time.Now().Add(-2 * time.Hour))
+	if stale.IsFresh() {
+		t.Error("index synced before MaxAge should not be fresh")
+	}
+	if stale.SearchAlbums("blue", NewAlbumFilter(AlbumFilterOptions{})) != nil {
+		t.Error("stale index should not answer searches")
+	}
+	if _, ok := stale.SearchAll("blue", 9); ok {
+		t.Error("stale index should not answer SearchAll")
+	}
+	if !testLibraryIndex(time.Now()).IsFresh() {
+		t.Error("just synced index should be fresh")
+	}
+}
+
+func Test_LibraryIndexSearch(t *testing.T) {
+	l := testLibraryIndex(time.Now())
+	trackIDs := func(iter TrackIterator) string {
+		var ids string
+		for tr := iter.Next(); tr != nil; tr = iter.Next() {
+			ids += tr.ID
+		}
+		return ids
+	}
+	noFilter := NewTrackFilter(TrackFilterOptions{})
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "t1t2t3"},
+		{"blue", "t2t3"},
+		{"BLUE train", "t2t3"},
+		{"coltrane moment", "t3"},
+		{"bjork senorita", "t1"},
+		{"train hunter", ""},
+	}
+	for _, tt := range tests {
+		if got := trackIDs(l.IterateTracks(tt.query, noFilter)); got != tt.want {
+			t.Errorf("IterateTracks(%q): got %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	iter := l.SearchAlbums("", NewAlbumFilter(AlbumFilterOptions{MaxYear: 1960}))
+	if al := iter.Next(); al == nil || al.ID != "al2" || iter.Next() != nil {
+		t.Error("album filter not applied")
+	}
+
+	artistIter := l.IterateArtists(NewArtistFilter(ArtistFilterOptions{Genres: []string{"Jazz"}}))
+	if ar := artistIter.Next(); ar == nil || ar.ID != "ar2" || artistIter.Next() != nil {
+		t.Error("artist genres not filled in from albums")
+	}
+
+	// items are copies, so modifying them doesn't affect the index
+	l.IterateTracks("hunter", noFilter).Next().Name = "Changed"
+	if trackIDs(l.IterateTracks("hunter", noFilter)) != "t1" {
+		t.Error("modifying an iterated track modified the index")
+	}
+
+	results, ok := l.SearchAll("blue", 9)
+	if !ok || len(results) != 3 {
+		t.Errorf("SearchAll: got %d results (ok %v), want 3", len(results), ok)
+	}
+}
+
+func Test_LibraryIndexSaveLoad(t *testing.T) {
+	l := testLibraryIndex(time.Now())
+	l.UpdateFavorite(RatingFavoriteParameters{TrackIDs: []string{"t2"}}, true)
+	var buf bytes.Buffer
+	if err := l.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewLibraryIndex(time.Hour)
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsFresh() || !loaded.LastFullSync().Equal(l.LastFullSync()) {
+		t.Error("sync times not restored")
+	}
+	if stats := loaded.Stats(); stats.Albums != 2 || stats.Artists != 2 || stats.Tracks != 3 {
+		t.Errorf("got stats %+v", stats)
+	}
+	iter := loaded.IterateTracks("", NewTrackFilter(TrackFilterOptions{ExcludeUnfavorited: true}))
+	if tr := iter.Next(); tr == nil || tr.ID != "t2" || iter.Next() != nil {
+		t.Error("favorite not restored")
+	}
+}
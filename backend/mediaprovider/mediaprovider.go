@@ -11,6 +11,21 @@ type MediaIterator[M any] interface {
 	Next() *M
 }
 
+// IteratorWithErr is implemented by iterators which
+// can report an error which ended the iteration early.
+type IteratorWithErr interface {
+	Err() error
+}
+
+// IteratorErr returns the error which ended the iteration early,
+// or nil if there was none or the iterator can't report it.
+func IteratorErr[M any](iter MediaIterator[M]) error {
+	if e, ok := iter.(IteratorWithErr); ok {
+		return e.Err()
+	}
+	return nil
+}
+
 type ArtistIterator = MediaIterator[Artist]
 type AlbumIterator = MediaIterator[Album]
 type TrackIterator = MediaIterator[Track]
@@ -25,23 +40,41 @@ type MediaFilter[M, F any] interface {
 
 type AlbumFilter = MediaFilter[Album, AlbumFilterOptions]
 
+// TagMatchMode specifies how a list of tags (genres, moods)
+// in a filter is matched against the tags of an item.
+type TagMatchMode int
+
+const (
+	TagMatchAny TagMatchMode = iota // item has at least one of the tags (OR)
+	TagMatchAll                     // item has all of the tags (AND)
+)
+
 type AlbumFilterOptions struct {
 	MinYear int
 	MaxYear int      // 0 == unset/match any
 	Genres  []string // len(0) == unset/match any
 
+	GenreMatch    TagMatchMode
+	ExcludeGenres []string // NOT - albums with any of these genres don't match
+	Moods         []string // len(0) == unset/match any
+	MoodMatch     TagMatchMode
+	ExcludeMoods  []string // NOT - albums with any of these moods don't match
+
 	ExcludeFavorited   bool // mut. exc. with ExcludeUnfavorited
 	ExcludeUnfavorited bool // mut. exc. with ExcludeFavorited
 }
 
 // Clone returns a deep copy of the filter options
 func (o AlbumFilterOptions) Clone() AlbumFilterOptions {
-	genres := make([]string, len(o.Genres))
-	copy(genres, o.Genres)
 	return AlbumFilterOptions{
 		MinYear:            o.MinYear,
 		MaxYear:            o.MaxYear,
-		Genres:             genres,
+		Genres:             cloneStrings(o.Genres),
+		GenreMatch:         o.GenreMatch,
+		ExcludeGenres:      cloneStrings(o.ExcludeGenres),
+		Moods:              cloneStrings(o.Moods),
+		MoodMatch:          o.MoodMatch,
+		ExcludeMoods:       cloneStrings(o.ExcludeMoods),
 		ExcludeFavorited:   o.ExcludeFavorited,
 		ExcludeUnfavorited: o.ExcludeUnfavorited,
 	}
@@ -71,7 +104,8 @@ func (a albumFilter) Clone() AlbumFilter {
 // Returns true if the filter is the nil filter - i.e. matches everything
 func (a albumFilter) IsNil() bool {
 	return a.options.MinYear == 0 && a.options.MaxYear == 0 &&
-		len(a.options.Genres) == 0 &&
+		len(a.options.Genres) == 0 && len(a.options.ExcludeGenres) == 0 &&
+		len(a.options.Moods) == 0 && len(a.options.ExcludeMoods) == 0 &&
 		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited
 }
 
@@ -88,19 +122,120 @@ func (f albumFilter) Matches(album *Album) bool {
 	if y := album.Year; y < f.options.MinYear || (f.options.MaxYear > 0 && y > f.options.MaxYear) {
 		return false
 	}
-	if len(f.options.Genres) == 0 {
-		return true
+	return TagsMatch(f.options.Genres, f.options.GenreMatch, f.options.ExcludeGenres, album.Genres) &&
+		TagsMatch(f.options.Moods, f.options.MoodMatch, f.options.ExcludeMoods, album.Moods)
+}
+
+type TrackFilter = MediaFilter[Track, TrackFilterOptions]
+
+type TrackFilterOptions struct {
+	MinYear int
+	MaxYear int      // 0 == unset/match any
+	Genres  []string // len(0) == unset/match any
+
+	GenreMatch    TagMatchMode
+	ExcludeGenres []string // NOT - tracks with any of these genres don't match
+	Moods         []string // len(0) == unset/match any
+	MoodMatch     TagMatchMode
+	ExcludeMoods  []string // NOT - tracks with any of these moods don't match
+
+	ExcludeFavorited   bool // mut. exc. with ExcludeUnfavorited
+	ExcludeUnfavorited bool // mut. exc. with ExcludeFavorited
+}
+
+// Clone returns a deep copy of the filter options
+func (o TrackFilterOptions) Clone() TrackFilterOptions {
+	return TrackFilterOptions{
+		MinYear:            o.MinYear,
+		MaxYear:            o.MaxYear,
+		Genres:             cloneStrings(o.Genres),
+		GenreMatch:         o.GenreMatch,
+		ExcludeGenres:      cloneStrings(o.ExcludeGenres),
+		Moods:              cloneStrings(o.Moods),
+		MoodMatch:          o.MoodMatch,
+		ExcludeMoods:       cloneStrings(o.ExcludeMoods),
+		ExcludeFavorited:   o.ExcludeFavorited,
+		ExcludeUnfavorited: o.ExcludeUnfavorited,
+	}
+}
+
+type trackFilter struct {
+	options TrackFilterOptions
+}
+
+func NewTrackFilter(options TrackFilterOptions) *trackFilter {
+	return &trackFilter{options}
+}
+
+func (t trackFilter) Options() TrackFilterOptions {
+	return t.options
+}
+
+func (t *trackFilter) SetOptions(options TrackFilterOptions) {
+	t.options = options
+}
+
+// Clone returns a deep copy of the filter
+func (t trackFilter) Clone() TrackFilter {
+	return NewTrackFilter(t.options.Clone())
+}
+
+// Returns true if the filter is the nil filter - i.e. matches everything
+func (t trackFilter) IsNil() bool {
+	return t.options.MinYear == 0 && t.options.MaxYear == 0 &&
+		len(t.options.Genres) == 0 && len(t.options.ExcludeGenres) == 0 &&
+		len(t.options.Moods) == 0 && len(t.options.ExcludeMoods) == 0 &&
+		!t.options.ExcludeFavorited && !t.options.ExcludeUnfavorited
+}
+
+func (f trackFilter) Matches(track *Track) bool {
+	if track == nil {
+		return false
+	}
+	if f.options.ExcludeFavorited && track.Favorite {
+		return false
 	}
-	return genresMatch(f.options.Genres, album.Genres)
+	if f.options.ExcludeUnfavorited && !track.Favorite {
+		return false
+	}
+	if y := track.Year; y < f.options.MinYear || (f.options.MaxYear > 0 && y > f.options.MaxYear) {
+		return false
+	}
+	return TagsMatch(f.options.Genres, f.options.GenreMatch, f.options.ExcludeGenres, track.Genres) &&
+		TagsMatch(f.options.Moods, f.options.MoodMatch, f.options.ExcludeMoods, track.Moods)
 }
 
 type ArtistFilter = MediaFilter[Artist, ArtistFilterOptions]
 
-type ArtistFilterOptions struct{}
+type ArtistFilterOptions struct {
+	MinAlbumCount int      // 0 == unset/match any
+	Genres        []string // len(0) == unset/match any
+
+	GenreMatch    TagMatchMode
+	ExcludeGenres []string // NOT - artists with albums in any of these genres don't match
+
+	ExcludeFavorited    bool // mut. exc. with ExcludeUnfavorited
+	ExcludeUnfavorited  bool // mut. exc. with ExcludeFavorited
+	ExcludeWithoutImage bool
+}
 
 // Clone returns a deep copy of the filter options
 func (o ArtistFilterOptions) Clone() ArtistFilterOptions {
-	return ArtistFilterOptions{}
+	return ArtistFilterOptions{
+		MinAlbumCount:       o.MinAlbumCount,
+		Genres:              cloneStrings(o.Genres),
+		GenreMatch:          o.GenreMatch,
+		ExcludeGenres:       cloneStrings(o.ExcludeGenres),
+		ExcludeFavorited:    o.ExcludeFavorited,
+		ExcludeUnfavorited:  o.ExcludeUnfavorited,
+		ExcludeWithoutImage: o.ExcludeWithoutImage,
+	}
+}
+
+// HasGenreFilter returns true if the filter includes or excludes any genres.
+// Artist iterators must then fill in the artists' genres for the filter to match.
+func (o ArtistFilterOptions) HasGenreFilter() bool {
+	return len(o.Genres) > 0 || len(o.ExcludeGenres) > 0
 }
 
 type artistFilter struct {
@@ -126,14 +261,28 @@ func (a artistFilter) Clone() ArtistFilter {
 
 // Returns true if the filter is the nil filter - i.e. matches everything
 func (a artistFilter) IsNil() bool {
-	return true
+	return a.options.MinAlbumCount == 0 && !a.options.HasGenreFilter() &&
+		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited &&
+		!a.options.ExcludeWithoutImage
 }
 
 func (f artistFilter) Matches(artist *Artist) bool {
 	if artist == nil {
 		return false
 	}
-	return false
+	if f.options.ExcludeFavorited && artist.Favorite {
+		return false
+	}
+	if f.options.ExcludeUnfavorited && !artist.Favorite {
+		return false
+	}
+	if f.options.ExcludeWithoutImage && !artist.HasImage {
+		return false
+	}
+	if artist.AlbumCount < f.options.MinAlbumCount {
+		return false
+	}
+	return TagsMatch(f.options.Genres, f.options.GenreMatch, f.options.ExcludeGenres, artist.Genres)
 }
 
 type RatingFavoriteParameters struct {
@@ -179,7 +328,7 @@ type MediaProvider interface {
 
 	IterateAlbums(sortOrder string, filter AlbumFilter) AlbumIterator
 
-	IterateTracks(searchQuery string) TrackIterator
+	IterateTracks(searchQuery string, filter TrackFilter) TrackIterator
 
 	SearchAlbums(searchQuery string, filter AlbumFilter) AlbumIterator
 
@@ -250,6 +399,44 @@ type LyricsProvider interface {
 	GetLyrics(track *Track) (*Lyrics, error)
 }
 
+// TrackScrobbleDecider is implemented by media providers for which whether
+// the client decides when a track is scrobbled depends on the track,
+// such as when merging the libraries of several servers.
+type TrackScrobbleDecider interface {
+	ClientDecidesTrackScrobble(trackID string) bool
+}
+
+// ClientDecidesScrobble returns true if the `submission` parameter
+// to the provider's TrackEndedPlayback will be respected for the track.
+func ClientDecidesScrobble(mp MediaProvider, trackID string) bool {
+	if d, ok := mp.(TrackScrobbleDecider); ok {
+		return d.ClientDecidesTrackScrobble(trackID)
+	}
+	return mp.ClientDecidesScrobble()
+}
+
+// ComposerProvider is implemented by media providers which can
+// browse the library by composer, for classical music.
+type ComposerProvider interface {
+	GetComposers() ([]*Composer, error)
+	IterateComposerTracks(composer string) TrackIterator
+}
+
+// AdminProvider is implemented by media providers which support
+// administering the server. All methods require an admin user.
+type AdminProvider interface {
+	GetScanStatus() (*ScanStatus, error)
+
+	GetUsers() ([]*User, error)
+	CreateUser(user *User, password string) error
+	UpdateUser(user *User) error
+	DeleteUser(username string) error
+	ChangePassword(username, password string) error
+
+	GetShares() ([]*Share, error)
+	DeleteShare(shareID string) error
+}
+
 type JukeboxProvider interface {
 	JukeboxStart() error
 	JukeboxStop() error
@@ -269,13 +456,43 @@ type JukeboxStatus struct {
 	PositionSeconds float64
 }
 
-func genresMatch(filterGenres, albumGenres []string) bool {
-	for _, g1 := range filterGenres {
-		for _, g2 := range albumGenres {
-			if strings.EqualFold(g1, g2) {
-				return true
-			}
+// TagsMatch returns true if the item tags contain none of the excluded tags,
+// and either any (TagMatchAny) or all (TagMatchAll) of the included tags.
+// Tags are compared case-insensitively. An empty include list matches any item.
+func TagsMatch(include []string, mode TagMatchMode, exclude []string, itemTags []string) bool {
+	for _, t := range exclude {
+		if containsTag(itemTags, t) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, t := range include {
+		has := containsTag(itemTags, t)
+		if has && mode == TagMatchAny {
+			return true
+		} else if !has && mode == TagMatchAll {
+			return false
+		}
+	}
+	return mode == TagMatchAll
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
 		}
 	}
 	return false
 }
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	c := make([]string, len(s))
+	copy(c, s)
+	return c
+}
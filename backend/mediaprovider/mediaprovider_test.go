@@ -0,0 +1,100 @@
+package mediaprovider
+
+import "testing"
+
+func Test_TagsMatch(t *testing.T) {
+	item := []string{"Rock", "Jazz"}
+	tests := []struct {
+		name    string
+		include []string
+		mode    TagMatchMode
+		exclude []string
+		tags    []string
+		want    bool
+	}{
+		{name: "empty filter", tags: item, want: true},
+		{name: "empty filter, no tags", want: true},
+		{name: "any, one present", include: []string{"rock", "pop"}, mode: TagMatchAny, tags: item, want: true},
+		{name: "any, none present", include: []string{"pop", "metal"}, mode: TagMatchAny, tags: item, want: false},
+		{name: "any, no tags", include: []string{"pop"}, mode: TagMatchAny, want: false},
+		{name: "all, all present", include: []string{"rock", "JAZZ"}, mode: TagMatchAll, tags: item, want: true},
+		{name: "all, one missing", include: []string{"rock", "pop"}, mode: TagMatchAll, tags: item, want: false},
+		{name: "none, excluded present", exclude: []string{"jazz"}, tags: item, want: false},
+		{name: "none, excluded absent", exclude: []string{"pop"}, tags: item, want: true},
+		{name: "any, but excluded", include: []string{"rock"}, mode: TagMatchAny, exclude: []string{"jazz"}, tags: item, want: false},
+		{name: "all, but excluded", include: []string{"rock", "jazz"}, mode: TagMatchAll, exclude: []string{"Jazz"}, tags: item, want: false},
+	}
+	for _, tt := range tests {
+		if got := TagsMatch(tt.include, tt.mode, tt.exclude, tt.tags); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_AlbumFilterGenreAndMood(t *testing.T) {
+	album := &Album{Genres: []string{"Rock", "Blues"}, Moods: []string{"Happy"}}
+	filter := NewAlbumFilter(AlbumFilterOptions{
+		Genres:     []string{"Rock", "Blues"},
+		GenreMatch: TagMatchAll,
+		Moods:      []string{"happy", "sad"},
+		MoodMatch:  TagMatchAny,
+	})
+	if !filter.Matches(album) {
+		t.Error("album should match genres (all) and moods (any)")
+	}
+	filter.SetOptions(AlbumFilterOptions{Moods: []string{"happy", "sad"}, MoodMatch: TagMatchAll})
+	if filter.Matches(album) {
+		t.Error("album should not match all moods")
+	}
+}
+
+func Test_NewTrackCredits(t *testing.T) {
+	if c := NewTrackCredits(&Track{ID: "1", Name: "Prelude"}); c != nil {
+		t.Errorf("got credits %+v for track without any, want nil", c)
+	}
+	tr := &Track{
+		ID:            "2",
+		Name:          "Symphony No. 5: I. Allegro con brio",
+		TrackNumber:   1,
+		DiscNumber:    2,
+		ComposerNames: []string{"Ludwig van Beethoven"},
+		Contributors:  []Contributor{{ArtistID: "3", Name: "Carlos Kleiber", Role: "conductor"}},
+	}
+	c := NewTrackCredits(tr)
+	if c == nil {
+		t.Fatal("got nil credits")
+	}
+	if c.TrackID != "2" || c.Name != tr.Name || c.TrackNumber != 1 || c.DiscNumber != 2 {
+		t.Errorf("got track %q %q %d-%d, want 2 %q 2-1", c.TrackID, c.Name, c.DiscNumber, c.TrackNumber, tr.Name)
+	}
+	if len(c.Composers) != 1 || c.Composers[0] != "Ludwig van Beethoven" {
+		t.Errorf("got composers %v", c.Composers)
+	}
+	if len(c.Performers) != 1 || c.Performers[0].Role != "conductor" {
+		t.Errorf("got performers %v", c.Performers)
+	}
+}
+
+func Test_ServerVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		min     string
+		want    bool
+	}{
+		{"0.55.0", "0.55.0", true},
+		{"0.55.2 (d2d0ab2)", "0.55.0", true},
+		{"v0.56", "0.55.0", true},
+		{"1.0", "0.55.0", true},
+		{"0.54.5", "0.55.0", false},
+		{"0.9.0", "0.55.0", false},
+		{"0.55", "0.55.0", true},
+		{"", "0.55.0", false},
+		{"dev", "0.55.0", false},
+	}
+	for _, tt := range tests {
+		c := &Capabilities{ServerVersion: tt.version}
+		if got := c.ServerVersionAtLeast(tt.min); got != tt.want {
+			t.Errorf("ServerVersionAtLeast(%q) for version %q: got %v, want %v", tt.min, tt.version, got, tt.want)
+		}
+	}
+}
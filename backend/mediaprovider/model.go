@@ -1,5 +1,7 @@
 package mediaprovider
 
+import "time"
+
 // Bit field flag for the ReleaseTypes property
 type ReleaseType = int32
 
@@ -40,20 +42,78 @@ type Album struct {
 	Year         int
 	ReissueYear  int
 	Genres       []string
+	Moods        []string
 	TrackCount   int
 	Favorite     bool
 	ReleaseTypes ReleaseTypes
+
+	MusicBrainzID string // release MBID, if known
 }
 
 type AlbumWithTracks struct {
 	Album
 	Tracks []*Track
+
+	// DiscTitles maps disc number to the disc's subtitle,
+	// for multi-disc albums where the server provides them. May be nil.
+	DiscTitles map[int]string
 }
 
 type AlbumInfo struct {
 	Notes         string
 	LastFmUrl     string
 	MusicBrainzID string
+
+	// Release date as returned by the server - may be partial
+	// (e.g. "1977" or "1977-03") depending on how the album is tagged
+	ReleaseDate   string
+	RecordLabels  []string
+	CatalogNumber string
+	ReleaseTypes  ReleaseTypes
+
+	// Map of external service name (e.g. "Discogs") to the album's ID on that service
+	ExternalIDs  map[string]string
+	ExternalURLs []ExternalURL
+
+	// Per-track credits, for tracks that have any credits reported by the server
+	TrackCredits []*TrackCredits
+}
+
+type ExternalURL struct {
+	Name string
+	URL  string
+}
+
+type TrackCredits struct {
+	TrackID     string
+	Name        string
+	TrackNumber int
+	DiscNumber  int
+	Composers   []string
+	Performers  []Contributor
+}
+
+// NewTrackCredits returns the credits of the track, from its composers
+// and other contributors, or nil if it has none.
+func NewTrackCredits(tr *Track) *TrackCredits {
+	if len(tr.ComposerNames) == 0 && len(tr.Contributors) == 0 {
+		return nil
+	}
+	return &TrackCredits{
+		TrackID:     tr.ID,
+		Name:        tr.Name,
+		TrackNumber: tr.TrackNumber,
+		DiscNumber:  tr.DiscNumber,
+		Composers:   tr.ComposerNames,
+		Performers:  tr.Contributors,
+	}
+}
+
+type Contributor struct {
+	ArtistID string
+	Name     string
+	Role     string // e.g. "composer", "conductor", "performer"
+	SubRole  string // e.g. the instrument, for performers
 }
 
 type Artist struct {
@@ -62,6 +122,13 @@ type Artist struct {
 	Name       string
 	Favorite   bool
 	AlbumCount int
+	HasImage   bool
+
+	MusicBrainzID string // artist MBID, if known
+
+	// Genres of the artist's albums. Only filled in
+	// by artist iterators when filtering by genre.
+	Genres []string
 }
 
 type ArtistWithAlbums struct {
@@ -82,27 +149,48 @@ type Genre struct {
 	TrackCount int
 }
 
+type Composer struct {
+	ID         string
+	Name       string
+	AlbumCount int // -1 if unknown
+	TrackCount int // -1 if unknown
+}
+
 type Track struct {
-	ID          string
-	CoverArtID  string
-	ParentID    string
-	Name        string
-	Duration    int
-	TrackNumber int
-	DiscNumber  int
-	Genre       string
-	ArtistIDs   []string
-	ArtistNames []string
-	Album       string
-	AlbumID     string
-	Year        int
-	Rating      int
-	Favorite    bool
-	Size        int64
-	PlayCount   int
-	FilePath    string
-	BitRate     int
-	Comment     string
+	ID            string
+	CoverArtID    string
+	ParentID      string
+	Name          string
+	Duration      int
+	TrackNumber   int
+	DiscNumber    int
+	Genres        []string
+	Moods         []string
+	ArtistIDs     []string
+	ArtistNames   []string
+	ComposerIDs   []string
+	ComposerNames []string
+	Album         string
+	AlbumID       string
+	Year          int
+	Rating        int
+	Favorite      bool
+	Size          int64
+	PlayCount     int
+	LastPlayed    time.Time // zero if never played or unknown
+	FilePath      string
+	BitRate       int
+	Comment       string
+	MusicBrainzID string // recording MBID, if known
+
+	// For classical music, the work this track belongs to and its movement
+	// within the work, if the track title is of the form "Work: I. Movement"
+	Work     string
+	Movement string
+
+	// Credited contributors other than the track artist(s) and composer(s),
+	// such as conductor, performer, orchestra, etc. May be nil.
+	Contributors []Contributor
 }
 
 type Playlist struct {
@@ -114,6 +202,7 @@ type Playlist struct {
 	Owner       string
 	Duration    int
 	TrackCount  int
+	Changed     time.Time // last modified time, zero if unknown
 }
 
 type PlaylistWithTracks struct {
@@ -174,3 +263,41 @@ type SearchResult struct {
 	// Unset for ContentTypes Artist, Playlist, and Genre
 	ArtistName string
 }
+
+type ScanStatus struct {
+	Scanning bool
+	Count    int64 // number of files scanned so far
+}
+
+// User is a user account on the server, managed by admin users.
+type User struct {
+	Username   string
+	Email      string
+	MaxBitRate int // in Kbps, 0 for no limit
+
+	AdminRole    bool
+	SettingsRole bool
+	StreamRole   bool
+	DownloadRole bool
+	UploadRole   bool
+	PlaylistRole bool
+	CoverArtRole bool
+	CommentRole  bool
+	PodcastRole  bool
+	JukeboxRole  bool
+	ShareRole    bool
+}
+
+// Share is a public link created by a user to share tracks or albums.
+type Share struct {
+	ID          string
+	URL         string
+	Description string
+	Username    string
+	Created     time.Time
+	Expires     time.Time // zero if the share never expires
+	LastVisited time.Time
+	VisitCount  int
+	EntryCount  int
+	EntryNames  []string // names of the shared tracks or albums
+}
@@ -0,0 +1,116 @@
+package subsonic
+
+import (
+	"strconv"
+
+	"github.com/dweymouth/go-subsonic/subsonic"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var _ mediaprovider.AdminProvider = (*subsonicMediaProvider)(nil)
+
+func (s *subsonicMediaProvider) GetScanStatus() (*mediaprovider.ScanStatus, error) {
+	status, err := s.client.GetScanStatus()
+	if err != nil {
+		return nil, err
+	}
+	return &mediaprovider.ScanStatus{Scanning: status.Scanning, Count: status.Count}, nil
+}
+
+func (s *subsonicMediaProvider) GetUsers() ([]*mediaprovider.User, error) {
+	// go-subsonic's GetUsers panics on an empty response
+	resp, err := s.client.Get("getUsers", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Users == nil {
+		return nil, nil
+	}
+	return sharedutil.MapSlice(resp.Users.User, toUser), nil
+}
+
+func (s *subsonicMediaProvider) CreateUser(user *mediaprovider.User, password string) error {
+	return s.client.CreateUser(user.Username, password, user.Email, userRoleParams(user))
+}
+
+func (s *subsonicMediaProvider) UpdateUser(user *mediaprovider.User) error {
+	params := userRoleParams(user)
+	params["email"] = user.Email
+	params["maxBitRate"] = strconv.Itoa(user.MaxBitRate)
+	return s.client.UpdateUser(user.Username, params)
+}
+
+func (s *subsonicMediaProvider) DeleteUser(username string) error {
+	return s.client.DeleteUser(username)
+}
+
+func (s *subsonicMediaProvider) ChangePassword(username, password string) error {
+	return s.client.ChangePassword(username, password)
+}
+
+func (s *subsonicMediaProvider) GetShares() ([]*mediaprovider.Share, error) {
+	// go-subsonic's GetShares panics on an empty response
+	resp, err := s.client.Get("getShares", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Shares == nil {
+		return nil, nil
+	}
+	return sharedutil.MapSlice(resp.Shares.Share, toShare), nil
+}
+
+func (s *subsonicMediaProvider) DeleteShare(shareID string) error {
+	return s.client.DeleteShare(shareID)
+}
+
+func toUser(u *subsonic.User) *mediaprovider.User {
+	return &mediaprovider.User{
+		Username:     u.Username,
+		Email:        u.Email,
+		MaxBitRate:   u.MaxBitRate,
+		AdminRole:    u.AdminRole,
+		SettingsRole: u.SettingsRole,
+		StreamRole:   u.StreamRole,
+		DownloadRole: u.DownloadRole,
+		UploadRole:   u.UploadRole,
+		PlaylistRole: u.PlaylistRole,
+		CoverArtRole: u.CoverArtRole,
+		CommentRole:  u.CommentRole,
+		PodcastRole:  u.PodcastRole,
+		JukeboxRole:  u.JukeboxRole,
+		ShareRole:    u.ShareRole,
+	}
+}
+
+func userRoleParams(u *mediaprovider.User) map[string]string {
+	return map[string]string{
+		"adminRole":    strconv.FormatBool(u.AdminRole),
+		"settingsRole": strconv.FormatBool(u.SettingsRole),
+		"streamRole":   strconv.FormatBool(u.StreamRole),
+		"downloadRole": strconv.FormatBool(u.DownloadRole),
+		"uploadRole":   strconv.FormatBool(u.UploadRole),
+		"playlistRole": strconv.FormatBool(u.PlaylistRole),
+		"coverArtRole": strconv.FormatBool(u.CoverArtRole),
+		"commentRole":  strconv.FormatBool(u.CommentRole),
+		"podcastRole":  strconv.FormatBool(u.PodcastRole),
+		"jukeboxRole":  strconv.FormatBool(u.JukeboxRole),
+		"shareRole":    strconv.FormatBool(u.ShareRole),
+	}
+}
+
+func toShare(sh *subsonic.Share) *mediaprovider.Share {
+	return &mediaprovider.Share{
+		ID:          sh.ID,
+		URL:         sh.Url,
+		Description: sh.Description,
+		Username:    sh.Username,
+		Created:     sh.Created,
+		Expires:     sh.Expires,
+		LastVisited: sh.LastVisited,
+		VisitCount:  sh.VisitCount,
+		EntryCount:  len(sh.Entry),
+		EntryNames:  sharedutil.MapSlice(sh.Entry, func(ch *subsonic.Child) string { return ch.Title }),
+	}
+}
@@ -3,12 +3,10 @@ package subsonic
 import (
 	"log"
 	"strconv"
-	"strings"
 
 	"github.com/dweymouth/go-subsonic/subsonic"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
-	"github.com/dweymouth/supersonic/sharedutil"
 )
 
 const (
@@ -35,32 +33,10 @@ func (s *subsonicMediaProvider) AlbumSortOrders() []string {
 	}
 }
 
-func filterAlbumMatches(f mediaprovider.AlbumFilter, album *subsonic.AlbumID3, ignoreGenre bool) bool {
-	filterOptions := f.Options()
-	if album == nil {
-		return false
-	}
-	if filterOptions.ExcludeFavorited && !album.Starred.IsZero() {
-		return false
-	}
-	if filterOptions.ExcludeUnfavorited && album.Starred.IsZero() {
-		return false
-	}
-	if y := album.Year; y < filterOptions.MinYear || (filterOptions.MaxYear > 0 && y > filterOptions.MaxYear) {
-		return false
-	}
-	if ignoreGenre || len(filterOptions.Genres) == 0 {
-		return true
-	}
-	for _, g := range filterOptions.Genres {
-		if strings.EqualFold(g, album.Genre) {
-			return true
-		}
-	}
-	return false
-}
-
 func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	if iter := s.iterateIndexedAlbums(sortOrder, filter); iter != nil {
+		return iter
+	}
 	filterOptions := filter.Options()
 	if sortOrder == "" && len(filterOptions.Genres) == 1 {
 		genre := filterOptions.Genres[0]
@@ -72,11 +48,11 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 		modifiedOptions := modifiedFilter.Options()
 		modifiedOptions.Genres = nil
 		modifiedFilter.SetOptions(modifiedOptions)
-		fetchFn := func(offset, limit int) ([]*subsonic.AlbumID3, error) {
-			return s.client.GetAlbumList2("byGenre",
+		fetchFn := func(offset, limit int) ([]*mediaprovider.Album, error) {
+			return s.getAlbumList2("byGenre",
 				map[string]string{"genre": genre, "offset": strconv.Itoa(offset), "limit": strconv.Itoa(limit)})
 		}
-		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), modifiedFilter, s.prefetchCoverCB)
+		return helpers.NewAlbumIterator(fetchFn, modifiedFilter, s.prefetchCoverCB)
 	}
 	if sortOrder == "" && filterOptions.ExcludeUnfavorited {
 		modifiedFilter := filter.Clone()
@@ -102,24 +78,51 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 	case AlbumSortArtistAZ:
 		return s.baseIterFromSimpleSortOrder("alphabeticalByArtist", filter)
 	case AlbumSortYearAscending:
-		fetchFn := func(offset, limit int) ([]*subsonic.AlbumID3, error) {
-			return s.client.GetAlbumList2("byYear",
+		fetchFn := func(offset, limit int) ([]*mediaprovider.Album, error) {
+			return s.getAlbumList2("byYear",
 				map[string]string{"fromYear": "0", "toYear": "3000", "offset": strconv.Itoa(offset), "limit": strconv.Itoa(limit)})
 		}
-		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), filter, s.prefetchCoverCB)
+		return helpers.NewAlbumIterator(fetchFn, filter, s.prefetchCoverCB)
 	case AlbumSortYearDescending:
-		fetchFn := func(offset, limit int) ([]*subsonic.AlbumID3, error) {
-			return s.client.GetAlbumList2("byYear",
+		fetchFn := func(offset, limit int) ([]*mediaprovider.Album, error) {
+			return s.getAlbumList2("byYear",
 				map[string]string{"fromYear": "3000", "toYear": "0", "offset": strconv.Itoa(offset), "limit": strconv.Itoa(limit)})
 		}
-		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), filter, s.prefetchCoverCB)
+		return helpers.NewAlbumIterator(fetchFn, filter, s.prefetchCoverCB)
 	default:
 		log.Printf("Undefined album sort order: %s", sortOrder)
 		return nil
 	}
 }
 
+// iterateIndexedAlbums returns an iterator over the albums from the library index,
+// or nil if the index is not fresh or can't answer for the sort order.
+// Note that non-OpenSubsonic servers report only the first genre of an album,
+// so genre filtering from the index may miss some multi-genre albums.
+func (s *subsonicMediaProvider) iterateIndexedAlbums(sortOrder string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	var cmp func(a, b *mediaprovider.Album) int
+	switch sortOrder {
+	case "", AlbumSortRecentlyAdded:
+		// index order
+	case AlbumSortTitleAZ:
+		cmp = mediaprovider.CompareAlbumsByTitle
+	case AlbumSortArtistAZ:
+		cmp = mediaprovider.CompareAlbumsByArtist
+	case AlbumSortYearAscending:
+		cmp = mediaprovider.CompareAlbumsByYear
+	case AlbumSortYearDescending:
+		cmp = func(a, b *mediaprovider.Album) int { return mediaprovider.CompareAlbumsByYear(b, a) }
+	default:
+		// play history and random orders come from the server
+		return nil
+	}
+	return s.index.Load().IterateAlbums(filter, cmp)
+}
+
 func (s *subsonicMediaProvider) SearchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	if iter := s.index.Load().SearchAlbums(searchQuery, filter); iter != nil {
+		return iter
+	}
 	return s.newSearchAlbumIter(searchQuery, filter, s.prefetchCoverCB)
 }
 
@@ -138,7 +141,7 @@ func (s *subsonicMediaProvider) newSearchAlbumIter(query string, filter mediapro
 	return &searchAlbumIter{
 		searchIterBase: searchIterBase{
 			query: query,
-			s:     s.client,
+			mp:    s,
 		},
 		prefetchCB: cb,
 		filter:     filter,
@@ -166,11 +169,11 @@ func (s *searchAlbumIter) Next() *mediaprovider.Album {
 
 		// add results from artists search
 		for _, artist := range results.Artist {
-			artist, err := s.s.GetArtist(artist.ID)
-			if err != nil || artist == nil {
+			albums, err := s.getArtistAlbums(artist.ID)
+			if err != nil {
 				log.Printf("error fetching artist: %s", err.Error())
 			} else {
-				s.addNewAlbums(artist.Album)
+				s.addNewAlbums(albums)
 			}
 		}
 		s.artistOffset += len(results.Artist)
@@ -180,8 +183,8 @@ func (s *searchAlbumIter) Next() *mediaprovider.Album {
 			if song.AlbumID == "" {
 				continue
 			}
-			album, err := s.s.GetAlbum(song.AlbumID)
-			if err != nil || album == nil {
+			album, err := s.getAlbum(song.AlbumID)
+			if err != nil {
 				log.Printf("error fetching album: %s", err.Error())
 			} else {
 				s.addNewAlbums([]*subsonic.AlbumID3{album})
@@ -199,7 +202,7 @@ func (s *searchAlbumIter) Next() *mediaprovider.Album {
 			s.prefetchedPos = 0
 		}
 
-		return toAlbum(a)
+		return s.toAlbum(a)
 	}
 
 	return nil
@@ -210,7 +213,7 @@ func (s *searchAlbumIter) addNewAlbums(al []*subsonic.AlbumID3) {
 		if _, have := s.albumIDset[album.ID]; have {
 			continue
 		}
-		if !filterAlbumMatches(s.filter, album, false) {
+		if !s.filter.Matches(s.toAlbum(album)) {
 			continue
 		}
 		s.prefetched = append(s.prefetched, album)
@@ -224,9 +227,9 @@ func (s *searchAlbumIter) addNewAlbums(al []*subsonic.AlbumID3) {
 func (s *subsonicMediaProvider) newRandomIter(filter mediaprovider.AlbumFilter, cb func(string)) mediaprovider.AlbumIterator {
 	return helpers.NewRandomAlbumIter(
 		s.fetchFnFromStandardSort("newest"),
-		makeFetchFn(func(offset, limit int) ([]*subsonic.AlbumID3, error) {
-			return s.client.GetAlbumList2("random", map[string]string{"size": strconv.Itoa(limit)})
-		}),
+		func(offset, limit int) ([]*mediaprovider.Album, error) {
+			return s.getAlbumList2("random", map[string]string{"size": strconv.Itoa(limit)})
+		},
 		filter, s.prefetchCoverCB)
 }
 
@@ -235,17 +238,7 @@ func (s *subsonicMediaProvider) baseIterFromSimpleSortOrder(sort string, filter
 }
 
 func (s *subsonicMediaProvider) fetchFnFromStandardSort(sort string) helpers.AlbumFetchFn {
-	return makeFetchFn(func(offset, limit int) ([]*subsonic.AlbumID3, error) {
-		return s.client.GetAlbumList2(sort, map[string]string{"size": strconv.Itoa(limit), "offset": strconv.Itoa(offset)})
-	})
-}
-
-func makeFetchFn(subsonicFetchFn func(offset, limit int) ([]*subsonic.AlbumID3, error)) helpers.AlbumFetchFn {
 	return func(offset, limit int) ([]*mediaprovider.Album, error) {
-		al, err := subsonicFetchFn(offset, limit)
-		if err != nil {
-			return nil, err
-		}
-		return sharedutil.MapSlice(al, toAlbum), nil
+		return s.getAlbumList2(sort, map[string]string{"size": strconv.Itoa(limit), "offset": strconv.Itoa(offset)})
 	}
 }
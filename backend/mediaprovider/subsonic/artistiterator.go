@@ -23,19 +23,15 @@ func (s *subsonicMediaProvider) ArtistSortOrders() []string {
 	}
 }
 
-func filterArtistMatches(f mediaprovider.ArtistFilter, artist *subsonic.ArtistID3) bool {
-	if artist == nil {
-		return false
-	}
-	return true
-}
-
 func (s *subsonicMediaProvider) IterateArtists(sortOrder string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
 	if sortOrder == "" {
 		sortOrder = ArtistSortNameAZ // default
 	}
 	switch sortOrder {
 	case ArtistSortNameAZ:
+		if iter := s.index.Load().IterateArtists(filter); iter != nil {
+			return iter
+		}
 		return s.baseArtistIterFromSimpleSortOrder(
 			func(artists []*subsonic.ArtistID3) []*subsonic.ArtistID3 {
 				c := collate.New(language.English, collate.Loose)
@@ -53,6 +49,9 @@ func (s *subsonicMediaProvider) IterateArtists(sortOrder string, filter mediapro
 }
 
 func (s *subsonicMediaProvider) SearchArtists(searchQuery string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
+	if iter := s.index.Load().SearchArtists(searchQuery, filter); iter != nil {
+		return iter
+	}
 	return s.newSearchArtistIter(searchQuery, filter, s.prefetchCoverCB)
 }
 
@@ -61,7 +60,8 @@ type searchArtistIter struct {
 
 	prefetchCB    func(string)
 	filter        mediaprovider.ArtistFilter
-	prefetched    []*subsonic.ArtistID3
+	genreLookup   *helpers.ArtistGenreLookup
+	prefetched    []*mediaprovider.Artist
 	prefetchedPos int
 	artistIDset   map[string]bool
 	done          bool
@@ -71,10 +71,11 @@ func (s *subsonicMediaProvider) newSearchArtistIter(query string, filter mediapr
 	return &searchArtistIter{
 		searchIterBase: searchIterBase{
 			query: query,
-			s:     s.client,
+			mp:    s,
 		},
 		prefetchCB:  cb,
 		filter:      filter,
+		genreLookup: s.artistGenres.Lookup(filter),
 		artistIDset: make(map[string]bool),
 	}
 }
@@ -107,30 +108,33 @@ func (s *searchArtistIter) Next() *mediaprovider.Artist {
 			s.prefetchedPos = 0
 		}
 
-		return toArtistFromID3(a)
+		return a
 	}
 
 	return nil
 }
 
 func (s *searchArtistIter) addNewArtists(artists []*subsonic.ArtistID3) {
-	for _, artist := range artists {
+	newArtists := sharedutil.MapSlice(artists, toArtistFromID3)
+	s.genreLookup.FillGenres(newArtists)
+	for _, artist := range newArtists {
 		if _, have := s.artistIDset[artist.ID]; have {
 			continue
 		}
-		if !filterArtistMatches(s.filter, artist) {
+		if !s.filter.Matches(artist) {
 			continue
 		}
 		s.prefetched = append(s.prefetched, artist)
 		if s.prefetchCB != nil {
-			go s.prefetchCB(artist.CoverArt)
+			go s.prefetchCB(artist.CoverArtID)
 		}
 		s.artistIDset[artist.ID] = true
 	}
 }
 
 func (s *subsonicMediaProvider) baseArtistIterFromSimpleSortOrder(sortFn func([]*subsonic.ArtistID3) []*subsonic.ArtistID3, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
-	return helpers.NewArtistIterator(s.artistFetchFnFromStandardSort(sortFn), filter, s.prefetchCoverCB)
+	genreLookup := s.artistGenres.Lookup(filter)
+	return helpers.NewArtistIterator(genreLookup.WrapFetchFn(s.artistFetchFnFromStandardSort(sortFn)), filter, s.prefetchCoverCB)
 }
 
 func (s *subsonicMediaProvider) artistFetchFnFromStandardSort(sortFn func([]*subsonic.ArtistID3) []*subsonic.ArtistID3) helpers.ArtistFetchFn {
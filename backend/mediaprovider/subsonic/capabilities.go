@@ -0,0 +1,102 @@
+package subsonic
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/dweymouth/go-subsonic/subsonic"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var _ mediaprovider.CapabilityProvider = (*subsonicMediaProvider)(nil)
+
+// The first versions of the OpenSubsonic servers known to report the artist
+// roles and track contributors, by which composers are found, and the moods
+// of albums and tracks. These fields are optional, and not covered by any
+// OpenSubsonic extension, so other servers are assumed not to report them.
+var (
+	composerServerVersions = map[string]string{"navidrome": "0.55.0"}
+	moodServerVersions     = map[string]string{"navidrome": "0.55.0"}
+)
+
+// GetCapabilities queries the server type and version, the OpenSubsonic
+// extensions it supports, and the roles of the logged-in user.
+func (s *subsonicMediaProvider) GetCapabilities() (*mediaprovider.Capabilities, error) {
+	resp, ext, err := s.getWithExtensions("ping", nil)
+	if err != nil {
+		return nil, err
+	}
+	caps := &mediaprovider.Capabilities{
+		ServerType:      ext.Type,
+		ServerVersion:   ext.ServerVersion,
+		APIVersion:      resp.Version,
+		OpenSubsonic:    resp.OpenSubsonic,
+		Rating:          true,
+		Sharing:         true,
+		ShareArtists:    s.CanShareArtists(),
+		Lyrics:          true,
+		Jukebox:         true,
+		Podcasts:        true,
+		Transcoding:     true,
+		PublicPlaylists: s.CanMakePublicPlaylist(),
+	}
+	if caps.ServerType == "" {
+		caps.ServerType = "Subsonic"
+	}
+
+	if caps.OpenSubsonic {
+		caps.Extensions = sharedutil.MapSlice(s.openSubsonicExtensions(), func(e *subsonic.OpenSubsonicExtension) mediaprovider.Extension {
+			return mediaprovider.Extension{Name: e.Name, Versions: e.Versions}
+		})
+		caps.SyncedLyrics = caps.HasExtension(subsonic.SongLyricsExtension)
+		caps.Composers = serverVersionAtLeast(caps, composerServerVersions)
+		caps.Moods = serverVersionAtLeast(caps, moodServerVersions)
+	} else {
+		// don't query for extensions the server can't have
+		s.extensionsLock.Lock()
+		s.extensions, s.extensionsFetched = nil, true
+		s.extensionsLock.Unlock()
+	}
+
+	if user, err := s.client.GetUser(s.client.User); err == nil {
+		caps.Jukebox = user.JukeboxRole
+		caps.Podcasts = user.PodcastRole
+		caps.Sharing = user.ShareRole
+		caps.Admin = user.AdminRole
+	} else {
+		// assume the features are allowed; the server will refuse them if not
+		log.Printf("error fetching user roles: %v", err)
+	}
+	return caps, nil
+}
+
+// openSubsonicExtensions returns the OpenSubsonic extensions supported
+// by the server, fetching them only once per session.
+func (s *subsonicMediaProvider) openSubsonicExtensions() []*subsonic.OpenSubsonicExtension {
+	s.extensionsLock.Lock()
+	defer s.extensionsLock.Unlock()
+	if !s.extensionsFetched {
+		ext, err := s.client.GetOpenSubsonicExtensions()
+		if err != nil {
+			log.Printf("error fetching OpenSubsonic extensions: %v", err)
+			return nil
+		}
+		s.extensions, s.extensionsFetched = ext, true
+	}
+	return s.extensions
+}
+
+func (s *subsonicMediaProvider) hasExtension(name string) bool {
+	return slices.ContainsFunc(s.openSubsonicExtensions(), func(e *subsonic.OpenSubsonicExtension) bool {
+		return e.Name == name
+	})
+}
+
+// serverVersionAtLeast returns true if the server is of one of the types
+// in minVersions, at least at the version given for it.
+func serverVersionAtLeast(caps *mediaprovider.Capabilities, minVersions map[string]string) bool {
+	min, ok := minVersions[strings.ToLower(caps.ServerType)]
+	return ok && caps.ServerVersionAtLeast(min)
+}
@@ -0,0 +1,318 @@
+package subsonic
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/go-subsonic/subsonic"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// OpenSubsonic response fields which are not (yet) modeled by go-subsonic.
+// These are decoded from the same response body as the go-subsonic types.
+
+type osResponse struct {
+	Type          string `xml:"type,attr"`
+	ServerVersion string `xml:"serverVersion,attr"`
+
+	Album      *osAlbum     `xml:"album"`
+	Song       *osChild     `xml:"song"`
+	Artists    *osArtists   `xml:"artists"`
+	Artist     *osArtistID3 `xml:"artist"`
+	AlbumList2 *osAlbumList `xml:"albumList2"`
+
+	SearchResult3 *osSearchResult `xml:"searchResult3"`
+}
+
+type osSearchResult struct {
+	Album []*osAlbum `xml:"album"`
+	Song  []*osChild `xml:"song"`
+}
+
+type osArtistID3 struct {
+	Album []*osAlbum `xml:"album"`
+}
+
+type osAlbumList struct {
+	Album []*osAlbum `xml:"album"`
+}
+
+type osArtists struct {
+	Index []struct {
+		Artist []*osArtist `xml:"artist"`
+	} `xml:"index"`
+}
+
+type osArtist struct {
+	ID         string   `xml:"id,attr"`
+	Name       string   `xml:"name,attr"`
+	AlbumCount int      `xml:"albumCount,attr"`
+	Roles      []string `xml:"roles"`
+}
+
+type osAlbum struct {
+	ID            string          `xml:"id,attr"`
+	MusicBrainzID string          `xml:"musicBrainzId,attr"`
+	RecordLabels  []osRecordLabel `xml:"recordLabels"`
+	DiscTitles    []osDiscTitle   `xml:"discTitles"`
+	Moods         []string        `xml:"moods"`
+	Song          []*osChild      `xml:"song"`
+}
+
+type osDiscTitle struct {
+	Disc  int    `xml:"disc,attr"`
+	Title string `xml:"title,attr"`
+}
+
+type osRecordLabel struct {
+	Name string `xml:"name,attr"`
+}
+
+type osChild struct {
+	ID              string          `xml:"id,attr"`
+	DisplayComposer string          `xml:"displayComposer,attr"`
+	Played          string          `xml:"played,attr"`
+	MusicBrainzID   string          `xml:"musicBrainzId,attr"`
+	Contributors    []osContributor `xml:"contributors"`
+	Moods           []string        `xml:"moods"`
+}
+
+type osContributor struct {
+	Role    string          `xml:"role,attr"`
+	SubRole string          `xml:"subRole,attr"`
+	Artist  subsonic.IDName `xml:"artist"`
+}
+
+// getWithExtensions performs a GET request against the given endpoint and
+// decodes the response both into the go-subsonic Response type and
+// into an osResponse containing the extra OpenSubsonic fields.
+func (s *subsonicMediaProvider) getWithExtensions(endpoint string, params map[string]string) (*subsonic.Response, *osResponse, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Add(k, v)
+	}
+	resp, err := s.client.Request("GET", endpoint, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parsed subsonic.Response
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return nil, nil, err
+	}
+	if parsed.Error != nil {
+		return nil, nil, fmt.Errorf("Error #%d: %s", parsed.Error.Code, parsed.Error.Message)
+	}
+	var ext osResponse
+	if err := xml.Unmarshal(body, &ext); err != nil {
+		return nil, nil, err
+	}
+	return &parsed, &ext, nil
+}
+
+func (s *subsonicMediaProvider) getAlbumWithExtensions(albumID string) (*subsonic.AlbumID3, *osAlbum, error) {
+	resp, ext, err := s.getWithExtensions("getAlbum", map[string]string{"id": albumID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Album == nil {
+		return nil, nil, errors.New("server returned empty album")
+	}
+	if ext.Album == nil {
+		ext.Album = &osAlbum{}
+	}
+	return resp.Album, ext.Album, nil
+}
+
+// getAlbumList2 fetches a page of the given album list type,
+// filling in the OpenSubsonic mood tags of each album if present.
+func (s *subsonicMediaProvider) getAlbumList2(listType string, params map[string]string) ([]*mediaprovider.Album, error) {
+	p := map[string]string{"type": listType}
+	for k, v := range params {
+		p[k] = v
+	}
+	resp, ext, err := s.getWithExtensions("getAlbumList2", p)
+	if err != nil {
+		return nil, err
+	}
+	if resp.AlbumList2 == nil {
+		return nil, nil
+	}
+	albums := sharedutil.MapSlice(resp.AlbumList2.Album, toAlbum)
+	if ext.AlbumList2 != nil {
+		moods := make(map[string][]string, len(ext.AlbumList2.Album))
+		for _, a := range ext.AlbumList2.Album {
+			moods[a.ID] = a.Moods
+		}
+		for _, a := range albums {
+			a.Moods = moods[a.ID]
+		}
+	}
+	return albums, nil
+}
+
+// fillTrackExtensions fills in the composers and other contributors
+// of the track from the OpenSubsonic extension fields, if present.
+func fillTrackExtensions(tr *mediaprovider.Track, ext *osChild) {
+	if tr == nil || ext == nil {
+		return
+	}
+	tr.Moods = ext.Moods
+	tr.MusicBrainzID = ext.MusicBrainzID
+	if t, err := time.Parse(time.RFC3339Nano, ext.Played); err == nil {
+		tr.LastPlayed = t
+	}
+	for _, c := range ext.Contributors {
+		switch c.Role {
+		case "composer":
+			tr.ComposerIDs = append(tr.ComposerIDs, c.Artist.ID)
+			tr.ComposerNames = append(tr.ComposerNames, c.Artist.Name)
+		case "artist", "albumartist":
+			// already modeled as the track artist(s)
+		default:
+			tr.Contributors = append(tr.Contributors, mediaprovider.Contributor{
+				ArtistID: c.Artist.ID,
+				Name:     c.Artist.Name,
+				Role:     c.Role,
+				SubRole:  c.SubRole,
+			})
+		}
+	}
+	if len(tr.ComposerNames) == 0 && ext.DisplayComposer != "" {
+		tr.ComposerNames = []string{ext.DisplayComposer}
+		tr.ComposerIDs = []string{""}
+	}
+}
+
+// GetComposers returns the artists which the server reports as having the
+// composer role. Requires a server which supports the OpenSubsonic artist roles.
+func (s *subsonicMediaProvider) GetComposers() ([]*mediaprovider.Composer, error) {
+	_, ext, err := s.getWithExtensions("getArtists", nil)
+	if err != nil {
+		return nil, err
+	}
+	var composers []*mediaprovider.Composer
+	if ext.Artists == nil {
+		return composers, nil
+	}
+	for _, idx := range ext.Artists.Index {
+		for _, a := range idx.Artist {
+			if slices.Contains(a.Roles, "composer") {
+				composers = append(composers, &mediaprovider.Composer{
+					ID:         a.ID,
+					Name:       a.Name,
+					AlbumCount: -1,
+					TrackCount: -1,
+				})
+			}
+		}
+	}
+	return composers, nil
+}
+
+// IterateComposerTracks iterates over the tracks composed by the given composer.
+// The Subsonic API provides no way to query tracks by composer, so this is done
+// client-side, over the library index if it is fresh, or otherwise over the
+// results of searching for the composer's name.
+func (s *subsonicMediaProvider) IterateComposerTracks(composer string) mediaprovider.TrackIterator {
+	iter := s.index.Load().IterateTracks("", mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+	if iter == nil {
+		iter = &composerSearchIterator{s: s, query: composer}
+	}
+	return &composerTracksIterator{
+		composer: composer,
+		iter:     iter,
+	}
+}
+
+const composerSearchPageSize = 100
+
+// composerSearchIterator iterates over the songs returned by search3
+// for the query, including the composers from the OpenSubsonic fields.
+type composerSearchIterator struct {
+	s      *subsonicMediaProvider
+	query  string
+	offset int
+	page   []*mediaprovider.Track
+	pos    int
+	done   bool
+}
+
+func (c *composerSearchIterator) Next() *mediaprovider.Track {
+	if c.pos >= len(c.page) {
+		if c.done {
+			return nil
+		}
+		c.fetchPage()
+		if len(c.page) == 0 {
+			return nil
+		}
+	}
+	tr := c.page[c.pos]
+	c.pos++
+	return tr
+}
+
+func (c *composerSearchIterator) fetchPage() {
+	c.page, c.pos = nil, 0
+	resp, ext, err := c.s.getWithExtensions("search3", map[string]string{
+		"query":       c.query,
+		"artistCount": "0",
+		"albumCount":  "0",
+		"songCount":   strconv.Itoa(composerSearchPageSize),
+		"songOffset":  strconv.Itoa(c.offset),
+	})
+	if err != nil {
+		log.Printf("error searching tracks: %v", err)
+		c.done = true
+		return
+	}
+	if resp.SearchResult3 == nil || len(resp.SearchResult3.Song) < composerSearchPageSize {
+		c.done = true
+	}
+	if resp.SearchResult3 == nil {
+		return
+	}
+	extSongs := make(map[string]*osChild)
+	if ext.SearchResult3 != nil {
+		for _, song := range ext.SearchResult3.Song {
+			extSongs[song.ID] = song
+		}
+	}
+	for _, ch := range resp.SearchResult3.Song {
+		tr := toTrack(ch)
+		fillTrackExtensions(tr, extSongs[tr.ID])
+		c.page = append(c.page, tr)
+	}
+	c.offset += len(resp.SearchResult3.Song)
+}
+
+type composerTracksIterator struct {
+	composer string
+	iter     mediaprovider.TrackIterator
+}
+
+func (c *composerTracksIterator) Next() *mediaprovider.Track {
+	for tr := c.iter.Next(); tr != nil; tr = c.iter.Next() {
+		if slices.ContainsFunc(tr.ComposerNames, func(name string) bool {
+			return strings.EqualFold(name, c.composer)
+		}) {
+			return tr
+		}
+	}
+	return nil
+}
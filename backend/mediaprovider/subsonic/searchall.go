@@ -19,21 +19,27 @@ func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 	var playlists []*subsonic.Playlist
 	var genres []*subsonic.Genre
 
-	wg.Add(1)
-	go func() {
-		count := strconv.Itoa(maxResults / 3)
-		res, e := s.client.Search3(searchQuery, map[string]string{
-			"artistCount": count,
-			"albumCount":  count,
-			"songCount":   count,
-		})
-		if e != nil {
-			err = e
-		} else {
-			result = res
-		}
-		wg.Done()
-	}()
+	// albums, artists and tracks come from the library index if fresh
+	indexed, haveIndexed := s.index.Load().SearchAll(searchQuery, maxResults)
+	if haveIndexed {
+		result = &subsonic.SearchResult3{}
+	} else {
+		wg.Add(1)
+		go func() {
+			count := strconv.Itoa(maxResults / 3)
+			res, e := s.client.Search3(searchQuery, map[string]string{
+				"artistCount": count,
+				"albumCount":  count,
+				"songCount":   count,
+			})
+			if e != nil {
+				err = e
+			} else {
+				result = res
+			}
+			wg.Done()
+		}()
+	}
 
 	querySanitized := strings.ToLower(sanitize.Accents(searchQuery))
 	queryLowerWords := strings.Fields(querySanitized)
@@ -65,7 +71,7 @@ func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 		return nil, err
 	}
 
-	results := mergeResults(result, playlists, genres)
+	results := append(indexed, mergeResults(result, playlists, genres)...)
 	helpers.RankSearchResults(results, querySanitized, queryLowerWords)
 	if len(results) > maxResults {
 		results = results[:maxResults]
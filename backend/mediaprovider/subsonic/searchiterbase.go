@@ -1,10 +1,12 @@
 package subsonic
 
 import (
+	"errors"
 	"log"
 	"strconv"
 
 	"github.com/dweymouth/go-subsonic/subsonic"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
 
 type searchIterBase struct {
@@ -12,22 +14,83 @@ type searchIterBase struct {
 	artistOffset int
 	albumOffset  int
 	songOffset   int
-	s            *subsonic.Client
+	mp           *subsonicMediaProvider
+
+	// OpenSubsonic fields of the albums and songs fetched so far,
+	// which go-subsonic does not decode
+	albumMoods map[string][]string
+	songExt    map[string]*osChild
 }
 
 func (s *searchIterBase) fetchResults() *subsonic.SearchResult3 {
 	searchOpts := map[string]string{
+		"query":        s.query,
 		"artistOffset": strconv.Itoa(s.artistOffset),
 		"albumOffset":  strconv.Itoa(s.albumOffset),
 		"songOffset":   strconv.Itoa(s.songOffset),
 	}
-	results, err := s.s.Search3(s.query, searchOpts)
+	resp, ext, err := s.mp.getWithExtensions("search3", searchOpts)
 	if err != nil {
 		log.Println(err)
-		results = nil
+		return nil
 	}
+	results := resp.SearchResult3
 	if results == nil || len(results.Album)+len(results.Artist)+len(results.Song) == 0 {
 		return nil
 	}
+	if ext.SearchResult3 != nil {
+		s.addExtensions(ext.SearchResult3.Album, ext.SearchResult3.Song)
+	}
 	return results
 }
+
+// getArtistAlbums fetches the albums of the artist, with their OpenSubsonic fields.
+func (s *searchIterBase) getArtistAlbums(artistID string) ([]*subsonic.AlbumID3, error) {
+	resp, ext, err := s.mp.getWithExtensions("getArtist", map[string]string{"id": artistID})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Artist == nil {
+		return nil, errors.New("server returned empty artist")
+	}
+	if ext.Artist != nil {
+		s.addExtensions(ext.Artist.Album, nil)
+	}
+	return resp.Artist.Album, nil
+}
+
+// getAlbum fetches the album and its songs, with their OpenSubsonic fields.
+func (s *searchIterBase) getAlbum(albumID string) (*subsonic.AlbumID3, error) {
+	al, ext, err := s.mp.getAlbumWithExtensions(albumID)
+	if err != nil {
+		return nil, err
+	}
+	ext.ID = al.ID
+	s.addExtensions([]*osAlbum{ext}, ext.Song)
+	return al, nil
+}
+
+func (s *searchIterBase) addExtensions(albums []*osAlbum, songs []*osChild) {
+	if s.albumMoods == nil {
+		s.albumMoods = make(map[string][]string)
+		s.songExt = make(map[string]*osChild)
+	}
+	for _, al := range albums {
+		s.albumMoods[al.ID] = al.Moods
+	}
+	for _, song := range songs {
+		s.songExt[song.ID] = song
+	}
+}
+
+func (s *searchIterBase) toAlbum(al *subsonic.AlbumID3) *mediaprovider.Album {
+	album := toAlbum(al)
+	album.Moods = s.albumMoods[al.ID]
+	return album
+}
+
+func (s *searchIterBase) toTrack(ch *subsonic.Child) *mediaprovider.Track {
+	tr := toTrack(ch)
+	fillTrackExtensions(tr, s.songExt[ch.ID])
+	return tr
+}
@@ -2,18 +2,21 @@ package subsonic
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"io"
+	"log"
 	"math"
 	"net/url"
-	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dweymouth/go-subsonic/subsonic"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
 )
 
@@ -28,16 +31,31 @@ type subsonicMediaProvider struct {
 
 	playlistsCached   []*mediaprovider.Playlist
 	playlistsCachedAt int64 // unix
+
+	index        atomic.Pointer[mediaprovider.LibraryIndex]
+	artistGenres *helpers.ArtistGenreCache
+
+	extensionsLock    sync.Mutex
+	extensions        []*subsonic.OpenSubsonicExtension
+	extensionsFetched bool
 }
 
 func SubsonicMediaProvider(subsonicClient *subsonic.Client) mediaprovider.MediaProvider {
-	return &subsonicMediaProvider{client: subsonicClient}
+	s := &subsonicMediaProvider{client: subsonicClient}
+	s.artistGenres = helpers.NewArtistGenreCache(s.IterateAlbums)
+	return s
 }
 
 func (s *subsonicMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID string)) {
 	s.prefetchCoverCB = cb
 }
 
+var _ mediaprovider.LibraryIndexer = (*subsonicMediaProvider)(nil)
+
+func (s *subsonicMediaProvider) SetLibraryIndex(index *mediaprovider.LibraryIndex) {
+	s.index.Store(index)
+}
+
 func (s *subsonicMediaProvider) CreatePlaylist(name string, trackIDs []string) error {
 	return s.client.CreatePlaylistWithTracks(trackIDs, map[string]string{"name": name})
 }
@@ -67,22 +85,44 @@ func (s *subsonicMediaProvider) RemovePlaylistTracks(id string, removeIdxs []int
 }
 
 func (s *subsonicMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
-	tr, err := s.client.GetSong(trackID)
+	resp, ext, err := s.getWithExtensions("getSong", map[string]string{"id": trackID})
 	if err != nil {
 		return nil, err
 	}
-	return toTrack(tr), nil
+	if resp.Song == nil {
+		return nil, errors.New("server returned empty song")
+	}
+	tr := toTrack(resp.Song)
+	fillTrackExtensions(tr, ext.Song)
+	return tr, nil
 }
 
 func (s *subsonicMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
-	al, err := s.client.GetAlbum(albumID)
+	al, ext, err := s.getAlbumWithExtensions(albumID)
 	if err != nil {
 		return nil, err
 	}
 	album := &mediaprovider.AlbumWithTracks{
 		Tracks: sharedutil.MapSlice(al.Song, toTrack),
 	}
+	extSongs := make(map[string]*osChild, len(ext.Song))
+	for _, song := range ext.Song {
+		extSongs[song.ID] = song
+	}
+	for _, tr := range album.Tracks {
+		fillTrackExtensions(tr, extSongs[tr.ID])
+	}
 	fillAlbum(al, &album.Album)
+	album.Moods = ext.Moods
+	album.MusicBrainzID = ext.MusicBrainzID
+	if len(ext.DiscTitles) > 0 {
+		album.DiscTitles = make(map[int]string, len(ext.DiscTitles))
+		for _, d := range ext.DiscTitles {
+			if d.Title != "" {
+				album.DiscTitles[d.Disc] = d.Title
+			}
+		}
+	}
 	return album, nil
 }
 
@@ -96,9 +136,64 @@ func (s *subsonicMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.Alb
 		LastFmUrl:     al.LastFmUrl,
 		MusicBrainzID: al.MusicBrainzID,
 	}
+
+	// fill in the extra metadata from the album itself
+	subAlbum, ext, err := s.getAlbumWithExtensions(albumID)
+	if err != nil {
+		// still return the basic album info
+		log.Printf("error fetching extended album info: %v", err)
+		return album, nil
+	}
+	if album.MusicBrainzID == "" {
+		album.MusicBrainzID = ext.MusicBrainzID
+	}
+	album.ReleaseDate = formatItemDate(subAlbum.ReleaseDate)
+	if album.ReleaseDate == "" && subAlbum.Year > 0 {
+		album.ReleaseDate = strconv.Itoa(subAlbum.Year)
+	}
+	for _, l := range ext.RecordLabels {
+		album.RecordLabels = append(album.RecordLabels, l.Name)
+	}
+	album.ReleaseTypes = normalizeReleaseTypes(subAlbum.ReleaseTypes)
+	if subAlbum.IsCompilation {
+		album.ReleaseTypes |= mediaprovider.ReleaseTypeCompilation
+	}
+	if album.MusicBrainzID != "" {
+		album.ExternalIDs = map[string]string{"MusicBrainz": album.MusicBrainzID}
+	}
+	if album.LastFmUrl != "" {
+		album.ExternalURLs = append(album.ExternalURLs, mediaprovider.ExternalURL{Name: "Last.fm", URL: album.LastFmUrl})
+	}
+
+	extSongs := make(map[string]*osChild, len(ext.Song))
+	for _, s := range ext.Song {
+		extSongs[s.ID] = s
+	}
+	for _, song := range subAlbum.Song {
+		tr := toTrack(song)
+		fillTrackExtensions(tr, extSongs[song.ID])
+		if credits := mediaprovider.NewTrackCredits(tr); credits != nil {
+			album.TrackCredits = append(album.TrackCredits, credits)
+		}
+	}
 	return album, nil
 }
 
+// formatItemDate formats an OpenSubsonic ItemDate as a (possibly partial) ISO 8601 date
+func formatItemDate(d *subsonic.ItemDate) string {
+	if d == nil || d.Year == nil || *d.Year == 0 {
+		return ""
+	}
+	date := fmt.Sprintf("%04d", *d.Year)
+	if d.Month != nil && *d.Month > 0 {
+		date += fmt.Sprintf("-%02d", *d.Month)
+		if d.Date != nil && *d.Date > 0 {
+			date += fmt.Sprintf("-%02d", *d.Date)
+		}
+	}
+	return date
+}
+
 func (s *subsonicMediaProvider) GetArtist(artistID string) (*mediaprovider.ArtistWithAlbums, error) {
 	ar, err := s.client.GetArtist(artistID)
 	if err != nil {
@@ -268,10 +363,16 @@ func (s *subsonicMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteP
 		ArtistIDs: params.ArtistIDs,
 		SongIDs:   params.TrackIDs,
 	}
+	var err error
 	if favorite {
-		return s.client.Star(subParams)
+		err = s.client.Star(subParams)
+	} else {
+		err = s.client.Unstar(subParams)
 	}
-	return s.client.Unstar(subParams)
+	if err == nil {
+		s.index.Load().UpdateFavorite(params, favorite)
+	}
+	return err
 }
 
 func (s *subsonicMediaProvider) SetRating(params mediaprovider.RatingFavoriteParameters, rating int) error {
@@ -300,6 +401,9 @@ func (s *subsonicMediaProvider) SetRating(params mediaprovider.RatingFavoritePar
 		wg.Wait()
 	}
 
+	if err == nil {
+		s.index.Load().UpdateRating(params, rating)
+	}
 	return err
 }
 
@@ -336,12 +440,7 @@ func (s *subsonicMediaProvider) RescanLibrary() error {
 var _ mediaprovider.LyricsProvider = (*subsonicMediaProvider)(nil)
 
 func (s *subsonicMediaProvider) GetLyrics(track *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
-	ext, err := s.client.GetOpenSubsonicExtensions()
-	supportsSynced := err == nil &&
-		slices.ContainsFunc(ext, func(ext *subsonic.OpenSubsonicExtension) bool {
-			return ext.Name == subsonic.SongLyricsExtension
-		})
-	if supportsSynced {
+	if s.hasExtension(subsonic.SongLyricsExtension) {
 		lyrics, err := s.client.GetLyricsBySongId(track.ID)
 		if err != nil || len(lyrics.StructuredLyrics) == 0 {
 			return nil, err
@@ -395,6 +494,17 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		artistIDs = append(artistIDs, ch.ArtistID)
 	}
 
+	var genres []string
+	if len(ch.Genres) > 0 {
+		// OpenSubsonic extension
+		for _, g := range ch.Genres {
+			genres = append(genres, g.Name)
+		}
+	} else if ch.Genre != "" {
+		genres = []string{ch.Genre}
+	}
+
+	work, movement := helpers.SplitWorkMovement(ch.Title)
 	return &mediaprovider.Track{
 		ID:          ch.ID,
 		CoverArtID:  ch.CoverArt,
@@ -403,7 +513,7 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		Duration:    ch.Duration,
 		TrackNumber: ch.Track,
 		DiscNumber:  ch.DiscNumber,
-		Genre:       ch.Genre,
+		Genres:      genres,
 		ArtistIDs:   artistIDs,
 		ArtistNames: artistNames,
 		Album:       ch.Album,
@@ -416,6 +526,8 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		Size:        ch.Size,
 		BitRate:     ch.BitRate,
 		Comment:     ch.Comment,
+		Work:        work,
+		Movement:    movement,
 	}
 }
 
@@ -530,6 +642,9 @@ func toArtistFromID3(ar *subsonic.ArtistID3) *mediaprovider.Artist {
 		Name:       ar.Name,
 		Favorite:   !ar.Starred.IsZero(),
 		AlbumCount: ar.AlbumCount,
+		// unreliable, since some servers (e.g. Navidrome) return an
+		// image URL for every artist, so ArtistImages is not supported
+		HasImage: ar.ArtistImageUrl != "",
 	}
 }
 
@@ -551,6 +666,10 @@ func fillPlaylist(pl *subsonic.Playlist, playlist *mediaprovider.Playlist) {
 	playlist.Public = pl.Public
 	playlist.TrackCount = pl.SongCount
 	playlist.Duration = pl.Duration
+	playlist.Changed = pl.Changed
+	if playlist.Changed.IsZero() {
+		playlist.Changed = pl.Created
+	}
 }
 
 func (s *subsonicMediaProvider) GetSongRadio(trackID string, count int) ([]*mediaprovider.Track, error) {
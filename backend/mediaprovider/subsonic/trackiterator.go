@@ -5,9 +5,18 @@ import (
 
 	"github.com/dweymouth/go-subsonic/subsonic"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 )
 
-func (s *subsonicMediaProvider) IterateTracks(searchQuery string) mediaprovider.TrackIterator {
+func (s *subsonicMediaProvider) IterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if iter := s.index.Load().IterateTracks(searchQuery, filter); iter != nil {
+		return iter
+	}
+	// The Subsonic API has no track-level filtering, so filter client-side
+	return helpers.NewFilteredIterator(s.iterateTracks(searchQuery), filter)
+}
+
+func (s *subsonicMediaProvider) iterateTracks(searchQuery string) mediaprovider.TrackIterator {
 	if searchQuery == "" {
 		return &allTracksIterator{
 			s: s,
@@ -19,7 +28,7 @@ func (s *subsonicMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 	}
 	return &searchTracksIterator{
 		searchIterBase: searchIterBase{
-			s:     s.client,
+			mp:    s,
 			query: searchQuery,
 		},
 		trackIDset: make(map[string]bool),
@@ -89,11 +98,11 @@ func (s *searchTracksIterator) Next() *mediaprovider.Track {
 
 			// add results from artists search
 			for _, artist := range results.Artist {
-				artist, err := s.s.GetArtist(artist.ID)
+				albums, err := s.getArtistAlbums(artist.ID)
 				if err != nil {
 					log.Printf("error fetching artist: %s", err.Error())
 				} else {
-					s.addNewTracksFromAlbums(artist.Album)
+					s.addNewTracksFromAlbums(albums)
 				}
 			}
 			s.artistOffset += len(results.Artist)
@@ -112,7 +121,7 @@ func (s *searchTracksIterator) Next() *mediaprovider.Track {
 			s.prefetched = s.prefetched[:0]
 			s.prefetchedPos = 0
 		}
-		return toTrack(tr)
+		return s.toTrack(tr)
 	}
 
 	// no more results
@@ -134,7 +143,7 @@ func (s *searchTracksIterator) addNewTracks(tracks []*subsonic.Child) {
 
 func (s *searchTracksIterator) addNewTracksFromAlbums(albums []*subsonic.AlbumID3) {
 	for _, al := range albums {
-		if album, err := s.s.GetAlbum(al.ID); err != nil {
+		if album, err := s.getAlbum(al.ID); err != nil {
 			log.Printf("error fetching album: %s", err.Error())
 		} else {
 			s.addNewTracks(album.Song)
@@ -0,0 +1,285 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// MigrationOptions selects what is copied by a ServerMigration.
+type MigrationOptions struct {
+	Playlists bool
+	Favorites bool
+	Ratings   bool
+
+	// If true, items are matched and reported but nothing
+	// is changed on the destination server.
+	DryRun bool
+}
+
+// MigrationReport is the outcome of a ServerMigration.
+type MigrationReport struct {
+	DryRun bool
+
+	PlaylistsCreated []string
+	FavoriteTracks   int
+	FavoriteAlbums   int
+	FavoriteArtists  int
+	RatedTracks      int
+
+	// Human-readable descriptions of the items which could not be matched
+	// on the destination server, or could not be applied to it.
+	Unmatched []string
+	Errors    []string
+}
+
+// ServerMigration copies playlists, favorites and ratings from one server
+// to another, matching tracks by MusicBrainz ID, file path, or by
+// artist, album, title and duration.
+type ServerMigration struct {
+	src mediaprovider.MediaProvider
+	dst mediaprovider.MediaProvider
+
+	// OnProgress, if set, is called with a description of the current step.
+	OnProgress func(string)
+
+	dstTracks *trackMatcher
+}
+
+func NewServerMigration(src, dst mediaprovider.MediaProvider) *ServerMigration {
+	return &ServerMigration{src: src, dst: dst}
+}
+
+// Run performs the migration, returning a report of what was (or, for a
+// dry run, would be) migrated and the items that could not be matched.
+func (m *ServerMigration) Run(opts MigrationOptions) *MigrationReport {
+	report := &MigrationReport{DryRun: opts.DryRun}
+	if opts.Playlists || opts.Favorites || opts.Ratings {
+		m.progress("Indexing tracks on destination server")
+		m.dstTracks = newTrackMatcher(m.dst)
+	}
+	if opts.Playlists {
+		m.migratePlaylists(opts.DryRun, report)
+	}
+	if opts.Favorites {
+		m.migrateFavorites(opts.DryRun, report)
+	}
+	if opts.Ratings {
+		m.migrateRatings(opts.DryRun, report)
+	}
+	return report
+}
+
+func (m *ServerMigration) migratePlaylists(dryRun bool, report *MigrationReport) {
+	m.progress("Reading playlists")
+	playlists, err := m.src.GetPlaylists()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("reading playlists: %v", err))
+		return
+	}
+	for _, p := range playlists {
+		m.progress(fmt.Sprintf("Migrating playlist %s", p.Name))
+		pl, err := m.src.GetPlaylist(p.ID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("reading playlist %s: %v", p.Name, err))
+			continue
+		}
+		var ids []string
+		for _, tr := range pl.Tracks {
+			if match := m.dstTracks.Match(tr); match != nil {
+				ids = append(ids, match.ID)
+			} else {
+				report.Unmatched = append(report.Unmatched,
+					fmt.Sprintf("Track in playlist %s: %s", p.Name, describeTrack(tr)))
+			}
+		}
+		if len(ids) == 0 {
+			report.Unmatched = append(report.Unmatched,
+				fmt.Sprintf("Playlist %s: no matching tracks", p.Name))
+			continue
+		}
+		if !dryRun {
+			if err := m.dst.CreatePlaylist(pl.Name, ids); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("creating playlist %s: %v", p.Name, err))
+				continue
+			}
+		}
+		report.PlaylistsCreated = append(report.PlaylistsCreated, pl.Name)
+	}
+}
+
+func (m *ServerMigration) migrateFavorites(dryRun bool, report *MigrationReport) {
+	m.progress("Reading favorites")
+	favs, err := m.src.GetFavorites()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("reading favorites: %v", err))
+		return
+	}
+	var params mediaprovider.RatingFavoriteParameters
+	for _, tr := range favs.Tracks {
+		if match := m.dstTracks.Match(tr); match != nil {
+			params.TrackIDs = append(params.TrackIDs, match.ID)
+		} else {
+			report.Unmatched = append(report.Unmatched, "Favorite track: "+describeTrack(tr))
+		}
+	}
+	for _, al := range favs.Albums {
+		if id := m.matchAlbum(al); id != "" {
+			params.AlbumIDs = append(params.AlbumIDs, id)
+		} else {
+			report.Unmatched = append(report.Unmatched,
+				fmt.Sprintf("Favorite album: %s - %s", strings.Join(al.ArtistNames, ", "), al.Name))
+		}
+	}
+	for _, ar := range favs.Artists {
+		if id := m.matchArtist(ar); id != "" {
+			params.ArtistIDs = append(params.ArtistIDs, id)
+		} else {
+			report.Unmatched = append(report.Unmatched, "Favorite artist: "+ar.Name)
+		}
+	}
+	if !dryRun {
+		m.progress("Setting favorites")
+		if err := m.dst.SetFavorite(params, true); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("setting favorites: %v", err))
+			return
+		}
+	}
+	report.FavoriteTracks = len(params.TrackIDs)
+	report.FavoriteAlbums = len(params.AlbumIDs)
+	report.FavoriteArtists = len(params.ArtistIDs)
+}
+
+func (m *ServerMigration) migrateRatings(dryRun bool, report *MigrationReport) {
+	dstRating, ok := m.dst.(mediaprovider.SupportsRating)
+	if !ok {
+		report.Errors = append(report.Errors, "the destination server does not support ratings")
+		return
+	}
+	m.progress("Reading track ratings")
+	// group track IDs by rating to set them in bulk
+	idsByRating := make(map[int][]string)
+	iter := m.src.IterateTracks("", mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		if tr.Rating <= 0 {
+			continue
+		}
+		if match := m.dstTracks.Match(tr); match != nil {
+			idsByRating[tr.Rating] = append(idsByRating[tr.Rating], match.ID)
+		} else {
+			report.Unmatched = append(report.Unmatched,
+				fmt.Sprintf("Rated track (%d stars): %s", tr.Rating, describeTrack(tr)))
+		}
+	}
+	for rating, ids := range idsByRating {
+		if !dryRun {
+			m.progress(fmt.Sprintf("Setting %d-star ratings", rating))
+			err := dstRating.SetRating(mediaprovider.RatingFavoriteParameters{TrackIDs: ids}, rating)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("setting ratings: %v", err))
+				continue
+			}
+		}
+		report.RatedTracks += len(ids)
+	}
+}
+
+func (m *ServerMigration) matchAlbum(al *mediaprovider.Album) string {
+	name := normalizeForMatch(al.Name)
+	artist := normalizeForMatch(strings.Join(al.ArtistNames, ""))
+	iter := m.dst.SearchAlbums(al.Name, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+	for i, a := 0, iter.Next(); a != nil && i < 25; i, a = i+1, iter.Next() {
+		if normalizeForMatch(a.Name) == name && normalizeForMatch(strings.Join(a.ArtistNames, "")) == artist {
+			return a.ID
+		}
+	}
+	return ""
+}
+
+func (m *ServerMigration) matchArtist(ar *mediaprovider.Artist) string {
+	name := normalizeForMatch(ar.Name)
+	iter := m.dst.SearchArtists(ar.Name, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+	for i, a := 0, iter.Next(); a != nil && i < 25; i, a = i+1, iter.Next() {
+		if normalizeForMatch(a.Name) == name {
+			return a.ID
+		}
+	}
+	return ""
+}
+
+func (m *ServerMigration) progress(step string) {
+	if m.OnProgress != nil {
+		m.OnProgress(step)
+	}
+}
+
+func describeTrack(tr *mediaprovider.Track) string {
+	return fmt.Sprintf("%s - %s (%s)", strings.Join(tr.ArtistNames, ", "), tr.Name, tr.Album)
+}
+
+// trackMatcher finds the track in a library corresponding to
+// a track from another server's library.
+type trackMatcher struct {
+	byMBID map[string]*mediaprovider.Track
+	byPath map[string][]*mediaprovider.Track // keyed by lowercase file name
+	byName map[string][]*mediaprovider.Track // keyed by normalized artist and title
+}
+
+func newTrackMatcher(mp mediaprovider.MediaProvider) *trackMatcher {
+	t := &trackMatcher{
+		byMBID: make(map[string]*mediaprovider.Track),
+		byPath: make(map[string][]*mediaprovider.Track),
+		byName: make(map[string][]*mediaprovider.Track),
+	}
+	iter := mp.IterateTracks("", mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		t.add(tr)
+	}
+	return t
+}
+
+func (t *trackMatcher) add(tr *mediaprovider.Track) {
+	if tr.MusicBrainzID != "" {
+		t.byMBID[tr.MusicBrainzID] = tr
+	}
+	if tr.FilePath != "" {
+		base := path.Base(normalizePath(tr.FilePath))
+		t.byPath[base] = append(t.byPath[base], tr)
+	}
+	key := trackNameKey(tr)
+	t.byName[key] = append(t.byName[key], tr)
+}
+
+// Match returns the matching track, or nil if there is none.
+func (t *trackMatcher) Match(tr *mediaprovider.Track) *mediaprovider.Track {
+	if match, ok := t.byMBID[tr.MusicBrainzID]; ok && tr.MusicBrainzID != "" {
+		return match
+	}
+	if match := matchTrackByPath(t.byPath, tr.FilePath); match != nil {
+		return match
+	}
+	// match artist and title, preferring the same album,
+	// and rejecting tracks of significantly different length
+	album := normalizeForMatch(tr.Album)
+	var best *mediaprovider.Track
+	for _, c := range t.byName[trackNameKey(tr)] {
+		if d := c.Duration - tr.Duration; tr.Duration > 0 && c.Duration > 0 && (d > 3 || d < -3) {
+			continue
+		}
+		if normalizeForMatch(c.Album) == album {
+			return c
+		}
+		if best == nil {
+			best = c
+		}
+	}
+	return best
+}
+
+func trackNameKey(tr *mediaprovider.Track) string {
+	artists := sharedutil.MapSlice(tr.ArtistNames, normalizeForMatch)
+	return strings.Join(artists, "") + "|" + normalizeForMatch(tr.Name)
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_TrackMatcher(t *testing.T) {
+	matcher := &trackMatcher{
+		byMBID: make(map[string]*mediaprovider.Track),
+		byPath: make(map[string][]*mediaprovider.Track),
+		byName: make(map[string][]*mediaprovider.Track),
+	}
+	for _, tr := range []*mediaprovider.Track{
+		{ID: "mbid", MusicBrainzID: "1234", Name: "Tagged", ArtistNames: []string{"Artist"}},
+		{ID: "path", FilePath: "Artist/Album/01 Song.flac", Name: "Renamed", ArtistNames: []string{"Other"}},
+		{ID: "live", Name: "Song", ArtistNames: []string{"Artist"}, Album: "Live", Duration: 300},
+		{ID: "studio", Name: "Song", ArtistNames: []string{"Artist"}, Album: "Studio", Duration: 200},
+		{ID: "compilation", Name: "Hit", ArtistNames: []string{"Artist"}, Album: "Best Of", Duration: 180},
+	} {
+		matcher.add(tr)
+	}
+
+	tests := []struct {
+		name  string
+		track *mediaprovider.Track
+		want  string
+	}{
+		{"musicbrainz ID", &mediaprovider.Track{MusicBrainzID: "1234", Name: "Different"}, "mbid"},
+		{"unknown musicbrainz ID falls back to name", &mediaprovider.Track{MusicBrainzID: "5678", Name: "Tagged", ArtistNames: []string{"Artist"}}, "mbid"},
+		{"path with different root", &mediaprovider.Track{FilePath: "/srv/music/artist/album/01 song.flac"}, "path"},
+		{"same album preferred", &mediaprovider.Track{Name: "song", ArtistNames: []string{"ARTIST"}, Album: "Studio"}, "studio"},
+		{"duration rejects other version", &mediaprovider.Track{Name: "Song", ArtistNames: []string{"Artist"}, Album: "Studio", Duration: 298}, "live"},
+		{"other album", &mediaprovider.Track{Name: "Hit", ArtistNames: []string{"Artist"}, Album: "Single", Duration: 181}, "compilation"},
+		{"duration mismatch", &mediaprovider.Track{Name: "Hit", ArtistNames: []string{"Artist"}, Duration: 240}, ""},
+		{"unknown track", &mediaprovider.Track{Name: "Missing", ArtistNames: []string{"Artist"}}, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if match := matcher.Match(tt.track); match != nil {
+			got = match.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
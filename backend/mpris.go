@@ -269,7 +269,8 @@ func (m *MPRISHandler) Metadata() (types.Metadata, error) {
 		Artist:         tr.ArtistNames,
 		DiscNumber:     tr.DiscNumber,
 		TrackNumber:    tr.TrackNumber,
-		Genre:          []string{tr.Genre},
+		Genre:          tr.Genres,
+		Composer:       tr.ComposerNames,
 		UserRating:     float64(tr.Rating) / 5,
 		ContentCreated: strconv.Itoa(tr.Year),
 		UseCount:       tr.PlayCount,
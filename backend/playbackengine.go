@@ -5,9 +5,11 @@ import (
 	"errors"
 	"log"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/aggregate"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/util"
 	"github.com/dweymouth/supersonic/sharedutil"
@@ -40,6 +42,14 @@ type playbackEngine struct {
 	latestTrackPosition float64 // cleared by checkScrobble
 	callbacksDisabled   bool
 
+	// guards the state below, which is accessed both by commands
+	// and by the handlers of the player's events
+	eventMutex sync.Mutex
+	// the file loaded into a URLPlayer when the last track change was handled
+	curURL string
+	// set while reloading the current track from a new stream URL
+	reload *streamReload
+
 	playQueue     []*mediaprovider.Track
 	nowPlayingIdx int
 	wasStopped    bool // true iff player was stopped before handleOnTrackChange invocation
@@ -51,6 +61,11 @@ type playbackEngine struct {
 	transcodeCfg  *TranscodingConfig
 	replayGainCfg ReplayGainConfig
 
+	// local record of plays, if set
+	history *ListeningHistory
+	// direct scrobbling to Last.fm and ListenBrainz, if set
+	scrobblers *ScrobbleManager
+
 	// registered callbacks
 	onSongChange     []func(nowPlaying, justScrobbledIfAny *mediaprovider.Track)
 	onPlayTimeUpdate []func(float64, float64)
@@ -99,10 +114,6 @@ func NewPlaybackEngine(
 		pm.invokeNoArgCallbacks(pm.onPlaying)
 	})
 
-	s.OnLogout(func() {
-		pm.StopAndClearPlayQueue()
-	})
-
 	return pm
 }
 
@@ -371,7 +382,87 @@ func (p *playbackEngine) SetReplayGainMode(mode player.ReplayGainMode) {
 	})
 }
 
+// streamReload is a reload of the current track from a new stream URL,
+// which resumes the track at the position it was playing at.
+type streamReload struct {
+	trackID   string
+	url       string
+	resumePos float64
+	wasPaused bool
+}
+
+// reloadStreamURLs reloads the current track, resuming at the current
+// position, and the next track, from their stream URLs at the hostname
+// now in use, if the current track is from the server with the given ID
+// (as given by aggregate.SplitID). It must be run as a PlaybackManager command.
+func (p *playbackEngine) reloadStreamURLs(serverID string) {
+	urlP, ok := p.player.(player.URLPlayer)
+	if !ok || p.nowPlayingIdx < 0 || p.nowPlayingIdx >= len(p.playQueue) {
+		return
+	}
+	track := p.playQueue[p.nowPlayingIdx]
+	if id, _ := aggregate.SplitID(track.ID); id != serverID {
+		return
+	}
+
+	p.eventMutex.Lock()
+	defer p.eventMutex.Unlock()
+	status := p.player.GetStatus()
+	if status.State == player.Stopped {
+		return
+	}
+	if urlP.CurrentFile() != p.curURL {
+		// the player has moved on to another file, and the track change
+		// is yet to be handled - don't have it mistaken for the reload
+		return
+	}
+	url, err := p.streamURL(p.nowPlayingIdx)
+	if err == nil {
+		err = urlP.PlayFile(url)
+	}
+	if err != nil {
+		log.Printf("error reloading track after hostname change: %v", err)
+		return
+	}
+	p.reload = &streamReload{
+		trackID:   track.ID,
+		url:       url,
+		resumePos: status.TimePos,
+		wasPaused: status.State == player.Paused,
+	}
+}
+
+// handleReloaded resumes the current track at its previous position and
+// returns true if the track change is the load of the file reloaded by
+// reloadStreamURLs for it. Otherwise it records the newly loaded file.
+func (p *playbackEngine) handleReloaded() bool {
+	urlP, ok := p.player.(player.URLPlayer)
+	if !ok {
+		return false
+	}
+	p.eventMutex.Lock()
+	p.curURL = urlP.CurrentFile()
+	r := p.reload
+	if r == nil || r.url != p.curURL || p.nowPlayingIdx < 0 ||
+		p.nowPlayingIdx >= len(p.playQueue) || p.playQueue[p.nowPlayingIdx].ID != r.trackID {
+		p.eventMutex.Unlock()
+		return false
+	}
+	p.reload = nil
+	p.eventMutex.Unlock()
+
+	p.player.SeekSeconds(r.resumePos)
+	if r.wasPaused {
+		p.player.Pause()
+	}
+	p.setNextTrackBasedOnLoopMode(false)
+	return true
+}
+
 func (p *playbackEngine) handleOnTrackChange() {
+	if p.handleReloaded() {
+		return
+	}
 	p.checkScrobble() // scrobble the previous song if needed
 	if p.player.GetStatus().State == player.Playing {
 		p.playTimeStopwatch.Start()
@@ -391,6 +482,10 @@ func (p *playbackEngine) handleOnTrackChange() {
 }
 
 func (p *playbackEngine) handleOnStopped() {
+	p.eventMutex.Lock()
+	p.curURL = ""
+	p.reload = nil
+	p.eventMutex.Unlock()
 	p.playTimeStopwatch.Stop()
 	p.checkScrobble()
 	p.stopPollTimePos()
@@ -447,14 +542,17 @@ func (p *playbackEngine) setTrack(idx int, next bool) error {
 		url := ""
 		if idx >= 0 {
 			var err error
-			url, err = p.sm.Server.GetStreamURL(p.playQueue[idx].ID, p.transcodeCfg.ForceRawFile)
-			if err != nil {
+			if url, err = p.streamURL(idx); err != nil {
 				return err
 			}
 		}
 		if next {
 			return urlP.SetNextFile(url)
 		}
+		// a new track replaces any reload in progress
+		p.eventMutex.Lock()
+		p.reload = nil
+		p.eventMutex.Unlock()
 		return urlP.PlayFile(url)
 	} else if trP, ok := p.player.(player.TrackPlayer); ok {
 		var track *mediaprovider.Track
@@ -469,13 +567,23 @@ func (p *playbackEngine) setTrack(idx int, next bool) error {
 	panic("Unsupported player type")
 }
 
+// streamURL returns the URL to stream the track at idx from,
+// at the server's hostname now in use.
+func (p *playbackEngine) streamURL(idx int) (string, error) {
+	url, err := p.sm.Server.GetStreamURL(p.playQueue[idx].ID, p.transcodeCfg.ForceRawFile)
+	if err != nil {
+		return "", err
+	}
+	return p.sm.ResolveURL(url), nil
+}
+
 func (p *playbackEngine) setNextTrack(idx int) error {
 	return p.setTrack(idx, true)
 }
 
 // call BEFORE updating p.nowPlayingIdx
 func (p *playbackEngine) checkScrobble() {
-	if !p.scrobbleCfg.Enabled || len(p.playQueue) == 0 || p.nowPlayingIdx < 0 {
+	if len(p.playQueue) == 0 || p.nowPlayingIdx < 0 {
 		return
 	}
 	playDur := p.playTimeStopwatch.Elapsed()
@@ -485,11 +593,24 @@ func (p *playbackEngine) checkScrobble() {
 	pcnt := playDur.Seconds() / p.curTrackTime * 100
 	timeThresholdMet := p.scrobbleCfg.ThresholdTimeSeconds >= 0 &&
 		playDur.Seconds() >= float64(p.scrobbleCfg.ThresholdTimeSeconds)
+	thresholdMet := timeThresholdMet || pcnt >= float64(p.scrobbleCfg.ThresholdPercent)
 
 	track := p.playQueue[p.nowPlayingIdx]
+	if p.history != nil {
+		p.history.RecordPlay(p.sm.ServerID.String(), track, playDur, thresholdMet)
+	}
+	if p.scrobblers != nil && thresholdMet {
+		p.scrobblers.Scrobble(track, time.Now().Add(-playDur))
+	}
+	if !p.scrobbleCfg.Enabled {
+		p.latestTrackPosition = 0
+		p.playTimeStopwatch.Reset()
+		return
+	}
+
 	var submission bool
 	server := p.sm.Server
-	if server.ClientDecidesScrobble() && (timeThresholdMet || pcnt >= float64(p.scrobbleCfg.ThresholdPercent)) {
+	if mediaprovider.ClientDecidesScrobble(server, track.ID) && thresholdMet {
 		track.PlayCount += 1
 		p.lastScrobbled = track
 		submission = true
@@ -500,12 +621,18 @@ func (p *playbackEngine) checkScrobble() {
 }
 
 func (p *playbackEngine) sendNowPlayingScrobble() {
-	if !p.scrobbleCfg.Enabled || len(p.playQueue) == 0 || p.nowPlayingIdx < 0 {
+	if len(p.playQueue) == 0 || p.nowPlayingIdx < 0 {
 		return
 	}
 	track := p.playQueue[p.nowPlayingIdx]
+	if p.scrobblers != nil {
+		p.scrobblers.NowPlaying(track)
+	}
+	if !p.scrobbleCfg.Enabled {
+		return
+	}
 	server := p.sm.Server
-	if !server.ClientDecidesScrobble() {
+	if !mediaprovider.ClientDecidesScrobble(server, track.ID) {
 		// server will count track as scrobbled as soon as it starts playing
 		p.lastScrobbled = track
 		track.PlayCount += 1
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"sync"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/player"
@@ -12,7 +13,12 @@ import (
 // A high-level MediaProvider-aware playback engine, serves as an
 // intermediary between the frontend and various Player backends.
 type PlaybackManager struct {
-	engine *playbackEngine
+	engine         *playbackEngine
+	smartPlaylists *SmartPlaylistManager
+
+	// serializes the commands which change the play queue or the current
+	// track, which may be issued from the UI and from background goroutines
+	cmdMutex sync.Mutex
 }
 
 func NewPlaybackManager(
@@ -22,9 +28,36 @@ func NewPlaybackManager(
 	scrobbleCfg *ScrobbleConfig,
 	transcodeCfg *TranscodingConfig,
 ) *PlaybackManager {
-	return &PlaybackManager{
+	pm := &PlaybackManager{
 		engine: NewPlaybackEngine(ctx, s, p, scrobbleCfg, transcodeCfg),
 	}
+	s.OnLogout(pm.StopAndClearPlayQueue)
+	s.OnHostnameChanged(func(serverID string) {
+		pm.runCommand(func() error {
+			pm.engine.reloadStreamURLs(serverID)
+			return nil
+		})
+	})
+	return pm
+}
+
+// runCommand runs a command which changes the play queue or the current track,
+// once any other such command in progress has completed.
+func (p *PlaybackManager) runCommand(cmd func() error) error {
+	p.cmdMutex.Lock()
+	defer p.cmdMutex.Unlock()
+	return cmd()
+}
+
+// SetListeningHistory sets the history in which every play is recorded.
+func (p *PlaybackManager) SetListeningHistory(h *ListeningHistory) {
+	p.engine.history = h
+}
+
+// SetScrobbleManager sets the manager for direct scrobbling to
+// Last.fm and ListenBrainz, independently of the server.
+func (p *PlaybackManager) SetScrobbleManager(s *ScrobbleManager) {
+	p.engine.scrobblers = s
 }
 
 func (p *PlaybackManager) CurrentPlayer() player.BasePlayer {
@@ -110,6 +143,13 @@ func (p *PlaybackManager) LoadAlbum(albumID string, appendToQueue bool, shuffle
 
 // Loads the specified playlist into the play queue.
 func (p *PlaybackManager) LoadPlaylist(playlistID string, appendToQueue bool, shuffle bool) error {
+	if IsSmartPlaylistID(playlistID) && p.smartPlaylists != nil {
+		tracks, err := p.smartPlaylists.EvaluateByID(playlistID)
+		if err != nil {
+			return err
+		}
+		return p.LoadTracks(tracks, appendToQueue, shuffle)
+	}
 	playlist, err := p.engine.sm.Server.GetPlaylist(playlistID)
 	if err != nil {
 		return err
@@ -120,14 +160,18 @@ func (p *PlaybackManager) LoadPlaylist(playlistID string, appendToQueue bool, sh
 // Load tracks into the play queue.
 // If replacing the current queue (!appendToQueue), playback will be stopped.
 func (p *PlaybackManager) LoadTracks(tracks []*mediaprovider.Track, appendToQueue, shuffle bool) error {
-	return p.engine.LoadTracks(tracks, appendToQueue, shuffle)
+	return p.runCommand(func() error {
+		return p.engine.LoadTracks(tracks, appendToQueue, shuffle)
+	})
 }
 
 // Replaces the play queue with the given set of tracks.
 // Does not stop playback if the currently playing track is in the new queue,
 // but updates the now playing index to point to the first instance of the track in the new queue.
 func (p *PlaybackManager) UpdatePlayQueue(tracks []*mediaprovider.Track) error {
-	return p.engine.UpdatePlayQueue(tracks)
+	return p.runCommand(func() error {
+		return p.engine.UpdatePlayQueue(tracks)
+	})
 }
 
 func (p *PlaybackManager) PlayAlbum(albumID string, firstTrack int, shuffle bool) error {
@@ -163,11 +207,13 @@ func (p *PlaybackManager) PlayTrack(trackID string) error {
 }
 
 func (p *PlaybackManager) PlayFromBeginning() error {
-	return p.engine.PlayTrackAt(0)
+	return p.PlayTrackAt(0)
 }
 
 func (p *PlaybackManager) PlayTrackAt(idx int) error {
-	return p.engine.PlayTrackAt(idx)
+	return p.runCommand(func() error {
+		return p.engine.PlayTrackAt(idx)
+	})
 }
 
 func (p *PlaybackManager) PlayRandomSongs(genreName string) {
@@ -211,12 +257,18 @@ func (p *PlaybackManager) OnTrackRatingChanged(id string, rating int) {
 }
 
 func (p *PlaybackManager) RemoveTracksFromQueue(trackIDs []string) {
-	p.engine.RemoveTracksFromQueue(trackIDs)
+	p.runCommand(func() error {
+		p.engine.RemoveTracksFromQueue(trackIDs)
+		return nil
+	})
 }
 
 // Stop playback and clear the play queue.
 func (p *PlaybackManager) StopAndClearPlayQueue() {
-	p.engine.StopAndClearPlayQueue()
+	p.runCommand(func() error {
+		p.engine.StopAndClearPlayQueue()
+		return nil
+	})
 }
 
 func (p *PlaybackManager) SetReplayGainOptions(config ReplayGainConfig) {
@@ -262,11 +314,11 @@ func (p *PlaybackManager) Volume() int {
 }
 
 func (p *PlaybackManager) SeekNext() error {
-	return p.engine.SeekNext()
+	return p.runCommand(p.engine.SeekNext)
 }
 
 func (p *PlaybackManager) SeekBackOrPrevious() error {
-	return p.engine.SeekBackOrPrevious()
+	return p.runCommand(p.engine.SeekBackOrPrevious)
 }
 
 // Seek to given absolute position in the current track by seconds.
@@ -294,7 +346,7 @@ func (p *PlaybackManager) Pause() error {
 }
 
 func (p *PlaybackManager) Continue() error {
-	return p.engine.Continue()
+	return p.runCommand(p.engine.Continue)
 }
 
 func (p *PlaybackManager) PlayPause() error {
@@ -302,9 +354,9 @@ func (p *PlaybackManager) PlayPause() error {
 	case player.Playing:
 		return p.engine.Pause()
 	case player.Paused:
-		return p.engine.Continue()
+		return p.Continue()
 	case player.Stopped:
-		return p.engine.PlayTrackAt(0)
+		return p.PlayTrackAt(0)
 	}
 	return errors.New("unreached - invalid player state")
 }
@@ -187,6 +187,18 @@ func (p *Player) SetNextFile(url string) error {
 	return err
 }
 
+// Returns the URL of the file currently loaded, if any.
+func (p *Player) CurrentFile() string {
+	if !p.initialized {
+		return ""
+	}
+	path, err := p.mpv.GetProperty("path", mpv.FORMAT_STRING)
+	if err != nil || path == nil {
+		return ""
+	}
+	return path.(string)
+}
+
 // Seeks within the currently playing track.
 // See MPV seek command documentation for more details.
 func (p *Player) SeekSeconds(secs float64) error {
@@ -6,6 +6,8 @@ type URLPlayer interface {
 	BasePlayer
 	PlayFile(url string) error
 	SetNextFile(url string) error
+	// CurrentFile returns the URL of the file currently loaded, if any.
+	CurrentFile() string
 }
 
 type TrackPlayer interface {
@@ -0,0 +1,467 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+type PlaylistFileFormat int
+
+const (
+	PlaylistFormatM3U8 PlaylistFileFormat = iota
+	PlaylistFormatXSPF
+	PlaylistFormatJSPF
+)
+
+var ErrUnknownPlaylistFormat = errors.New("unknown playlist file format")
+
+// PlaylistFileExtensions are the file extensions of the supported playlist formats.
+var PlaylistFileExtensions = []string{".m3u8", ".m3u", ".xspf", ".jspf"}
+
+// PlaylistFormatForFile returns the playlist format matching the file's extension.
+func PlaylistFormatForFile(filePath string) (PlaylistFileFormat, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".m3u8", ".m3u":
+		return PlaylistFormatM3U8, nil
+	case ".xspf":
+		return PlaylistFormatXSPF, nil
+	case ".jspf", ".json":
+		return PlaylistFormatJSPF, nil
+	}
+	return 0, ErrUnknownPlaylistFormat
+}
+
+// PlaylistFileEntry is a single entry of an imported playlist file.
+// Any of the fields may be empty, depending on the format and the
+// application that wrote the file.
+type PlaylistFileEntry struct {
+	Path     string
+	Title    string
+	Artist   string
+	Album    string
+	Duration int // seconds
+}
+
+func (e PlaylistFileEntry) String() string {
+	if e.Title == "" {
+		return e.Path
+	}
+	if e.Artist == "" {
+		return e.Title
+	}
+	return fmt.Sprintf("%s - %s", e.Artist, e.Title)
+}
+
+// WritePlaylistFile writes the tracks as a playlist file in the given format.
+// It returns the number of tracks which could not be written, since some
+// formats can only reference tracks by their file path.
+func WritePlaylistFile(w io.Writer, format PlaylistFileFormat, name string, tracks []*mediaprovider.Track) (int, error) {
+	switch format {
+	case PlaylistFormatM3U8:
+		return writeM3U8(w, name, tracks)
+	case PlaylistFormatXSPF:
+		return 0, writeXSPF(w, name, tracks)
+	case PlaylistFormatJSPF:
+		return 0, writeJSPF(w, name, tracks)
+	}
+	return 0, ErrUnknownPlaylistFormat
+}
+
+// ReadPlaylistFile parses a playlist file in the given format,
+// returning the playlist name (if stored in the file) and its entries.
+func ReadPlaylistFile(r io.Reader, format PlaylistFileFormat) (string, []PlaylistFileEntry, error) {
+	switch format {
+	case PlaylistFormatM3U8:
+		return readM3U8(r)
+	case PlaylistFormatXSPF:
+		return readXSPF(r)
+	case PlaylistFormatJSPF:
+		return readJSPF(r)
+	}
+	return "", nil, ErrUnknownPlaylistFormat
+}
+
+// writeM3U8 writes an extended M3U playlist. Since M3U entries are
+// file paths, tracks without a known path are skipped and counted.
+func writeM3U8(w io.Writer, name string, tracks []*mediaprovider.Track) (int, error) {
+	skipped := 0
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+	if name != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", name)
+	}
+	for _, tr := range tracks {
+		if tr.FilePath == "" {
+			skipped++
+			continue
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", tr.Duration, strings.Join(tr.ArtistNames, ", "), tr.Name)
+		if tr.Album != "" {
+			fmt.Fprintf(bw, "#EXTALB:%s\n", tr.Album)
+		}
+		bw.WriteString(tr.FilePath + "\n")
+	}
+	return skipped, bw.Flush()
+}
+
+func readM3U8(r io.Reader) (string, []PlaylistFileEntry, error) {
+	var name string
+	var entries []PlaylistFileEntry
+	var cur PlaylistFileEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			name = strings.TrimPrefix(line, "#PLAYLIST:")
+		case strings.HasPrefix(line, "#EXTALB:"):
+			cur.Album = strings.TrimPrefix(line, "#EXTALB:")
+		case strings.HasPrefix(line, "#EXTINF:"):
+			durStr, info, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			// duration may be followed by space-separated attributes
+			durStr, _, _ = strings.Cut(durStr, " ")
+			if dur, err := strconv.Atoi(durStr); err == nil && dur > 0 {
+				cur.Duration = dur
+			}
+			if artist, title, ok := strings.Cut(info, " - "); ok {
+				cur.Artist, cur.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+			} else {
+				cur.Title = strings.TrimSpace(info)
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			cur.Path = line
+			entries = append(entries, cur)
+			cur = PlaylistFileEntry{}
+		}
+	}
+	return name, entries, scanner.Err()
+}
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// the namespace is written as an attribute rather than as part of XMLName
+// so that files written without the namespace can be read as well
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	Duration int    `xml:"duration,omitempty"` // milliseconds
+}
+
+func writeXSPF(w io.Writer, name string, tracks []*mediaprovider.Track) error {
+	pl := xspfPlaylist{
+		Xmlns:   xspfNamespace,
+		Version: "1",
+		Title:   name,
+		Tracks: sharedutil.MapSlice(tracks, func(tr *mediaprovider.Track) xspfTrack {
+			return xspfTrack{
+				Location: pathToURI(tr.FilePath),
+				Title:    tr.Name,
+				Creator:  strings.Join(tr.ArtistNames, ", "),
+				Album:    tr.Album,
+				Duration: tr.Duration * 1000,
+			}
+		}),
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(pl)
+}
+
+func readXSPF(r io.Reader) (string, []PlaylistFileEntry, error) {
+	var pl xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&pl); err != nil {
+		return "", nil, err
+	}
+	entries := sharedutil.MapSlice(pl.Tracks, func(t xspfTrack) PlaylistFileEntry {
+		return PlaylistFileEntry{
+			Path:     uriToPath(t.Location),
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Album:    t.Album,
+			Duration: t.Duration / 1000,
+		}
+	})
+	return pl.Title, entries, nil
+}
+
+type jspfFile struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+type jspfPlaylist struct {
+	Title  string      `json:"title,omitempty"`
+	Tracks []jspfTrack `json:"track"`
+}
+
+type jspfTrack struct {
+	Location []string `json:"location,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Creator  string   `json:"creator,omitempty"`
+	Album    string   `json:"album,omitempty"`
+	Duration int      `json:"duration,omitempty"` // milliseconds
+}
+
+func writeJSPF(w io.Writer, name string, tracks []*mediaprovider.Track) error {
+	f := jspfFile{Playlist: jspfPlaylist{
+		Title: name,
+		Tracks: sharedutil.MapSlice(tracks, func(tr *mediaprovider.Track) jspfTrack {
+			t := jspfTrack{
+				Title:    tr.Name,
+				Creator:  strings.Join(tr.ArtistNames, ", "),
+				Album:    tr.Album,
+				Duration: tr.Duration * 1000,
+			}
+			if tr.FilePath != "" {
+				t.Location = []string{pathToURI(tr.FilePath)}
+			}
+			return t
+		}),
+	}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+func readJSPF(r io.Reader) (string, []PlaylistFileEntry, error) {
+	var f jspfFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return "", nil, err
+	}
+	entries := sharedutil.MapSlice(f.Playlist.Tracks, func(t jspfTrack) PlaylistFileEntry {
+		e := PlaylistFileEntry{
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Album:    t.Album,
+			Duration: t.Duration / 1000,
+		}
+		if len(t.Location) > 0 {
+			e.Path = uriToPath(t.Location[0])
+		}
+		return e
+	})
+	return f.Playlist.Title, entries, nil
+}
+
+func pathToURI(p string) string {
+	if p == "" {
+		return ""
+	}
+	u := url.URL{Path: filepath.ToSlash(p)}
+	if strings.HasPrefix(u.Path, "/") {
+		u.Scheme = "file"
+	}
+	return u.String()
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "" && u.Scheme != "file") {
+		return uri
+	}
+	return u.Path
+}
+
+// PlaylistImportResult is the result of resolving the entries
+// of a playlist file to tracks on the current server.
+type PlaylistImportResult struct {
+	Tracks    []*mediaprovider.Track
+	Unmatched []PlaylistFileEntry
+}
+
+// ResolvePlaylistEntries finds the server track for each playlist entry, first
+// by a fuzzy search on artist, title and duration, then by matching file paths.
+// Since matching paths requires listing every track on the server, this is only
+// done if some entries with a path could not be matched by searching.
+// onProgress, if non-nil, is called after each entry is searched.
+func ResolvePlaylistEntries(mp mediaprovider.MediaProvider, entries []PlaylistFileEntry, onProgress func(done, total int)) *PlaylistImportResult {
+	matched := make([]*mediaprovider.Track, len(entries))
+	needPathMatch := false
+	for i, e := range entries {
+		if e.Title != "" {
+			matched[i] = searchTrack(mp, e)
+		}
+		if matched[i] == nil && e.Path != "" {
+			needPathMatch = true
+		}
+		if onProgress != nil {
+			onProgress(i+1, len(entries))
+		}
+	}
+
+	if needPathMatch {
+		pathIndex := buildTrackPathIndex(mp)
+		for i, e := range entries {
+			if matched[i] == nil {
+				matched[i] = matchTrackByPath(pathIndex, e.Path)
+			}
+		}
+	}
+
+	result := &PlaylistImportResult{}
+	for i, tr := range matched {
+		if tr != nil {
+			result.Tracks = append(result.Tracks, tr)
+		} else {
+			result.Unmatched = append(result.Unmatched, entries[i])
+		}
+	}
+	return result
+}
+
+// index of all tracks on the server by lowercased file name
+func buildTrackPathIndex(mp mediaprovider.MediaProvider) map[string][]*mediaprovider.Track {
+	index := make(map[string][]*mediaprovider.Track)
+	iter := mp.IterateTracks("", mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		if tr.FilePath == "" {
+			continue
+		}
+		p := normalizePath(tr.FilePath)
+		base := path.Base(p)
+		index[base] = append(index[base], tr)
+	}
+	return index
+}
+
+// matchTrackByPath returns the track whose path matches the entry's path. Since the
+// entry may have been written by another application with a different library root,
+// the paths match if one is a suffix of the other on a directory boundary.
+func matchTrackByPath(index map[string][]*mediaprovider.Track, entryPath string) *mediaprovider.Track {
+	if index == nil || entryPath == "" {
+		return nil
+	}
+	p := normalizePath(entryPath)
+	for _, tr := range index[path.Base(p)] {
+		trPath := normalizePath(tr.FilePath)
+		if isPathSuffix(p, trPath) || isPathSuffix(trPath, p) {
+			return tr
+		}
+	}
+	return nil
+}
+
+func normalizePath(p string) string {
+	return strings.ToLower(strings.ReplaceAll(p, "\\", "/"))
+}
+
+func isPathSuffix(p, suffix string) bool {
+	suffix = strings.TrimPrefix(suffix, "/")
+	return p == suffix || strings.HasSuffix(p, "/"+suffix)
+}
+
+const minTrackMatchScore = 4
+
+func searchTrack(mp mediaprovider.MediaProvider, e PlaylistFileEntry) *mediaprovider.Track {
+	var best *mediaprovider.Track
+	bestScore := 0
+	iter := mp.IterateTracks(e.Title, mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+	for i, tr := 0, iter.Next(); tr != nil && i < 25; i, tr = i+1, iter.Next() {
+		if score := trackMatchScore(e, tr.Name, strings.Join(tr.ArtistNames, " "), tr.Duration); score > bestScore {
+			best, bestScore = tr, score
+		}
+	}
+	if bestScore >= minTrackMatchScore {
+		return best
+	}
+
+	// fall back to a full search including the artist name
+	results, err := mp.SearchAll(strings.TrimSpace(e.Artist+" "+e.Title), 20)
+	if err != nil {
+		return nil
+	}
+	var bestID string
+	bestScore = 0
+	for _, r := range results {
+		if r.Type != mediaprovider.ContentTypeTrack {
+			continue
+		}
+		if score := trackMatchScore(e, r.Name, r.ArtistName, r.Size); score > bestScore {
+			bestID, bestScore = r.ID, score
+		}
+	}
+	if bestScore < minTrackMatchScore {
+		return nil
+	}
+	tr, err := mp.GetTrack(bestID)
+	if err != nil {
+		return nil
+	}
+	return tr
+}
+
+// trackMatchScore scores how well a track matches a playlist entry.
+// A score of at least minTrackMatchScore requires a matching title.
+func trackMatchScore(e PlaylistFileEntry, title, artist string, duration int) int {
+	score := 0
+	eTitle, tTitle := normalizeForMatch(e.Title), normalizeForMatch(title)
+	if eTitle == "" || tTitle == "" {
+		return 0
+	}
+	switch {
+	case eTitle == tTitle:
+		score += 3
+	case strings.Contains(tTitle, eTitle) || strings.Contains(eTitle, tTitle):
+		score += 1
+	default:
+		return 0
+	}
+	if e.Artist == "" {
+		score += 1
+	} else if eArtist, tArtist := normalizeForMatch(e.Artist), normalizeForMatch(artist); eArtist == tArtist {
+		score += 3
+	} else if strings.Contains(tArtist, eArtist) || strings.Contains(eArtist, tArtist) {
+		score += 2
+	}
+	if e.Duration > 0 && duration > 0 {
+		diff := e.Duration - duration
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= 3 {
+			score += 2
+		} else if diff > 15 {
+			score -= 2
+		}
+	}
+	return score
+}
+
+// lowercases and removes all non-alphanumeric characters
+func normalizeForMatch(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_ReadPlaylistFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   PlaylistFileFormat
+		input    string
+		wantName string
+		want     []PlaylistFileEntry
+	}{
+		{
+			name:   "plain m3u",
+			format: PlaylistFormatM3U8,
+			input:  "music/a.mp3\r\n\r\n# comment\nC:\\Music\\b.flac\n",
+			want: []PlaylistFileEntry{
+				{Path: "music/a.mp3"},
+				{Path: "C:\\Music\\b.flac"},
+			},
+		},
+		{
+			name:     "extended m3u",
+			format:   PlaylistFormatM3U8,
+			input:    "\ufeff#EXTM3U\n#PLAYLIST:Mix\n#EXTINF:215 tvg-id=\"x\",Artist - Song - Live\n#EXTALB:Album\n/music/song.mp3\n#EXTINF:-1,Untitled\nhttp://example.com/stream\n",
+			wantName: "Mix",
+			want: []PlaylistFileEntry{
+				{Path: "/music/song.mp3", Title: "Song - Live", Artist: "Artist", Album: "Album", Duration: 215},
+				{Path: "http://example.com/stream", Title: "Untitled"},
+			},
+		},
+		{
+			name:     "xspf",
+			format:   PlaylistFormatXSPF,
+			input:    `<?xml version="1.0"?><playlist version="1" xmlns="http://xspf.org/ns/0/"><title>Mix</title><trackList><track><location>file:///music/a%20b.mp3</location><title>Song</title><creator>Artist</creator><album>Album</album><duration>215000</duration></track></trackList></playlist>`,
+			wantName: "Mix",
+			want: []PlaylistFileEntry{
+				{Path: "/music/a b.mp3", Title: "Song", Artist: "Artist", Album: "Album", Duration: 215},
+			},
+		},
+		{
+			name:   "xspf without namespace",
+			format: PlaylistFormatXSPF,
+			input:  `<playlist version="1"><trackList><track><title>Song</title></track></trackList></playlist>`,
+			want: []PlaylistFileEntry{
+				{Title: "Song"},
+			},
+		},
+		{
+			name:     "jspf",
+			format:   PlaylistFormatJSPF,
+			input:    `{"playlist":{"title":"Mix","track":[{"location":["music/a.mp3"],"title":"Song","creator":"Artist","duration":215000},{"title":"Other"}]}}`,
+			wantName: "Mix",
+			want: []PlaylistFileEntry{
+				{Path: "music/a.mp3", Title: "Song", Artist: "Artist", Duration: 215},
+				{Title: "Other"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		name, entries, err := ReadPlaylistFile(strings.NewReader(tt.input), tt.format)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if name != tt.wantName {
+			t.Errorf("%s: got name %q, want %q", tt.name, name, tt.wantName)
+		}
+		if !reflect.DeepEqual(entries, tt.want) {
+			t.Errorf("%s: got entries %+v, want %+v", tt.name, entries, tt.want)
+		}
+	}
+}
+
+func Test_PlaylistFileRoundTrip(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{Name: "Song", ArtistNames: []string{"A", "B"}, Album: "Album", Duration: 215, FilePath: "/music/a b/song.flac"},
+		{Name: "Other", ArtistNames: []string{"C"}, Duration: 100, FilePath: "relative/other.mp3"},
+		{Name: "No Path", ArtistNames: []string{"D"}, Duration: 60},
+	}
+	for _, format := range []PlaylistFileFormat{PlaylistFormatM3U8, PlaylistFormatXSPF, PlaylistFormatJSPF} {
+		var buf bytes.Buffer
+		skipped, err := WritePlaylistFile(&buf, format, "Mix", tracks)
+		if err != nil {
+			t.Fatalf("format %d: write error: %v", format, err)
+		}
+		name, entries, err := ReadPlaylistFile(&buf, format)
+		if err != nil {
+			t.Fatalf("format %d: read error: %v", format, err)
+		}
+		if name != "Mix" {
+			t.Errorf("format %d: got name %q", format, name)
+		}
+		if len(entries)+skipped != len(tracks) {
+			t.Errorf("format %d: got %d entries and %d skipped for %d tracks", format, len(entries), skipped, len(tracks))
+			continue
+		}
+		for i, e := range entries {
+			tr := tracks[i]
+			want := PlaylistFileEntry{
+				Path:     tr.FilePath,
+				Title:    tr.Name,
+				Artist:   strings.Join(tr.ArtistNames, ", "),
+				Album:    tr.Album,
+				Duration: tr.Duration,
+			}
+			if e != want {
+				t.Errorf("format %d: got entry %+v, want %+v", format, e, want)
+			}
+		}
+	}
+}
+
+func Test_WriteM3U8SkipsTracksWithoutPath(t *testing.T) {
+	var buf bytes.Buffer
+	skipped, err := WritePlaylistFile(&buf, PlaylistFormatM3U8, "", []*mediaprovider.Track{{Name: "No Path"}})
+	if err != nil || skipped != 1 {
+		t.Errorf("got skipped %d, err %v", skipped, err)
+	}
+	if strings.Contains(buf.String(), "No Path") {
+		t.Errorf("track without path was written: %q", buf.String())
+	}
+}
+
+func Test_MatchTrackByPath(t *testing.T) {
+	tr := &mediaprovider.Track{FilePath: "Artist/Album/01 Song.flac"}
+	index := map[string][]*mediaprovider.Track{"01 song.flac": {tr}}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/home/me/Music/Artist/Album/01 Song.flac", true},
+		{"C:\\Music\\artist\\album\\01 song.flac", true},
+		{"Album/01 Song.flac", true},
+		{"Other/01 Song.flac", false},
+		{"Artist/Album/1 Song.flac", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := matchTrackByPath(index, tt.path) != nil; got != tt.want {
+			t.Errorf("matchTrackByPath(%q): got %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const (
+	PlaylistSortName         = "Name"
+	PlaylistSortOwner        = "Owner"
+	PlaylistSortTrackCount   = "Track count"
+	PlaylistSortDuration     = "Duration"
+	PlaylistSortLastModified = "Last modified"
+)
+
+var PlaylistSortOrders = []string{
+	PlaylistSortName,
+	PlaylistSortOwner,
+	PlaylistSortTrackCount,
+	PlaylistSortDuration,
+	PlaylistSortLastModified,
+}
+
+// SortPlaylists sorts the playlists in place by the given sort order.
+// Counts, durations and modified times are sorted largest/most recent first.
+func SortPlaylists(playlists []*mediaprovider.Playlist, sortOrder string) {
+	var less func(a, b *mediaprovider.Playlist) bool
+	switch sortOrder {
+	case PlaylistSortOwner:
+		less = func(a, b *mediaprovider.Playlist) bool { return strings.ToLower(a.Owner) < strings.ToLower(b.Owner) }
+	case PlaylistSortTrackCount:
+		less = func(a, b *mediaprovider.Playlist) bool { return a.TrackCount > b.TrackCount }
+	case PlaylistSortDuration:
+		less = func(a, b *mediaprovider.Playlist) bool { return a.Duration > b.Duration }
+	case PlaylistSortLastModified:
+		less = func(a, b *mediaprovider.Playlist) bool { return a.Changed.After(b.Changed) }
+	default:
+		less = func(a, b *mediaprovider.Playlist) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	}
+	sort.SliceStable(playlists, func(i, j int) bool { return less(playlists[i], playlists[j]) })
+}
+
+// FolderOf returns the folder of the playlist, or "" if it is not in a folder.
+func (p *PlaylistOrganizationConfig) FolderOf(playlistID string) string {
+	return p.Folders[playlistID]
+}
+
+// SetFolder moves the playlist to the given folder,
+// or out of any folder if folder is "".
+func (p *PlaylistOrganizationConfig) SetFolder(playlistID, folder string) {
+	folder = strings.TrimSpace(folder)
+	if folder == "" {
+		delete(p.Folders, playlistID)
+		return
+	}
+	if p.Folders == nil {
+		p.Folders = make(map[string]string)
+	}
+	p.Folders[playlistID] = folder
+}
+
+// FolderNames returns the sorted names of all folders containing playlists.
+func (p *PlaylistOrganizationConfig) FolderNames() []string {
+	var names []string
+	for _, f := range p.Folders {
+		if !slices.Contains(names, f) {
+			names = append(names, f)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (p *PlaylistOrganizationConfig) IsPinned(playlistID string) bool {
+	return slices.ContainsFunc(p.Pinned, func(pin PinnedPlaylist) bool { return pin.ID == playlistID })
+}
+
+// SetPinned pins the playlist to (or unpins it from) the navigation sidebar.
+func (p *PlaylistOrganizationConfig) SetPinned(playlist *mediaprovider.Playlist, pinned bool) {
+	p.Pinned = slices.DeleteFunc(p.Pinned, func(pin PinnedPlaylist) bool { return pin.ID == playlist.ID })
+	if pinned {
+		p.Pinned = append(p.Pinned, PinnedPlaylist{ID: playlist.ID, Name: playlist.Name})
+	}
+}
+
+// Update drops folder assignments and pins of playlists that no longer exist,
+// and updates the names of pinned playlists. Returns true if anything changed.
+func (p *PlaylistOrganizationConfig) Update(playlists []*mediaprovider.Playlist) bool {
+	names := make(map[string]string, len(playlists))
+	for _, pl := range playlists {
+		names[pl.ID] = pl.Name
+	}
+	changed := false
+	for id := range p.Folders {
+		if _, ok := names[id]; !ok {
+			delete(p.Folders, id)
+			changed = true
+		}
+	}
+	pinned := p.Pinned[:0]
+	for _, pin := range p.Pinned {
+		name, ok := names[pin.ID]
+		if !ok {
+			changed = true
+			continue
+		}
+		if name != pin.Name {
+			pin.Name = name
+			changed = true
+		}
+		pinned = append(pinned, pin)
+	}
+	p.Pinned = pinned
+	return changed
+}
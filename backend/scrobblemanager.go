@@ -0,0 +1,303 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/scrobbler"
+	"github.com/dweymouth/supersonic/res"
+)
+
+const (
+	scrobbleQueueFile = "scrobble_queue.json"
+
+	// credential store entries for the scrobbling service credentials
+	lastFMSessionKeyringUser     = "lastfm-session"
+	lastFMAPISecretKeyringUser   = "lastfm-api-secret"
+	listenBrainzTokenKeyringUser = "listenbrainz-token"
+
+	scrobbleRetryInterval = 5 * time.Minute
+)
+
+// the keys of all the scrobbling credentials in the credential store
+var scrobbleCredentialKeys = []string{
+	lastFMSessionKeyringUser, lastFMAPISecretKeyringUser, listenBrainzTokenKeyringUser,
+}
+
+// ScrobbleManager submits plays directly to Last.fm and ListenBrainz,
+// independently of the media server. Listens that can't be submitted
+// are kept in a queue on disk and retried periodically.
+type ScrobbleManager struct {
+	credentials CredentialStore
+	cfg         *ScrobbleConfig
+	queue       *scrobbler.Queue
+
+	mutex        sync.Mutex
+	lastFM       *scrobbler.LastFM
+	listenBrainz *scrobbler.ListenBrainz
+
+	// the API secret entered for a Last.fm authorization in progress
+	pendingLastFMSecret string
+
+	flushMutex sync.Mutex
+}
+
+func NewScrobbleManager(ctx context.Context, credentials CredentialStore, cfg *ScrobbleConfig, queueFile string) *ScrobbleManager {
+	s := &ScrobbleManager{
+		credentials: credentials,
+		cfg:         cfg,
+		queue:       scrobbler.NewQueue(queueFile),
+	}
+	s.LoadCredentials()
+	go s.retryLoop(ctx)
+	return s
+}
+
+// LoadCredentials reads the credentials of the enabled services from the
+// credential store. It must be called again if the store was locked.
+func (s *ScrobbleManager) LoadCredentials() {
+	if s.cfg.LastFM.Enabled {
+		sk, err := s.credentials.Get(lastFMSessionKeyringUser)
+		if err != nil {
+			log.Printf("error reading Last.fm session key: %v", err)
+		}
+		secret, err2 := s.credentials.Get(lastFMAPISecretKeyringUser)
+		if err2 != nil {
+			log.Printf("error reading Last.fm API secret: %v", err2)
+		}
+		if err == nil && err2 == nil {
+			s.mutex.Lock()
+			s.lastFM = s.newLastFM(secret, sk)
+			s.mutex.Unlock()
+		}
+	}
+	if s.cfg.ListenBrainz.Enabled {
+		if token, err := s.credentials.Get(listenBrainzTokenKeyringUser); err == nil {
+			s.mutex.Lock()
+			s.listenBrainz = s.newListenBrainz(token)
+			s.mutex.Unlock()
+		} else {
+			log.Printf("error reading ListenBrainz token: %v", err)
+		}
+	}
+}
+
+// NowPlaying sends a "now playing" update to each enabled service.
+func (s *ScrobbleManager) NowPlaying(track *mediaprovider.Track) {
+	listen := listenFromTrack(track, time.Now())
+	for _, sc := range s.scrobblers() {
+		go func(sc scrobbler.Scrobbler) {
+			if err := sc.NowPlaying(listen); err != nil {
+				log.Printf("error sending now playing: %v", err)
+			}
+		}(sc)
+	}
+}
+
+// Scrobble submits a play of the track, which began at the given
+// time, to each enabled service, or queues it to be retried later.
+func (s *ScrobbleManager) Scrobble(track *mediaprovider.Track, startedAt time.Time) {
+	scrobblers := s.scrobblers()
+	if len(scrobblers) == 0 {
+		return
+	}
+	listen := listenFromTrack(track, startedAt)
+	for _, sc := range scrobblers {
+		s.queue.Add(sc.Name(), listen)
+	}
+	go s.flush()
+}
+
+// Shutdown saves any unsaved changes to the queue of listens.
+func (s *ScrobbleManager) Shutdown() {
+	s.queue.Close()
+}
+
+// QueuedCount returns the number of listens waiting to be submitted to the service.
+func (s *ScrobbleManager) QueuedCount(service string) int {
+	return s.queue.Len(service)
+}
+
+// LastFMAPISecret returns the saved Last.fm API secret, if any.
+func (s *ScrobbleManager) LastFMAPISecret() string {
+	secret, _ := s.credentials.Get(lastFMAPISecretKeyringUser)
+	return secret
+}
+
+// BeginLastFMAuth requests a token from Last.fm with the given API account
+// and returns the URL at which the user must authorize it in the browser.
+func (s *ScrobbleManager) BeginLastFMAuth(apiKey, apiSecret string) (token, authURL string, err error) {
+	s.cfg.LastFM.APIKey = apiKey
+	s.mutex.Lock()
+	s.pendingLastFMSecret = apiSecret
+	s.mutex.Unlock()
+	lfm := s.newLastFM(apiSecret, "")
+	token, err = lfm.GetToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, lfm.UserAuthURL(token), nil
+}
+
+// CompleteLastFMAuth obtains a session for the token the user has
+// authorized, and enables scrobbling to Last.fm.
+func (s *ScrobbleManager) CompleteLastFMAuth(token string) error {
+	s.mutex.Lock()
+	secret := s.pendingLastFMSecret
+	s.mutex.Unlock()
+	lfm := s.newLastFM(secret, "")
+	sk, username, err := lfm.GetSession(token)
+	if err != nil {
+		return err
+	}
+	if err := s.credentials.Set(lastFMAPISecretKeyringUser, secret); err != nil {
+		return err
+	}
+	if err := s.credentials.Set(lastFMSessionKeyringUser, sk); err != nil {
+		return err
+	}
+	lfm.SessionKey = sk
+	s.mutex.Lock()
+	s.lastFM = lfm
+	s.pendingLastFMSecret = ""
+	s.mutex.Unlock()
+	s.cfg.LastFM.Enabled = true
+	s.cfg.LastFM.Username = username
+	go s.flush()
+	return nil
+}
+
+// DisconnectLastFM disables scrobbling to Last.fm and forgets the session.
+func (s *ScrobbleManager) DisconnectLastFM() {
+	s.mutex.Lock()
+	s.lastFM = nil
+	s.mutex.Unlock()
+	s.cfg.LastFM.Enabled = false
+	s.cfg.LastFM.Username = ""
+	s.credentials.Delete(lastFMSessionKeyringUser)
+}
+
+// ConnectListenBrainz validates the user token and
+// enables scrobbling to ListenBrainz.
+func (s *ScrobbleManager) ConnectListenBrainz(token string) error {
+	lb := s.newListenBrainz(strings.TrimSpace(token))
+	username, err := lb.ValidateToken()
+	if err != nil {
+		return err
+	}
+	if err := s.credentials.Set(listenBrainzTokenKeyringUser, lb.Token); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.listenBrainz = lb
+	s.mutex.Unlock()
+	s.cfg.ListenBrainz.Enabled = true
+	s.cfg.ListenBrainz.Username = username
+	go s.flush()
+	return nil
+}
+
+// DisconnectListenBrainz disables scrobbling to ListenBrainz and forgets the token.
+func (s *ScrobbleManager) DisconnectListenBrainz() {
+	s.mutex.Lock()
+	s.listenBrainz = nil
+	s.mutex.Unlock()
+	s.cfg.ListenBrainz.Enabled = false
+	s.cfg.ListenBrainz.Username = ""
+	s.credentials.Delete(listenBrainzTokenKeyringUser)
+}
+
+func (s *ScrobbleManager) newLastFM(apiSecret, sessionKey string) *scrobbler.LastFM {
+	return &scrobbler.LastFM{
+		APIURL:     s.cfg.LastFM.APIURL,
+		AuthURL:    s.cfg.LastFM.AuthURL,
+		APIKey:     s.cfg.LastFM.APIKey,
+		APISecret:  apiSecret,
+		SessionKey: sessionKey,
+	}
+}
+
+func (s *ScrobbleManager) newListenBrainz(token string) *scrobbler.ListenBrainz {
+	return &scrobbler.ListenBrainz{
+		APIURL:        s.cfg.ListenBrainz.APIURL,
+		Token:         token,
+		ClientName:    res.DisplayName,
+		ClientVersion: res.AppVersion,
+	}
+}
+
+// returns the services to scrobble to, which is none if scrobbling is disabled
+func (s *ScrobbleManager) scrobblers() []scrobbler.Scrobbler {
+	if !s.cfg.Enabled {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var scrobblers []scrobbler.Scrobbler
+	if s.lastFM != nil && s.cfg.LastFM.Enabled {
+		scrobblers = append(scrobblers, s.lastFM)
+	}
+	if s.listenBrainz != nil && s.cfg.ListenBrainz.Enabled {
+		scrobblers = append(scrobblers, s.listenBrainz)
+	}
+	return scrobblers
+}
+
+func (s *ScrobbleManager) retryLoop(ctx context.Context) {
+	s.flush()
+	t := time.NewTicker(scrobbleRetryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.flush()
+		}
+	}
+}
+
+// flush submits the queued listens of each enabled service, in batches,
+// until the queue is empty or a submission fails.
+func (s *ScrobbleManager) flush() {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+	for _, sc := range s.scrobblers() {
+		for {
+			batch := s.queue.Peek(sc.Name(), sc.MaxBatchSize())
+			if len(batch) == 0 {
+				break
+			}
+			err := sc.Scrobble(batch)
+			if err != nil && !errors.Is(err, scrobbler.ErrRejected) {
+				log.Printf("error scrobbling, will retry later: %v", err)
+				break
+			}
+			if err != nil {
+				log.Printf("dropping rejected scrobbles: %v", err)
+			}
+			s.queue.Remove(sc.Name(), len(batch))
+		}
+	}
+}
+
+func listenFromTrack(track *mediaprovider.Track, t time.Time) scrobbler.Listen {
+	var artist string
+	if len(track.ArtistNames) > 0 {
+		artist = track.ArtistNames[0]
+	}
+	return scrobbler.Listen{
+		Artist:        artist,
+		Title:         track.Name,
+		Album:         track.Album,
+		DurationSecs:  track.Duration,
+		TrackNumber:   track.TrackNumber,
+		MusicBrainzID: track.MusicBrainzID,
+		Time:          t,
+	}
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/scrobbler"
+)
+
+func Test_ScrobbleManagerRetry(t *testing.T) {
+	var requests int
+	response := `{"error":11,"message":"Service Offline"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	cfg := &ScrobbleConfig{Enabled: true, LastFM: LastFMConfig{Enabled: true}}
+	s := &ScrobbleManager{
+		cfg:    cfg,
+		queue:  scrobbler.NewQueue(filepath.Join(t.TempDir(), scrobbleQueueFile)),
+		lastFM: &scrobbler.LastFM{APIURL: srv.URL, APIKey: "key", APISecret: "secret", SessionKey: "sk"},
+	}
+	defer s.Shutdown()
+	track := &mediaprovider.Track{Name: "Song", ArtistNames: []string{"Artist"}}
+	lastFM := s.lastFM.Name()
+
+	// a failed submission keeps the listens queued
+	s.queue.Add(lastFM, listenFromTrack(track, time.Now()))
+	s.queue.Add(lastFM, listenFromTrack(track, time.Now()))
+	s.flush()
+	if requests != 1 || s.QueuedCount(lastFM) != 2 {
+		t.Errorf("after failure: got %d requests, %d queued", requests, s.QueuedCount(lastFM))
+	}
+
+	// a retry submits all queued listens
+	response = `{"scrobbles":{}}`
+	s.flush()
+	if requests != 2 || s.QueuedCount(lastFM) != 0 {
+		t.Errorf("after retry: got %d requests, %d queued", requests, s.QueuedCount(lastFM))
+	}
+
+	// rejected listens are dropped rather than retried
+	response = `{"error":6,"message":"Invalid parameters"}`
+	s.queue.Add(lastFM, listenFromTrack(track, time.Now()))
+	s.flush()
+	if s.QueuedCount(lastFM) != 0 {
+		t.Errorf("rejected listen still queued")
+	}
+
+	// nothing is queued while scrobbling is disabled
+	cfg.Enabled = false
+	s.Scrobble(track, time.Now())
+	if s.QueuedCount(lastFM) != 0 {
+		t.Errorf("listen queued while scrobbling is disabled")
+	}
+}
@@ -0,0 +1,182 @@
+package scrobbler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLastFMAPIURL  = "https://ws.audioscrobbler.com/2.0/"
+	DefaultLastFMAuthURL = "https://www.last.fm/api/auth/"
+
+	lastFMMaxBatchSize = 50
+)
+
+// Last.fm API error codes that mean the submitted data was invalid
+var lastFMRejectedCodes = []int{
+	6, // invalid parameters
+	7, // invalid resource specified
+}
+
+// LastFM is a client for the Last.fm scrobbling API, or a compatible service.
+type LastFM struct {
+	APIURL     string
+	AuthURL    string
+	APIKey     string
+	APISecret  string
+	SessionKey string // obtained from GetSession
+}
+
+var _ Scrobbler = (*LastFM)(nil)
+
+func (l *LastFM) Name() string {
+	return "Last.fm"
+}
+
+func (l *LastFM) MaxBatchSize() int {
+	return lastFMMaxBatchSize
+}
+
+// GetToken requests a token for the user to authorize in the browser,
+// at the URL returned by UserAuthURL.
+func (l *LastFM) GetToken() (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := l.call("auth.getToken", url.Values{}, false, &resp); err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// UserAuthURL returns the URL at which the user authorizes the token.
+func (l *LastFM) UserAuthURL(token string) string {
+	return fmt.Sprintf("%s?api_key=%s&token=%s",
+		l.AuthURL, url.QueryEscape(l.APIKey), url.QueryEscape(token))
+}
+
+// GetSession exchanges an authorized token for a session key,
+// returning the key and the name of the user.
+func (l *LastFM) GetSession(token string) (sessionKey, username string, err error) {
+	var resp struct {
+		Session struct {
+			Name string `json:"name"`
+			Key  string `json:"key"`
+		} `json:"session"`
+	}
+	if err := l.call("auth.getSession", url.Values{"token": {token}}, false, &resp); err != nil {
+		return "", "", err
+	}
+	return resp.Session.Key, resp.Session.Name, nil
+}
+
+func (l *LastFM) NowPlaying(listen Listen) error {
+	params := url.Values{}
+	setLastFMTrackParams(params, listen, "")
+	return l.call("track.updateNowPlaying", params, true, nil)
+}
+
+func (l *LastFM) Scrobble(listens []Listen) error {
+	params := url.Values{}
+	for i, listen := range listens {
+		suffix := "[" + strconv.Itoa(i) + "]"
+		setLastFMTrackParams(params, listen, suffix)
+		params.Set("timestamp"+suffix, strconv.FormatInt(listen.Time.Unix(), 10))
+	}
+	return l.call("track.scrobble", params, true, nil)
+}
+
+func setLastFMTrackParams(params url.Values, listen Listen, suffix string) {
+	params.Set("artist"+suffix, listen.Artist)
+	params.Set("track"+suffix, listen.Title)
+	if listen.Album != "" {
+		params.Set("album"+suffix, listen.Album)
+	}
+	if listen.DurationSecs > 0 {
+		params.Set("duration"+suffix, strconv.Itoa(listen.DurationSecs))
+	}
+	if listen.TrackNumber > 0 {
+		params.Set("trackNumber"+suffix, strconv.Itoa(listen.TrackNumber))
+	}
+	if listen.MusicBrainzID != "" {
+		params.Set("mbid"+suffix, listen.MusicBrainzID)
+	}
+}
+
+// call makes a signed POST request to the API method,
+// decoding the JSON response into result if non-nil.
+func (l *LastFM) call(method string, params url.Values, withSession bool, result any) error {
+	if l.APIKey == "" || l.APISecret == "" {
+		return errors.New("Last.fm: API key and secret are not configured")
+	}
+	params.Set("method", method)
+	params.Set("api_key", l.APIKey)
+	if withSession {
+		if l.SessionKey == "" {
+			return errors.New("Last.fm: not authorized")
+		}
+		params.Set("sk", l.SessionKey)
+	}
+	params.Set("api_sig", l.signature(params))
+	params.Set("format", "json")
+
+	resp, err := httpClient.PostForm(l.APIURL, params)
+	if err != nil {
+		return fmt.Errorf("Last.fm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	var raw json.RawMessage
+	decodeErr := json.NewDecoder(resp.Body).Decode(&raw)
+	if decodeErr == nil {
+		_ = json.Unmarshal(raw, &body)
+	}
+	if body.Error != 0 {
+		for _, code := range lastFMRejectedCodes {
+			if body.Error == code {
+				return fmt.Errorf("Last.fm: %s (error %d): %w", body.Message, body.Error, ErrRejected)
+			}
+		}
+		return fmt.Errorf("Last.fm: %s (error %d)", body.Message, body.Error)
+	}
+	if resp.StatusCode != 200 {
+		return statusError("Last.fm", resp)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("Last.fm: invalid response: %w", decodeErr)
+	}
+	if result != nil {
+		return json.Unmarshal(raw, result)
+	}
+	return nil
+}
+
+// signature computes the api_sig parameter: the MD5 hash of all
+// parameters concatenated as name and value in order of name,
+// followed by the API secret.
+func (l *LastFM) signature(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString(params.Get(k))
+	}
+	sb.WriteString(l.APISecret)
+	sum := md5.Sum([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
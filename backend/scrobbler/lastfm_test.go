@@ -0,0 +1,93 @@
+package scrobbler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_LastFMSignature(t *testing.T) {
+	l := &LastFM{APISecret: "secret"}
+	params := url.Values{
+		"method":  {"auth.getSession"},
+		"api_key": {"key"},
+		"token":   {"tok"},
+		"artist":  {"Björk"},
+	}
+	sum := md5.Sum([]byte("api_keykeyartistBjörkmethodauth.getSessiontokentoksecret"))
+	if got, want := l.signature(params), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("got signature %s, want %s", got, want)
+	}
+}
+
+func Test_LastFMScrobbleRequest(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`{"scrobbles":{}}`))
+	}))
+	defer srv.Close()
+
+	l := &LastFM{APIURL: srv.URL, APIKey: "key", APISecret: "secret", SessionKey: "sk"}
+	listens := []Listen{
+		{Artist: "A", Title: "One", Time: time.Unix(1000, 0)},
+		{Artist: "B", Title: "Two", Album: "Album", DurationSecs: 180, Time: time.Unix(2000, 0)},
+	}
+	if err := l.Scrobble(listens); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"method": "track.scrobble", "sk": "sk", "format": "json",
+		"artist[0]": "A", "timestamp[0]": "1000", "album[1]": "Album", "duration[1]": "180", "timestamp[1]": "2000",
+	} {
+		if got := form.Get(key); got != want {
+			t.Errorf("got %s=%q, want %q", key, got, want)
+		}
+	}
+	// the signature covers all parameters except format and api_sig itself
+	signed := url.Values{}
+	for k, v := range form {
+		if k != "format" && k != "api_sig" {
+			signed[k] = v
+		}
+	}
+	if got, want := form.Get("api_sig"), l.signature(signed); got != want {
+		t.Errorf("got api_sig %s, want %s", got, want)
+	}
+}
+
+func Test_LastFMErrors(t *testing.T) {
+	tests := []struct {
+		status       int
+		body         string
+		wantRejected bool
+	}{
+		{200, `{"error":6,"message":"Invalid parameters"}`, true},
+		{200, `{"error":9,"message":"Invalid session key"}`, false},
+		{400, `{}`, true},
+		{429, `{}`, false},
+		{503, `not json`, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		l := &LastFM{APIURL: srv.URL, APIKey: "key", APISecret: "secret", SessionKey: "sk"}
+		err := l.Scrobble([]Listen{{Artist: "A", Title: "One"}})
+		srv.Close()
+		if err == nil {
+			t.Errorf("%d %s: expected an error", tt.status, tt.body)
+			continue
+		}
+		if got := errors.Is(err, ErrRejected); got != tt.wantRejected {
+			t.Errorf("%d %s: got rejected %v, want %v", tt.status, tt.body, got, tt.wantRejected)
+		}
+	}
+}
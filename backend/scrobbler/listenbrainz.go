@@ -0,0 +1,149 @@
+package scrobbler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultListenBrainzAPIURL = "https://api.listenbrainz.org"
+
+	listenBrainzMaxBatchSize = 100
+)
+
+// ListenBrainz is a client for the ListenBrainz API, or a compatible service.
+type ListenBrainz struct {
+	APIURL string
+	Token  string // the user token from the ListenBrainz profile page
+
+	ClientName    string
+	ClientVersion string
+}
+
+var _ Scrobbler = (*ListenBrainz)(nil)
+
+func (l *ListenBrainz) Name() string {
+	return "ListenBrainz"
+}
+
+func (l *ListenBrainz) MaxBatchSize() int {
+	return listenBrainzMaxBatchSize
+}
+
+// ValidateToken checks the token and returns the name of its user.
+func (l *ListenBrainz) ValidateToken() (string, error) {
+	req, err := l.newRequest(http.MethodGet, "/1/validate-token", nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Valid    bool   `json:"valid"`
+		UserName string `json:"user_name"`
+	}
+	if err := l.do(req, &result); err != nil {
+		return "", err
+	}
+	if !result.Valid {
+		return "", errors.New("ListenBrainz: invalid user token")
+	}
+	return result.UserName, nil
+}
+
+func (l *ListenBrainz) NowPlaying(listen Listen) error {
+	return l.submit("playing_now", []Listen{listen})
+}
+
+func (l *ListenBrainz) Scrobble(listens []Listen) error {
+	listenType := "single"
+	if len(listens) > 1 {
+		listenType = "import"
+	}
+	return l.submit(listenType, listens)
+}
+
+type listenBrainzPayload struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo map[string]any `json:"additional_info,omitempty"`
+}
+
+func (l *ListenBrainz) submit(listenType string, listens []Listen) error {
+	payload := make([]listenBrainzPayload, len(listens))
+	for i, listen := range listens {
+		info := map[string]any{
+			"submission_client":         l.ClientName,
+			"submission_client_version": l.ClientVersion,
+		}
+		if listen.DurationSecs > 0 {
+			info["duration_ms"] = listen.DurationSecs * 1000
+		}
+		if listen.TrackNumber > 0 {
+			info["tracknumber"] = listen.TrackNumber
+		}
+		if listen.MusicBrainzID != "" {
+			info["recording_mbid"] = listen.MusicBrainzID
+		}
+		payload[i].TrackMetadata = listenBrainzTrackMetadata{
+			ArtistName:     listen.Artist,
+			TrackName:      listen.Title,
+			ReleaseName:    listen.Album,
+			AdditionalInfo: info,
+		}
+		if listenType != "playing_now" {
+			payload[i].ListenedAt = listen.Time.Unix()
+		}
+	}
+	body, err := json.Marshal(map[string]any{
+		"listen_type": listenType,
+		"payload":     payload,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := l.newRequest(http.MethodPost, "/1/submit-listens", body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return l.do(req, nil)
+}
+
+func (l *ListenBrainz) newRequest(method, path string, body []byte) (*http.Request, error) {
+	if l.Token == "" {
+		return nil, errors.New("ListenBrainz: no user token")
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(l.APIURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+l.Token)
+	return req, nil
+}
+
+// do sends the request, decoding the JSON response into result if non-nil.
+func (l *ListenBrainz) do(req *http.Request, result any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ListenBrainz: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return statusError("ListenBrainz", resp)
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("ListenBrainz: invalid response: %w", err)
+		}
+	}
+	return nil
+}
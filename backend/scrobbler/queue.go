@@ -0,0 +1,163 @@
+package scrobbler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// the maximum number of listens kept per service; the oldest are
+// dropped first, since services may not accept very old scrobbles anyway
+const maxQueuedPerService = 5000
+
+// Queue holds the listens not yet submitted to each service,
+// persisted to a file so they can be retried after a restart.
+// Changes are saved from a background goroutine, so that adding
+// a listen doesn't block on file I/O.
+type Queue struct {
+	filepath string
+
+	mutex   sync.Mutex
+	pending map[string][]Listen // by service name
+	dirty   bool
+
+	saveMutex  sync.Mutex // serializes writes to the file
+	saveSignal chan struct{}
+	closed     chan struct{}
+	loopDone   chan struct{}
+}
+
+// NewQueue creates a queue persisted to the given file,
+// loading any listens that were queued when it was last saved.
+func NewQueue(filepath string) *Queue {
+	q := &Queue{
+		filepath:   filepath,
+		pending:    make(map[string][]Listen),
+		saveSignal: make(chan struct{}, 1),
+		closed:     make(chan struct{}),
+		loopDone:   make(chan struct{}),
+	}
+	go q.saveLoop()
+	b, err := os.ReadFile(filepath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("error reading scrobble queue: %v", err)
+		}
+		return q
+	}
+	if err := json.Unmarshal(b, &q.pending); err != nil {
+		log.Printf("error reading scrobble queue: %v", err)
+	}
+	if q.pending == nil {
+		q.pending = make(map[string][]Listen)
+	}
+	return q
+}
+
+// Add appends the listen to the queue of the given service.
+func (q *Queue) Add(service string, listen Listen) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	l := append(q.pending[service], listen)
+	if len(l) > maxQueuedPerService {
+		l = l[len(l)-maxQueuedPerService:]
+	}
+	q.pending[service] = l
+	q.markDirty()
+}
+
+// Peek returns up to n of the oldest listens queued for the service.
+func (q *Queue) Peek(service string, n int) []Listen {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	l := q.pending[service]
+	if len(l) > n {
+		l = l[:n]
+	}
+	return append([]Listen(nil), l...)
+}
+
+// Remove removes the n oldest listens queued for the service.
+func (q *Queue) Remove(service string, n int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	l := q.pending[service]
+	n = min(n, len(l))
+	if n == 0 {
+		return
+	}
+	if len(l) == n {
+		delete(q.pending, service)
+	} else {
+		q.pending[service] = l[n:]
+	}
+	q.markDirty()
+}
+
+// Len returns the number of listens queued for the service.
+func (q *Queue) Len(service string) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.pending[service])
+}
+
+// Save writes the queue to its file if it has changed since it was last saved.
+func (q *Queue) Save() {
+	q.saveMutex.Lock()
+	defer q.saveMutex.Unlock()
+	q.mutex.Lock()
+	if !q.dirty {
+		q.mutex.Unlock()
+		return
+	}
+	b, err := json.Marshal(q.pending)
+	q.dirty = false
+	q.mutex.Unlock()
+
+	if err == nil {
+		os.MkdirAll(filepath.Dir(q.filepath), 0755)
+		err = os.WriteFile(q.filepath, b, 0644)
+	}
+	if err != nil {
+		log.Printf("error saving scrobble queue: %v", err)
+	}
+}
+
+// must be called with the mutex held
+func (q *Queue) markDirty() {
+	q.dirty = true
+	select {
+	case q.saveSignal <- struct{}{}:
+	default:
+		// a save is already pending
+	}
+}
+
+// Close stops saving changes in the background and saves any pending
+// changes. It should be called before exiting. Changes made to the
+// queue after it is closed are no longer saved.
+func (q *Queue) Close() {
+	select {
+	case <-q.closed:
+		return // already closed
+	default:
+	}
+	close(q.closed)
+	<-q.loopDone
+	q.Save()
+}
+
+func (q *Queue) saveLoop() {
+	defer close(q.loopDone)
+	for {
+		select {
+		case <-q.closed:
+			return
+		case <-q.saveSignal:
+			q.Save()
+		}
+	}
+}
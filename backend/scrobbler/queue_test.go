@@ -0,0 +1,48 @@
+package scrobbler
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func Test_Queue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := NewQueue(path)
+	for _, title := range []string{"a", "b", "c"} {
+		q.Add("svc", Listen{Title: title})
+	}
+	q.Add("other", Listen{Title: "x"})
+
+	if got := q.Peek("svc", 2); len(got) != 2 || got[0].Title != "a" || got[1].Title != "b" {
+		t.Errorf("got %+v from Peek", got)
+	}
+	q.Remove("svc", 2)
+	if got := q.Peek("svc", 10); len(got) != 1 || got[0].Title != "c" {
+		t.Errorf("got %+v after Remove", got)
+	}
+	q.Remove("other", 5)
+	if q.Len("other") != 0 {
+		t.Error("queue of other service not emptied")
+	}
+
+	q.Close()
+	loaded := NewQueue(path)
+	defer loaded.Close()
+	if got := loaded.Peek("svc", 10); len(got) != 1 || got[0].Title != "c" {
+		t.Errorf("got %+v from reloaded queue", got)
+	}
+}
+
+func Test_QueueDropsOldest(t *testing.T) {
+	q := NewQueue(filepath.Join(t.TempDir(), "queue.json"))
+	defer q.Close()
+	for i := 0; i < maxQueuedPerService+10; i++ {
+		q.Add("svc", Listen{TrackNumber: i})
+	}
+	if q.Len("svc") != maxQueuedPerService {
+		t.Errorf("got %d queued, want %d", q.Len("svc"), maxQueuedPerService)
+	}
+	if got := q.Peek("svc", 1)[0].TrackNumber; got != 10 {
+		t.Errorf("got oldest listen %d, want 10", got)
+	}
+}
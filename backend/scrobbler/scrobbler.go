@@ -0,0 +1,57 @@
+// Package scrobbler implements clients for submitting listens
+// directly to scrobbling services such as Last.fm and ListenBrainz.
+package scrobbler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrRejected is wrapped by errors returned when the service refused
+// the submitted data itself, so that retrying the submission is pointless.
+var ErrRejected = errors.New("rejected by service")
+
+// Listen is the metadata of one play of a track.
+type Listen struct {
+	Artist        string
+	Title         string
+	Album         string
+	DurationSecs  int
+	TrackNumber   int
+	MusicBrainzID string    // recording MBID, if known
+	Time          time.Time // when the track began playing
+}
+
+// Scrobbler is a client for a scrobbling service.
+type Scrobbler interface {
+	// Name is the name of the service, which also identifies
+	// the scrobbler's listens in the retry queue.
+	Name() string
+
+	// NowPlaying informs the service that the track has begun playing.
+	NowPlaying(listen Listen) error
+
+	// Scrobble submits the listens, in order of time.
+	// At most MaxBatchSize listens may be submitted at once.
+	Scrobble(listens []Listen) error
+
+	// MaxBatchSize is the maximum number of listens
+	// that may be submitted in one call to Scrobble.
+	MaxBatchSize() int
+}
+
+var httpClient = &http.Client{Timeout: 20 * time.Second}
+
+// statusError returns an error for an unsuccessful HTTP response status.
+// Client errors other than authorization failures and rate limiting
+// mean the request was invalid, and wrap ErrRejected.
+func statusError(service string, resp *http.Response) error {
+	code := resp.StatusCode
+	if code >= 400 && code < 500 && code != http.StatusUnauthorized &&
+		code != http.StatusForbidden && code != http.StatusTooManyRequests {
+		return fmt.Errorf("%s: %s: %w", service, resp.Status, ErrRejected)
+	}
+	return fmt.Errorf("%s: %s", service, resp.Status)
+}
@@ -5,16 +5,19 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
 	"github.com/dweymouth/go-subsonic/subsonic"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/aggregate"
 	jellyfinMP "github.com/dweymouth/supersonic/backend/mediaprovider/jellyfin"
 	subsonicMP "github.com/dweymouth/supersonic/backend/mediaprovider/subsonic"
 	"github.com/dweymouth/supersonic/res"
+	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/google/uuid"
-	"github.com/zalando/go-keyring"
 )
 
 type ServerManager struct {
@@ -22,17 +25,33 @@ type ServerManager struct {
 	ServerID     uuid.UUID
 	Server       mediaprovider.MediaProvider
 
+	capabilities      *mediaprovider.Capabilities
+	admin             mediaprovider.AdminProvider
+	server            mediaprovider.Server
+	monitor           *connectionMonitor
+	streamProxy       *streamProxy
+	secondaries       []*secondaryConnection
 	prefetchCoverCB   func(string)
-	appName           string
+	credentials       CredentialStore
 	config            *Config
 	onServerConnected []func()
 	onLogout          []func()
+	onHostnameChanged []func(serverID string)
+}
+
+// secondaryConnection is a server connected alongside the
+// current server, with its library merged with the current server's.
+type secondaryConnection struct {
+	conf        *ServerConfig
+	provider    mediaprovider.MediaProvider
+	monitor     *connectionMonitor
+	streamProxy *streamProxy
 }
 
 var ErrUnreachable = errors.New("server is unreachable")
 
-func NewServerManager(appName string, config *Config) *ServerManager {
-	return &ServerManager{appName: appName, config: config}
+func NewServerManager(config *Config, credentials CredentialStore) *ServerManager {
+	return &ServerManager{config: config, credentials: credentials}
 }
 
 func (s *ServerManager) SetPrefetchAlbumCoverCallback(cb func(string)) {
@@ -43,11 +62,22 @@ func (s *ServerManager) SetPrefetchAlbumCoverCallback(cb func(string)) {
 }
 
 func (s *ServerManager) ConnectToServer(conf *ServerConfig, password string) error {
-	cli, err := s.connect(conf.ServerConnection, password)
+	cli, monitor, err := s.connect(conf.ServerConnection, password)
 	if err != nil {
 		return err
 	}
+	monitor.setOnHostnameChanged(func() { s.hostnameChanged("") })
+	s.server = cli
+	s.monitor = monitor
+	s.streamProxy = s.startStreamProxy(conf, monitor)
 	s.Server = cli.MediaProvider()
+	s.capabilities = fetchCapabilities(s.Server)
+	if ap, ok := s.Server.(mediaprovider.AdminProvider); ok && s.capabilities.Admin {
+		s.admin = ap
+	}
+	if members := s.connectSecondaries(conf.ID); len(members) > 0 {
+		s.Server = aggregate.NewAggregateMediaProvider(s.Server, members)
+	}
 	s.Server.SetPrefetchCoverCallback(s.prefetchCoverCB)
 	s.LoggedInUser = conf.Username
 	s.ServerID = conf.ID
@@ -58,13 +88,166 @@ func (s *ServerManager) ConnectToServer(conf *ServerConfig, password string) err
 	return nil
 }
 
+// connectSecondaries connects to the servers whose libraries are merged
+// with the primary server's, returning the members of the aggregate
+// media provider for those that could be connected to.
+func (s *ServerManager) connectSecondaries(primaryID uuid.UUID) []aggregate.Member {
+	var confs []*ServerConfig
+	for _, conf := range s.config.Servers {
+		if conf.MergeLibrary && conf.ID != primaryID {
+			confs = append(confs, conf)
+		}
+	}
+	conns := make([]*secondaryConnection, len(confs))
+	var wg sync.WaitGroup
+	for i, conf := range confs {
+		wg.Add(1)
+		go func(i int, conf *ServerConfig) {
+			defer wg.Done()
+			password, err := s.GetServerPassword(conf.ID)
+			if err != nil {
+				log.Printf("error reading password of server %q: %v", conf.Nickname, err)
+				return
+			}
+			cli, monitor, err := s.connect(conf.ServerConnection, password)
+			if err != nil {
+				log.Printf("error connecting to server %q: %v", conf.Nickname, err)
+				return
+			}
+			serverID := conf.ID.String()
+			monitor.setOnHostnameChanged(func() { s.hostnameChanged(serverID) })
+			conns[i] = &secondaryConnection{
+				conf:        conf,
+				provider:    cli.MediaProvider(),
+				monitor:     monitor,
+				streamProxy: s.startStreamProxy(conf, monitor),
+			}
+		}(i, conf)
+	}
+	wg.Wait()
+
+	var members []aggregate.Member
+	for _, c := range conns {
+		if c != nil {
+			s.secondaries = append(s.secondaries, c)
+			members = append(members, aggregate.Member{ServerID: c.conf.ID.String(), Provider: c.provider})
+		}
+	}
+	return members
+}
+
+// startStreamProxy starts the stream proxy for the server if needed.
+func (s *ServerManager) startStreamProxy(conf *ServerConfig, monitor *connectionMonitor) *streamProxy {
+	if conf.HTTP.IsZero() {
+		return nil
+	}
+	// mpv can't apply the HTTP options itself,
+	// so it streams through a local proxy
+	proxy, err := newStreamProxy(monitor)
+	if err != nil {
+		log.Printf("error starting stream proxy: %v", err)
+	}
+	return proxy
+}
+
+func (s *ServerManager) hostnameChanged(serverID string) {
+	for _, cb := range s.onHostnameChanged {
+		cb(serverID)
+	}
+}
+
+// fetchCapabilities queries the server for its capabilities, falling back
+// to those determined by the interfaces the media provider implements.
+func fetchCapabilities(mp mediaprovider.MediaProvider) *mediaprovider.Capabilities {
+	if cp, ok := mp.(mediaprovider.CapabilityProvider); ok {
+		caps, err := cp.GetCapabilities()
+		if err == nil {
+			return caps
+		}
+		log.Printf("error fetching server capabilities: %v", err)
+	}
+	return mediaprovider.DefaultCapabilities(mp)
+}
+
+// Capabilities returns the capabilities of the connected server, which are
+// fetched when connecting. If merging libraries, these are the capabilities
+// of the primary server. If not connected, no features are supported.
+func (s *ServerManager) Capabilities() *mediaprovider.Capabilities {
+	if s.capabilities == nil {
+		return &mediaprovider.Capabilities{}
+	}
+	return s.capabilities
+}
+
+// Admin returns the administration interface of the connected server,
+// or nil if it is unsupported or the logged-in user is not an admin.
+// If merging libraries, this administers the primary server only.
+func (s *ServerManager) Admin() mediaprovider.AdminProvider {
+	return s.admin
+}
+
+// SecondaryServers returns the configs of the servers connected
+// alongside the current server, whose libraries are merged with it.
+func (s *ServerManager) SecondaryServers() []*ServerConfig {
+	return sharedutil.MapSlice(s.secondaries, func(c *secondaryConnection) *ServerConfig { return c.conf })
+}
+
+// ItemServer returns the config of the server an item
+// (track, album, etc.) with the given ID came from.
+func (s *ServerManager) ItemServer(itemID string) *ServerConfig {
+	if serverID, _ := aggregate.SplitID(itemID); serverID != "" {
+		for _, c := range s.secondaries {
+			if c.conf.ID.String() == serverID {
+				return c.conf
+			}
+		}
+	}
+	return s.CurrentServerConfig()
+}
+
+// OpenSecondaryProvider connects to the given server without changing
+// the currently connected server, returning a media provider for it.
+// If the given server is already connected, its provider is returned.
+func (s *ServerManager) OpenSecondaryProvider(conf *ServerConfig) (mediaprovider.MediaProvider, error) {
+	if s.Server != nil && conf.ID == s.ServerID {
+		if len(s.secondaries) > 0 {
+			// not the merged library
+			return s.newUnindexedProvider(), nil
+		}
+		return s.Server, nil
+	}
+	for _, c := range s.secondaries {
+		if c.conf.ID == conf.ID {
+			return c.provider, nil
+		}
+	}
+	password, err := s.GetServerPassword(conf.ID)
+	if err != nil {
+		return nil, err
+	}
+	cli, _, err := s.connect(conf.ServerConnection, password)
+	if err != nil {
+		return nil, err
+	}
+	return cli.MediaProvider(), nil
+}
+
+// newUnindexedProvider returns a new media provider for the connected server,
+// which always makes requests to the server rather than the library index.
+func (s *ServerManager) newUnindexedProvider() mediaprovider.MediaProvider {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.MediaProvider()
+}
+
 func (s *ServerManager) TestConnectionAndAuth(
 	ctx context.Context, connection ServerConnection, password string,
 ) error {
 	err := ErrUnreachable
 	done := make(chan bool)
 	go func() {
-		_, err = s.connect(connection, password)
+		_, _, err = s.connect(connection, password)
 		close(done)
 	}()
 	select {
@@ -87,6 +270,19 @@ func (s *ServerManager) GetDefaultServer() *ServerConfig {
 	return nil
 }
 
+// CurrentServerConfig returns the config of the connected server, or nil if not connected.
+func (s *ServerManager) CurrentServerConfig() *ServerConfig {
+	if s.Server == nil {
+		return nil
+	}
+	for _, conf := range s.config.Servers {
+		if conf.ID == s.ServerID {
+			return conf
+		}
+	}
+	return nil
+}
+
 func (s *ServerManager) SetDefaultServer(serverID uuid.UUID) {
 	var found bool
 	for _, s := range s.config.Servers {
@@ -131,13 +327,29 @@ func (s *ServerManager) Logout(deletePassword bool) {
 			cb()
 		}
 		s.Server = nil
+		s.server = nil
+		s.capabilities = nil
+		s.admin = nil
+		s.monitor.setOnHostnameChanged(nil)
+		s.monitor = nil
+		if s.streamProxy != nil {
+			s.streamProxy.Close()
+			s.streamProxy = nil
+		}
+		for _, c := range s.secondaries {
+			c.monitor.setOnHostnameChanged(nil)
+			if c.streamProxy != nil {
+				c.streamProxy.Close()
+			}
+		}
+		s.secondaries = nil
 		s.LoggedInUser = ""
 		s.ServerID = uuid.UUID{}
 	}
 }
 
 func (s *ServerManager) deleteServerPassword(serverID uuid.UUID) {
-	keyring.Delete(s.appName, s.ServerID.String())
+	s.credentials.Delete(serverID.String())
 }
 
 // Sets a callback that is invoked when a server is connected to.
@@ -145,37 +357,96 @@ func (s *ServerManager) OnServerConnected(cb func()) {
 	s.onServerConnected = append(s.onServerConnected, cb)
 }
 
+// Sets a callback that is invoked (on a background goroutine) when requests
+// to the connected server, or a server whose library is merged with it, are
+// switched between its primary and alternate hostnames, after the hostname in
+// use became unreachable. The callback is passed the ID of the server, as
+// prefixed to its items' IDs (see aggregate.SplitID), which is empty for the
+// connected server.
+func (s *ServerManager) OnHostnameChanged(cb func(serverID string)) {
+	s.onHostnameChanged = append(s.onHostnameChanged, cb)
+}
+
+// ResolveURL rewrites a URL for a connected server, such as a stream URL,
+// to the server's hostname that is currently in use, and to go through the
+// local stream proxy if the server's HTTP options require it.
+func (s *ServerManager) ResolveURL(url string) string {
+	if s.monitor == nil {
+		return url
+	}
+	monitor, proxy := s.monitor, s.streamProxy
+	if !monitor.Owns(url) {
+		// a URL for one of the secondary servers
+		for _, c := range s.secondaries {
+			if c.monitor.Owns(url) {
+				monitor, proxy = c.monitor, c.streamProxy
+				break
+			}
+		}
+	}
+	url = monitor.ResolveURL(url)
+	if proxy != nil {
+		url = proxy.URL(url)
+	}
+	return url
+}
+
 // Sets a callback that is invoked when the user logs out of a server.
 func (s *ServerManager) OnLogout(cb func()) {
 	s.onLogout = append(s.onLogout, cb)
 }
 
 func (s *ServerManager) GetServerPassword(serverID uuid.UUID) (string, error) {
-	return keyring.Get(s.appName, serverID.String())
+	return s.credentials.Get(serverID.String())
 }
 
 func (s *ServerManager) SetServerPassword(server *ServerConfig, password string) error {
-	return keyring.Set(s.appName, server.ID.String(), password)
+	return s.credentials.Set(server.ID.String(), password)
 }
 
-func (s *ServerManager) connect(connection ServerConnection, password string) (mediaprovider.Server, error) {
+// connect returns a client for the server at whichever of its hostnames
+// responds first, and the monitor of its connection.
+func (s *ServerManager) connect(connection ServerConnection, password string) (mediaprovider.Server, *connectionMonitor, error) {
 	var cli, altCli mediaprovider.Server
 
+	// all clients share the monitor, which switches their requests
+	// to the other hostname if the active one becomes unreachable
+	monitor := newConnectionMonitor(nil, connection.Hostname, connection.AltHostname)
+	transport, err := newHTTPTransport(connection.HTTP, monitor)
+	if err != nil {
+		return nil, nil, err
+	}
+	var authorizeURL func(*url.URL) *url.URL
+	if connection.AuthMode == AuthModeAPIKey {
+		// the "password" is the API key or access token
+		if connection.ServerType == ServerTypeJellyfin {
+			transport = &jellyfinTokenTransport{base: transport, token: password, username: connection.Username}
+		} else {
+			transport = &subsonicAPIKeyTransport{base: transport, apiKey: password}
+			authorizeURL = func(u *url.URL) *url.URL { return subsonicAPIKeyURL(u, password) }
+		}
+	}
+	monitor.base = transport
+	monitor.authorizeURL = authorizeURL
+	httpClient := func() *http.Client {
+		return &http.Client{Timeout: 10 * time.Second, Transport: monitor}
+	}
+
 	if connection.ServerType == ServerTypeJellyfin {
-		client, err := jellyfin.NewClient(connection.Hostname, res.AppName, res.AppVersion, jellyfin.WithTimeout(10*time.Second))
+		client, err := jellyfin.NewClient(connection.Hostname, res.AppName, res.AppVersion, jellyfin.WithHTTPClient(httpClient()))
 		if err != nil {
 			log.Printf("error creating Jellyfin client: %s", err.Error())
-			return nil, err
+			return nil, nil, err
 		}
 		cli = &jellyfinMP.JellyfinServer{
 			Client: *client,
 		}
 
 		if connection.AltHostname != "" {
-			altClient, err := jellyfin.NewClient(connection.AltHostname, res.AppName, res.AppVersion, jellyfin.WithTimeout(10*time.Second))
+			altClient, err := jellyfin.NewClient(connection.AltHostname, res.AppName, res.AppVersion, jellyfin.WithHTTPClient(httpClient()))
 			if err != nil {
 				log.Printf("error creating Jellyfin alternative client: %s", err.Error())
-				return nil, err
+				return nil, nil, err
 			}
 			altCli = &jellyfinMP.JellyfinServer{
 				Client: *altClient,
@@ -184,7 +455,7 @@ func (s *ServerManager) connect(connection ServerConnection, password string) (m
 	} else {
 		cli = &subsonicMP.SubsonicServer{
 			Client: subsonic.Client{
-				Client:       &http.Client{Timeout: 10 * time.Second},
+				Client:       httpClient(),
 				BaseUrl:      connection.Hostname,
 				User:         connection.Username,
 				PasswordAuth: connection.LegacyAuth,
@@ -193,7 +464,7 @@ func (s *ServerManager) connect(connection ServerConnection, password string) (m
 		}
 		altCli = &subsonicMP.SubsonicServer{
 			Client: subsonic.Client{
-				Client:       &http.Client{Timeout: 10 * time.Second},
+				Client:       httpClient(),
 				BaseUrl:      connection.AltHostname,
 				User:         connection.Username,
 				PasswordAuth: connection.LegacyAuth,
@@ -221,11 +492,12 @@ func (s *ServerManager) connect(connection ServerConnection, password string) (m
 	defer cancel()
 	select {
 	case <-ctx.Done():
-		return nil, ErrUnreachable
+		return nil, nil, ErrUnreachable
 	case altPing := <-pingChan:
+		monitor.setActive(altPing)
 		if altPing {
-			return altCli, authError
+			return altCli, monitor, authError
 		}
-		return cli, authError
+		return cli, monitor, authError
 	}
 }
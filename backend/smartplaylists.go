@@ -0,0 +1,337 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+const (
+	smartPlaylistsFile = "smart_playlists.json"
+
+	// prefix of the IDs of smart playlists, to distinguish them from server playlists
+	SmartPlaylistIDPrefix = "smart:"
+)
+
+const (
+	SmartPlaylistSortNone       = "None"
+	SmartPlaylistSortRandom     = "Random"
+	SmartPlaylistSortTitle      = "Title"
+	SmartPlaylistSortArtist     = "Artist"
+	SmartPlaylistSortAlbum      = "Album"
+	SmartPlaylistSortYear       = "Year"
+	SmartPlaylistSortPlayCount  = "Play count"
+	SmartPlaylistSortRating     = "Rating"
+	SmartPlaylistSortLastPlayed = "Last played"
+	SmartPlaylistSortDuration   = "Duration"
+)
+
+var SmartPlaylistSortOrders = []string{
+	SmartPlaylistSortNone,
+	SmartPlaylistSortRandom,
+	SmartPlaylistSortTitle,
+	SmartPlaylistSortArtist,
+	SmartPlaylistSortAlbum,
+	SmartPlaylistSortYear,
+	SmartPlaylistSortPlayCount,
+	SmartPlaylistSortRating,
+	SmartPlaylistSortLastPlayed,
+	SmartPlaylistSortDuration,
+}
+
+var ErrSmartPlaylistNotFound = errors.New("smart playlist not found")
+
+// SmartPlaylist is a client-side playlist whose tracks are
+// the tracks in the library matching a set of rules.
+type SmartPlaylist struct {
+	ID             string             `json:"id"`
+	ServerID       string             `json:"serverID"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Rules          SmartPlaylistRules `json:"rules"`
+	SortOrder      string             `json:"sortOrder"`
+	SortDescending bool               `json:"sortDescending"`
+	Limit          int                `json:"limit"` // 0 == no limit
+
+	// number of tracks from the last evaluation, for display
+	LastTrackCount int `json:"lastTrackCount"`
+}
+
+// SmartPlaylistRules are the rules a track must match to be included
+// in a smart playlist. Zero-valued rules are unset and match any track.
+type SmartPlaylistRules struct {
+	Genres              []string `json:"genres,omitempty"`  // track has any of the genres
+	Artists             []string `json:"artists,omitempty"` // track is by any of the artists
+	MinYear             int      `json:"minYear,omitempty"`
+	MaxYear             int      `json:"maxYear,omitempty"`
+	MinPlayCount        int      `json:"minPlayCount,omitempty"`
+	MaxPlayCount        int      `json:"maxPlayCount,omitempty"`
+	MinRating           int      `json:"minRating,omitempty"`
+	FavoritesOnly       bool     `json:"favoritesOnly,omitempty"`
+	PlayedWithinDays    int      `json:"playedWithinDays,omitempty"`
+	NotPlayedWithinDays int      `json:"notPlayedWithinDays,omitempty"`
+	MinDurationSecs     int      `json:"minDurationSecs,omitempty"`
+	MaxDurationSecs     int      `json:"maxDurationSecs,omitempty"`
+	MinBitRate          int      `json:"minBitRate,omitempty"` // kbps
+	MaxBitRate          int      `json:"maxBitRate,omitempty"` // kbps
+	PathPrefix          string   `json:"pathPrefix,omitempty"`
+}
+
+func IsSmartPlaylistID(id string) bool {
+	return strings.HasPrefix(id, SmartPlaylistIDPrefix)
+}
+
+// AsPlaylist returns the smart playlist as a Playlist,
+// to be shown alongside the server's playlists.
+func (p *SmartPlaylist) AsPlaylist() *mediaprovider.Playlist {
+	return &mediaprovider.Playlist{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Owner:       "Smart playlist",
+		TrackCount:  p.LastTrackCount,
+	}
+}
+
+// clone returns a deep copy of the smart playlist.
+func (p *SmartPlaylist) clone() *SmartPlaylist {
+	c := *p
+	c.Rules.Genres = slices.Clone(p.Rules.Genres)
+	c.Rules.Artists = slices.Clone(p.Rules.Artists)
+	return &c
+}
+
+// Matches returns true if the track matches all of the rules.
+func (r SmartPlaylistRules) Matches(tr *mediaprovider.Track, now time.Time) bool {
+	if len(r.Genres) > 0 && !mediaprovider.TagsMatch(r.Genres, mediaprovider.TagMatchAny, nil, tr.Genres) {
+		return false
+	}
+	if len(r.Artists) > 0 && !slices.ContainsFunc(tr.ArtistNames, func(name string) bool {
+		return slices.ContainsFunc(r.Artists, func(a string) bool { return strings.EqualFold(a, name) })
+	}) {
+		return false
+	}
+	if tr.Year < r.MinYear || (r.MaxYear > 0 && tr.Year > r.MaxYear) {
+		return false
+	}
+	if tr.PlayCount < r.MinPlayCount || (r.MaxPlayCount > 0 && tr.PlayCount > r.MaxPlayCount) {
+		return false
+	}
+	if tr.Rating < r.MinRating || (r.FavoritesOnly && !tr.Favorite) {
+		return false
+	}
+	if r.PlayedWithinDays > 0 && (tr.LastPlayed.IsZero() ||
+		now.Sub(tr.LastPlayed) > time.Duration(r.PlayedWithinDays)*24*time.Hour) {
+		return false
+	}
+	if r.NotPlayedWithinDays > 0 && !tr.LastPlayed.IsZero() &&
+		now.Sub(tr.LastPlayed) <= time.Duration(r.NotPlayedWithinDays)*24*time.Hour {
+		return false
+	}
+	if tr.Duration < r.MinDurationSecs || (r.MaxDurationSecs > 0 && tr.Duration > r.MaxDurationSecs) {
+		return false
+	}
+	if tr.BitRate < r.MinBitRate || (r.MaxBitRate > 0 && tr.BitRate > r.MaxBitRate) {
+		return false
+	}
+	if r.PathPrefix != "" && !strings.HasPrefix(tr.FilePath, r.PathPrefix) {
+		return false
+	}
+	return true
+}
+
+// trackFilter returns the subset of the rules which can be
+// handed to the media provider as a TrackFilter.
+func (r SmartPlaylistRules) trackFilter() mediaprovider.TrackFilter {
+	return mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{
+		MinYear:            r.MinYear,
+		MaxYear:            r.MaxYear,
+		Genres:             r.Genres,
+		ExcludeUnfavorited: r.FavoritesOnly,
+	})
+}
+
+// SmartPlaylistManager stores the user's smart playlists in the config
+// directory and evaluates them against the current server.
+type SmartPlaylistManager struct {
+	sm       *ServerManager
+	filepath string
+
+	mutex     sync.Mutex
+	playlists []*SmartPlaylist
+}
+
+func NewSmartPlaylistManager(sm *ServerManager, filepath string) *SmartPlaylistManager {
+	s := &SmartPlaylistManager{sm: sm, filepath: filepath}
+	if b, err := os.ReadFile(filepath); err == nil {
+		if err := json.Unmarshal(b, &s.playlists); err != nil {
+			log.Printf("error reading smart playlists: %v", err)
+		}
+	}
+	return s
+}
+
+// GetSmartPlaylists returns the smart playlists of the current server.
+func (s *SmartPlaylistManager) GetSmartPlaylists() []*SmartPlaylist {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	serverID := s.sm.ServerID.String()
+	var pls []*SmartPlaylist
+	for _, p := range s.playlists {
+		if p.ServerID == serverID {
+			pls = append(pls, p.clone())
+		}
+	}
+	return pls
+}
+
+// GetSmartPlaylist returns a copy of the smart playlist with the given ID.
+func (s *SmartPlaylistManager) GetSmartPlaylist(id string) (*SmartPlaylist, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	idx := s.indexOf(id)
+	if idx < 0 {
+		return nil, ErrSmartPlaylistNotFound
+	}
+	return s.playlists[idx].clone(), nil
+}
+
+// SaveSmartPlaylist adds the smart playlist for the current server if it
+// is new (has an empty ID) or else updates the existing one.
+func (s *SmartPlaylistManager) SaveSmartPlaylist(pl *SmartPlaylist) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := pl.clone()
+	if c.ID == "" {
+		c.ID = SmartPlaylistIDPrefix + uuid.NewString()
+		c.ServerID = s.sm.ServerID.String()
+		pl.ID, pl.ServerID = c.ID, c.ServerID
+		s.playlists = append(s.playlists, c)
+	} else if idx := s.indexOf(c.ID); idx >= 0 {
+		s.playlists[idx] = c
+	} else {
+		return ErrSmartPlaylistNotFound
+	}
+	return s.writeFile()
+}
+
+func (s *SmartPlaylistManager) DeleteSmartPlaylist(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	idx := s.indexOf(id)
+	if idx < 0 {
+		return ErrSmartPlaylistNotFound
+	}
+	s.playlists = slices.Delete(s.playlists, idx, idx+1)
+	return s.writeFile()
+}
+
+// EvaluateByID evaluates the smart playlist with the given ID.
+func (s *SmartPlaylistManager) EvaluateByID(id string) ([]*mediaprovider.Track, error) {
+	pl, err := s.GetSmartPlaylist(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Evaluate(pl), nil
+}
+
+// Evaluate returns the tracks of the smart playlist, iterating
+// over the tracks of the current server.
+func (s *SmartPlaylistManager) Evaluate(pl *SmartPlaylist) []*mediaprovider.Track {
+	mp := s.sm.Server
+	if mp == nil {
+		return nil
+	}
+	now := time.Now()
+	sortOrder := pl.SortOrder
+	if sortOrder == "" {
+		sortOrder = SmartPlaylistSortNone
+	}
+	var tracks []*mediaprovider.Track
+	iter := mp.IterateTracks("", pl.Rules.trackFilter())
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		if !pl.Rules.Matches(tr, now) {
+			continue
+		}
+		tracks = append(tracks, tr)
+		if sortOrder == SmartPlaylistSortNone && pl.Limit > 0 && len(tracks) >= pl.Limit {
+			break
+		}
+	}
+	sortSmartPlaylistTracks(tracks, sortOrder, pl.SortDescending)
+	if pl.Limit > 0 && len(tracks) > pl.Limit {
+		tracks = tracks[:pl.Limit]
+	}
+
+	s.mutex.Lock()
+	if idx := s.indexOf(pl.ID); idx >= 0 && s.playlists[idx].LastTrackCount != len(tracks) {
+		s.playlists[idx].LastTrackCount = len(tracks)
+		if err := s.writeFile(); err != nil {
+			log.Printf("error saving smart playlists: %v", err)
+		}
+	}
+	s.mutex.Unlock()
+	return tracks
+}
+
+func sortSmartPlaylistTracks(tracks []*mediaprovider.Track, sortOrder string, descending bool) {
+	strCmp := func(fn func(*mediaprovider.Track) string) func(a, b *mediaprovider.Track) int {
+		return func(a, b *mediaprovider.Track) int {
+			return strings.Compare(strings.ToLower(fn(a)), strings.ToLower(fn(b)))
+		}
+	}
+	intCmp := func(fn func(*mediaprovider.Track) int) func(a, b *mediaprovider.Track) int {
+		return func(a, b *mediaprovider.Track) int { return fn(a) - fn(b) }
+	}
+	var cmp func(a, b *mediaprovider.Track) int
+	switch sortOrder {
+	case SmartPlaylistSortRandom:
+		rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+		return
+	case SmartPlaylistSortTitle:
+		cmp = strCmp(func(t *mediaprovider.Track) string { return t.Name })
+	case SmartPlaylistSortArtist:
+		cmp = strCmp(func(t *mediaprovider.Track) string { return strings.Join(t.ArtistNames, ", ") })
+	case SmartPlaylistSortAlbum:
+		cmp = strCmp(func(t *mediaprovider.Track) string { return t.Album })
+	case SmartPlaylistSortYear:
+		cmp = intCmp(func(t *mediaprovider.Track) int { return t.Year })
+	case SmartPlaylistSortPlayCount:
+		cmp = intCmp(func(t *mediaprovider.Track) int { return t.PlayCount })
+	case SmartPlaylistSortRating:
+		cmp = intCmp(func(t *mediaprovider.Track) int { return t.Rating })
+	case SmartPlaylistSortDuration:
+		cmp = intCmp(func(t *mediaprovider.Track) int { return t.Duration })
+	case SmartPlaylistSortLastPlayed:
+		cmp = func(a, b *mediaprovider.Track) int { return a.LastPlayed.Compare(b.LastPlayed) }
+	default:
+		return
+	}
+	if descending {
+		slices.SortStableFunc(tracks, func(a, b *mediaprovider.Track) int { return cmp(b, a) })
+	} else {
+		slices.SortStableFunc(tracks, cmp)
+	}
+}
+
+func (s *SmartPlaylistManager) indexOf(id string) int {
+	return slices.IndexFunc(s.playlists, func(p *SmartPlaylist) bool { return p.ID == id })
+}
+
+// must be called with the mutex held
+func (s *SmartPlaylistManager) writeFile() error {
+	b, err := json.Marshal(s.playlists)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filepath, b, 0644)
+}
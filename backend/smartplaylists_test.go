@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_SmartPlaylistRulesMatches(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	track := &mediaprovider.Track{
+		Genres:      []string{"Rock", "Blues"},
+		ArtistNames: []string{"The Band"},
+		Year:        1970,
+		PlayCount:   5,
+		Rating:      4,
+		Favorite:    true,
+		LastPlayed:  now.Add(-3 * 24 * time.Hour),
+		Duration:    240,
+		BitRate:     320,
+		FilePath:    "music/rock/track.flac",
+	}
+	tests := []struct {
+		name  string
+		rules SmartPlaylistRules
+		want  bool
+	}{
+		{"no rules", SmartPlaylistRules{}, true},
+		{"genre any", SmartPlaylistRules{Genres: []string{"jazz", "blues"}}, true},
+		{"genre none", SmartPlaylistRules{Genres: []string{"jazz"}}, false},
+		{"artist", SmartPlaylistRules{Artists: []string{"the band"}}, true},
+		{"other artist", SmartPlaylistRules{Artists: []string{"Other"}}, false},
+		{"year in range", SmartPlaylistRules{MinYear: 1965, MaxYear: 1970}, true},
+		{"year too old", SmartPlaylistRules{MinYear: 1971}, false},
+		{"year too new", SmartPlaylistRules{MaxYear: 1969}, false},
+		{"play count", SmartPlaylistRules{MinPlayCount: 5, MaxPlayCount: 10}, true},
+		{"max play count", SmartPlaylistRules{MaxPlayCount: 4}, false},
+		{"min rating", SmartPlaylistRules{MinRating: 5}, false},
+		{"favorites only", SmartPlaylistRules{FavoritesOnly: true}, true},
+		{"played within", SmartPlaylistRules{PlayedWithinDays: 7}, true},
+		{"not played within", SmartPlaylistRules{PlayedWithinDays: 2}, false},
+		{"not played recently", SmartPlaylistRules{NotPlayedWithinDays: 2}, true},
+		{"played recently", SmartPlaylistRules{NotPlayedWithinDays: 7}, false},
+		{"duration", SmartPlaylistRules{MinDurationSecs: 200, MaxDurationSecs: 300}, true},
+		{"too short", SmartPlaylistRules{MinDurationSecs: 300}, false},
+		{"bit rate", SmartPlaylistRules{MinBitRate: 256}, true},
+		{"bit rate too high", SmartPlaylistRules{MaxBitRate: 256}, false},
+		{"path prefix", SmartPlaylistRules{PathPrefix: "music/rock/"}, true},
+		{"other path", SmartPlaylistRules{PathPrefix: "music/jazz/"}, false},
+		{"all rules", SmartPlaylistRules{Genres: []string{"Rock"}, MinYear: 1960, FavoritesOnly: true, MinRating: 3}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.Matches(track, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	never := &mediaprovider.Track{}
+	if (SmartPlaylistRules{PlayedWithinDays: 7}).Matches(never, now) {
+		t.Error("never played track should not match PlayedWithinDays")
+	}
+	if !(SmartPlaylistRules{NotPlayedWithinDays: 7}).Matches(never, now) {
+		t.Error("never played track should match NotPlayedWithinDays")
+	}
+}
+
+func Test_SortSmartPlaylistTracks(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "a", Name: "beta", Year: 2000},
+		{ID: "b", Name: "Alpha", Year: 1990},
+		{ID: "c", Name: "gamma", Year: 2010},
+	}
+	sortSmartPlaylistTracks(tracks, SmartPlaylistSortTitle, false)
+	if got := trackIDs(tracks); got != "bac" {
+		t.Errorf("sort by title: got %s", got)
+	}
+	sortSmartPlaylistTracks(tracks, SmartPlaylistSortYear, true)
+	if got := trackIDs(tracks); got != "cab" {
+		t.Errorf("sort by year descending: got %s", got)
+	}
+	sortSmartPlaylistTracks(tracks, SmartPlaylistSortNone, false)
+	if got := trackIDs(tracks); got != "cab" {
+		t.Errorf("no sort order should keep order: got %s", got)
+	}
+}
+
+func Test_SmartPlaylistClone(t *testing.T) {
+	pl := &SmartPlaylist{Rules: SmartPlaylistRules{Genres: []string{"Rock"}, Artists: []string{"A"}}}
+	c := pl.clone()
+	c.Rules.Genres[0] = "Jazz"
+	c.Rules.Artists = append(c.Rules.Artists, "B")
+	if pl.Rules.Genres[0] != "Rock" || len(pl.Rules.Artists) != 1 {
+		t.Error("modifying the clone modified the original rules")
+	}
+}
+
+func trackIDs(tracks []*mediaprovider.Track) string {
+	var s string
+	for _, tr := range tracks {
+		s += tr.ID
+	}
+	return s
+}
@@ -0,0 +1,265 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/res"
+	"github.com/google/uuid"
+)
+
+// The Subsonic and Jellyfin client libraries only implement password
+// authentication, so API key and token authentication are implemented
+// by rewriting their requests in the HTTP transport.
+
+// subsonicAPIKeyTransport authenticates Subsonic API requests with an
+// OpenSubsonic API key in place of the username and password or token.
+type subsonicAPIKeyTransport struct {
+	base   http.RoundTripper
+	apiKey string
+}
+
+func (s *subsonicAPIKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL = subsonicAPIKeyURL(req.URL, s.apiKey)
+	return s.base.RoundTrip(req)
+}
+
+// subsonicAPIKeyURL returns the Subsonic API URL with its
+// authentication parameters replaced by the API key.
+func subsonicAPIKeyURL(u *url.URL, apiKey string) *url.URL {
+	if !strings.Contains(u.Path, "/rest/") {
+		return u
+	}
+	q := u.Query()
+	for _, p := range []string{"u", "p", "t", "s"} {
+		q.Del(p)
+	}
+	q.Set("apiKey", apiKey)
+	rewritten := *u
+	rewritten.RawQuery = q.Encode()
+	return &rewritten
+}
+
+// jellyfinTokenTransport authenticates to Jellyfin with an access token or
+// API key by answering the client's login request itself, with a login
+// response for the user of the token, so that the client uses the token
+// for subsequent requests.
+type jellyfinTokenTransport struct {
+	base     http.RoundTripper
+	token    string
+	username string // to identify the user if the token is an API key not bound to a user
+}
+
+type jellyfinUser struct {
+	Name     string `json:"Name"`
+	ID       string `json:"Id"`
+	ServerID string `json:"ServerId"`
+}
+
+func (j *jellyfinTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(strings.ToLower(req.URL.Path), "/users/authenticatebyname") {
+		return j.base.RoundTrip(req)
+	}
+	user, status, err := j.tokenUser(req)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return newJSONResponse(req, status, map[string]string{"error": "invalid access token"}), nil
+	}
+	return newJSONResponse(req, http.StatusOK, map[string]any{
+		"User":        user,
+		"AccessToken": j.token,
+		"ServerId":    user.ServerID,
+	}), nil
+}
+
+// tokenUser returns the user the token authenticates as, or nil
+// and the HTTP status of the failed request if the token is invalid.
+func (j *jellyfinTokenTransport) tokenUser(loginReq *http.Request) (*jellyfinUser, int, error) {
+	base := *loginReq.URL
+	base.Path = strings.TrimSuffix(base.Path, "/Users/authenticatebyname")
+	base.Path = strings.TrimSuffix(base.Path, "/users/authenticatebyname")
+	get := func(path string, result any) (int, error) {
+		u := base
+		u.Path += path
+		req, err := http.NewRequestWithContext(loginReq.Context(), http.MethodGet, u.String(), nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("X-Emby-Token", j.token)
+		resp, err := j.base.RoundTrip(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(result)
+	}
+
+	var user jellyfinUser
+	status, err := get("/Users/Me", &user)
+	if err != nil || status == http.StatusOK {
+		return &user, status, err
+	}
+	// API keys aren't bound to a user, so find the user by name
+	var users []jellyfinUser
+	status, err = get("/Users", &users)
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Name, j.username) {
+			return &u, status, nil
+		}
+	}
+	return nil, http.StatusUnauthorized, nil
+}
+
+func newJSONResponse(req *http.Request, status int, body any) *http.Response {
+	b, _ := json.Marshal(body)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+		Request:       req,
+	}
+}
+
+var ErrQuickConnectUnavailable = errors.New("Quick Connect is not enabled on this server")
+
+// JellyfinQuickConnect is an in-progress Jellyfin Quick Connect authorization.
+// The user enters the Code in a Jellyfin client where they are already
+// signed in, authorizing this client to obtain an access token.
+type JellyfinQuickConnect struct {
+	Code string
+
+	secret   string
+	baseURL  string
+	client   *http.Client
+	deviceID string
+}
+
+// StartJellyfinQuickConnect begins a Quick Connect authorization with the
+// Jellyfin server of the connection.
+func (s *ServerManager) StartJellyfinQuickConnect(connection ServerConnection) (*JellyfinQuickConnect, error) {
+	server := newConnectionMonitor(nil, connection.Hostname, connection.AltHostname)
+	transport, err := newHTTPTransport(connection.HTTP, server)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	q := &JellyfinQuickConnect{
+		baseURL:  strings.TrimSuffix(connection.Hostname, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second, Transport: transport},
+		deviceID: uuid.NewSHA1(uuid.NameSpaceOID, []byte(hostname+res.AppName)).String(),
+	}
+	var result struct {
+		Code   string `json:"Code"`
+		Secret string `json:"Secret"`
+	}
+	// newer servers initiate with POST, older ones with GET
+	status, err := q.request(context.Background(), http.MethodPost, "/QuickConnect/Initiate", nil, &result)
+	if err == nil && (status == http.StatusNotFound || status == http.StatusMethodNotAllowed) {
+		status, err = q.request(context.Background(), http.MethodGet, "/QuickConnect/Initiate", nil, &result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, ErrQuickConnectUnavailable
+	}
+	q.Code, q.secret = result.Code, result.Secret
+	return q, nil
+}
+
+// Wait waits for the user to authorize the code, returning the access
+// token and the name of the user who authorized it.
+func (q *JellyfinQuickConnect) Wait(ctx context.Context) (token, username string, err error) {
+	t := time.NewTicker(2 * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-t.C:
+		}
+		var state struct {
+			Authenticated bool `json:"Authenticated"`
+		}
+		status, err := q.request(ctx, http.MethodGet, "/QuickConnect/Connect?secret="+url.QueryEscape(q.secret), nil, &state)
+		if err != nil {
+			return "", "", err
+		}
+		if status != http.StatusOK {
+			return "", "", fmt.Errorf("Quick Connect failed: %s", http.StatusText(status))
+		}
+		if state.Authenticated {
+			break
+		}
+	}
+	var result struct {
+		User        jellyfinUser `json:"User"`
+		AccessToken string       `json:"AccessToken"`
+	}
+	status, err := q.request(ctx, http.MethodPost, "/Users/AuthenticateWithQuickConnect",
+		map[string]string{"Secret": q.secret}, &result)
+	if err != nil {
+		return "", "", err
+	}
+	if status != http.StatusOK {
+		return "", "", fmt.Errorf("Quick Connect failed: %s", http.StatusText(status))
+	}
+	return result.AccessToken, result.User.Name, nil
+}
+
+// request makes a request to the server, decoding the JSON response into
+// result if it succeeded, and returns the HTTP status.
+func (q *JellyfinQuickConnect) request(ctx context.Context, method, path string, body, result any) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, q.baseURL+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("X-Emby-Authorization", fmt.Sprintf(
+		`MediaBrowser Client="%s", Device="%s", DeviceId="%s", Version="%s"`,
+		res.DisplayName, res.DisplayName, q.deviceID, res.AppVersion))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return 0, fmt.Errorf("invalid Quick Connect response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+// ResizeImage scales the image down so that its larger dimension
+// is at most maxSize, preserving the aspect ratio.
+// Images which are already small enough are returned unchanged.
+func ResizeImage(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	if w > h {
+		w, h = maxSize, h*maxSize/w
+	} else {
+		w, h = w*maxSize/h, maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// BlurImage returns a small, heavily blurred copy of the image, suitable
+// for using as a backdrop when scaled up with smooth scaling.
+func BlurImage(img image.Image) image.Image {
+	const (
+		size   = 48
+		radius = 3
+	)
+	resized := ResizeImage(img, size)
+	small, ok := resized.(*image.RGBA)
+	if !ok || resized == img {
+		// image was already small; copy it so the original is not modified
+		small = image.NewRGBA(image.Rect(0, 0, resized.Bounds().Dx(), resized.Bounds().Dy()))
+		draw.Draw(small, small.Bounds(), resized, resized.Bounds().Min, draw.Src)
+	}
+	// two passes of a separable box blur approximate a gaussian blur
+	for i := 0; i < 2; i++ {
+		small = boxBlur(small, radius, true)
+		small = boxBlur(small, radius, false)
+	}
+	return small
+}
+
+func boxBlur(src *image.RGBA, radius int, horizontal bool) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var r, g, bl, a, n int
+			for d := -radius; d <= radius; d++ {
+				px, py := x, y
+				if horizontal {
+					px = min(max(x+d, b.Min.X), b.Max.X-1)
+				} else {
+					py = min(max(y+d, b.Min.Y), b.Max.Y-1)
+				}
+				c := src.RGBAAt(px, py)
+				r += int(c.R)
+				g += int(c.G)
+				bl += int(c.B)
+				a += int(c.A)
+				n++
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
@@ -15,6 +15,8 @@ require (
 	github.com/pelletier/go-toml/v2 v2.0.8
 	github.com/quarckster/go-mpris-server v1.0.3
 	github.com/zalando/go-keyring v0.2.1
+	golang.org/x/crypto v0.14.0
+	golang.org/x/image v0.15.0
 	golang.org/x/net v0.17.0
 	golang.org/x/text v0.14.0
 )
@@ -40,7 +42,6 @@ require (
 	github.com/stretchr/testify v1.8.4 // indirect
 	github.com/tevino/abool v1.2.0 // indirect
 	github.com/yuin/goldmark v1.5.5 // indirect
-	golang.org/x/image v0.15.0 // indirect
 	golang.org/x/mobile v0.0.0-20230531173138-3c911d8e3eda // indirect
 	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
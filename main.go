@@ -52,12 +52,14 @@ func main() {
 		if runtime.GOOS == "linux" {
 			time.Sleep(250 * time.Millisecond)
 		}
-		defaultServer := myApp.ServerManager.GetDefaultServer()
-		if defaultServer == nil {
-			mainWindow.Controller.PromptForFirstServer()
-		} else {
-			mainWindow.Controller.DoConnectToServerWorkflow(defaultServer)
-		}
+		mainWindow.Controller.DoUnlockCredentialsWorkflow(func() {
+			defaultServer := myApp.ServerManager.GetDefaultServer()
+			if defaultServer == nil {
+				mainWindow.Controller.PromptForFirstServer()
+			} else {
+				mainWindow.Controller.DoConnectToServerWorkflow(defaultServer)
+			}
+		})
 	}()
 
 	mainWindow.Show()
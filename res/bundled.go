@@ -80,6 +80,11 @@ var ResFilterSvg = &fyne.StaticResource{
 	StaticContent: []byte(
 		"<svg fill=\"#000000\" version=\"1.1\" id=\"Capa_1\" xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" \r\n\t width=\"800px\" height=\"800px\" viewBox=\"0 0 971.986 971.986\"\r\n\t xml:space=\"preserve\">\r\n<g>\r\n\t<path d=\"M370.216,459.3c10.2,11.1,15.8,25.6,15.8,40.6v442c0,26.601,32.1,40.101,51.1,21.4l123.3-141.3\r\n\t\tc16.5-19.8,25.6-29.601,25.6-49.2V500c0-15,5.7-29.5,15.8-40.601L955.615,75.5c26.5-28.8,6.101-75.5-33.1-75.5h-873\r\n\t\tc-39.2,0-59.7,46.6-33.1,75.5L370.216,459.3z\"/>\r\n</g>\r\n</svg>\r\n"),
 }
+var ResScoreSvg = &fyne.StaticResource{
+	StaticName: "score.svg",
+	StaticContent: []byte(
+		"<svg fill=\"#000000\" width=\"800px\" height=\"800px\" viewBox=\"0 0 20 20\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M3 0h14a2 2 0 0 1 2 2v16a2 2 0 0 1-2 2H3a2 2 0 0 1-2-2V2a2 2 0 0 1 2-2zm0 2v16h14V2H3zm1 2h12v1H4V4zm0 3h12v1H4V7zm0 3h6v1H4v-1zm8 0h4v1h-2v5a2 2 0 1 1-2-2c.35 0 .69.09 1 .26V11h-1v-1zM4 13h5v1H4v-1z\"/></svg>\n"),
+}
 var ResBroadcastSvg = &fyne.StaticResource{
 	StaticName: "broadcast.svg",
 	StaticContent: []byte(
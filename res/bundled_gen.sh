@@ -15,6 +15,7 @@ fyne bundle -append -prefix Res icons/publicdomain/theatermasks.svg >> bundled.g
 fyne bundle -append -prefix Res icons/publicdomain/grid.svg >> bundled.go
 fyne bundle -append -prefix Res icons/publicdomain/list.svg >> bundled.go
 fyne bundle -append -prefix Res icons/publicdomain/filter.svg >> bundled.go
+fyne bundle -append -prefix Res icons/publicdomain/score.svg >> bundled.go
 fyne bundle -append -prefix Res icons/remix_design/broadcast.svg >> bundled.go
 fyne bundle -append -prefix Res icons/remix_design/repeat.svg >> bundled.go
 fyne bundle -append -prefix Res icons/remix_design/repeatone.svg >> bundled.go
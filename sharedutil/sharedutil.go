@@ -3,6 +3,7 @@ package sharedutil
 import (
 	"math"
 	"slices"
+	"strings"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
@@ -88,6 +89,13 @@ func AlbumIDOrEmptyStr(track *mediaprovider.Track) string {
 	return track.AlbumID
 }
 
+func FirstOrEmptyStr(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	return ss[0]
+}
+
 func TracksToIDs(tracks []*mediaprovider.Track) []string {
 	return MapSlice(tracks, func(tr *mediaprovider.Track) string {
 		return tr.ID
@@ -181,3 +189,58 @@ func lastIdxCanMoveDown(idxs []int, lenSlice int) int {
 	}
 	return -1
 }
+
+// MoveTracks moves the tracks at idxToMove so that they are inserted,
+// in their original relative order, before the track at insertIdx
+// (or at the end if insertIdx == len(tracks)), and returns a new track slice.
+// idxToMove must contain only valid indexes into tracks, and no repeats
+func MoveTracks(tracks []*mediaprovider.Track, idxToMove []int, insertIdx int) []*mediaprovider.Track {
+	idxToMoveSet := ToSet(idxToMove)
+	moved := make([]*mediaprovider.Track, 0, len(idxToMove))
+	rest := make([]*mediaprovider.Track, 0, len(tracks)-len(idxToMove))
+	restInsertIdx := 0
+	for i, t := range tracks {
+		if _, ok := idxToMoveSet[i]; ok {
+			moved = append(moved, t)
+		} else {
+			rest = append(rest, t)
+			if i < insertIdx {
+				restInsertIdx++
+			}
+		}
+	}
+	newTracks := make([]*mediaprovider.Track, 0, len(tracks))
+	newTracks = append(newTracks, rest[:restInsertIdx]...)
+	newTracks = append(newTracks, moved...)
+	return append(newTracks, rest[restInsertIdx:]...)
+}
+
+// FindDuplicateTracks returns the indexes of the tracks which duplicate an earlier
+// track in the list. Tracks are duplicates if they have the same ID or, if
+// matchAcrossAlbums is true, the same artists and title (case-insensitive) and
+// durations within 2 seconds of each other, such as the same song on a different album.
+func FindDuplicateTracks(tracks []*mediaprovider.Track, matchAcrossAlbums bool) []int {
+	var dupIdxs []int
+	seenIDs := make(map[string]struct{}, len(tracks))
+	seenSongs := make(map[string][]*mediaprovider.Track)
+	for i, tr := range tracks {
+		if _, ok := seenIDs[tr.ID]; ok {
+			dupIdxs = append(dupIdxs, i)
+			continue
+		}
+		seenIDs[tr.ID] = struct{}{}
+		if !matchAcrossAlbums {
+			continue
+		}
+		key := strings.ToLower(strings.Join(tr.ArtistNames, ";") + "|" + tr.Name)
+		if slices.ContainsFunc(seenSongs[key], func(t *mediaprovider.Track) bool {
+			d := t.Duration - tr.Duration
+			return d <= 2 && d >= -2
+		}) {
+			dupIdxs = append(dupIdxs, i)
+			continue
+		}
+		seenSongs[key] = append(seenSongs[key], tr)
+	}
+	return dupIdxs
+}
@@ -78,9 +78,72 @@ func Test_ReorderTracks(t *testing.T) {
 	}
 }
 
+func Test_MoveTracks(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "a"},
+		{ID: "b"},
+		{ID: "c"},
+		{ID: "d"},
+		{ID: "e"},
+	}
+
+	// move down
+	want := []*mediaprovider.Track{
+		{ID: "b"},
+		{ID: "d"},
+		{ID: "a"},
+		{ID: "c"},
+		{ID: "e"},
+	}
+	if !tracklistsEqual(t, MoveTracks(tracks, []int{0, 2}, 4), want) {
+		t.Error("MoveTracks: move down order incorrect")
+	}
+
+	// move up
+	want = []*mediaprovider.Track{
+		{ID: "a"},
+		{ID: "d"},
+		{ID: "e"},
+		{ID: "b"},
+		{ID: "c"},
+	}
+	if !tracklistsEqual(t, MoveTracks(tracks, []int{3, 4}, 1), want) {
+		t.Error("MoveTracks: move up order incorrect")
+	}
+
+	// move to end
+	want = []*mediaprovider.Track{
+		{ID: "b"},
+		{ID: "c"},
+		{ID: "d"},
+		{ID: "e"},
+		{ID: "a"},
+	}
+	if !tracklistsEqual(t, MoveTracks(tracks, []int{0}, 5), want) {
+		t.Error("MoveTracks: move to end order incorrect")
+	}
+}
+
 func tracklistsEqual(t *testing.T, a, b []*mediaprovider.Track) bool {
 	t.Helper()
 	return slices.EqualFunc(a, b, func(a, b *mediaprovider.Track) bool {
 		return a.ID == b.ID
 	})
 }
+
+func Test_FindDuplicateTracks(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "a", Name: "Song", ArtistNames: []string{"Artist"}, Duration: 200},
+		{ID: "b", Name: "Other", ArtistNames: []string{"Artist"}, Duration: 150},
+		{ID: "a", Name: "Song", ArtistNames: []string{"Artist"}, Duration: 200},
+		{ID: "c", Name: "song", ArtistNames: []string{"artist"}, Duration: 201},
+		{ID: "d", Name: "Song", ArtistNames: []string{"Artist"}, Duration: 320},
+	}
+
+	if got := FindDuplicateTracks(tracks, false); !slices.Equal(got, []int{2}) {
+		t.Errorf("FindDuplicateTracks by ID: got %v, want [2]", got)
+	}
+	if got := FindDuplicateTracks(tracks, true); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("FindDuplicateTracks across albums: got %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"image/color"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/theme"
+
+	"fyne.io/fyne/v2"
+)
+
+// The accent color fades to the new color in a few steps, since each
+// step re-applies the theme and so refreshes the whole UI.
+const (
+	accentTransitionSteps    = 4
+	accentTransitionInterval = 60 * time.Millisecond
+)
+
+// accentColorUpdater sets the theme's accent color from the cover art
+// of the playing track, if enabled, fading from the previous color.
+type accentColorUpdater struct {
+	theme  *theme.MyTheme
+	config *backend.ThemeConfig
+	im     *backend.ImageManager
+
+	// incremented on each update to cancel any transition in progress,
+	// or an update whose cover loads after a newer one's
+	generation atomic.Int64
+}
+
+func (a *accentColorUpdater) OnSongChange(track *mediaprovider.Track) {
+	gen := a.generation.Add(1)
+	if !a.config.DynamicAccentColor {
+		return
+	}
+	go func() {
+		var accent color.Color
+		if track != nil && track.CoverArtID != "" {
+			// usually already cached, having been fetched for the bottom panel
+			if img, err := a.im.GetCoverThumbnail(track.CoverArtID); err == nil {
+				accent, _ = theme.AccentColorFromImage(img)
+			} else {
+				log.Printf("error loading cover for accent color: %v", err)
+			}
+		}
+		a.apply(gen, accent)
+	}()
+}
+
+// apply fades the accent color to the new color, or back to the theme's
+// own colors if accent is nil. The theme is re-applied, refreshing the UI,
+// only if the color has changed.
+func (a *accentColorUpdater) apply(gen int64, accent color.Color) {
+	if a.generation.Load() != gen {
+		return // superseded by a newer update
+	}
+	from := a.theme.AccentColor()
+	a.theme.SetAccentColor(accent)
+	to := a.theme.AccentColor()
+	if color.NRGBAModel.Convert(from) == color.NRGBAModel.Convert(to) {
+		return
+	}
+	for i := 1; i <= accentTransitionSteps; i++ {
+		if i < accentTransitionSteps {
+			a.theme.SetAccentColor(theme.BlendColors(from, to, float64(i)/accentTransitionSteps))
+		} else {
+			a.theme.SetAccentColor(accent)
+		}
+		fyne.CurrentApp().Settings().SetTheme(a.theme)
+		if i < accentTransitionSteps {
+			time.Sleep(accentTransitionInterval)
+			if a.generation.Load() != gen {
+				return // superseded by a newer update, which fades from here
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"image"
+	"log"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend"
@@ -24,15 +25,18 @@ type BottomPanel struct {
 	Controls    *widgets.PlayerControls
 	AuxControls *widgets.AuxControls
 
+	pm         *backend.PlaybackManager
 	coverArtID string
 	container  *fyne.Container
 }
 
 var _ fyne.Widget = (*BottomPanel)(nil)
+var _ widgets.DropTarget = (*BottomPanel)(nil)
 
 func NewBottomPanel(pm *backend.PlaybackManager, contr *controller.Controller) *BottomPanel {
-	bp := &BottomPanel{}
+	bp := &BottomPanel{pm: pm}
 	bp.ExtendBaseWidget(bp)
+	widgets.RegisterDropTarget(bp)
 
 	pm.OnSongChange(bp.onSongChange)
 	pm.OnPlayTimeUpdate(func(cur, total float64) {
@@ -120,6 +124,28 @@ func (bp *BottomPanel) onSongChange(song, _ *mediaprovider.Track) {
 	}
 }
 
+// DragOver accepts tracks dragged from anywhere but the play queue itself,
+// since the bottom panel is always visible as a way to add to the queue.
+func (bp *BottomPanel) DragOver(data *widgets.DragData, _ fyne.Position) string {
+	if _, ok := data.Source.(*widgets.PlayQueueList); ok {
+		return ""
+	}
+	return "Add to queue"
+}
+
+func (bp *BottomPanel) DragLeave() {}
+
+func (bp *BottomPanel) Drop(data *widgets.DragData, _ fyne.Position) {
+	go func() {
+		tracks, err := data.Tracks()
+		if err != nil {
+			log.Printf("error loading dropped tracks: %s", err.Error())
+			return
+		}
+		bp.pm.LoadTracks(tracks, true /*append*/, false /*shuffle*/)
+	}()
+}
+
 func (bp *BottomPanel) CreateRenderer() fyne.WidgetRenderer {
 	bp.ExtendBaseWidget(bp)
 	return widget.NewSimpleRenderer(bp.container)
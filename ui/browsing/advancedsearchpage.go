@@ -0,0 +1,311 @@
+package browsing
+
+import (
+	"fmt"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/layouts"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maximum number of results of each kind shown on the Advanced Search page
+const advancedSearchMaxResults = 500
+
+type AdvancedSearchPage struct {
+	widget.BaseWidget
+
+	pool  *util.WidgetPool
+	contr *controller.Controller
+	mp    mediaprovider.MediaProvider
+	im    *backend.ImageManager
+
+	disposed     bool
+	query        string
+	results      *backend.AdvancedSearchResults
+	nowPlayingID string
+
+	albumGrid    *widgets.GridView
+	artistGrid   *widgets.GridView
+	tracklist    *widgets.Tracklist
+	tracklistCtr *fyne.Container
+	searcher     *widgets.SearchEntry
+	helpBtn      *widget.Button
+	statusLbl    *widget.Label
+	loadingDots  *widgets.LoadingDots
+	titleDisp    *widget.RichText
+	toggleBtns   *widgets.ToggleButtonGroup
+	container    *fyne.Container
+}
+
+func NewAdvancedSearchPage(query string, pool *util.WidgetPool, contr *controller.Controller, mp mediaprovider.MediaProvider, im *backend.ImageManager) *AdvancedSearchPage {
+	a := &AdvancedSearchPage{
+		pool:  pool,
+		contr: contr,
+		mp:    mp,
+		im:    im,
+	}
+	a.ExtendBaseWidget(a)
+	a.build(0, &backend.AdvancedSearchResults{})
+	if query != "" {
+		a.searcher.Entry.Text = query
+		a.OnSearched(query)
+	}
+	return a
+}
+
+func (a *AdvancedSearchPage) build(activeBtnIdx int, results *backend.AdvancedSearchResults) {
+	a.titleDisp = widget.NewRichTextWithText("Advanced Search")
+	a.titleDisp.Segments[0].(*widget.TextSegment).Style = widget.RichTextStyle{
+		SizeName: theme.SizeNameHeadingText,
+	}
+	a.toggleBtns = widgets.NewToggleButtonGroup(activeBtnIdx,
+		widget.NewButtonWithIcon("", myTheme.AlbumIcon, a.onShowAlbums),
+		widget.NewButtonWithIcon("", myTheme.ArtistIcon, a.onShowArtists),
+		widget.NewButtonWithIcon("", myTheme.TracksIcon, a.onShowTracks))
+	a.searcher = widgets.NewSearchEntry()
+	a.searcher.PlaceHolder = `e.g. artist:"pink floyd" year:1970..1979 -live`
+	a.searcher.OnSearched = a.OnSearched
+	a.searcher.Entry.Text = a.query
+	a.helpBtn = widget.NewButtonWithIcon("", theme.QuestionIcon(), a.showSyntaxHelp)
+	a.statusLbl = widget.NewLabel("")
+	a.loadingDots = widgets.NewLoadingDots()
+
+	albumModel := buildAlbumGridViewModel(results.Albums)
+	if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
+		a.albumGrid = g.(*widgets.GridView)
+		a.albumGrid.Placeholder = myTheme.AlbumIcon
+		a.albumGrid.ResetFixed(albumModel)
+	} else {
+		a.albumGrid = widgets.NewFixedGridView(albumModel, a.im, myTheme.AlbumIcon)
+	}
+	a.contr.ConnectAlbumGridActions(a.albumGrid)
+
+	artistModel := buildArtistGridViewModel(results.Artists)
+	if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
+		a.artistGrid = g.(*widgets.GridView)
+		a.artistGrid.Placeholder = myTheme.ArtistIcon
+		a.artistGrid.ResetFixed(artistModel)
+	} else {
+		a.artistGrid = widgets.NewFixedGridView(artistModel, a.im, myTheme.ArtistIcon)
+	}
+	a.artistGrid.DisableSharing = !a.contr.App.ServerManager.Capabilities().ShareArtists
+	a.contr.ConnectArtistGridActions(a.artistGrid)
+
+	if tl := a.pool.Obtain(util.WidgetTypeTracklist); tl != nil {
+		a.tracklist = tl.(*widgets.Tracklist)
+		a.tracklist.Reset()
+		a.tracklist.SetTracks(results.Tracks)
+	} else {
+		a.tracklist = widgets.NewTracklist(results.Tracks)
+	}
+	a.tracklist.Options = widgets.TracklistOptions{AutoNumber: true}
+	caps := a.contr.App.ServerManager.Capabilities()
+	a.tracklist.Options.DisableRating = !caps.Rating
+	a.tracklist.Options.DisableSharing = !caps.Sharing
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.contr.ConnectTracklistActions(a.tracklist)
+	a.tracklistCtr = container.New(
+		&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
+		a.tracklist)
+
+	a.results = results
+	a.updateStatus()
+
+	var initialView fyne.CanvasObject = a.albumGrid
+	switch activeBtnIdx {
+	case 1:
+		initialView = a.artistGrid
+	case 2:
+		initialView = a.tracklistCtr
+	}
+	searchVbox := container.NewVBox(layout.NewSpacer(), a.searcher, layout.NewSpacer())
+	header := container.NewBorder(nil, nil,
+		container.NewHBox(util.NewHSpace(9), a.titleDisp, container.NewCenter(a.toggleBtns)),
+		container.NewHBox(container.NewCenter(a.helpBtn), util.NewHSpace(15)),
+		searchVbox)
+	status := container.NewHBox(util.NewHSpace(9), a.statusLbl, container.NewCenter(a.loadingDots))
+	a.container = container.NewBorder(container.NewVBox(header, status),
+		nil, nil, nil, initialView)
+}
+
+func (a *AdvancedSearchPage) Route() controller.Route {
+	return controller.AdvancedSearchRoute(a.query)
+}
+
+func (a *AdvancedSearchPage) Reload() {
+	a.OnSearched(a.query)
+}
+
+func (a *AdvancedSearchPage) Tapped(*fyne.PointEvent) {
+	a.tracklist.UnselectAll()
+}
+
+var _ Searchable = (*AdvancedSearchPage)(nil)
+
+func (a *AdvancedSearchPage) SearchWidget() fyne.Focusable {
+	return a.searcher
+}
+
+func (a *AdvancedSearchPage) OnSearched(query string) {
+	a.query = query
+	q, err := backend.ParseSearchQuery(query)
+	if err != nil {
+		a.statusLbl.SetText(fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+	a.statusLbl.SetText("Searching...")
+	a.loadingDots.Start()
+	go func() {
+		results := backend.AdvancedSearch(a.mp, q, advancedSearchMaxResults)
+		if a.disposed || a.query != query {
+			// navigated away, or a newer search was started
+			return
+		}
+		a.loadingDots.Stop()
+		a.results = results
+		a.albumGrid.ResetFixed(buildAlbumGridViewModel(results.Albums))
+		a.artistGrid.ResetFixed(buildArtistGridViewModel(results.Artists))
+		a.tracklist.SetTracks(results.Tracks)
+		a.tracklist.Refresh()
+		a.updateStatus()
+	}()
+}
+
+func (a *AdvancedSearchPage) updateStatus() {
+	if a.query == "" {
+		a.statusLbl.SetText("Enter a query to search albums, artists and tracks")
+		return
+	}
+	a.statusLbl.SetText(fmt.Sprintf("%d albums, %d artists, %d tracks",
+		len(a.results.Albums), len(a.results.Artists), len(a.results.Tracks)))
+}
+
+func (a *AdvancedSearchPage) showSyntaxHelp() {
+	lbl := widget.NewLabel(backend.AdvancedSearchSyntax)
+	pop := widget.NewPopUp(lbl, fyne.CurrentApp().Driver().CanvasForObject(a.helpBtn))
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(a.helpBtn)
+	pos.X += a.helpBtn.Size().Width - pop.MinSize().Width
+	pos.Y += a.helpBtn.Size().Height
+	pop.ShowAtPosition(pos)
+}
+
+var _ Scrollable = (*AdvancedSearchPage)(nil)
+
+func (a *AdvancedSearchPage) Scroll(amount float32) {
+	switch a.toggleBtns.ActivatedButtonIndex() {
+	case 0:
+		a.albumGrid.ScrollToOffset(a.albumGrid.GetScrollOffset() + amount)
+	case 1:
+		a.artistGrid.ScrollToOffset(a.artistGrid.GetScrollOffset() + amount)
+	default:
+		a.tracklist.Scroll(amount)
+	}
+}
+
+var _ CanShowNowPlaying = (*AdvancedSearchPage)(nil)
+
+func (a *AdvancedSearchPage) OnSongChange(song, lastScrobbledIfAny *mediaprovider.Track) {
+	a.nowPlayingID = sharedutil.TrackIDOrEmptyStr(song)
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.tracklist.IncrementPlayCount(sharedutil.TrackIDOrEmptyStr(lastScrobbledIfAny))
+}
+
+var _ CanSelectAll = (*AdvancedSearchPage)(nil)
+
+func (a *AdvancedSearchPage) SelectAll() {
+	if a.toggleBtns.ActivatedButtonIndex() == 2 /*tracks*/ {
+		a.tracklist.SelectAll()
+	}
+}
+
+func (a *AdvancedSearchPage) onShowAlbums() {
+	a.container.Objects[0] = a.albumGrid
+	a.Refresh()
+}
+
+func (a *AdvancedSearchPage) onShowArtists() {
+	a.container.Objects[0] = a.artistGrid
+	a.Refresh()
+}
+
+func (a *AdvancedSearchPage) onShowTracks() {
+	a.container.Objects[0] = a.tracklistCtr
+	a.Refresh()
+}
+
+func buildAlbumGridViewModel(albums []*mediaprovider.Album) []widgets.GridViewItemModel {
+	return sharedutil.MapSlice(albums, func(al *mediaprovider.Album) widgets.GridViewItemModel {
+		return widgets.GridViewItemModel{
+			Name:         al.Name,
+			ID:           al.ID,
+			CoverArtID:   al.CoverArtID,
+			Secondary:    al.ArtistNames,
+			SecondaryIDs: al.ArtistIDs,
+
+			ArtworkArtist: sharedutil.FirstOrEmptyStr(al.ArtistNames),
+			ArtworkAlbum:  al.Name,
+		}
+	})
+}
+
+func (a *AdvancedSearchPage) CreateRenderer() fyne.WidgetRenderer {
+	a.ExtendBaseWidget(a)
+	return widget.NewSimpleRenderer(a.container)
+}
+
+func (a *AdvancedSearchPage) Save() SavedPage {
+	a.disposed = true
+	s := &savedAdvancedSearchPage{
+		pool:            a.pool,
+		contr:           a.contr,
+		mp:              a.mp,
+		im:              a.im,
+		query:           a.query,
+		results:         a.results,
+		nowPlayingID:    a.nowPlayingID,
+		activeToggleBtn: a.toggleBtns.ActivatedButtonIndex(),
+	}
+	a.albumGrid.Clear()
+	a.pool.Release(util.WidgetTypeGridView, a.albumGrid)
+	a.artistGrid.Clear()
+	a.pool.Release(util.WidgetTypeGridView, a.artistGrid)
+	a.tracklist.Clear()
+	a.pool.Release(util.WidgetTypeTracklist, a.tracklist)
+	return s
+}
+
+type savedAdvancedSearchPage struct {
+	pool            *util.WidgetPool
+	contr           *controller.Controller
+	mp              mediaprovider.MediaProvider
+	im              *backend.ImageManager
+	query           string
+	results         *backend.AdvancedSearchResults
+	nowPlayingID    string
+	activeToggleBtn int
+}
+
+func (s *savedAdvancedSearchPage) Restore() Page {
+	a := &AdvancedSearchPage{
+		pool:         s.pool,
+		contr:        s.contr,
+		mp:           s.mp,
+		im:           s.im,
+		query:        s.query,
+		nowPlayingID: s.nowPlayingID,
+	}
+	a.ExtendBaseWidget(a)
+	a.build(s.activeToggleBtn, s.results)
+	return a
+}
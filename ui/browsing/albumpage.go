@@ -94,10 +94,9 @@ func newAlbumPage(
 	}
 	a.tracklist.SetVisibleColumns(a.cfg.TracklistColumns)
 	a.tracklist.SetSorting(sort)
-	_, canRate := a.mp.(mediaprovider.SupportsRating)
-	_, canShare := a.mp.(mediaprovider.SupportsSharing)
-	a.tracklist.Options.DisableRating = !canRate
-	a.tracklist.Options.DisableSharing = !canShare
+	caps := a.contr.App.ServerManager.Capabilities()
+	a.tracklist.Options.DisableRating = !caps.Rating
+	a.tracklist.Options.DisableSharing = !caps.Sharing
 	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
 		a.cfg.TracklistColumns = cols
 	}
@@ -165,7 +164,10 @@ func (a *AlbumPage) load() {
 		return
 	}
 	a.header.Update(album, a.im)
-	a.tracklist.Options.ShowDiscNumber = len(album.Tracks) > 0 && album.Tracks[0].DiscNumber != album.Tracks[len(album.Tracks)-1].DiscNumber
+	multiDisc := len(album.Tracks) > 0 && album.Tracks[0].DiscNumber != album.Tracks[len(album.Tracks)-1].DiscNumber
+	a.tracklist.Options.ShowDiscNumber = multiDisc
+	a.tracklist.Options.GroupByDisc = multiDisc
+	a.tracklist.Options.DiscTitles = album.DiscTitles
 	a.tracks = album.Tracks
 	a.tracklist.SetTracks(album.Tracks)
 	a.tracklist.SetNowPlaying(a.nowPlayingID)
@@ -257,8 +259,7 @@ func NewAlbumPageHeader(page *AlbumPage) *AlbumPageHeader {
 			menu := fyne.NewMenu("", queue, playlist, download, info, a.shareMenuItem)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
-		_, canShare := page.mp.(mediaprovider.SupportsSharing)
-		a.shareMenuItem.Disabled = !canShare
+		a.shareMenuItem.Disabled = !page.contr.App.ServerManager.Capabilities().Sharing
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
 		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
 	}
@@ -41,6 +41,7 @@ func (a *albumsPageAdapter) Filter() mediaprovider.AlbumFilter {
 func (a *albumsPageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Album, mediaprovider.AlbumFilterOptions] {
 	if a.filterBtn == nil {
 		a.filterBtn = widgets.NewAlbumFilterButton(a.Filter(), a.mp.GetGenres)
+		a.filterBtn.MoodDisabled = !a.contr.App.ServerManager.Capabilities().Moods
 	}
 	return a.filterBtn
 }
@@ -73,5 +74,6 @@ func (a *albumsPageAdapter) SearchIter(query string, filter mediaprovider.AlbumF
 }
 
 func (a *albumsPageAdapter) ConnectGridActions(gv *widgets.GridView) {
+	gv.DisableSharing = !a.contr.App.ServerManager.Capabilities().Sharing
 	a.contr.ConnectAlbumGridActions(gv)
 }
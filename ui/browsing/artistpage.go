@@ -47,6 +47,7 @@ type ArtistPage struct {
 	tracklistCtr *fyne.Container
 	nowPlayingID string
 	header       *ArtistPageHeader
+	backdrop     *widgets.Backdrop
 	container    *fyne.Container
 }
 
@@ -94,6 +95,7 @@ func newArtistPage(artistID string, cfg *backend.ArtistPageConfig, pool *util.Wi
 		container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 15, PadBottom: 10}, a.header),
 		nil, nil, nil,
 		container.NewBorder(viewToggleRow, nil, nil, nil, layout.NewSpacer()))
+	a.backdrop = widgets.NewBackdrop()
 	go a.load()
 	return a
 }
@@ -182,6 +184,9 @@ func (a *ArtistPage) load() {
 		log.Printf("Failed to get artist info: %s", err.Error())
 	}
 	a.header.UpdateInfo(info)
+	if img, ok := a.im.GetArtistBackdrop(a.artistID, artist.Name); ok && !a.disposed {
+		a.backdrop.SetImage(img)
+	}
 }
 
 func (a *ArtistPage) showAlbumGrid() {
@@ -197,6 +202,9 @@ func (a *ArtistPage) showAlbumGrid() {
 				ID:         al.ID,
 				CoverArtID: al.CoverArtID,
 				Secondary:  []string{strconv.Itoa(al.Year)},
+
+				ArtworkArtist: a.artistInfo.Name,
+				ArtworkAlbum:  al.Name,
 			}
 		})
 		if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
@@ -236,10 +244,9 @@ func (a *ArtistPage) showTopTracks() {
 			tl = widgets.NewTracklist(ts)
 		}
 		tl.Options = widgets.TracklistOptions{AutoNumber: true}
-		_, canRate := a.mp.(mediaprovider.SupportsRating)
-		_, canShare := a.mp.(mediaprovider.SupportsSharing)
-		tl.Options.DisableRating = !canRate
-		tl.Options.DisableSharing = !canShare
+		caps := a.contr.App.ServerManager.Capabilities()
+		tl.Options.DisableRating = !caps.Rating
+		tl.Options.DisableSharing = !caps.Sharing
 		tl.SetVisibleColumns(a.cfg.TracklistColumns)
 		tl.SetSorting(a.trackSort)
 		tl.OnVisibleColumnsChanged = func(cols []string) {
@@ -273,7 +280,7 @@ func (a *ArtistPage) onViewChange(num int) {
 
 func (a *ArtistPage) CreateRenderer() fyne.WidgetRenderer {
 	a.ExtendBaseWidget(a)
-	return widget.NewSimpleRenderer(a.container)
+	return widget.NewSimpleRenderer(container.NewStack(a.backdrop, a.container))
 }
 
 func (s *artistPageState) Restore() Page {
@@ -288,6 +295,7 @@ type ArtistPageHeader struct {
 	artistID       string
 	artistPage     *ArtistPage
 	artistImage    *widgets.ImagePlaceholder
+	haveLocalImage bool // artist image is from the local artwork directory
 	titleDisp      *widget.RichText
 	biographyDisp  *widgets.MaxRowsLabel
 	similarArtists *fyne.Container
@@ -370,6 +378,7 @@ func (a *ArtistPageHeader) Clear() {
 		obj.Hide()
 	}
 	a.artistImage.SetImage(nil, false)
+	a.haveLocalImage = false
 }
 
 func (a *ArtistPageHeader) Update(artist *mediaprovider.ArtistWithAlbums) {
@@ -381,6 +390,11 @@ func (a *ArtistPageHeader) Update(artist *mediaprovider.ArtistWithAlbums) {
 	a.artistID = artist.ID
 	a.titleDisp.Segments[0].(*widget.TextSegment).Text = artist.Name
 	a.titleDisp.Refresh()
+	if im, ok := a.artistPage.im.GetLocalArtistImage(artist.Name); ok {
+		a.haveLocalImage = true
+		a.artistImage.SetImage(im, true /*tappable*/)
+		return
+	}
 	if artist.CoverArtID == "" {
 		return
 	}
@@ -425,7 +439,7 @@ func (a *ArtistPageHeader) UpdateInfo(info *mediaprovider.ArtistInfo) {
 	}
 	a.similarArtists.Refresh()
 
-	if info.ImageURL != "" {
+	if info.ImageURL != "" && !a.haveLocalImage {
 		if a.artistImage.HaveImage() {
 			_ = a.artistPage.im.RefreshCachedArtistImageIfExpired(a.artistID, info.ImageURL)
 		} else {
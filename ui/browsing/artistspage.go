@@ -14,11 +14,12 @@ import (
 )
 
 type artistsPageAdapter struct {
-	cfg    *backend.ArtistsPageConfig
-	contr  *controller.Controller
-	mp     mediaprovider.MediaProvider
-	pm     *backend.PlaybackManager
-	filter mediaprovider.ArtistFilter
+	cfg       *backend.ArtistsPageConfig
+	contr     *controller.Controller
+	mp        mediaprovider.MediaProvider
+	pm        *backend.PlaybackManager
+	filter    mediaprovider.ArtistFilter
+	filterBtn *widgets.ArtistFilterButton
 }
 
 func NewArtistsPage(cfg *backend.ArtistsPageConfig, pool *util.WidgetPool, contr *controller.Controller, pm *backend.PlaybackManager, mp mediaprovider.MediaProvider, im *backend.ImageManager) Page {
@@ -38,7 +39,11 @@ func (a *artistsPageAdapter) Filter() mediaprovider.ArtistFilter {
 }
 
 func (a *artistsPageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Artist, mediaprovider.ArtistFilterOptions] {
-	return nil
+	if a.filterBtn == nil {
+		a.filterBtn = widgets.NewArtistFilterButton(a.Filter(), a.mp.GetGenres)
+		a.filterBtn.HasImageDisabled = !a.contr.App.ServerManager.Capabilities().ArtistImages
+	}
+	return a.filterBtn
 }
 
 func (a *artistsPageAdapter) PlaceholderResource() fyne.Resource { return myTheme.ArtistIcon }
@@ -69,10 +74,6 @@ func (a *artistsPageAdapter) SearchIter(query string, filter mediaprovider.Artis
 }
 
 func (a *artistsPageAdapter) ConnectGridActions(gv *widgets.GridView) {
-	canShareArtists := false
-	if r, canShare := a.mp.(mediaprovider.SupportsSharing); canShare {
-		canShareArtists = r.CanShareArtists()
-	}
-	gv.DisableSharing = !canShareArtists
+	gv.DisableSharing = !a.contr.App.ServerManager.Capabilities().ShareArtists
 	a.contr.ConnectArtistGridActions(gv)
 }
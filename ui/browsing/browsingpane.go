@@ -1,6 +1,8 @@
 package browsing
 
 import (
+	"log"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
@@ -8,9 +10,11 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/controller"
 	"github.com/dweymouth/supersonic/ui/layouts"
 	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/widgets"
 )
 
 type Page interface {
@@ -56,7 +60,8 @@ type CanShowPlayQueue interface {
 type BrowsingPane struct {
 	widget.BaseWidget
 
-	app *backend.App
+	app   *backend.App
+	contr *controller.Controller
 
 	curPage Page
 
@@ -69,14 +74,17 @@ type BrowsingPane struct {
 
 	settingsBtn      *widget.Button
 	settingsMenu     *fyne.Menu
+	settingsItems    []*fyne.MenuItem // all items, including hidden ones
+	hiddenSettings   map[*fyne.MenuItem]bool
 	navBtnsContainer *fyne.Container
+	sidebar          *pinnedPlaylistsSidebar
 	pageContainer    *fyne.Container
 	container        *fyne.Container
 	navBtnsPageMap   map[controller.PageName]fyne.Resource
 }
 
 func NewBrowsingPane(app *backend.App, contr *controller.Controller) *BrowsingPane {
-	b := &BrowsingPane{app: app}
+	b := &BrowsingPane{app: app, contr: contr}
 	b.ExtendBaseWidget(b)
 	b.home = widget.NewButtonWithIcon("", theme.HomeIcon(), b.GoHome)
 	b.back = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), b.GoBack)
@@ -97,12 +105,15 @@ func NewBrowsingPane(app *backend.App, contr *controller.Controller) *BrowsingPa
 	b.settingsMenu = fyne.NewMenu("")
 	b.navBtnsContainer = container.NewHBox()
 	b.navBtnsPageMap = map[controller.PageName]fyne.Resource{}
+	b.sidebar = newPinnedPlaylistsSidebar(contr)
+	b.sidebar.Hidden = true
+	widgets.RegisterDropTarget(b.sidebar)
 	b.container = container.NewBorder(container.New(
 		&layouts.MaxPadLayout{PadLeft: -5, PadRight: -5},
 		container.New(layouts.NewLeftMiddleRightLayout(0),
 			container.NewHBox(b.home, b.back, b.forward, b.reload), b.navBtnsContainer,
 			container.NewHBox(layout.NewSpacer(), quickSearchBtn, b.settingsBtn))),
-		nil, nil, nil, b.pageContainer)
+		nil, b.sidebar, nil, b.pageContainer)
 	b.updateHistoryButtons()
 	return b
 }
@@ -130,14 +141,143 @@ func (b *BrowsingPane) ClearHistory() {
 	b.updateHistoryButtons()
 }
 
-func (b *BrowsingPane) AddSettingsMenuItem(label string, action func()) {
-	b.settingsMenu.Items = append(b.settingsMenu.Items,
-		fyne.NewMenuItem(label, action))
+func (b *BrowsingPane) AddSettingsMenuItem(label string, action func()) *fyne.MenuItem {
+	item := fyne.NewMenuItem(label, action)
+	b.settingsItems = append(b.settingsItems, item)
+	b.settingsMenu.Items = append(b.settingsMenu.Items, item)
+	return item
 }
 
 func (b *BrowsingPane) AddSettingsMenuSeparator() {
-	b.settingsMenu.Items = append(b.settingsMenu.Items,
-		fyne.NewMenuItemSeparator())
+	item := fyne.NewMenuItemSeparator()
+	b.settingsItems = append(b.settingsItems, item)
+	b.settingsMenu.Items = append(b.settingsMenu.Items, item)
+}
+
+// SetSettingsMenuItemHidden shows or hides an item added to the settings menu.
+func (b *BrowsingPane) SetSettingsMenuItemHidden(item *fyne.MenuItem, hidden bool) {
+	if b.hiddenSettings == nil {
+		b.hiddenSettings = make(map[*fyne.MenuItem]bool)
+	}
+	b.hiddenSettings[item] = hidden
+	b.settingsMenu.Items = sharedutil.FilterSlice(b.settingsItems, func(i *fyne.MenuItem) bool {
+		return !b.hiddenSettings[i]
+	})
+}
+
+// SetPinnedPlaylists sets the playlists shown in the pinned playlists
+// sidebar. The sidebar is hidden if there are no pinned playlists.
+func (b *BrowsingPane) SetPinnedPlaylists(pins []backend.PinnedPlaylist) {
+	b.sidebar.SetPinnedPlaylists(pins)
+	b.sidebar.Hidden = len(pins) == 0
+	b.container.Refresh()
+}
+
+// pinnedPlaylistsSidebar lists the pinned playlists, and adds
+// tracks dragged onto a playlist's button to the playlist.
+type pinnedPlaylistsSidebar struct {
+	widget.BaseWidget
+
+	contr     *controller.Controller
+	pins      []backend.PinnedPlaylist
+	buttons   *fyne.Container
+	dropIdx   int // index of the playlist being dragged over, or -1
+	container *fyne.Container
+}
+
+var _ widgets.DropTarget = (*pinnedPlaylistsSidebar)(nil)
+
+func newPinnedPlaylistsSidebar(contr *controller.Controller) *pinnedPlaylistsSidebar {
+	s := &pinnedPlaylistsSidebar{contr: contr, dropIdx: -1}
+	s.ExtendBaseWidget(s)
+	s.buttons = container.NewVBox()
+	title := widget.NewLabel("Pinned playlists")
+	title.TextStyle.Bold = true
+	s.container = container.NewBorder(title, nil, nil, nil, container.NewVScroll(s.buttons))
+	return s
+}
+
+func (s *pinnedPlaylistsSidebar) SetPinnedPlaylists(pins []backend.PinnedPlaylist) {
+	s.pins = pins
+	s.dropIdx = -1
+	s.buttons.RemoveAll()
+	for _, pin := range pins {
+		id := pin.ID
+		name := pin.Name
+		if r := []rune(name); len(r) > 28 {
+			name = string(r[:27]) + "…"
+		}
+		btn := widget.NewButtonWithIcon(name, myTheme.PlaylistIcon, func() {
+			s.contr.NavigateTo(controller.PlaylistRoute(id))
+		})
+		btn.Importance = widget.LowImportance
+		btn.Alignment = widget.ButtonAlignLeading
+		s.buttons.Add(btn)
+	}
+}
+
+func (s *pinnedPlaylistsSidebar) DragOver(data *widgets.DragData, pos fyne.Position) string {
+	d := fyne.CurrentApp().Driver()
+	absPos := d.AbsolutePositionForObject(s).Add(pos)
+	idx := -1
+	for i, obj := range s.buttons.Objects {
+		origin := d.AbsolutePositionForObject(obj)
+		size := obj.Size()
+		if absPos.Y >= origin.Y && absPos.Y < origin.Y+size.Height {
+			idx = i
+			break
+		}
+	}
+	// smart playlists' tracks are determined by their rules
+	if idx >= 0 && backend.IsSmartPlaylistID(s.pins[idx].ID) {
+		idx = -1
+	}
+	s.setDropIdx(idx)
+	if idx < 0 {
+		return ""
+	}
+	return "Add to " + s.pins[idx].Name
+}
+
+func (s *pinnedPlaylistsSidebar) DragLeave() {
+	s.setDropIdx(-1)
+}
+
+func (s *pinnedPlaylistsSidebar) Drop(data *widgets.DragData, _ fyne.Position) {
+	if s.dropIdx < 0 {
+		return
+	}
+	playlistID := s.pins[s.dropIdx].ID
+	s.setDropIdx(-1)
+	go func() {
+		tracks, err := data.Tracks()
+		if err != nil {
+			log.Printf("error loading dropped tracks: %s", err.Error())
+			return
+		}
+		s.contr.AddTracksToPlaylist(playlistID, sharedutil.TracksToIDs(tracks))
+	}()
+}
+
+// highlights the button of the playlist being dragged over
+func (s *pinnedPlaylistsSidebar) setDropIdx(idx int) {
+	if idx == s.dropIdx {
+		return
+	}
+	for i, obj := range s.buttons.Objects {
+		btn := obj.(*widget.Button)
+		if i == idx {
+			btn.Importance = widget.HighImportance
+		} else {
+			btn.Importance = widget.LowImportance
+		}
+	}
+	s.dropIdx = idx
+	s.buttons.Refresh()
+}
+
+func (s *pinnedPlaylistsSidebar) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
 }
 
 func (b *BrowsingPane) AddNavigationButton(icon fyne.Resource, pageName controller.PageName, action func()) {
@@ -0,0 +1,271 @@
+package browsing
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/layouts"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type ComposersPage struct {
+	widget.BaseWidget
+
+	contr     *controller.Controller
+	mp        mediaprovider.MediaProvider
+	composers []*mediaprovider.Composer
+	list      *ComposerList
+
+	titleDisp *widget.RichText
+	message   *widget.Label
+	container *fyne.Container
+	searcher  *widgets.SearchEntry
+}
+
+func NewComposersPage(contr *controller.Controller, mp mediaprovider.MediaProvider) *ComposersPage {
+	return newComposersPage(contr, mp, "", widgets.ListHeaderSort{})
+}
+
+func newComposersPage(contr *controller.Controller, mp mediaprovider.MediaProvider, searchText string, sorting widgets.ListHeaderSort) *ComposersPage {
+	a := &ComposersPage{
+		contr:     contr,
+		mp:        mp,
+		titleDisp: widget.NewRichTextWithText("Composers"),
+		message:   widget.NewLabel(""),
+	}
+	a.ExtendBaseWidget(a)
+	a.titleDisp.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+	a.message.Alignment = fyne.TextAlignCenter
+	a.message.Hidden = true
+	a.list = NewComposerList(sorting)
+	a.list.OnNavTo = func(name string) { a.contr.NavigateTo(controller.ComposerTracksRoute(name)) }
+	a.searcher = widgets.NewSearchEntry()
+	a.searcher.PlaceHolder = "Search page"
+	a.searcher.OnSearched = a.onSearched
+	a.searcher.Entry.Text = searchText
+	a.buildContainer()
+	go a.load(searchText != "")
+	return a
+}
+
+// should be called asynchronously
+func (a *ComposersPage) load(searchOnLoad bool) {
+	cp, ok := a.mp.(mediaprovider.ComposerProvider)
+	if !a.contr.App.ServerManager.Capabilities().Composers || !ok {
+		a.showMessage("Browsing by composer is not supported by this server")
+		return
+	}
+	composers, err := cp.GetComposers()
+	if err != nil {
+		log.Printf("error loading composers: %v", err.Error())
+	}
+	sort.SliceStable(composers, func(i, j int) bool {
+		return strings.ToLower(composers[i].Name) < strings.ToLower(composers[j].Name)
+	})
+	a.composers = composers
+	if len(composers) == 0 {
+		a.showMessage("No composers found")
+		return
+	}
+	a.message.Hidden = true
+	a.list.Hidden = false
+	if searchOnLoad {
+		a.onSearched(a.searcher.Entry.Text)
+	} else {
+		a.list.SetComposers(a.composers)
+		a.list.Refresh()
+	}
+}
+
+func (a *ComposersPage) showMessage(msg string) {
+	a.message.Text = msg
+	a.message.Hidden = false
+	a.list.Hidden = true
+	a.Refresh()
+}
+
+func (a *ComposersPage) onSearched(query string) {
+	// the composers list is returned in full non-paginated, so we will do our own
+	// simple search based on the composer name, rather than calling a server API
+	if query == "" {
+		a.list.SetComposers(a.composers)
+	} else {
+		query = strings.ToLower(query)
+		result := sharedutil.FilterSlice(a.composers, func(x *mediaprovider.Composer) bool {
+			return strings.Contains(strings.ToLower(x.Name), query)
+		})
+		a.list.SetComposers(result)
+	}
+	a.list.Refresh()
+}
+
+var _ Searchable = (*ComposersPage)(nil)
+
+func (a *ComposersPage) SearchWidget() fyne.Focusable {
+	return a.searcher
+}
+
+var _ Scrollable = (*ComposersPage)(nil)
+
+func (a *ComposersPage) Scroll(amount float32) {
+	a.list.list.ScrollToOffset(a.list.list.GetScrollOffset() + amount)
+}
+
+func (a *ComposersPage) Route() controller.Route {
+	return controller.ComposersRoute()
+}
+
+func (a *ComposersPage) Reload() {
+	go a.load(false)
+}
+
+func (a *ComposersPage) Save() SavedPage {
+	return &savedComposersPage{
+		contr:      a.contr,
+		mp:         a.mp,
+		searchText: a.searcher.Entry.Text,
+		sorting:    a.list.sorting,
+	}
+}
+
+type savedComposersPage struct {
+	contr      *controller.Controller
+	mp         mediaprovider.MediaProvider
+	searchText string
+	sorting    widgets.ListHeaderSort
+}
+
+func (s *savedComposersPage) Restore() Page {
+	return newComposersPage(s.contr, s.mp, s.searchText, s.sorting)
+}
+
+func (a *ComposersPage) buildContainer() {
+	searchVbox := container.NewVBox(layout.NewSpacer(), a.searcher, layout.NewSpacer())
+	a.container = container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
+		container.NewBorder(
+			container.New(&layouts.MaxPadLayout{PadLeft: -5},
+				container.NewHBox(a.titleDisp, layout.NewSpacer(), searchVbox)),
+			nil, nil, nil, container.NewStack(a.list, container.NewCenter(a.message))))
+}
+
+func (a *ComposersPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+type ComposerList struct {
+	widget.BaseWidget
+
+	OnNavTo func(string)
+
+	sorting            widgets.ListHeaderSort
+	composers          []*mediaprovider.Composer
+	composersOrigOrder []*mediaprovider.Composer
+
+	columnsLayout *layouts.ColumnsLayout
+	hdr           *widgets.ListHeader
+	list          *widgets.FocusList
+	container     *fyne.Container
+}
+
+type ComposerListRow struct {
+	widgets.FocusListRowBase
+
+	Item *mediaprovider.Composer
+
+	nameLabel *widget.Label
+}
+
+func NewComposerListRow(layout *layouts.ColumnsLayout) *ComposerListRow {
+	a := &ComposerListRow{
+		nameLabel: widget.NewLabel(""),
+	}
+	a.ExtendBaseWidget(a)
+	a.Content = container.New(layout, a.nameLabel)
+	return a
+}
+
+func NewComposerList(sorting widgets.ListHeaderSort) *ComposerList {
+	a := &ComposerList{
+		sorting:       sorting,
+		columnsLayout: layouts.NewColumnsLayout([]float32{-1}),
+	}
+	a.ExtendBaseWidget(a)
+	a.hdr = widgets.NewListHeader([]widgets.ListColumn{
+		{Text: "Name", Alignment: fyne.TextAlignLeading, CanToggleVisible: false}},
+		a.columnsLayout)
+	a.hdr.SetSorting(sorting)
+	a.hdr.OnColumnSortChanged = a.onSorted
+	a.list = widgets.NewFocusList(
+		func() int { return len(a.composers) },
+		func() fyne.CanvasObject {
+			r := NewComposerListRow(a.columnsLayout)
+			r.OnTapped = func() { a.onGoToComposer(r.Item) }
+			r.OnFocusNeighbor = func(up bool) {
+				a.list.FocusNeighbor(r.ItemID(), up)
+			}
+			return r
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*ComposerListRow)
+			a.list.SetItemForID(id, row)
+			if row.Item != a.composers[id] {
+				row.EnsureUnfocused()
+				row.ListItemID = id
+				row.Item = a.composers[id]
+				row.nameLabel.Text = row.Item.Name
+				row.Refresh()
+			}
+		},
+	)
+	a.container = container.NewBorder(a.hdr, nil, nil, nil, a.list)
+	return a
+}
+
+func (c *ComposerList) SetComposers(composers []*mediaprovider.Composer) {
+	c.composersOrigOrder = composers
+	c.doSortComposers()
+	c.Refresh()
+}
+
+func (c *ComposerList) onSorted(sort widgets.ListHeaderSort) {
+	c.sorting = sort
+	c.doSortComposers()
+	c.Refresh()
+}
+
+func (c *ComposerList) doSortComposers() {
+	if c.sorting.Type == widgets.SortNone {
+		c.composers = c.composersOrigOrder
+		return
+	}
+	new := make([]*mediaprovider.Composer, len(c.composersOrigOrder))
+	copy(new, c.composersOrigOrder)
+	sort.SliceStable(new, func(i, j int) bool {
+		cmp := strings.Compare(strings.ToLower(new[i].Name), strings.ToLower(new[j].Name))
+		if c.sorting.Type == widgets.SortDescending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	c.composers = new
+}
+
+func (c *ComposerList) onGoToComposer(item *mediaprovider.Composer) {
+	if c.OnNavTo != nil {
+		c.OnNavTo(item.Name)
+	}
+}
+
+func (c *ComposerList) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.container)
+}
@@ -99,6 +99,7 @@ func (a *FavoritesPage) createHeader(activeBtnIdx int) {
 	a.searcher.Entry.Text = a.searchText
 	a.filterBtn = widgets.NewAlbumFilterButton(a.filter, a.mp.GetGenres)
 	a.filterBtn.FavoriteDisabled = true
+	a.filterBtn.MoodDisabled = !a.contr.App.ServerManager.Capabilities().Moods
 	a.filterBtn.OnChanged = a.Reload
 }
 
@@ -338,11 +339,7 @@ func (a *FavoritesPage) onShowFavoriteArtists() {
 			} else {
 				a.artistGrid = widgets.NewFixedGridView(model, a.im, myTheme.ArtistIcon)
 			}
-			canShareArtists := false
-			if r, canShare := a.mp.(mediaprovider.SupportsSharing); canShare {
-				canShareArtists = r.CanShareArtists()
-			}
-			a.artistGrid.DisableSharing = !canShareArtists
+			a.artistGrid.DisableSharing = !a.contr.App.ServerManager.Capabilities().ShareArtists
 			a.contr.ConnectArtistGridActions(a.artistGrid)
 			a.container.Objects[0] = a.artistGrid
 			a.Refresh()
@@ -366,6 +363,8 @@ func buildArtistGridViewModel(artists []*mediaprovider.Artist) []widgets.GridVie
 			CoverArtID: ar.CoverArtID,
 			Name:       ar.Name,
 			Secondary:  []string{fmt.Sprintf("%d %s", ar.AlbumCount, albums)},
+
+			ArtworkArtist: ar.Name,
 		})
 	}
 	return model
@@ -401,10 +400,9 @@ func (a *FavoritesPage) onShowFavoriteSongs() {
 				tracklist = widgets.NewTracklist(fav.Tracks)
 			}
 			tracklist.Options = widgets.TracklistOptions{AutoNumber: true}
-			_, canRate := a.mp.(mediaprovider.SupportsRating)
-			_, canShare := a.mp.(mediaprovider.SupportsSharing)
-			tracklist.Options.DisableRating = !canRate
-			tracklist.Options.DisableSharing = !canShare
+			caps := a.contr.App.ServerManager.Capabilities()
+			tracklist.Options.DisableRating = !caps.Rating
+			tracklist.Options.DisableSharing = !caps.Sharing
 			tracklist.SetVisibleColumns(a.cfg.TracklistColumns)
 			tracklist.SetSorting(a.trackSort)
 			tracklist.OnVisibleColumnsChanged = func(cols []string) {
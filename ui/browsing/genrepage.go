@@ -43,6 +43,7 @@ func (g *genrePageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Alb
 	if g.filterBtn == nil {
 		g.filterBtn = widgets.NewAlbumFilterButton(g.Filter(), func() ([]*mediaprovider.Genre, error) { return nil, nil })
 		g.filterBtn.GenreDisabled = true
+		g.filterBtn.MoodDisabled = !g.contr.App.ServerManager.Capabilities().Moods
 	}
 	return g.filterBtn
 }
@@ -69,5 +70,6 @@ func (a *genrePageAdapter) SearchIter(query string, filter mediaprovider.AlbumFi
 }
 
 func (g *genrePageAdapter) ConnectGridActions(gv *widgets.GridView) {
+	gv.DisableSharing = !g.contr.App.ServerManager.Capabilities().Sharing
 	g.contr.ConnectAlbumGridActions(gv)
 }
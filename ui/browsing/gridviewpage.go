@@ -114,7 +114,6 @@ func NewGridViewPage[M, F any](
 	gp.ExtendBaseWidget(gp)
 	gp.createTitleAndSort()
 
-	_, canShare := mp.(mediaprovider.SupportsSharing)
 	iter := adapter.Iter(gp.getSortOrder(), gp.getFilter())
 	if g := pool.Obtain(util.WidgetTypeGridView); g != nil {
 		gp.grid = g.(*widgets.GridView)
@@ -123,7 +122,6 @@ func NewGridViewPage[M, F any](
 	} else {
 		gp.grid = widgets.NewGridView(iter, im, adapter.PlaceholderResource())
 	}
-	gp.grid.DisableSharing = !canShare
 	adapter.ConnectGridActions(gp.grid)
 	gp.createSearchAndFilter()
 	gp.createContainer()
@@ -36,6 +36,7 @@ type NowPlayingPage struct {
 	lyricsViewer    *widgets.LyricsViewer
 	imageLoadCancel context.CancelFunc
 	card            *widgets.LargeNowPlayingCard
+	backdrop        *widgets.Backdrop
 	statusLabel     *widget.Label
 	totalTime       float64
 	nowPlayingID    string
@@ -74,6 +75,7 @@ func NewNowPlayingPage(
 	pm.OnStopped(a.formatStatusLine)
 
 	a.card = widgets.NewLargeNowPlayingCard()
+	a.backdrop = widgets.NewBackdrop()
 	a.card.DisableRating = !canRate
 	a.card.OnAlbumNameTapped = func() {
 		contr.NavigateTo(controller.AlbumRoute(a.albumID))
@@ -92,6 +94,9 @@ func NewNowPlayingPage(
 	a.queueList.DisableRating = !canRate
 	a.queueList.DisableSharing = !canShare
 	a.queueList.OnReorderTracks = a.doSetNewTrackOrder
+	a.queueList.OnMoveTracks = func(idxs []int, insertIdx int) {
+		a.pm.UpdatePlayQueue(sharedutil.MoveTracks(a.queue, idxs, insertIdx))
+	}
 	a.queueList.OnDownload = contr.ShowDownloadDialog
 	a.queueList.OnShare = func(tracks []*mediaprovider.Track) {
 		if len(tracks) > 0 {
@@ -154,6 +159,7 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 			container.New(paddedLayout,
 				util.AddHeaderBackground(tabs)))
 		a.container = container.NewStack(
+			a.backdrop,
 			mainContent,
 			container.NewVBox(
 				layout.NewSpacer(),
@@ -192,6 +198,7 @@ func (a *NowPlayingPage) OnSongChange(song, lastScrobbledIfAny *mediaprovider.Tr
 	a.card.Update(song)
 	if song == nil {
 		a.card.SetCoverImage(nil)
+		a.backdrop.SetImage(nil)
 		return
 	}
 
@@ -204,7 +211,14 @@ func (a *NowPlayingPage) OnSongChange(song, lastScrobbledIfAny *mediaprovider.Tr
 	})
 
 	go func() {
-		if lp, ok := a.sm.Server.(mediaprovider.LyricsProvider); ok {
+		img, _ := a.im.GetCoverBackdrop(song.CoverArtID, sharedutil.FirstOrEmptyStr(song.ArtistNames))
+		if a.nowPlayingID == song.ID {
+			a.backdrop.SetImage(img)
+		}
+	}()
+
+	go func() {
+		if lp, ok := a.sm.Server.(mediaprovider.LyricsProvider); ok && a.sm.Capabilities().Lyrics {
 			lyrics, err := lp.GetLyrics(song)
 			if err == nil {
 				a.lyricsViewer.SetLyrics(lyrics)
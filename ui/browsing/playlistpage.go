@@ -86,17 +86,20 @@ func newPlaylistPage(
 	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
 		conf.TracklistColumns = cols
 	}
-	_, canRate := a.sm.Server.(mediaprovider.SupportsRating)
-	_, canShare := a.sm.Server.(mediaprovider.SupportsSharing)
+	caps := a.sm.Capabilities()
 	remove := fyne.NewMenuItem("Remove from playlist", a.onRemoveSelectedFromPlaylist)
 	remove.Icon = theme.ContentClearIcon()
 	a.tracklist.Options = widgets.TracklistOptions{
-		DisableRating:  !canRate,
-		DisableSharing: !canShare,
-		AuxiliaryMenuItems: []*fyne.MenuItem{
+		DisableRating:  !caps.Rating,
+		DisableSharing: !caps.Sharing,
+	}
+	if !a.isSmartPlaylist() {
+		// smart playlist contents are determined by their rules
+		a.tracklist.Options.AuxiliaryMenuItems = []*fyne.MenuItem{
 			util.NewReorderTracksSubmenu(a.doSetNewTrackOrder),
 			remove,
-		},
+		}
+		a.tracklist.OnMoveTracks = a.doMoveTracks
 	}
 	// connect tracklist actions
 	a.contr.ConnectTracklistActions(a.tracklist)
@@ -150,9 +153,19 @@ func (a *PlaylistPage) Scroll(scrollAmt float32) {
 	a.tracklist.Scroll(scrollAmt)
 }
 
+func (a *PlaylistPage) isSmartPlaylist() bool {
+	return backend.IsSmartPlaylistID(a.playlistID)
+}
+
 // should be called asynchronously
 func (a *PlaylistPage) load() {
-	playlist, err := a.sm.Server.GetPlaylist(a.playlistID)
+	var playlist *mediaprovider.PlaylistWithTracks
+	var err error
+	if a.isSmartPlaylist() {
+		playlist, err = a.loadSmartPlaylist()
+	} else {
+		playlist, err = a.sm.Server.GetPlaylist(a.playlistID)
+	}
 	if err != nil {
 		log.Printf("Failed to get playlist: %s", err.Error())
 		return
@@ -167,6 +180,24 @@ func (a *PlaylistPage) load() {
 	a.header.Update(playlist)
 }
 
+func (a *PlaylistPage) loadSmartPlaylist() (*mediaprovider.PlaylistWithTracks, error) {
+	spm := a.contr.App.SmartPlaylistManager
+	smart, err := spm.GetSmartPlaylist(a.playlistID)
+	if err != nil {
+		return nil, err
+	}
+	tracks := spm.Evaluate(smart)
+	playlist := &mediaprovider.PlaylistWithTracks{
+		Playlist: *smart.AsPlaylist(),
+		Tracks:   tracks,
+	}
+	playlist.TrackCount = len(tracks)
+	for _, tr := range tracks {
+		playlist.Duration += tr.Duration
+	}
+	return playlist, nil
+}
+
 func renumberTracks(tracks []*mediaprovider.Track) {
 	// Playlists, like albums, have a sequential running order. We want the number column to
 	// represent the track's original position in the playlist, if the user applies a sort.
@@ -187,12 +218,22 @@ func (a *PlaylistPage) doSetNewTrackOrder(op sharedutil.TrackReorderOp) {
 			idxs = append(idxs, i)
 		}
 	}
-	newTracks := sharedutil.ReorderTracks(a.tracks, idxs, op)
+	a.setNewTrackOrder(sharedutil.ReorderTracks(a.tracks, idxs, op))
+}
+
+// drag-and-drop reordering is only enabled while the tracklist is
+// unsorted, so the indexes are already in the original run order
+func (a *PlaylistPage) doMoveTracks(idxs []int, insertIdx int) {
+	a.setNewTrackOrder(sharedutil.MoveTracks(a.tracks, idxs, insertIdx))
+}
+
+func (a *PlaylistPage) setNewTrackOrder(newTracks []*mediaprovider.Track) {
 	ids := sharedutil.TracksToIDs(newTracks)
 	if err := a.sm.Server.ReplacePlaylistTracks(a.playlistID, ids); err != nil {
 		log.Printf("error updating playlist: %s", err.Error())
 	} else {
 		renumberTracks(newTracks)
+		a.tracks = newTracks
 		// force-switch back to unsorted view to show new track order
 		a.tracklist.SetSorting(widgets.TracklistSort{})
 		a.tracklist.SetTracks(newTracks)
@@ -222,6 +263,8 @@ type PlaylistPageHeader struct {
 	image        *widgets.ImagePlaceholder
 
 	editButton       *widget.Button
+	menuPop          *widget.PopUpMenu
+	pinMenuItem      *fyne.MenuItem
 	titleLabel       *widget.RichText
 	descriptionLabel *widget.Label
 	createdAtLabel   *widget.Label
@@ -247,7 +290,14 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 	a.createdAtLabel = widget.NewLabel("")
 	a.trackTimeLabel = widget.NewLabel("")
 	a.editButton = widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
-		if a.playlistInfo != nil {
+		if a.playlistInfo == nil {
+			return
+		}
+		if a.page.isSmartPlaylist() {
+			if smart, err := a.page.contr.App.SmartPlaylistManager.GetSmartPlaylist(a.page.playlistID); err == nil {
+				a.page.contr.DoEditSmartPlaylistWorkflow(smart)
+			}
+		} else {
 			a.page.contr.DoEditPlaylistWorkflow(&a.playlistInfo.Playlist)
 		}
 	})
@@ -260,10 +310,9 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 		a.page.pm.LoadTracks(a.page.tracks, false /*append*/, true /*shuffle*/)
 		a.page.pm.PlayFromBeginning()
 	})
-	var pop *widget.PopUpMenu
 	menuBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
 	menuBtn.OnTapped = func() {
-		if pop == nil {
+		if a.menuPop == nil {
 			queue := fyne.NewMenuItem("Add to queue", func() {
 				go a.page.pm.LoadPlaylist(a.page.playlistID, true /*append*/, false /*shuffle*/)
 			})
@@ -277,11 +326,46 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 				a.page.contr.ShowDownloadDialog(a.page.tracks, a.titleLabel.String())
 			})
 			download.Icon = theme.DownloadIcon()
-			menu := fyne.NewMenu("", queue, playlist, download)
-			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
+			export := fyne.NewMenuItem("Export...", func() {
+				a.page.contr.ShowExportPlaylistDialog(a.page.tracks, a.titleLabel.String())
+			})
+			export.Icon = theme.DocumentSaveIcon()
+			a.pinMenuItem = fyne.NewMenuItem("Pin to sidebar", func() {
+				if a.playlistInfo != nil {
+					org := a.page.contr.PlaylistOrganization()
+					pinned := org != nil && org.IsPinned(a.page.playlistID)
+					a.page.contr.SetPlaylistPinned(&a.playlistInfo.Playlist, !pinned)
+				}
+			})
+			folder := fyne.NewMenuItem("Move to folder...", func() {
+				a.page.contr.DoMoveToPlaylistFolderWorkflow(a.page.playlistID)
+			})
+			folder.Icon = theme.FolderIcon()
+			menu := fyne.NewMenu("", queue, playlist, download, export,
+				fyne.NewMenuItemSeparator(), a.pinMenuItem, folder)
+			if a.page.isSmartPlaylist() {
+				save := fyne.NewMenuItem("Save as server playlist", func() {
+					a.page.contr.DoSaveSmartPlaylistToServerWorkflow(a.page.playlistID)
+				})
+				save.Icon = theme.DocumentSaveIcon()
+				menu.Items = append(menu.Items, save)
+			} else {
+				dups := fyne.NewMenuItem("Remove duplicates...", func() {
+					a.page.contr.DoRemovePlaylistDuplicatesWorkflow(a.page.playlistID, a.page.tracks)
+				})
+				dups.Icon = theme.ContentClearIcon()
+				menu.Items = append(menu.Items, dups)
+			}
+			a.menuPop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
+		if org := a.page.contr.PlaylistOrganization(); org != nil && org.IsPinned(a.page.playlistID) {
+			a.pinMenuItem.Label = "Unpin from sidebar"
+		} else {
+			a.pinMenuItem.Label = "Pin to sidebar"
+		}
+		a.menuPop.Refresh()
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
-		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
+		a.menuPop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
 	}
 
 	a.container = util.AddHeaderBackground(
@@ -301,6 +385,8 @@ func (a *PlaylistPageHeader) Clear() {
 	a.descriptionLabel.Text = ""
 	a.ownerLabel.Text = ""
 	a.image.SetImage(nil, false)
+	// menu items depend on whether the page's playlist is a smart playlist
+	a.menuPop = nil
 }
 
 func (a *PlaylistPageHeader) CreateRenderer() fyne.WidgetRenderer {
@@ -309,7 +395,7 @@ func (a *PlaylistPageHeader) CreateRenderer() fyne.WidgetRenderer {
 
 func (a *PlaylistPageHeader) Update(playlist *mediaprovider.PlaylistWithTracks) {
 	a.playlistInfo = playlist
-	a.editButton.Hidden = playlist.Owner != a.page.sm.LoggedInUser
+	a.editButton.Hidden = playlist.Owner != a.page.sm.LoggedInUser && !a.page.isSmartPlaylist()
 	a.titleLabel.Segments[0].(*widget.TextSegment).Text = playlist.Name
 	a.descriptionLabel.SetText(playlist.Description)
 	a.ownerLabel.SetText(a.formatPlaylistOwnerStr(playlist))
@@ -323,7 +409,7 @@ func (a *PlaylistPageHeader) Update(playlist *mediaprovider.PlaylistWithTracks)
 			haveCover = true
 		}
 	}
-	if !haveCover {
+	if !haveCover && !a.page.isSmartPlaylist() {
 		if im, err := a.page.im.GetCoverThumbnail(playlist.ID); err == nil && im != nil {
 			a.image.SetImage(im, false)
 		}
@@ -332,6 +418,9 @@ func (a *PlaylistPageHeader) Update(playlist *mediaprovider.PlaylistWithTracks)
 }
 
 func (a *PlaylistPageHeader) formatPlaylistOwnerStr(p *mediaprovider.PlaylistWithTracks) string {
+	if a.page.isSmartPlaylist() {
+		return p.Owner
+	}
 	pubPriv := "Public"
 	if !p.Public {
 		pubPriv = "Private"
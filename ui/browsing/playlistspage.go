@@ -3,6 +3,7 @@ package browsing
 import (
 	"fmt"
 	"log"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +25,11 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
+const (
+	allFoldersOption = "All folders"
+	unfiledOption    = "Not in a folder"
+)
+
 type PlaylistsPage struct {
 	widget.BaseWidget
 
@@ -33,8 +39,11 @@ type PlaylistsPage struct {
 	mp                mediaprovider.MediaProvider
 	playlists         []*mediaprovider.Playlist
 	searchedPlaylists []*mediaprovider.Playlist
+	folder            string
 
 	viewToggle *widgets.ToggleButtonGroup
+	sortSelect *sortOrderSelect
+	folderSel  *widget.Select
 	searcher   *widgets.SearchEntry
 	titleDisp  *widget.RichText
 	container  *fyne.Container
@@ -48,16 +57,17 @@ func NewPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *
 	if cfg.InitialView == "Grid" {
 		activeView = 1
 	}
-	return newPlaylistsPage(contr, pool, cfg, mp, "", activeView, widgets.ListHeaderSort{})
+	return newPlaylistsPage(contr, pool, cfg, mp, "", "", activeView, widgets.ListHeaderSort{})
 }
 
-func newPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *backend.PlaylistsPageConfig, mp mediaprovider.MediaProvider, searchText string, activeView int, listSort widgets.ListHeaderSort) *PlaylistsPage {
+func newPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *backend.PlaylistsPageConfig, mp mediaprovider.MediaProvider, searchText, folder string, activeView int, listSort widgets.ListHeaderSort) *PlaylistsPage {
 	a := &PlaylistsPage{
 		pool:      pool,
 		cfg:       cfg,
 		mp:        mp,
 		contr:     contr,
 		listSort:  listSort,
+		folder:    folder,
 		titleDisp: widget.NewRichTextWithText("Playlists"),
 	}
 	a.ExtendBaseWidget(a)
@@ -70,6 +80,13 @@ func newPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *
 		widget.NewButtonWithIcon("", theme.NewThemedResource(res.ResListSvg), a.showListView),
 		widget.NewButtonWithIcon("", theme.NewThemedResource(res.ResGridSvg), a.showGridView))
 	a.viewToggle.SetActivatedButton(activeView)
+	a.sortSelect = NewSortOrderSelect(backend.PlaylistSortOrders, a.onSortOrderChanged)
+	a.sortSelect.Selected = cfg.SortOrder
+	if !slices.Contains(backend.PlaylistSortOrders, cfg.SortOrder) {
+		a.sortSelect.Selected = backend.PlaylistSortName
+	}
+	a.folderSel = widget.NewSelect(nil, a.onFolderChanged)
+	a.updateFolderOptions()
 	if activeView == 0 {
 		a.createListView()
 		a.buildContainer(a.listView)
@@ -78,7 +95,7 @@ func newPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *
 		a.buildContainer(a.gridView)
 	}
 
-	go a.load(searchText != "")
+	go a.load()
 	return a
 }
 
@@ -92,17 +109,76 @@ func (p *PlaylistsPage) Scroll(scrollAmt float32) {
 	}
 }
 
-func (a *PlaylistsPage) load(searchOnLoad bool) {
+func (a *PlaylistsPage) load() {
 	playlists, err := a.mp.GetPlaylists()
 	if err != nil {
 		log.Printf("error loading playlists: %v", err.Error())
 	}
+	for _, smart := range a.contr.App.SmartPlaylistManager.GetSmartPlaylists() {
+		playlists = append(playlists, smart.AsPlaylist())
+	}
+	backend.SortPlaylists(playlists, a.sortSelect.Selected)
 	a.playlists = playlists
-	if searchOnLoad {
-		a.onSearched(a.searcher.Entry.Text)
-	} else {
-		a.refreshView(playlists)
+	// drop the organization of deleted playlists, and pick up renames of pinned ones
+	if org := a.contr.PlaylistOrganization(); org != nil && err == nil && org.Update(playlists) {
+		if a.contr.OnPinnedPlaylistsChanged != nil {
+			a.contr.OnPinnedPlaylistsChanged()
+		}
+	}
+	a.updateFolderOptions()
+	a.onSearched(a.searcher.Entry.Text)
+}
+
+func (a *PlaylistsPage) updateFolderOptions() {
+	options := []string{allFoldersOption}
+	if org := a.contr.PlaylistOrganization(); org != nil {
+		options = append(options, org.FolderNames()...)
 	}
+	options = append(options, unfiledOption)
+	a.folderSel.Options = options
+	switch {
+	case a.folder == "":
+		a.folderSel.Selected = allFoldersOption
+	case slices.Contains(options, a.folder):
+		a.folderSel.Selected = a.folder
+	default:
+		// folder no longer exists
+		a.folder = ""
+		a.folderSel.Selected = allFoldersOption
+	}
+	a.folderSel.Refresh()
+}
+
+func (a *PlaylistsPage) onFolderChanged(folder string) {
+	if folder == allFoldersOption {
+		folder = ""
+	}
+	if folder == a.folder {
+		return
+	}
+	a.folder = folder
+	a.onSearched(a.searcher.Entry.Text)
+}
+
+func (a *PlaylistsPage) onSortOrderChanged(sortOrder string) {
+	a.cfg.SortOrder = sortOrder
+	backend.SortPlaylists(a.playlists, sortOrder)
+	a.onSearched(a.searcher.Entry.Text)
+}
+
+// returns the playlists in the selected folder
+func (a *PlaylistsPage) playlistsInFolder() []*mediaprovider.Playlist {
+	org := a.contr.PlaylistOrganization()
+	if a.folder == "" || org == nil {
+		return a.playlists
+	}
+	folder := a.folder
+	if folder == unfiledOption {
+		folder = ""
+	}
+	return sharedutil.FilterSlice(a.playlists, func(p *mediaprovider.Playlist) bool {
+		return org.FolderOf(p.ID) == folder
+	})
 }
 
 func (a *PlaylistsPage) createListView() {
@@ -129,7 +205,7 @@ func (a *PlaylistsPage) createGridView(playlists []*mediaprovider.Playlist) {
 	a.gridView.OnShowSecondaryPage = nil
 	a.gridView.OnAddToPlaylist = func(id string) {
 		go func() {
-			pl, err := a.contr.App.ServerManager.Server.GetPlaylist(id)
+			pl, err := a.getPlaylist(id)
 			if err != nil {
 				log.Printf("error loading playlist: %s", err.Error())
 				return
@@ -137,9 +213,16 @@ func (a *PlaylistsPage) createGridView(playlists []*mediaprovider.Playlist) {
 			a.contr.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(pl.Tracks))
 		}()
 	}
+	a.gridView.TracksLoader = func(id string) ([]*mediaprovider.Track, error) {
+		pl, err := a.getPlaylist(id)
+		if err != nil {
+			return nil, err
+		}
+		return pl.Tracks, nil
+	}
 	a.gridView.OnDownload = func(id string) {
 		go func() {
-			pl, err := a.contr.App.ServerManager.Server.GetPlaylist(id)
+			pl, err := a.getPlaylist(id)
 			if err != nil {
 				log.Printf("error loading playlist: %s", err.Error())
 				return
@@ -149,15 +232,27 @@ func (a *PlaylistsPage) createGridView(playlists []*mediaprovider.Playlist) {
 	}
 }
 
+// getPlaylist loads the playlist from the server, or evaluates it if it is a smart playlist
+func (a *PlaylistsPage) getPlaylist(id string) (*mediaprovider.PlaylistWithTracks, error) {
+	if !backend.IsSmartPlaylistID(id) {
+		return a.contr.App.ServerManager.Server.GetPlaylist(id)
+	}
+	spm := a.contr.App.SmartPlaylistManager
+	smart, err := spm.GetSmartPlaylist(id)
+	if err != nil {
+		return nil, err
+	}
+	return &mediaprovider.PlaylistWithTracks{
+		Playlist: *smart.AsPlaylist(),
+		Tracks:   spm.Evaluate(smart),
+	}, nil
+}
+
 func (a *PlaylistsPage) showListView() {
 	a.cfg.InitialView = "List" // save setting
 	if a.listView == nil {
 		a.createListView()
-		if a.searcher.Entry.Text != "" {
-			a.listView.SetPlaylists(a.searchedPlaylists)
-		} else {
-			a.listView.SetPlaylists(a.playlists)
-		}
+		a.listView.SetPlaylists(a.searchedPlaylists)
 	}
 	a.container.Objects[0].(*fyne.Container).Objects[0] = a.listView
 	a.container.Objects[0].Refresh()
@@ -166,11 +261,7 @@ func (a *PlaylistsPage) showListView() {
 func (a *PlaylistsPage) showGridView() {
 	a.cfg.InitialView = "Grid" // save setting
 	if a.gridView == nil {
-		playlists := a.playlists
-		if a.searcher.Entry.Text != "" {
-			playlists = a.searchedPlaylists
-		}
-		a.createGridView(playlists)
+		a.createGridView(a.searchedPlaylists)
 	}
 	a.container.Objects[0].(*fyne.Container).Objects[0] = a.gridView
 	a.container.Objects[0].Refresh()
@@ -198,19 +289,17 @@ func (a *PlaylistsPage) showPlaylistPage(id string) {
 func (a *PlaylistsPage) onSearched(query string) {
 	// since the playlist list is returned in full non-paginated, we will do our own
 	// simple search based on the name, description, and owner, rather than calling a server API
-	var playlists []*mediaprovider.Playlist
-	if query == "" {
-		a.searchedPlaylists = nil
-		playlists = a.playlists
-	} else {
-		a.searchedPlaylists = sharedutil.FilterSlice(a.playlists, func(p *mediaprovider.Playlist) bool {
-			qLower := strings.ToLower(query)
+	// searchedPlaylists are the playlists in the selected folder which match the search
+	playlists := a.playlistsInFolder()
+	if query != "" {
+		qLower := strings.ToLower(query)
+		playlists = sharedutil.FilterSlice(playlists, func(p *mediaprovider.Playlist) bool {
 			return strings.Contains(strings.ToLower(p.Name), qLower) ||
 				strings.Contains(strings.ToLower(p.Description), qLower) ||
 				strings.Contains(strings.ToLower(p.Owner), qLower)
 		})
-		playlists = a.searchedPlaylists
 	}
+	a.searchedPlaylists = playlists
 	a.refreshView(playlists)
 }
 
@@ -241,7 +330,7 @@ func (a *PlaylistsPage) Route() controller.Route {
 }
 
 func (a *PlaylistsPage) Reload() {
-	go a.load(a.searcher.Entry.Text != "")
+	go a.load()
 }
 
 func (a *PlaylistsPage) Save() SavedPage {
@@ -251,6 +340,7 @@ func (a *PlaylistsPage) Save() SavedPage {
 		cfg:        a.cfg,
 		mp:         a.mp,
 		searchText: a.searcher.Entry.Text,
+		folder:     a.folder,
 		activeView: a.viewToggle.ActivatedButtonIndex(),
 	}
 	if a.gridView != nil {
@@ -269,19 +359,26 @@ type savedPlaylistsPage struct {
 	cfg        *backend.PlaylistsPageConfig
 	mp         mediaprovider.MediaProvider
 	searchText string
+	folder     string
 	activeView int
 	listSort   widgets.ListHeaderSort
 }
 
 func (s *savedPlaylistsPage) Restore() Page {
-	return newPlaylistsPage(s.contr, s.pool, s.cfg, s.mp, s.searchText, s.activeView, s.listSort)
+	return newPlaylistsPage(s.contr, s.pool, s.cfg, s.mp, s.searchText, s.folder, s.activeView, s.listSort)
 }
 
 func (a *PlaylistsPage) buildContainer(initialView fyne.CanvasObject) {
 	searchVbox := container.NewVBox(layout.NewSpacer(), a.searcher, layout.NewSpacer())
+	newSmartBtn := widget.NewButtonWithIcon("New smart playlist", theme.ContentAddIcon(), func() {
+		a.contr.DoEditSmartPlaylistWorkflow(nil)
+	})
+	importBtn := widget.NewButtonWithIcon("Import", theme.FolderOpenIcon(), a.contr.DoImportPlaylistWorkflow)
 	a.container = container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
 		container.NewBorder(
-			container.NewHBox(a.titleDisp, container.NewCenter(a.viewToggle), layout.NewSpacer(), searchVbox),
+			container.NewHBox(a.titleDisp, container.NewCenter(a.viewToggle),
+				container.NewCenter(a.folderSel), container.NewCenter(a.sortSelect), layout.NewSpacer(),
+				container.NewCenter(container.NewHBox(importBtn, newSmartBtn)), searchVbox),
 			nil, nil, nil, initialView))
 }
 
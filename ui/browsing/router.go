@@ -2,7 +2,6 @@ package browsing
 
 import (
 	"github.com/dweymouth/supersonic/backend"
-	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/ui/controller"
 	"github.com/dweymouth/supersonic/ui/util"
 )
@@ -30,8 +29,8 @@ func NewRouter(app *backend.App, controller *controller.Controller, nav Navigati
 }
 
 func (r Router) CreatePage(rte controller.Route) Page {
-	_, canRate := r.App.ServerManager.Server.(mediaprovider.SupportsRating)
-	_, canShare := r.App.ServerManager.Server.(mediaprovider.SupportsSharing)
+	caps := r.App.ServerManager.Capabilities()
+	canRate, canShare := caps.Rating, caps.Sharing
 	switch rte.Page {
 	case controller.Album:
 		return NewAlbumPage(rte.Arg, &r.App.Config.AlbumPage, r.widgetPool, r.App.PlaybackManager, r.App.ServerManager.Server, r.App.ImageManager, r.Controller)
@@ -41,6 +40,8 @@ func (r Router) CreatePage(rte controller.Route) Page {
 		return NewArtistPage(rte.Arg, &r.App.Config.ArtistPage, r.widgetPool, r.App.PlaybackManager, r.App.ServerManager.Server, r.App.ImageManager, r.Controller)
 	case controller.Artists:
 		return NewArtistsPage(&r.App.Config.ArtistsPage, r.widgetPool, r.Controller, r.App.PlaybackManager, r.App.ServerManager.Server, r.App.ImageManager)
+	case controller.Composers:
+		return NewComposersPage(r.Controller, r.App.ServerManager.Server)
 	case controller.Favorites:
 		return NewFavoritesPage(&r.App.Config.FavoritesPage, r.widgetPool, r.Controller, r.App.ServerManager.Server, r.App.PlaybackManager, r.App.ImageManager)
 	case controller.Genre:
@@ -54,7 +55,13 @@ func (r Router) CreatePage(rte controller.Route) Page {
 	case controller.Playlists:
 		return NewPlaylistsPage(r.Controller, r.widgetPool, &r.App.Config.PlaylistsPage, r.App.ServerManager.Server)
 	case controller.Tracks:
-		return NewTracksPage(r.Controller, &r.App.Config.TracksPage, r.widgetPool, r.App.ServerManager.Server)
+		return NewTracksPage(r.Controller, &r.App.Config.TracksPage, r.widgetPool, r.App.ServerManager.Server, rte.Arg)
+	case controller.AdvancedSearch:
+		return NewAdvancedSearchPage(rte.Arg, r.widgetPool, r.Controller, r.App.ServerManager.Server, r.App.ImageManager)
+	case controller.Stats:
+		return NewStatsPage(r.Controller, r.App.ListeningHistory, r.App.ServerManager.ServerID.String())
+	case controller.ServerAdmin:
+		return NewServerAdminPage(r.Controller, r.App.ServerManager.Admin())
 	}
 	return nil
 }
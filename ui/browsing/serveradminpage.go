@@ -0,0 +1,333 @@
+package browsing
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/layouts"
+	"github.com/dweymouth/supersonic/ui/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const scanStatusPollInterval = 2 * time.Second
+
+// ServerAdminPage lets admin users manage the library scan,
+// the users and the shares of the connected server.
+type ServerAdminPage struct {
+	widget.BaseWidget
+
+	contr *controller.Controller
+	admin mediaprovider.AdminProvider // nil if the user is not an admin
+	tab   int
+
+	scanPolling atomic.Bool
+
+	titleDisp  *widget.RichText
+	scanStatus *widget.Label
+	scanButton *widget.Button
+	users      *fyne.Container
+	shares     *fyne.Container
+	tabs       *container.AppTabs
+	container  *fyne.Container
+}
+
+func NewServerAdminPage(contr *controller.Controller, admin mediaprovider.AdminProvider) *ServerAdminPage {
+	return newServerAdminPage(contr, admin, 0)
+}
+
+func newServerAdminPage(contr *controller.Controller, admin mediaprovider.AdminProvider, tab int) *ServerAdminPage {
+	s := &ServerAdminPage{
+		contr:      contr,
+		admin:      admin,
+		tab:        tab,
+		titleDisp:  widget.NewRichTextWithText("Server Admin"),
+		scanStatus: widget.NewLabel(""),
+		users:      container.NewVBox(),
+		shares:     container.NewVBox(),
+	}
+	s.ExtendBaseWidget(s)
+	s.titleDisp.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+	s.scanButton = widget.NewButtonWithIcon("Scan Library", theme.ViewRefreshIcon(), s.startScan)
+
+	s.buildContainer()
+	go s.load()
+	return s
+}
+
+// should be called asynchronously
+func (s *ServerAdminPage) load() {
+	if s.admin == nil {
+		return
+	}
+	s.loadScanStatus()
+	s.loadUsers()
+	s.loadShares()
+}
+
+func (s *ServerAdminPage) loadScanStatus() {
+	status, err := s.admin.GetScanStatus()
+	if err != nil {
+		log.Printf("error getting scan status: %v", err)
+		s.scanStatus.SetText("Failed to get the library scan status")
+		return
+	}
+	if status.Scanning {
+		s.scanStatus.SetText(fmt.Sprintf("Scanning... %d files scanned", status.Count))
+		s.scanButton.Disable()
+		s.pollScanStatus()
+	} else {
+		s.scanStatus.SetText(fmt.Sprintf("Not scanning. The library contains %d files.", status.Count))
+		s.scanButton.Enable()
+	}
+}
+
+func (s *ServerAdminPage) startScan() {
+	s.scanButton.Disable()
+	go func() {
+		if err := s.contr.App.ServerManager.Server.RescanLibrary(); err != nil {
+			log.Printf("error starting library scan: %v", err)
+			s.scanStatus.SetText("Failed to start the library scan")
+			s.scanButton.Enable()
+			return
+		}
+		s.loadScanStatus()
+	}()
+}
+
+// pollScanStatus updates the scan status periodically until the
+// scan is finished or the page is navigated away from.
+func (s *ServerAdminPage) pollScanStatus() {
+	if !s.scanPolling.CompareAndSwap(false, true) {
+		return // already polling
+	}
+	go func() {
+		t := time.NewTicker(scanStatusPollInterval)
+		defer t.Stop()
+		defer s.scanPolling.Store(false)
+		for range t.C {
+			if s.contr.CurPageFunc().Page != controller.ServerAdmin {
+				return
+			}
+			status, err := s.admin.GetScanStatus()
+			if err != nil {
+				log.Printf("error getting scan status: %v", err)
+				return
+			}
+			if !status.Scanning {
+				s.scanStatus.SetText(fmt.Sprintf("Scan finished. The library contains %d files.", status.Count))
+				s.scanButton.Enable()
+				return
+			}
+			s.scanStatus.SetText(fmt.Sprintf("Scanning... %d files scanned", status.Count))
+		}
+	}()
+}
+
+func (s *ServerAdminPage) loadUsers() {
+	users, err := s.admin.GetUsers()
+	if err != nil {
+		log.Printf("error getting users: %v", err)
+		s.users.Objects = []fyne.CanvasObject{widget.NewLabel("Failed to load users")}
+		s.users.Refresh()
+		return
+	}
+	reload := func() { go s.loadUsers() }
+	objs := make([]fyne.CanvasObject, 0, len(users))
+	for _, u := range users {
+		u := u
+		name := util.NewTruncatingLabel()
+		name.Text = u.Username
+		name.TextStyle.Bold = true
+		details := util.NewTruncatingLabel()
+		details.Text = userDetails(u)
+		details.Importance = widget.LowImportance
+
+		edit := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+			s.contr.DoEditServerUserWorkflow(u, reload)
+		})
+		password := widget.NewButton("Change Password", func() {
+			s.contr.DoChangeServerUserPasswordWorkflow(u.Username)
+		})
+		del := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			s.contr.DoDeleteServerUserWorkflow(u.Username, reload)
+		})
+		if u.Username == s.contr.App.ServerManager.LoggedInUser {
+			// don't let admins lock themselves out
+			del.Disable()
+		}
+		objs = append(objs, container.NewBorder(nil, nil, nil,
+			container.NewHBox(edit, password, del),
+			container.NewVBox(name, container.New(&layouts.MaxPadLayout{PadTop: -15}, details))))
+	}
+	if len(objs) == 0 {
+		objs = append(objs, widget.NewLabel("No users"))
+	}
+	s.users.Objects = objs
+	s.users.Refresh()
+}
+
+// userDetails returns the email and roles of the user for display.
+func userDetails(u *mediaprovider.User) string {
+	var roles []string
+	addRole := func(has bool, name string) {
+		if has {
+			roles = append(roles, name)
+		}
+	}
+	addRole(u.AdminRole, "Admin")
+	addRole(u.StreamRole, "Stream")
+	addRole(u.DownloadRole, "Download")
+	addRole(u.UploadRole, "Upload")
+	addRole(u.PlaylistRole, "Playlists")
+	addRole(u.CoverArtRole, "Cover art")
+	addRole(u.CommentRole, "Comment")
+	addRole(u.PodcastRole, "Podcasts")
+	addRole(u.JukeboxRole, "Jukebox")
+	addRole(u.ShareRole, "Share")
+
+	var parts []string
+	if u.Email != "" {
+		parts = append(parts, u.Email)
+	}
+	if len(roles) > 0 {
+		parts = append(parts, strings.Join(roles, ", "))
+	}
+	if u.MaxBitRate > 0 {
+		parts = append(parts, fmt.Sprintf("max %d kbps", u.MaxBitRate))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (s *ServerAdminPage) loadShares() {
+	shares, err := s.admin.GetShares()
+	if err != nil {
+		log.Printf("error getting shares: %v", err)
+		s.shares.Objects = []fyne.CanvasObject{widget.NewLabel("Failed to load shares")}
+		s.shares.Refresh()
+		return
+	}
+	objs := make([]fyne.CanvasObject, 0, len(shares))
+	for _, sh := range shares {
+		sh := sh
+		title := sh.Description
+		if title == "" {
+			title = strings.Join(sh.EntryNames, ", ")
+		}
+		var titleObj fyne.CanvasObject
+		if u, err := url.Parse(sh.URL); err == nil && sh.URL != "" {
+			link := widget.NewHyperlink(title, u)
+			link.Truncation = fyne.TextTruncateEllipsis
+			titleObj = link
+		} else {
+			label := util.NewTruncatingLabel()
+			label.Text = title
+			titleObj = label
+		}
+		details := util.NewTruncatingLabel()
+		details.Text = shareDetails(sh)
+		details.Importance = widget.LowImportance
+
+		del := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			s.contr.DoDeleteShareWorkflow(sh, func() { go s.loadShares() })
+		})
+		objs = append(objs, container.NewBorder(nil, nil, nil, del,
+			container.NewVBox(titleObj, container.New(&layouts.MaxPadLayout{PadTop: -15}, details))))
+	}
+	if len(objs) == 0 {
+		objs = append(objs, widget.NewLabel("No shares"))
+	}
+	s.shares.Objects = objs
+	s.shares.Refresh()
+}
+
+// shareDetails returns the owner, visits and expiry of the share for display.
+func shareDetails(sh *mediaprovider.Share) string {
+	parts := []string{fmt.Sprintf("%d items", sh.EntryCount)}
+	if sh.Username != "" {
+		parts = append(parts, "shared by "+sh.Username)
+	}
+	if !sh.Created.IsZero() {
+		parts = append(parts, "created "+sh.Created.Format("Jan 2, 2006"))
+	}
+	visits := fmt.Sprintf("%d visits", sh.VisitCount)
+	if !sh.LastVisited.IsZero() {
+		visits += ", last " + sh.LastVisited.Format("Jan 2, 2006")
+	}
+	parts = append(parts, visits)
+	if sh.Expires.IsZero() {
+		parts = append(parts, "never expires")
+	} else if sh.Expires.Before(time.Now()) {
+		parts = append(parts, "expired "+sh.Expires.Format("Jan 2, 2006"))
+	} else {
+		parts = append(parts, "expires "+sh.Expires.Format("Jan 2, 2006"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (s *ServerAdminPage) Route() controller.Route {
+	return controller.ServerAdminRoute()
+}
+
+func (s *ServerAdminPage) Reload() {
+	go s.load()
+}
+
+func (s *ServerAdminPage) Save() SavedPage {
+	return &savedServerAdminPage{
+		contr: s.contr,
+		admin: s.admin,
+		tab:   s.tabs.SelectedIndex(),
+	}
+}
+
+type savedServerAdminPage struct {
+	contr *controller.Controller
+	admin mediaprovider.AdminProvider
+	tab   int
+}
+
+func (s *savedServerAdminPage) Restore() Page {
+	return newServerAdminPage(s.contr, s.admin, s.tab)
+}
+
+func (s *ServerAdminPage) buildContainer() {
+	addUser := widget.NewButtonWithIcon("Add User", theme.ContentAddIcon(), func() {
+		s.contr.DoEditServerUserWorkflow(nil, func() { go s.loadUsers() })
+	})
+
+	s.tabs = container.NewAppTabs(
+		container.NewTabItem("Library", container.NewVBox(
+			s.scanStatus,
+			container.NewHBox(s.scanButton),
+		)),
+		container.NewTabItem("Users", container.NewBorder(
+			container.NewHBox(layout.NewSpacer(), addUser), nil, nil, nil,
+			container.NewVScroll(s.users))),
+		container.NewTabItem("Shares", container.NewVScroll(s.shares)),
+	)
+	s.tabs.SelectIndex(s.tab)
+
+	var content fyne.CanvasObject = s.tabs
+	if s.admin == nil {
+		content = container.NewCenter(widget.NewLabel("Server administration requires an admin user"))
+	}
+	s.container = container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
+		container.NewBorder(
+			container.New(&layouts.MaxPadLayout{PadLeft: -5}, s.titleDisp),
+			nil, nil, nil, content))
+}
+
+func (s *ServerAdminPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
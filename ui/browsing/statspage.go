@@ -0,0 +1,406 @@
+package browsing
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/layouts"
+	"github.com/dweymouth/supersonic/ui/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	statsPeriodWeek    = "This Week"
+	statsPeriodMonth   = "This Month"
+	statsPeriodYear    = "This Year"
+	statsPeriodAllTime = "All Time"
+
+	statsTopN = 10
+)
+
+// StatsPage shows listening statistics computed from the local
+// listening history, for a recent period or any past year.
+type StatsPage struct {
+	widget.BaseWidget
+
+	contr    *controller.Controller
+	history  *backend.ListeningHistory
+	serverID string
+	period   string
+
+	titleDisp     *widget.RichText
+	periodSelect  *widget.Select
+	summary       *widget.Label
+	streaks       *widget.Label
+	chartTitle    *widget.Label
+	chart         *listeningChart
+	topArtists    *fyne.Container
+	topAlbums     *fyne.Container
+	topTracks     *fyne.Container
+	topGenres     *fyne.Container
+	emptyLabel    *widget.Label
+	contentScroll *container.Scroll
+	container     *fyne.Container
+}
+
+func NewStatsPage(contr *controller.Controller, history *backend.ListeningHistory, serverID string) *StatsPage {
+	return newStatsPage(contr, history, serverID, statsPeriodMonth)
+}
+
+func newStatsPage(contr *controller.Controller, history *backend.ListeningHistory, serverID, period string) *StatsPage {
+	s := &StatsPage{
+		contr:      contr,
+		history:    history,
+		serverID:   serverID,
+		period:     period,
+		titleDisp:  widget.NewRichTextWithText("Listening Stats"),
+		summary:    widget.NewLabel(""),
+		streaks:    widget.NewLabel(""),
+		chartTitle: widget.NewLabel("Listening time per day"),
+		chart:      newListeningChart(),
+		topArtists: container.NewVBox(),
+		topAlbums:  container.NewVBox(),
+		topTracks:  container.NewVBox(),
+		topGenres:  container.NewVBox(),
+		emptyLabel: widget.NewLabel("No plays have been recorded in this period."),
+	}
+	s.ExtendBaseWidget(s)
+	s.titleDisp.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+	s.chartTitle.TextStyle.Bold = true
+
+	s.periodSelect = widget.NewSelect(s.periodOptions(), nil)
+	// set Selected directly to not trigger OnChanged
+	s.periodSelect.Selected = period
+	s.periodSelect.OnChanged = func(p string) {
+		s.period = p
+		s.load()
+	}
+
+	s.buildContainer()
+	s.load()
+	return s
+}
+
+// periodOptions returns the recent periods, followed by each
+// past year in which there was any listening.
+func (s *StatsPage) periodOptions() []string {
+	opts := []string{statsPeriodWeek, statsPeriodMonth, statsPeriodYear, statsPeriodAllTime}
+	thisYear := time.Now().Year()
+	for _, y := range s.history.Years(s.serverID) {
+		if y != thisYear {
+			opts = append(opts, strconv.Itoa(y))
+		}
+	}
+	return opts
+}
+
+// periodRange returns the start (inclusive) and end (exclusive)
+// of the selected period. A zero time is unbounded.
+func (s *StatsPage) periodRange() (time.Time, time.Time) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch s.period {
+	case statsPeriodWeek:
+		// weeks start on Monday
+		offset := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -offset), time.Time{}
+	case statsPeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), time.Time{}
+	case statsPeriodYear:
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local), time.Time{}
+	case statsPeriodAllTime:
+		return time.Time{}, time.Time{}
+	}
+	if y, err := strconv.Atoi(s.period); err == nil {
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.Local), time.Date(y+1, 1, 1, 0, 0, 0, 0, time.Local)
+	}
+	return time.Time{}, time.Time{}
+}
+
+func (s *StatsPage) load() {
+	from, to := s.periodRange()
+	stats := s.history.Stats(s.serverID, from, to, statsTopN)
+
+	s.summary.SetText(fmt.Sprintf("%d plays, %d skips · %s listened",
+		stats.Plays, stats.Skips, formatListenedTime(stats.Listened)))
+	s.streaks.SetText(fmt.Sprintf("Current streak: %s · Longest streak in period: %s",
+		formatDays(stats.CurrentStreak), formatDays(stats.LongestStreak)))
+
+	s.fillTopList(s.topArtists, "Top Artists", stats.TopArtists, func(e backend.StatsEntry) func() {
+		return func() { s.contr.NavigateTo(controller.ArtistRoute(e.ID)) }
+	})
+	s.fillTopList(s.topAlbums, "Top Albums", stats.TopAlbums, func(e backend.StatsEntry) func() {
+		return func() { s.contr.NavigateTo(controller.AlbumRoute(e.ID)) }
+	})
+	s.fillTopList(s.topTracks, "Top Tracks", stats.TopTracks, nil)
+	s.fillTopList(s.topGenres, "Top Genres", stats.TopGenres, func(e backend.StatsEntry) func() {
+		return func() { s.contr.NavigateTo(controller.GenreRoute(e.Name)) }
+	})
+
+	if len(stats.Daily) > 0 {
+		if from.IsZero() {
+			from = stats.Daily[0].Date
+		}
+		last := time.Now()
+		if !to.IsZero() {
+			last = to.AddDate(0, 0, -1)
+		}
+		s.chart.SetDaily(stats.Daily, from, last)
+	} else {
+		s.chart.SetDaily(nil, time.Time{}, time.Time{})
+	}
+
+	if stats.Plays+stats.Skips > 0 {
+		s.emptyLabel.Hide()
+		s.contentScroll.Show()
+	} else {
+		s.contentScroll.Hide()
+		s.emptyLabel.Show()
+	}
+	s.Refresh()
+}
+
+// fillTopList replaces the content of the list with the ranked entries.
+// If onTapped is non-nil, the entry names are links created by it.
+func (s *StatsPage) fillTopList(list *fyne.Container, title string, entries []backend.StatsEntry, onTapped func(backend.StatsEntry) func()) {
+	heading := widget.NewLabel(title)
+	heading.TextStyle.Bold = true
+	list.Objects = []fyne.CanvasObject{heading}
+	for i, e := range entries {
+		name := fmt.Sprintf("%d. %s", i+1, e.Name)
+		var nameObj fyne.CanvasObject
+		if onTapped != nil {
+			link := widget.NewHyperlink(name, nil)
+			link.OnTapped = onTapped(e)
+			link.Truncation = fyne.TextTruncateEllipsis
+			nameObj = link
+		} else {
+			label := util.NewTruncatingLabel()
+			label.Text = name
+			nameObj = label
+		}
+		count := util.NewTrailingAlignLabel()
+		count.Text = fmt.Sprintf("%d plays", e.Plays)
+		row := container.NewBorder(nil, nil, nil, count, nameObj)
+		if e.Secondary != "" {
+			secondary := util.NewTruncatingLabel()
+			secondary.Text = e.Secondary
+			secondary.Importance = widget.LowImportance
+			list.Objects = append(list.Objects, container.NewVBox(row,
+				container.New(&layouts.MaxPadLayout{PadTop: -15}, secondary)))
+		} else {
+			list.Objects = append(list.Objects, row)
+		}
+	}
+	if len(entries) == 0 {
+		list.Objects = append(list.Objects, widget.NewLabel("—"))
+	}
+	list.Refresh()
+}
+
+func formatListenedTime(d time.Duration) string {
+	hours := int(d.Hours())
+	mins := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d h %d min", hours, mins)
+	}
+	return fmt.Sprintf("%d min", mins)
+}
+
+func formatDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+func (s *StatsPage) Route() controller.Route {
+	return controller.StatsRoute()
+}
+
+func (s *StatsPage) Reload() {
+	opts := s.periodOptions()
+	s.periodSelect.Options = opts
+	s.periodSelect.Refresh()
+	s.load()
+}
+
+func (s *StatsPage) Save() SavedPage {
+	return &savedStatsPage{
+		contr:    s.contr,
+		history:  s.history,
+		serverID: s.serverID,
+		period:   s.period,
+	}
+}
+
+type savedStatsPage struct {
+	contr    *controller.Controller
+	history  *backend.ListeningHistory
+	serverID string
+	period   string
+}
+
+func (s *savedStatsPage) Restore() Page {
+	return newStatsPage(s.contr, s.history, s.serverID, s.period)
+}
+
+var _ Scrollable = (*StatsPage)(nil)
+
+func (s *StatsPage) Scroll(amount float32) {
+	s.contentScroll.Offset.Y += amount
+	s.contentScroll.Refresh()
+}
+
+func (s *StatsPage) buildContainer() {
+	selectVbox := container.NewVBox(layout.NewSpacer(), s.periodSelect, layout.NewSpacer())
+	content := container.NewVBox(
+		s.summary,
+		container.New(&layouts.MaxPadLayout{PadTop: -10}, s.streaks),
+		s.chartTitle,
+		s.chart,
+		container.NewGridWithColumns(2, s.topArtists, s.topAlbums),
+		container.NewGridWithColumns(2, s.topTracks, s.topGenres),
+	)
+	s.contentScroll = container.NewVScroll(content)
+	s.container = container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
+		container.NewBorder(
+			container.New(&layouts.MaxPadLayout{PadLeft: -5},
+				container.NewHBox(s.titleDisp, layout.NewSpacer(), selectVbox)),
+			nil, nil, nil,
+			container.NewStack(s.contentScroll, container.NewCenter(s.emptyLabel))))
+}
+
+func (s *StatsPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
+
+// listeningChart is a bar chart of the listening time on each day of a range.
+type listeningChart struct {
+	widget.BaseWidget
+
+	listened []time.Duration // one per day
+	max      time.Duration
+
+	bars      []*canvas.Rectangle
+	maxLabel  *widget.Label
+	fromLabel *widget.Label
+	toLabel   *widget.Label
+}
+
+func newListeningChart() *listeningChart {
+	c := &listeningChart{
+		maxLabel:  widget.NewLabel(""),
+		fromLabel: widget.NewLabel(""),
+		toLabel:   widget.NewLabel(""),
+	}
+	c.ExtendBaseWidget(c)
+	c.maxLabel.Importance = widget.LowImportance
+	c.fromLabel.Importance = widget.LowImportance
+	c.toLabel.Importance = widget.LowImportance
+	return c
+}
+
+// SetDaily sets the listening time of each day from first through last.
+// Days without any listening need not be included in daily.
+func (c *listeningChart) SetDaily(daily []backend.DailyListening, first, last time.Time) {
+	c.listened = nil
+	c.max = 0
+	c.fromLabel.Text, c.toLabel.Text, c.maxLabel.Text = "", "", ""
+	if first.IsZero() {
+		c.Refresh()
+		return
+	}
+	byDay := make(map[time.Time]time.Duration, len(daily))
+	for _, d := range daily {
+		byDay[d.Date] = d.Listened
+	}
+	// daily dates are at midnight UTC
+	firstDate := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	lastDate := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+	for day := firstDate; !day.After(lastDate); day = day.AddDate(0, 0, 1) {
+		l := byDay[day]
+		c.listened = append(c.listened, l)
+		c.max = max(c.max, l)
+	}
+	c.fromLabel.Text = first.Format("Jan 2, 2006")
+	c.toLabel.Text = last.Format("Jan 2, 2006")
+	c.maxLabel.Text = "max " + formatListenedTime(c.max)
+	c.Refresh()
+}
+
+func (c *listeningChart) MinSize() fyne.Size {
+	return fyne.NewSize(200, 160)
+}
+
+func (c *listeningChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &listeningChartRenderer{chart: c}
+	r.Refresh()
+	return r
+}
+
+type listeningChartRenderer struct {
+	chart   *listeningChart
+	objects []fyne.CanvasObject
+}
+
+func (r *listeningChartRenderer) Layout(size fyne.Size) {
+	c := r.chart
+	labelHeight := c.fromLabel.MinSize().Height
+	chartHeight := size.Height - labelHeight
+	c.maxLabel.Resize(c.maxLabel.MinSize())
+	c.maxLabel.Move(fyne.NewPos(size.Width-c.maxLabel.MinSize().Width, 0))
+	c.fromLabel.Resize(c.fromLabel.MinSize())
+	c.fromLabel.Move(fyne.NewPos(0, chartHeight))
+	c.toLabel.Resize(c.toLabel.MinSize())
+	c.toLabel.Move(fyne.NewPos(size.Width-c.toLabel.MinSize().Width, chartHeight))
+
+	if len(c.bars) == 0 || c.max == 0 {
+		return
+	}
+	slot := size.Width / float32(len(c.bars))
+	gap := float32(0)
+	if slot > 4 {
+		gap = slot * 0.2
+	}
+	for i, bar := range c.bars {
+		h := chartHeight * float32(c.listened[i]) / float32(c.max)
+		bar.Resize(fyne.NewSize(slot-gap, h))
+		bar.Move(fyne.NewPos(float32(i)*slot+gap/2, chartHeight-h))
+	}
+}
+
+func (r *listeningChartRenderer) MinSize() fyne.Size {
+	return r.chart.MinSize()
+}
+
+func (r *listeningChartRenderer) Refresh() {
+	c := r.chart
+	for len(c.bars) < len(c.listened) {
+		c.bars = append(c.bars, canvas.NewRectangle(theme.PrimaryColor()))
+	}
+	c.bars = c.bars[:len(c.listened)]
+	r.objects = r.objects[:0]
+	for _, bar := range c.bars {
+		bar.FillColor = theme.PrimaryColor()
+		r.objects = append(r.objects, bar)
+	}
+	r.objects = append(r.objects, c.maxLabel, c.fromLabel, c.toLabel)
+	r.Layout(c.Size())
+	canvas.Refresh(c)
+}
+
+func (r *listeningChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *listeningChartRenderer) Destroy() {}
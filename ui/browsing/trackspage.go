@@ -1,8 +1,13 @@
 package browsing
 
 import (
+	"log"
+	"slices"
+	"strings"
+
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/controller"
 	"github.com/dweymouth/supersonic/ui/layouts"
@@ -30,26 +35,41 @@ type TracksPage struct {
 	searchTracklist *widgets.Tracklist
 	searchLoader    widgets.TracklistLoader
 	playRandom      *widget.Button
+	composerFilter  *widget.SelectEntry
+	composerNames   []string
+	filterBtn       *widgets.TrackFilterButton
 	container       *fyne.Container
 }
 
 type tracksPageState struct {
 	searchText string
+	composer   string
+	filter     mediaprovider.TrackFilter
 	widgetPool *util.WidgetPool
 	contr      *controller.Controller
 	conf       *backend.TracksPageConfig
 	mp         mediaprovider.MediaProvider
 	canRate    bool
 	canShare   bool
+	// nil unless the server supports browsing by composer
+	composers mediaprovider.ComposerProvider
+}
+
+func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig, pool *util.WidgetPool, mp mediaprovider.MediaProvider, composer string) *TracksPage {
+	return newTracksPage(contr, conf, pool, mp, composer,
+		mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
 }
 
-func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig, pool *util.WidgetPool, mp mediaprovider.MediaProvider) *TracksPage {
-	t := &TracksPage{tracksPageState: tracksPageState{contr: contr, conf: conf, widgetPool: pool, mp: mp}}
+func newTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig, pool *util.WidgetPool, mp mediaprovider.MediaProvider, composer string, filter mediaprovider.TrackFilter) *TracksPage {
+	t := &TracksPage{tracksPageState: tracksPageState{contr: contr, conf: conf, widgetPool: pool, mp: mp, composer: composer, filter: filter}}
 	t.ExtendBaseWidget(t)
 
 	t.tracklist = t.obtainTracklist()
-	_, t.canRate = mp.(mediaprovider.SupportsRating)
-	_, t.canShare = mp.(mediaprovider.SupportsSharing)
+	caps := contr.App.ServerManager.Capabilities()
+	t.canRate, t.canShare = caps.Rating, caps.Sharing
+	if cp, ok := mp.(mediaprovider.ComposerProvider); ok && caps.Composers {
+		t.composers = cp
+	}
 	t.tracklist.Options = widgets.TracklistOptions{
 		DisableSorting: true,
 		DisableRating:  !t.canRate,
@@ -71,6 +91,20 @@ func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig,
 	t.searcher = widgets.NewSearchEntry()
 	t.searcher.PlaceHolder = "Search page"
 	t.searcher.OnSearched = t.OnSearched
+	t.composerFilter = widget.NewSelectEntry(nil)
+	t.composerFilter.PlaceHolder = "Filter by composer"
+	t.composerFilter.Text = composer
+	t.composerFilter.OnChanged = t.onComposerFilterChanged
+	t.composerFilter.OnSubmitted = t.setComposer
+	if t.composers != nil {
+		go t.loadComposers()
+	} else {
+		t.composerFilter.Hidden = true
+	}
+	t.filterBtn = widgets.NewTrackFilterButton(filter, mp.GetGenres)
+	t.filterBtn.MoodDisabled = !caps.Moods
+	t.filterBtn.OnChanged = t.onFilterChanged
+	t.filterBtn.Refresh()
 	t.createContainer()
 	t.Reload()
 	return t
@@ -79,20 +113,73 @@ func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig,
 func (t *TracksPage) createContainer() {
 	playRandomVbox := container.NewVBox(layout.NewSpacer(), t.playRandom, layout.NewSpacer())
 	searchVbox := container.NewVBox(layout.NewSpacer(), t.searcher, layout.NewSpacer())
-	topRow := container.NewHBox(t.title, playRandomVbox, layout.NewSpacer(), searchVbox)
+	composerVbox := container.NewVBox(layout.NewSpacer(),
+		container.NewGridWrap(fyne.NewSize(200, t.composerFilter.MinSize().Height), t.composerFilter),
+		layout.NewSpacer())
+	filterVbox := container.NewVBox(layout.NewSpacer(), t.filterBtn, layout.NewSpacer())
+	topRow := container.NewHBox(t.title, playRandomVbox, layout.NewSpacer(), composerVbox, filterVbox, searchVbox)
 	t.container = container.New(&layouts.MaxPadLayout{PadLeft: 15, PadRight: 15, PadTop: 5, PadBottom: 15},
 		container.NewBorder(topRow, nil, nil, nil, t.tracklist))
 }
 
 func (t *TracksPage) Route() controller.Route {
-	return controller.TracksRoute()
+	return controller.ComposerTracksRoute(t.composer)
 }
 
 func (t *TracksPage) Reload() {
 	t.tracklist.Clear()
-	iter := t.mp.IterateTracks("")
+	var iter mediaprovider.TrackIterator
+	if t.composers != nil && t.composer != "" {
+		iter = helpers.NewFilteredIterator(t.composers.IterateComposerTracks(t.composer), t.filter)
+	} else {
+		iter = t.mp.IterateTracks("", t.filter)
+	}
 	// loads asynchronously
 	t.loader = widgets.NewTracklistLoader(t.tracklist, iter)
+	t.tracklist.Refresh()
+}
+
+// should be called asynchronously
+func (t *TracksPage) loadComposers() {
+	composers, err := t.composers.GetComposers()
+	if err != nil {
+		log.Printf("error loading composers: %v", err)
+		return
+	}
+	t.composerNames = sharedutil.MapSlice(composers, func(c *mediaprovider.Composer) string {
+		return c.Name
+	})
+	slices.SortFunc(t.composerNames, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	t.composerFilter.SetOptions(t.composerNames)
+}
+
+func (t *TracksPage) onComposerFilterChanged(text string) {
+	// apply the filter immediately when cleared or a composer is picked
+	// from the dropdown; typed text is applied on submit
+	if text == "" || slices.Contains(t.composerNames, text) {
+		t.setComposer(text)
+	}
+}
+
+func (t *TracksPage) setComposer(composer string) {
+	composer = strings.TrimSpace(composer)
+	if composer == t.composer {
+		return
+	}
+	t.composer = composer
+	t.Reload()
+	if t.searchText != "" {
+		t.doSearch(t.searchText)
+	}
+}
+
+func (t *TracksPage) onFilterChanged() {
+	t.Reload()
+	if t.searchText != "" {
+		t.doSearch(t.searchText)
+	}
 }
 
 func (t *TracksPage) OnSongChange(track, lastScrobbledIfAny *mediaprovider.Track) {
@@ -152,7 +239,15 @@ func (t *TracksPage) doSearch(query string) {
 	} else {
 		t.searchTracklist.Clear()
 	}
-	iter := t.mp.IterateTracks(query)
+	var iter mediaprovider.TrackIterator
+	if t.composers != nil && t.composer != "" {
+		// the server search can't be restricted by composer, so
+		// search the composer's tracks client-side instead
+		iter = newSearchFilteredTrackIterator(
+			helpers.NewFilteredIterator(t.composers.IterateComposerTracks(t.composer), t.filter), query)
+	} else {
+		iter = t.mp.IterateTracks(query, t.filter)
+	}
 	t.searchLoader = widgets.NewTracklistLoader(t.searchTracklist, iter)
 	t.container.Objects[0].(*fyne.Container).Objects[0] = t.searchTracklist
 	t.Refresh()
@@ -176,7 +271,7 @@ func (t *TracksPage) Save() SavedPage {
 }
 
 func (s *tracksPageState) Restore() Page {
-	t := NewTracksPage(s.contr, s.conf, s.widgetPool, s.mp)
+	t := newTracksPage(s.contr, s.conf, s.widgetPool, s.mp, s.composer, s.filter)
 	t.searchText = s.searchText
 	if t.searchText != "" {
 		t.searcher.Entry.Text = t.searchText
@@ -197,3 +292,29 @@ func (t *TracksPage) obtainTracklist() *widgets.Tracklist {
 	}
 	return widgets.NewTracklist(nil)
 }
+
+// searchFilteredTrackIterator filters the tracks returned by an iterator
+// to those whose title, artist or album match all of the search terms.
+type searchFilteredTrackIterator struct {
+	iter  mediaprovider.TrackIterator
+	terms []string
+}
+
+func newSearchFilteredTrackIterator(iter mediaprovider.TrackIterator, query string) *searchFilteredTrackIterator {
+	return &searchFilteredTrackIterator{
+		iter:  iter,
+		terms: strings.Fields(strings.ToLower(query)),
+	}
+}
+
+func (s *searchFilteredTrackIterator) Next() *mediaprovider.Track {
+	for tr := s.iter.Next(); tr != nil; tr = s.iter.Next() {
+		text := strings.ToLower(tr.Name + " " + strings.Join(tr.ArtistNames, " ") + " " + tr.Album)
+		if !slices.ContainsFunc(s.terms, func(term string) bool {
+			return !strings.Contains(text, term)
+		}) {
+			return tr
+		}
+	}
+	return nil
+}
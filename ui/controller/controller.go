@@ -3,6 +3,7 @@ package controller
 import (
 	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"io"
@@ -11,22 +12,28 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/aggregate"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/dialogs"
 	"github.com/dweymouth/supersonic/ui/util"
 	"github.com/dweymouth/supersonic/ui/widgets"
+	"github.com/google/uuid"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
@@ -45,6 +52,9 @@ type Controller struct {
 	CurPageFunc CurPageFunc
 	ReloadFunc  ReloadFunc
 
+	// Invoked when playlists are pinned to or unpinned from the navigation sidebar
+	OnPinnedPlaylistsChanged func()
+
 	escapablePopUp   *widget.PopUp
 	haveModal        bool
 	runOnModalClosed func()
@@ -197,6 +207,13 @@ func (m *Controller) ConnectAlbumGridActions(grid *widgets.GridView) {
 	grid.OnShare = func(albumID string) {
 		go m.ShowShareDialog(albumID)
 	}
+	grid.TracksLoader = func(albumID string) ([]*mediaprovider.Track, error) {
+		album, err := m.App.ServerManager.Server.GetAlbum(albumID)
+		if err != nil {
+			return nil, err
+		}
+		return album.Tracks, nil
+	}
 }
 
 func (m *Controller) ConnectArtistGridActions(grid *widgets.GridView) {
@@ -223,6 +240,9 @@ func (m *Controller) ConnectArtistGridActions(grid *widgets.GridView) {
 	grid.OnShare = func(artistID string) {
 		go m.ShowShareDialog(artistID)
 	}
+	grid.TracksLoader = func(artistID string) ([]*mediaprovider.Track, error) {
+		return m.GetArtistTracks(artistID), nil
+	}
 }
 
 func (m *Controller) GetArtistTracks(artistID string) []*mediaprovider.Track {
@@ -286,6 +306,7 @@ func (m *Controller) ShuffleArtistAlbums(artistID string) {
 
 func (m *Controller) PromptForFirstServer() {
 	d := dialogs.NewAddEditServerDialog("Connect to Server", false, nil, m.MainWindow.Canvas().Focus)
+	d.OnQuickConnect = func() { m.doJellyfinQuickConnect(d) }
 	pop := widget.NewModalPopUp(d, m.MainWindow.Canvas())
 	d.OnSubmit = func() {
 		d.DisableSubmit()
@@ -294,14 +315,8 @@ func (m *Controller) PromptForFirstServer() {
 				// connection is good
 				pop.Hide()
 				m.doModalClosed()
-				conn := backend.ServerConnection{
-					ServerType:  d.ServerType,
-					Hostname:    d.Host,
-					AltHostname: d.AltHost,
-					Username:    d.Username,
-					LegacyAuth:  d.LegacyAuth,
-				}
-				server := m.App.ServerManager.AddServer(d.Nickname, conn)
+				server := m.App.ServerManager.AddServer(d.Nickname, d.Connection())
+				server.MergeLibrary = d.MergeLibrary
 				if err := m.trySetPasswordAndConnectToServer(server, d.Password); err != nil {
 					log.Printf("error connecting to server: %s", err.Error())
 				}
@@ -349,8 +364,7 @@ func (m *Controller) DoAddTracksToPlaylistWorkflow(trackIDs []string) {
 			} else {
 				playlist := pls[playlistChoice]
 				m.App.Config.Application.DefaultPlaylistID = playlist.ID
-				go m.App.ServerManager.Server.AddPlaylistTracks(
-					playlist.ID, trackIDs)
+				go m.AddTracksToPlaylist(playlist.ID, trackIDs)
 			}
 		}
 		m.haveModal = true
@@ -358,8 +372,137 @@ func (m *Controller) DoAddTracksToPlaylistWorkflow(trackIDs []string) {
 	}()
 }
 
+// AddTracksToPlaylist adds the tracks to an existing playlist, first asking
+// the user what to do with duplicates if enabled in the settings.
+func (m *Controller) AddTracksToPlaylist(playlistID string, trackIDs []string) {
+	if m.App.Config.Application.WarnOnDuplicatePlaylistAdd {
+		m.addTracksToPlaylistCheckingDuplicates(playlistID, trackIDs)
+	} else {
+		m.App.ServerManager.Server.AddPlaylistTracks(playlistID, trackIDs)
+	}
+}
+
+// Adds the tracks to the playlist, first asking the user whether
+// to skip or add again any tracks that are already in the playlist.
+func (m *Controller) addTracksToPlaylistCheckingDuplicates(playlistID string, trackIDs []string) {
+	server := m.App.ServerManager.Server
+	playlist, err := server.GetPlaylist(playlistID)
+	if err != nil {
+		log.Printf("error loading playlist: %s", err.Error())
+		server.AddPlaylistTracks(playlistID, trackIDs)
+		return
+	}
+	existing := sharedutil.ToSet(sharedutil.TracksToIDs(playlist.Tracks))
+	newIDs := sharedutil.FilterSlice(trackIDs, func(id string) bool {
+		_, ok := existing[id]
+		return !ok
+	})
+	numDups := len(trackIDs) - len(newIDs)
+	if numDups == 0 {
+		server.AddPlaylistTracks(playlistID, trackIDs)
+		return
+	}
+
+	msg := fmt.Sprintf("%d of the tracks are already in %s.", numDups, playlist.Name)
+	if numDups == 1 {
+		msg = fmt.Sprintf("1 of the tracks is already in %s.", playlist.Name)
+	}
+	if len(trackIDs) == 1 {
+		msg = fmt.Sprintf("This track is already in %s.", playlist.Name)
+	}
+	dlg := dialog.NewCustomWithoutButtons("Duplicate Tracks", widget.NewLabel(msg), m.MainWindow)
+	closeWithAction := func(action func()) func() {
+		return func() {
+			dlg.Hide()
+			m.doModalClosed()
+			if action != nil {
+				go action()
+			}
+		}
+	}
+	skip := widget.NewButton("Skip Duplicates", closeWithAction(func() {
+		if len(newIDs) > 0 {
+			server.AddPlaylistTracks(playlistID, newIDs)
+		}
+	}))
+	skip.Importance = widget.HighImportance
+	dlg.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", closeWithAction(nil)),
+		widget.NewButton("Add Anyway", closeWithAction(func() {
+			server.AddPlaylistTracks(playlistID, trackIDs)
+		})),
+		skip,
+	})
+	m.haveModal = true
+	dlg.Show()
+}
+
+// DoRemovePlaylistDuplicatesWorkflow shows a dialog listing the duplicate
+// tracks in the playlist and removes them if the user confirms.
+// tracks must be the playlist's tracks in their running order.
+func (m *Controller) DoRemovePlaylistDuplicatesWorkflow(playlistID string, tracks []*mediaprovider.Track) {
+	dupIdxs := sharedutil.FindDuplicateTracks(tracks, false)
+	countLabel := widget.NewLabel("")
+	dupList := widget.NewLabel("")
+	dupList.Wrapping = fyne.TextTruncate
+	removeBtn := widget.NewButton("Remove Duplicates", nil)
+	removeBtn.Importance = widget.HighImportance
+	update := func() {
+		switch len(dupIdxs) {
+		case 0:
+			countLabel.SetText("No duplicate tracks found.")
+			removeBtn.Disable()
+		case 1:
+			countLabel.SetText("1 duplicate track found:")
+			removeBtn.Enable()
+		default:
+			countLabel.SetText(fmt.Sprintf("%d duplicate tracks found:", len(dupIdxs)))
+			removeBtn.Enable()
+		}
+		var sb strings.Builder
+		for _, idx := range dupIdxs {
+			tr := tracks[idx]
+			fmt.Fprintf(&sb, "%d. %s - %s\n", idx+1, strings.Join(tr.ArtistNames, ", "), tr.Name)
+		}
+		dupList.SetText(strings.TrimSuffix(sb.String(), "\n"))
+	}
+	acrossAlbums := widget.NewCheck("Include the same song on different albums", func(b bool) {
+		dupIdxs = sharedutil.FindDuplicateTracks(tracks, b)
+		update()
+	})
+	update()
+
+	scroll := container.NewVScroll(dupList)
+	scroll.SetMinSize(fyne.NewSize(400, 200))
+	content := container.NewBorder(container.NewVBox(acrossAlbums, countLabel), nil, nil, nil, scroll)
+	dlg := dialog.NewCustomWithoutButtons("Remove Duplicates", content, m.MainWindow)
+	removeBtn.OnTapped = func() {
+		dlg.Hide()
+		m.doModalClosed()
+		idxs := dupIdxs
+		go func() {
+			if err := m.App.ServerManager.Server.RemovePlaylistTracks(playlistID, idxs); err != nil {
+				log.Printf("error removing playlist tracks: %s", err.Error())
+				return
+			}
+			if rte := m.CurPageFunc(); rte.Page == Playlist && rte.Arg == playlistID {
+				m.ReloadFunc()
+			}
+		}()
+	}
+	dlg.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", func() {
+			dlg.Hide()
+			m.doModalClosed()
+		}),
+		removeBtn,
+	})
+	m.haveModal = true
+	dlg.Show()
+}
+
 func (m *Controller) DoEditPlaylistWorkflow(playlist *mediaprovider.Playlist) {
-	canMakePublic := m.App.ServerManager.Server.CanMakePublicPlaylist()
+	canMakePublic := m.App.ServerManager.Capabilities().PublicPlaylists
 	dlg := dialogs.NewEditPlaylistDialog(playlist, canMakePublic)
 	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
 	m.ClosePopUpOnEscape(pop)
@@ -403,11 +546,196 @@ func (m *Controller) DoEditPlaylistWorkflow(playlist *mediaprovider.Playlist) {
 	pop.Show()
 }
 
+// DoEditSmartPlaylistWorkflow shows the dialog to edit the given smart playlist,
+// or to create a new one if playlist is nil.
+func (m *Controller) DoEditSmartPlaylistWorkflow(playlist *backend.SmartPlaylist) {
+	isNew := playlist == nil
+	if isNew {
+		playlist = &backend.SmartPlaylist{SortOrder: backend.SmartPlaylistSortNone}
+	}
+	dlg := dialogs.NewSmartPlaylistDialog(playlist)
+	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
+	m.ClosePopUpOnEscape(pop)
+	dlg.OnCanceled = func() {
+		pop.Hide()
+		m.doModalClosed()
+	}
+	dlg.OnDelete = func() {
+		pop.Hide()
+		dialog.ShowCustomConfirm("Confirm Delete Playlist", "OK", "Cancel", layout.NewSpacer(), /*custom content*/
+			func(ok bool) {
+				if !ok {
+					pop.Show()
+					return
+				}
+				m.doModalClosed()
+				if err := m.App.SmartPlaylistManager.DeleteSmartPlaylist(playlist.ID); err != nil {
+					log.Printf("error deleting smart playlist: %s", err.Error())
+					m.showError(fmt.Sprintf("Failed to delete smart playlist: %s", err.Error()))
+				} else if rte := m.CurPageFunc(); rte.Page == Playlist && rte.Arg == playlist.ID {
+					// navigate to playlists page if user is still on the page of the deleted playlist
+					m.NavigateTo(PlaylistsRoute())
+				} else if rte.Page == Playlists {
+					m.ReloadFunc()
+				}
+			}, m.MainWindow)
+	}
+	dlg.OnSave = func() {
+		pop.Hide()
+		m.doModalClosed()
+		if err := m.App.SmartPlaylistManager.SaveSmartPlaylist(dlg.Playlist()); err != nil {
+			log.Printf("error saving smart playlist: %s", err.Error())
+			m.showError(fmt.Sprintf("Failed to save smart playlist: %s", err.Error()))
+			return
+		}
+		if isNew {
+			m.NavigateTo(PlaylistRoute(playlist.ID))
+		} else if rte := m.CurPageFunc(); rte.Page == Playlists || (rte.Page == Playlist && rte.Arg == playlist.ID) {
+			m.ReloadFunc()
+		}
+	}
+	m.haveModal = true
+	pop.Show()
+}
+
+// DoSaveSmartPlaylistToServerWorkflow creates a server playlist
+// with the current tracks of the given smart playlist.
+func (m *Controller) DoSaveSmartPlaylistToServerWorkflow(playlistID string) {
+	go func() {
+		pl, err := m.App.SmartPlaylistManager.GetSmartPlaylist(playlistID)
+		if err != nil {
+			log.Printf("error loading smart playlist: %s", err.Error())
+			m.showError(fmt.Sprintf("Failed to load smart playlist: %s", err.Error()))
+			return
+		}
+		tracks := m.App.SmartPlaylistManager.Evaluate(pl)
+		if err := m.App.ServerManager.Server.CreatePlaylist(pl.Name, sharedutil.TracksToIDs(tracks)); err != nil {
+			log.Printf("error creating playlist: %s", err.Error())
+			m.showError(fmt.Sprintf("Failed to create playlist: %s", err.Error()))
+			return
+		}
+		if rte := m.CurPageFunc(); rte.Page == Playlists {
+			m.ReloadFunc()
+		}
+	}()
+}
+
+// PlaylistOrganization returns the client-side playlist organization
+// of the connected server, or nil if not connected.
+func (m *Controller) PlaylistOrganization() *backend.PlaylistOrganizationConfig {
+	if conf := m.App.ServerManager.CurrentServerConfig(); conf != nil {
+		return &conf.PlaylistOrganization
+	}
+	return nil
+}
+
+// SetPlaylistPinned pins the playlist to (or unpins it from) the navigation sidebar.
+func (m *Controller) SetPlaylistPinned(playlist *mediaprovider.Playlist, pinned bool) {
+	org := m.PlaylistOrganization()
+	if org == nil {
+		return
+	}
+	org.SetPinned(playlist, pinned)
+	if m.OnPinnedPlaylistsChanged != nil {
+		m.OnPinnedPlaylistsChanged()
+	}
+}
+
+// DoMoveToPlaylistFolderWorkflow shows a dialog to choose the folder
+// to file the playlist under, which may be a new or existing folder.
+func (m *Controller) DoMoveToPlaylistFolderWorkflow(playlistID string) {
+	org := m.PlaylistOrganization()
+	if org == nil {
+		return
+	}
+	folder := widget.NewSelectEntry(org.FolderNames())
+	folder.SetPlaceHolder("(No folder)")
+	folder.SetText(org.FolderOf(playlistID))
+	dlg := dialog.NewForm("Move to Folder", "OK", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Folder", folder)},
+		func(ok bool) {
+			m.doModalClosed()
+			if !ok {
+				return
+			}
+			org.SetFolder(playlistID, folder.Text)
+			if m.CurPageFunc().Page == Playlists {
+				m.ReloadFunc()
+			}
+		}, m.MainWindow)
+	dlg.Resize(fyne.NewSize(350, dlg.MinSize().Height))
+	m.haveModal = true
+	dlg.Show()
+}
+
+// DoUnlockCredentialsWorkflow prompts for the master passphrase of the encrypted
+// credentials file, if the OS keyring is unavailable, then calls onDone.
+// If the user cancels, saved passwords are unavailable for this session.
+func (c *Controller) DoUnlockCredentialsWorkflow(onDone func()) {
+	if !c.App.CredentialsLocked() {
+		onDone()
+		return
+	}
+	c.showUnlockCredentialsDialog("", onDone)
+}
+
+func (c *Controller) showUnlockCredentialsDialog(errText string, onDone func()) {
+	create := !c.App.CredentialsFileExists()
+
+	pass := widget.NewPasswordEntry()
+	pass.Validator = func(s string) error {
+		if s == "" {
+			return errors.New("Passphrase is required")
+		}
+		return nil
+	}
+	passItem := widget.NewFormItem("Passphrase", pass)
+	items := []*widget.FormItem{passItem}
+	title, confirmText := "Unlock Saved Passwords", "Unlock"
+	if create {
+		title, confirmText = "Protect Saved Passwords", "OK"
+		passItem.HintText = "Encrypts saved passwords, as no system keyring is available"
+		confirm := widget.NewPasswordEntry()
+		confirm.Validator = func(s string) error {
+			if s != pass.Text {
+				return errors.New("Passphrases don't match")
+			}
+			return nil
+		}
+		items = append(items, widget.NewFormItem("Confirm", confirm))
+	} else {
+		passItem.HintText = "The master passphrase of your saved passwords"
+	}
+	if errText != "" {
+		passItem.HintText = errText
+	}
+
+	dlg := dialog.NewForm(title, confirmText, "Skip", items, func(ok bool) {
+		c.doModalClosed()
+		if !ok {
+			onDone()
+			return
+		}
+		go func() {
+			if err := c.App.UnlockCredentials(pass.Text); err != nil {
+				log.Printf("error unlocking credentials: %v", err)
+				c.showUnlockCredentialsDialog(err.Error(), onDone)
+				return
+			}
+			onDone()
+		}()
+	}, c.MainWindow)
+	dlg.Resize(fyne.NewSize(400, dlg.MinSize().Height))
+	c.haveModal = true
+	dlg.Show()
+	c.MainWindow.Canvas().Focus(pass)
+}
+
 // DoConnectToServerWorkflow does the workflow for connecting to the last active server on startup
 func (c *Controller) DoConnectToServerWorkflow(server *backend.ServerConfig) {
 	pass, err := c.App.ServerManager.GetServerPassword(server.ID)
 	if err != nil {
-		log.Printf("error getting password from keyring: %v", err)
+		log.Printf("error reading saved password: %v", err)
 		c.PromptForLoginAndConnect()
 		return
 	}
@@ -458,6 +786,8 @@ func (m *Controller) PromptForLoginAndConnect() {
 			err := m.App.ServerManager.TestConnectionAndAuth(ctx, server.ServerConnection, password)
 			if err == backend.ErrUnreachable {
 				d.SetErrorText("Server unreachable")
+			} else if errors.Is(err, backend.ErrInvalidHTTPOptions) {
+				d.SetErrorText(err.Error())
 			} else if err != nil {
 				d.SetErrorText("Authentication failed")
 			} else {
@@ -471,6 +801,7 @@ func (m *Controller) PromptForLoginAndConnect() {
 	d.OnEditServer = func(server *backend.ServerConfig) {
 		pop.Hide()
 		editD := dialogs.NewAddEditServerDialog("Edit server", true, server, m.MainWindow.Canvas().Focus)
+		editD.OnQuickConnect = func() { m.doJellyfinQuickConnect(editD) }
 		editPop := widget.NewModalPopUp(editD, m.MainWindow.Canvas())
 		editD.OnSubmit = func() {
 			d.DisableSubmit()
@@ -483,6 +814,9 @@ func (m *Controller) PromptForLoginAndConnect() {
 					server.Nickname = editD.Nickname
 					server.Username = editD.Username
 					server.LegacyAuth = editD.LegacyAuth
+					server.AuthMode = editD.AuthMode
+					server.HTTP = editD.HTTP
+					server.MergeLibrary = editD.MergeLibrary
 					m.trySetPasswordAndConnectToServer(server, editD.Password)
 					m.doModalClosed()
 				}
@@ -498,6 +832,7 @@ func (m *Controller) PromptForLoginAndConnect() {
 	d.OnNewServer = func() {
 		pop.Hide()
 		newD := dialogs.NewAddEditServerDialog("Add server", true, nil, m.MainWindow.Canvas().Focus)
+		newD.OnQuickConnect = func() { m.doJellyfinQuickConnect(newD) }
 		newPop := widget.NewModalPopUp(newD, m.MainWindow.Canvas())
 		newD.OnSubmit = func() {
 			d.DisableSubmit()
@@ -505,14 +840,8 @@ func (m *Controller) PromptForLoginAndConnect() {
 				if m.testConnectionAndUpdateDialogText(newD) {
 					// connection is good
 					newPop.Hide()
-					conn := backend.ServerConnection{
-						ServerType:  newD.ServerType,
-						Hostname:    newD.Host,
-						AltHostname: newD.AltHost,
-						Username:    newD.Username,
-						LegacyAuth:  newD.LegacyAuth,
-					}
-					server := m.App.ServerManager.AddServer(newD.Nickname, conn)
+					server := m.App.ServerManager.AddServer(newD.Nickname, newD.Connection())
+					server.MergeLibrary = newD.MergeLibrary
 					m.trySetPasswordAndConnectToServer(server, newD.Password)
 					m.doModalClosed()
 				}
@@ -559,6 +888,240 @@ func (c *Controller) ShowAboutDialog() {
 	pop.Show()
 }
 
+func (c *Controller) ShowServerInfoDialog() {
+	conf := c.App.ServerManager.CurrentServerConfig()
+	if conf == nil {
+		return
+	}
+	merged := sharedutil.MapSlice(c.App.ServerManager.SecondaryServers(), func(s *backend.ServerConfig) string {
+		return s.Nickname
+	})
+	dlg := dialogs.NewServerInfoDialog(conf.Nickname, conf.Hostname,
+		c.App.ServerManager.Capabilities(), merged)
+	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
+	dlg.OnDismiss = func() {
+		pop.Hide()
+		c.doModalClosed()
+	}
+	c.ClosePopUpOnEscape(pop)
+	c.haveModal = true
+	pop.Show()
+}
+
+// DoEditServerUserWorkflow shows a dialog to edit the user's email and roles
+// on the connected server, or to create a new user if user is nil.
+// onSaved is called (on a background goroutine) after the user is saved.
+func (c *Controller) DoEditServerUserWorkflow(user *mediaprovider.User, onSaved func()) {
+	admin := c.App.ServerManager.Admin()
+	if admin == nil {
+		return
+	}
+	creating := user == nil
+	// the server defaults for new users
+	edited := mediaprovider.User{SettingsRole: true, StreamRole: true}
+	if !creating {
+		edited = *user
+	}
+
+	username := widget.NewEntry()
+	username.SetText(edited.Username)
+	email := widget.NewEntry()
+	email.SetText(edited.Email)
+	password := widget.NewPasswordEntry()
+	maxBitRates := []string{"No limit", "32", "64", "96", "128", "160", "192", "256", "320"}
+	maxBitRate := widget.NewSelect(maxBitRates, nil)
+	maxBitRate.SetSelectedIndex(0)
+	if edited.MaxBitRate > 0 {
+		maxBitRate.SetSelected(strconv.Itoa(edited.MaxBitRate))
+	}
+
+	roles := []struct {
+		name string
+		role *bool
+	}{
+		{"Administrator", &edited.AdminRole},
+		{"Change own settings", &edited.SettingsRole},
+		{"Stream", &edited.StreamRole},
+		{"Download", &edited.DownloadRole},
+		{"Upload", &edited.UploadRole},
+		{"Manage playlists", &edited.PlaylistRole},
+		{"Change cover art and tags", &edited.CoverArtRole},
+		{"Comment and rate", &edited.CommentRole},
+		{"Manage podcasts", &edited.PodcastRole},
+		{"Jukebox", &edited.JukeboxRole},
+		{"Share", &edited.ShareRole},
+	}
+	roleChecks := container.NewGridWithColumns(2)
+	for _, r := range roles {
+		check := widget.NewCheck(r.name, nil)
+		check.SetChecked(*r.role)
+		roleChecks.Add(check)
+	}
+
+	items := []*widget.FormItem{widget.NewFormItem("Username", username)}
+	if creating {
+		username.Validator = func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return errors.New("username is required")
+			}
+			return nil
+		}
+		password.Validator = func(s string) error {
+			if s == "" {
+				return errors.New("password is required")
+			}
+			return nil
+		}
+		items = append(items, widget.NewFormItem("Password", password))
+	} else {
+		username.Disable()
+	}
+	items = append(items,
+		widget.NewFormItem("Email", email),
+		widget.NewFormItem("Max bit rate", maxBitRate),
+		widget.NewFormItem("Roles", roleChecks),
+	)
+
+	title := "Edit User"
+	if creating {
+		title = "Add User"
+	}
+	dlg := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+		c.doModalClosed()
+		if !ok {
+			return
+		}
+		edited.Username = strings.TrimSpace(username.Text)
+		edited.Email = email.Text
+		edited.MaxBitRate, _ = strconv.Atoi(maxBitRate.Selected)
+		for i, r := range roles {
+			*r.role = roleChecks.Objects[i].(*widget.Check).Checked
+		}
+		go func() {
+			var err error
+			if creating {
+				err = admin.CreateUser(&edited, password.Text)
+			} else {
+				err = admin.UpdateUser(&edited)
+			}
+			if err != nil {
+				log.Printf("error saving user %q: %v", edited.Username, err)
+				c.showError(fmt.Sprintf("Failed to save user: %v", err))
+				return
+			}
+			if onSaved != nil {
+				onSaved()
+			}
+		}()
+	}, c.MainWindow)
+	dlg.Resize(fyne.NewSize(500, dlg.MinSize().Height))
+	c.haveModal = true
+	dlg.Show()
+}
+
+// DoChangeServerUserPasswordWorkflow shows a dialog to set a new
+// password for the user on the connected server.
+func (c *Controller) DoChangeServerUserPasswordWorkflow(username string) {
+	admin := c.App.ServerManager.Admin()
+	if admin == nil {
+		return
+	}
+	pass := widget.NewPasswordEntry()
+	pass.Validator = func(s string) error {
+		if s == "" {
+			return errors.New("password is required")
+		}
+		return nil
+	}
+	confirm := widget.NewPasswordEntry()
+	confirm.Validator = func(s string) error {
+		if s != pass.Text {
+			return errors.New("passwords do not match")
+		}
+		return nil
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("New password", pass),
+		widget.NewFormItem("Confirm", confirm),
+	}
+	dlg := dialog.NewForm(fmt.Sprintf("Change Password for %s", username), "Change", "Cancel", items, func(ok bool) {
+		c.doModalClosed()
+		if !ok {
+			return
+		}
+		go func() {
+			if err := admin.ChangePassword(username, pass.Text); err != nil {
+				log.Printf("error changing password of user %q: %v", username, err)
+				c.showError(fmt.Sprintf("Failed to change password: %v", err))
+			}
+		}()
+	}, c.MainWindow)
+	dlg.Resize(fyne.NewSize(400, dlg.MinSize().Height))
+	c.haveModal = true
+	dlg.Show()
+	c.MainWindow.Canvas().Focus(pass)
+}
+
+// DoDeleteServerUserWorkflow asks for confirmation and deletes the user
+// from the connected server, calling onDeleted (on a background goroutine)
+// if successful.
+func (c *Controller) DoDeleteServerUserWorkflow(username string, onDeleted func()) {
+	admin := c.App.ServerManager.Admin()
+	if admin == nil {
+		return
+	}
+	dlg := dialog.NewConfirm("Confirm delete user",
+		fmt.Sprintf("Are you sure you want to delete the user %q from the server?", username),
+		func(ok bool) {
+			c.doModalClosed()
+			if !ok {
+				return
+			}
+			go func() {
+				if err := admin.DeleteUser(username); err != nil {
+					log.Printf("error deleting user %q: %v", username, err)
+					c.showError(fmt.Sprintf("Failed to delete user: %v", err))
+					return
+				}
+				if onDeleted != nil {
+					onDeleted()
+				}
+			}()
+		}, c.MainWindow)
+	c.haveModal = true
+	dlg.Show()
+}
+
+// DoDeleteShareWorkflow asks for confirmation and deletes the share from
+// the connected server, calling onDeleted (on a background goroutine)
+// if successful.
+func (c *Controller) DoDeleteShareWorkflow(share *mediaprovider.Share, onDeleted func()) {
+	admin := c.App.ServerManager.Admin()
+	if admin == nil {
+		return
+	}
+	dlg := dialog.NewConfirm("Confirm delete share",
+		"Are you sure you want to delete this share? The link will stop working.",
+		func(ok bool) {
+			c.doModalClosed()
+			if !ok {
+				return
+			}
+			go func() {
+				if err := admin.DeleteShare(share.ID); err != nil {
+					log.Printf("error deleting share: %v", err)
+					c.showError(fmt.Sprintf("Failed to delete share: %v", err))
+					return
+				}
+				if onDeleted != nil {
+					onDeleted()
+				}
+			}()
+		}, c.MainWindow)
+	c.haveModal = true
+	dlg.Show()
+}
+
 func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map[string]string) {
 	devs, err := c.App.LocalPlayer.ListAudioDevices()
 	if err != nil {
@@ -594,9 +1157,68 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 		copy(eq.BandGains[:], c.App.Config.LocalPlayback.GraphicEqualizerBands)
 		c.App.LocalPlayer.SetEqualizer(eq)
 	}
+	indexMgr := c.App.LibraryIndexManager
+	updateIndexStatus := func() {
+		dlg.SetLibraryIndexStatus(c.libraryIndexStatus())
+	}
+	updateIndexStatus()
+	indexMgr.OnSyncStatusChanged = updateIndexStatus
+	dlg.OnLibraryIndexEnabledChanged = func(enabled bool) {
+		indexMgr.SetEnabled(enabled)
+		updateIndexStatus()
+	}
+	dlg.OnLibraryIndexResync = indexMgr.Resync
+	imageMgr := c.App.ImageManager
+	updateImageCacheSizes := func() {
+		sizes := make(map[uuid.UUID]int64)
+		for _, server := range c.App.Config.Servers {
+			sizes[server.ID] = imageMgr.ServerCacheSize(server.ID)
+		}
+		dlg.SetImageCacheSizes(sizes)
+	}
+	updatePrefetchStatus := func() {
+		status := imageMgr.CoverArtPrefetchStatus()
+		dlg.SetCoverArtPrefetchStatus(status.Running, coverArtPrefetchStatus(status))
+	}
+	updatePrefetchStatus()
+	go updateImageCacheSizes()
+	imageMgr.OnCoverArtPrefetchProgress = func() {
+		updatePrefetchStatus()
+		if !imageMgr.CoverArtPrefetchStatus().Running {
+			updateImageCacheSizes()
+		}
+	}
+	dlg.OnOfflineImageCacheChanged = imageMgr.SetOfflineMode
+	dlg.OnLocalArtworkDirChanged = func() {
+		imageMgr.SetLocalArtworkDir(c.App.Config.Application.LocalArtworkDir)
+	}
+	dlg.OnPrefetchCoverArt = imageMgr.StartCoverArtPrefetch
+	dlg.OnCancelPrefetchCoverArt = imageMgr.CancelCoverArtPrefetch
+	dlg.OnClearImageCache = func(serverID uuid.UUID) {
+		go func() {
+			if err := imageMgr.ClearServerCache(serverID); err != nil {
+				log.Printf("error clearing image cache: %v", err)
+				c.showError("Failed to clear the image cache")
+			}
+			updateImageCacheSizes()
+		}()
+	}
+	scrobbleMgr := c.App.ScrobbleManager
+	dlg.OnConnectLastFM = func() { c.doConnectLastFMWorkflow(dlg.RefreshScrobblingServices) }
+	dlg.OnDisconnectLastFM = func() {
+		scrobbleMgr.DisconnectLastFM()
+		dlg.RefreshScrobblingServices()
+	}
+	dlg.OnConnectListenBrainz = func() { c.doConnectListenBrainzWorkflow(dlg.RefreshScrobblingServices) }
+	dlg.OnDisconnectListenBrainz = func() {
+		scrobbleMgr.DisconnectListenBrainz()
+		dlg.RefreshScrobblingServices()
+	}
 	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
 	dlg.OnDismiss = func() {
 		pop.Hide()
+		indexMgr.OnSyncStatusChanged = nil
+		imageMgr.OnCoverArtPrefetchProgress = nil
 		c.doModalClosed()
 		c.App.SaveConfigFile()
 	}
@@ -605,6 +1227,127 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 	pop.Show()
 }
 
+// doConnectLastFMWorkflow asks for the Last.fm API account to use,
+// has the user authorize the app in the browser, and then completes
+// the authorization to enable scrobbling to Last.fm.
+// It is shown over the settings dialog, so it doesn't manage the modal state.
+func (c *Controller) doConnectLastFMWorkflow(onConnected func()) {
+	cfg := &c.App.Config.Scrobbling.LastFM
+	apiKey := widget.NewEntry()
+	apiKey.SetText(cfg.APIKey)
+	apiSecret := widget.NewPasswordEntry()
+	apiSecret.SetText(c.App.ScrobbleManager.LastFMAPISecret())
+	dlg := dialog.NewForm("Connect to Last.fm", "Continue", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("API key", apiKey),
+			widget.NewFormItem("API secret", apiSecret),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			key, secret := strings.TrimSpace(apiKey.Text), strings.TrimSpace(apiSecret.Text)
+			go func() {
+				token, authURL, err := c.App.ScrobbleManager.BeginLastFMAuth(key, secret)
+				if err != nil {
+					log.Printf("error beginning Last.fm authorization: %v", err)
+					c.showError(err.Error())
+					return
+				}
+				if u, err := url.Parse(authURL); err == nil {
+					fyne.CurrentApp().OpenURL(u)
+				}
+				dialog.ShowConfirm("Connect to Last.fm",
+					"Authorize Supersonic in the browser window that opened,\nthen click Done.",
+					func(ok bool) {
+						if !ok {
+							return
+						}
+						go func() {
+							if err := c.App.ScrobbleManager.CompleteLastFMAuth(token); err != nil {
+								log.Printf("error completing Last.fm authorization: %v", err)
+								c.showError(err.Error())
+								return
+							}
+							c.App.SaveConfigFile()
+							onConnected()
+						}()
+					}, c.MainWindow)
+			}()
+		}, c.MainWindow)
+	dlg.Resize(fyne.NewSize(400, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// doConnectListenBrainzWorkflow asks for the user's ListenBrainz
+// token and enables scrobbling to ListenBrainz if it is valid.
+// It is shown over the settings dialog, so it doesn't manage the modal state.
+func (c *Controller) doConnectListenBrainzWorkflow(onConnected func()) {
+	token := widget.NewPasswordEntry()
+	tokenItem := widget.NewFormItem("User token", token)
+	tokenItem.HintText = "Found on your ListenBrainz profile page"
+	dlg := dialog.NewForm("Connect to ListenBrainz", "Connect", "Cancel",
+		[]*widget.FormItem{tokenItem},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			go func() {
+				if err := c.App.ScrobbleManager.ConnectListenBrainz(token.Text); err != nil {
+					log.Printf("error connecting to ListenBrainz: %v", err)
+					c.showError(err.Error())
+					return
+				}
+				c.App.SaveConfigFile()
+				onConnected()
+			}()
+		}, c.MainWindow)
+	dlg.Resize(fyne.NewSize(400, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// libraryIndexStatus returns a description of the library index of the current server.
+func (c *Controller) libraryIndexStatus() string {
+	if !c.App.Config.LibraryIndex.Enabled {
+		return "The library index is disabled."
+	}
+	stats, ok := c.App.LibraryIndexManager.Stats()
+	if !ok {
+		return "The library index is not supported for this server."
+	}
+	counts := fmt.Sprintf("%d tracks, %d albums and %d artists indexed.", stats.Tracks, stats.Albums, stats.Artists)
+	syncing, lastErr := c.App.LibraryIndexManager.SyncStatus()
+	switch {
+	case syncing:
+		return "Syncing... " + counts
+	case lastErr != nil:
+		return fmt.Sprintf("Last sync failed: %s. %s", lastErr.Error(), counts)
+	case stats.LastSync.IsZero():
+		return "Not yet synced."
+	default:
+		return fmt.Sprintf("%s Last synced %s.", counts, stats.LastSync.Format("Jan 2 15:04"))
+	}
+}
+
+func coverArtPrefetchStatus(status backend.CoverArtPrefetchStatus) string {
+	var failed string
+	if status.Failed > 0 {
+		failed = fmt.Sprintf(" (%d failed)", status.Failed)
+	}
+	switch {
+	case status.Running && status.Total == 0:
+		return "Finding cover art..."
+	case status.Running:
+		return fmt.Sprintf("Downloading cover art... %d of %d%s", status.Done, status.Total, failed)
+	case status.Total > 0 && status.Done < status.Total:
+		return fmt.Sprintf("Download cancelled. %d of %d covers cached%s.", status.Done-status.Failed, status.Total, failed)
+	case status.Total > 0:
+		return fmt.Sprintf("Downloaded cover art for %d albums%s.", status.Done-status.Failed, failed)
+	default:
+		return "Cover art is cached as it is displayed."
+	}
+}
+
 func (c *Controller) ShowQuickSearch() {
 	qs := dialogs.NewQuickSearch(c.App.ServerManager.Server, c.App.ImageManager)
 	pop := widget.NewModalPopUp(qs, c.MainWindow.Canvas())
@@ -628,6 +1371,11 @@ func (c *Controller) ShowQuickSearch() {
 			c.NavigateTo(GenreRoute(id))
 		}
 	}
+	qs.OnAdvancedSearch = func(query string) {
+		pop.Hide()
+		c.doModalClosed()
+		c.NavigateTo(AdvancedSearchRoute(query))
+	}
 	c.ClosePopUpOnEscape(pop)
 	c.haveModal = true
 	min := qs.MinSize()
@@ -639,7 +1387,7 @@ func (c *Controller) ShowQuickSearch() {
 
 func (c *Controller) trySetPasswordAndConnectToServer(server *backend.ServerConfig, password string) error {
 	if err := c.App.ServerManager.SetServerPassword(server, password); err != nil {
-		log.Printf("error setting keyring credentials: %v", err)
+		log.Printf("error saving password: %v", err)
 		// Don't return an error; fall back to just using the password in-memory
 		// User will need to log in with the password on subsequent runs.
 	}
@@ -662,26 +1410,61 @@ func (c *Controller) tryConnectToServer(ctx context.Context, server *backend.Ser
 
 func (c *Controller) testConnectionAndUpdateDialogText(dlg *dialogs.AddEditServerDialog) bool {
 	dlg.SetInfoText("Testing connection...")
-	conn := backend.ServerConnection{
-		ServerType:  dlg.ServerType,
-		Hostname:    dlg.Host,
-		AltHostname: dlg.AltHost,
-		Username:    dlg.Username,
-		LegacyAuth:  dlg.LegacyAuth,
-	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	err := c.App.ServerManager.TestConnectionAndAuth(ctx, conn, dlg.Password)
+	err := c.App.ServerManager.TestConnectionAndAuth(ctx, dlg.Connection(), dlg.Password)
 	if err == backend.ErrUnreachable {
 		dlg.SetErrorText("Could not reach server (wrong hostname?)")
 		return false
+	} else if errors.Is(err, backend.ErrInvalidHTTPOptions) {
+		dlg.SetErrorText(err.Error())
+		return false
 	} else if err != nil {
-		dlg.SetErrorText("Authentication failed (wrong username/password)")
+		if dlg.AuthMode == backend.AuthModeAPIKey {
+			dlg.SetErrorText("Authentication failed (invalid key?)")
+		} else {
+			dlg.SetErrorText("Authentication failed (wrong username/password)")
+		}
 		return false
 	}
 	return true
 }
 
+// doJellyfinQuickConnect obtains an access token for the dialog's
+// Jellyfin server by having the user authorize a Quick Connect code
+// from another Jellyfin client where they are signed in.
+func (c *Controller) doJellyfinQuickConnect(dlg *dialogs.AddEditServerDialog) {
+	if err := dlg.ParseHTTPOptions(); err != nil {
+		dlg.SetErrorText(err.Error())
+		return
+	}
+	conn := dlg.Connection()
+	go func() {
+		dlg.SetInfoText("Starting Quick Connect...")
+		qc, err := c.App.ServerManager.StartJellyfinQuickConnect(conn)
+		if err != nil {
+			log.Printf("error starting Quick Connect: %v", err)
+			if errors.Is(err, backend.ErrQuickConnectUnavailable) || errors.Is(err, backend.ErrInvalidHTTPOptions) {
+				dlg.SetErrorText(err.Error())
+			} else {
+				dlg.SetErrorText("Could not reach server (wrong hostname?)")
+			}
+			return
+		}
+		dlg.SetInfoText(fmt.Sprintf("Authorize code %s with Quick Connect in Jellyfin", qc.Code))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		token, username, err := qc.Wait(ctx)
+		if err != nil {
+			log.Printf("error waiting for Quick Connect: %v", err)
+			dlg.SetErrorText("Quick Connect was not authorized")
+			return
+		}
+		dlg.SetAccessToken(token, username)
+		dlg.SetInfoText("Quick Connect authorized")
+	}()
+}
+
 func (c *Controller) doModalClosed() {
 	c.haveModal = false
 	if c.runOnModalClosed != nil {
@@ -702,12 +1485,19 @@ func (c *Controller) SetTrackFavorites(trackIDs []string, favorite bool) {
 
 func (c *Controller) SetTrackRatings(trackIDs []string, rating int) {
 	r, ok := c.App.ServerManager.Server.(mediaprovider.SupportsRating)
-	if !ok {
+	if !c.App.ServerManager.Capabilities().Rating || !ok {
 		return
 	}
-	go r.SetRating(mediaprovider.RatingFavoriteParameters{
-		TrackIDs: trackIDs,
-	}, rating)
+	go func() {
+		// with merged libraries, a track's own server may not support rating
+		err := r.SetRating(mediaprovider.RatingFavoriteParameters{
+			TrackIDs: trackIDs,
+		}, rating)
+		if err != nil {
+			log.Printf("error setting rating: %v", err)
+			c.showError(fmt.Sprintf("Failed to set rating: %v", err))
+		}
+	}()
 
 	// Notify PlaybackManager of rating change to update
 	// the in-memory track models
@@ -746,17 +1536,21 @@ func (c *Controller) ShowShareDialog(id string) {
 
 func (c *Controller) createShareURL(id string) (*url.URL, error) {
 	r, ok := c.App.ServerManager.Server.(mediaprovider.SupportsSharing)
-	if !ok {
+	if !c.App.ServerManager.Capabilities().Sharing || !ok {
 		return nil, fmt.Errorf("server does not support sharing")
 	}
 
 	shareUrl, err := r.CreateShareURL(id)
 	if err != nil {
 		log.Printf("error creating share URL: %v", err)
-		c.showError(
-			"Failed to share content. This commonly occurs when the server does not support sharing," +
-				"or has the feature disabled.\nPlease check the server's settings and try again.",
-		)
+		if errors.Is(err, aggregate.ErrNotSupported) {
+			c.showError("Failed to share content. Its server does not support sharing.")
+		} else {
+			c.showError(
+				"Failed to share content. This commonly occurs when the server does not support sharing," +
+					"or has the feature disabled.\nPlease check the server's settings and try again.",
+			)
+		}
 		return nil, err
 	}
 	return shareUrl, nil
@@ -855,6 +1649,207 @@ func (c *Controller) downloadTracks(tracks []*mediaprovider.Track, filePath, dow
 	c.sendNotification(fmt.Sprintf("Download completed: %s", downloadName), fmt.Sprintf("Saved at: %s", filePath))
 }
 
+// ShowExportPlaylistDialog exports the tracks to a playlist file.
+// The file format is determined by the extension the user chooses.
+func (c *Controller) ShowExportPlaylistDialog(tracks []*mediaprovider.Track, playlistName string) {
+	dg := dialog.NewFileSave(
+		func(file fyne.URIWriteCloser, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if file == nil {
+				return
+			}
+			defer file.Close()
+			format, err := backend.PlaylistFormatForFile(file.URI().Path())
+			if err != nil {
+				format = backend.PlaylistFormatM3U8
+			}
+			skipped, err := backend.WritePlaylistFile(file, format, playlistName, tracks)
+			if err != nil {
+				log.Printf("error exporting playlist: %s", err.Error())
+				c.showError(fmt.Sprintf("Failed to export playlist: %s", err.Error()))
+			} else if skipped > 0 {
+				dialog.ShowInformation("Playlist Exported",
+					fmt.Sprintf("%d of %d tracks were not exported because the server did not report their file paths.", skipped, len(tracks)),
+					c.MainWindow)
+			}
+		},
+		c.MainWindow)
+	dg.SetFilter(&storage.ExtensionFileFilter{Extensions: backend.PlaylistFileExtensions})
+	dg.SetFileName(sanitizeFileName(playlistName) + ".m3u8")
+	dg.Show()
+}
+
+// DoImportPlaylistWorkflow imports a playlist file as a new server playlist,
+// matching the file's entries to tracks on the server.
+func (c *Controller) DoImportPlaylistWorkflow() {
+	dg := dialog.NewFileOpen(
+		func(file fyne.URIReadCloser, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if file == nil {
+				return
+			}
+			defer file.Close()
+			filePath := file.URI().Path()
+			format, err := backend.PlaylistFormatForFile(filePath)
+			if err != nil {
+				c.showError(err.Error())
+				return
+			}
+			name, entries, err := backend.ReadPlaylistFile(file, format)
+			if err != nil {
+				log.Printf("error reading playlist file: %s", err.Error())
+				c.showError(fmt.Sprintf("Failed to read playlist file: %s", err.Error()))
+				return
+			}
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+			}
+			go c.importPlaylistEntries(name, entries)
+		},
+		c.MainWindow)
+	dg.SetFilter(&storage.ExtensionFileFilter{Extensions: backend.PlaylistFileExtensions})
+	dg.Show()
+}
+
+func (c *Controller) importPlaylistEntries(name string, entries []backend.PlaylistFileEntry) {
+	progress := dialog.NewProgress("Importing Playlist", fmt.Sprintf("Matching tracks for %s", name), c.MainWindow)
+	progress.Show()
+	result := backend.ResolvePlaylistEntries(c.App.ServerManager.Server, entries, func(done, total int) {
+		progress.SetValue(float64(done) / float64(total))
+	})
+	progress.Hide()
+
+	if len(result.Tracks) == 0 {
+		c.showError(fmt.Sprintf("None of the %d entries in %s matched a track on the server", len(entries), name))
+		return
+	}
+	if err := c.App.ServerManager.Server.CreatePlaylist(name, sharedutil.TracksToIDs(result.Tracks)); err != nil {
+		log.Printf("error creating playlist: %s", err.Error())
+		c.showError(fmt.Sprintf("Failed to create playlist: %s", err.Error()))
+		return
+	}
+	if rte := c.CurPageFunc(); rte.Page == Playlists {
+		c.ReloadFunc()
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("Imported %d of %d tracks into %s.", len(result.Tracks), len(entries), name))
+	content := container.NewVBox(summary)
+	var unmatchedList fyne.CanvasObject
+	if len(result.Unmatched) > 0 {
+		content.Add(widget.NewLabel("The following entries could not be matched:"))
+		unmatched := sharedutil.MapSlice(result.Unmatched, func(e backend.PlaylistFileEntry) string {
+			return e.String()
+		})
+		unmatchedText := widget.NewLabel(strings.Join(unmatched, "\n"))
+		unmatchedText.Wrapping = fyne.TextTruncate
+		scroll := container.NewVScroll(unmatchedText)
+		scroll.SetMinSize(fyne.NewSize(400, 200))
+		unmatchedList = scroll
+	}
+	dlg := dialog.NewCustom("Playlist Imported", "OK", container.NewBorder(content, nil, nil, nil, unmatchedList), c.MainWindow)
+	dlg.Show()
+}
+
+// DoMigrateServerWorkflow copies playlists, favorites and ratings
+// from one configured server to another.
+func (c *Controller) DoMigrateServerWorkflow() {
+	dlg := dialogs.NewMigrationDialog(c.App.Config.Servers)
+	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
+	c.ClosePopUpOnEscape(pop)
+	dlg.OnCanceled = func() {
+		pop.Hide()
+		c.doModalClosed()
+	}
+	dlg.OnSubmit = func(src, dst *backend.ServerConfig, opts backend.MigrationOptions) {
+		pop.Hide()
+		c.doModalClosed()
+		go c.runServerMigration(src, dst, opts)
+	}
+	c.haveModal = true
+	pop.Show()
+}
+
+func (c *Controller) runServerMigration(src, dst *backend.ServerConfig, opts backend.MigrationOptions) {
+	progress := dialog.NewProgressInfinite("Migrating", fmt.Sprintf("Connecting to %s", src.Nickname), c.MainWindow)
+	progress.Show()
+	srcMP, err := c.App.ServerManager.OpenSecondaryProvider(src)
+	if err != nil {
+		progress.Hide()
+		c.showError(fmt.Sprintf("Failed to connect to %s: %s", src.Nickname, err.Error()))
+		return
+	}
+	dstMP, err := c.App.ServerManager.OpenSecondaryProvider(dst)
+	if err != nil {
+		progress.Hide()
+		c.showError(fmt.Sprintf("Failed to connect to %s: %s", dst.Nickname, err.Error()))
+		return
+	}
+	progress.Hide()
+
+	// the migration runs on this goroutine, so update the status
+	// label through a data binding, which is safe to set from any goroutine
+	status := binding.NewString()
+	statusDlg := dialog.NewCustomWithoutButtons("Migrating",
+		container.NewVBox(widget.NewLabelWithData(status), widget.NewProgressBarInfinite()), c.MainWindow)
+	statusDlg.Show()
+	migration := backend.NewServerMigration(srcMP, dstMP)
+	migration.OnProgress = func(step string) { _ = status.Set(step) }
+	report := migration.Run(opts)
+	statusDlg.Hide()
+
+	if !opts.DryRun && dst.ID == c.App.ServerManager.ServerID {
+		c.ReloadFunc()
+	}
+	c.showMigrationReport(src, dst, report)
+}
+
+func (c *Controller) showMigrationReport(src, dst *backend.ServerConfig, report *backend.MigrationReport) {
+	verb := "Migrated"
+	if report.DryRun {
+		verb = "Would migrate"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s from %s to %s:\n", verb, src.Nickname, dst.Nickname)
+	fmt.Fprintf(&sb, "%d playlists, %d favorite tracks, %d favorite albums, %d favorite artists, %d track ratings\n",
+		len(report.PlaylistsCreated), report.FavoriteTracks, report.FavoriteAlbums, report.FavoriteArtists, report.RatedTracks)
+	if len(report.Errors) > 0 {
+		sb.WriteString("\nErrors:\n")
+		sb.WriteString(strings.Join(report.Errors, "\n"))
+		sb.WriteString("\n")
+	}
+	if len(report.Unmatched) > 0 {
+		fmt.Fprintf(&sb, "\n%d items could not be matched:\n", len(report.Unmatched))
+		sb.WriteString(strings.Join(report.Unmatched, "\n"))
+	}
+	text := widget.NewLabel(sb.String())
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(500, 300))
+	title := "Migration Complete"
+	if report.DryRun {
+		title = "Migration Dry Run Report"
+	}
+	dialog.NewCustom(title, "OK", scroll, c.MainWindow).Show()
+}
+
+// replaces characters which are not allowed in file names on some platforms
+func sanitizeFileName(name string) string {
+	if name == "" {
+		return "playlist"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
 func (c *Controller) sendNotification(title, content string) {
 	fyne.CurrentApp().SendNotification(&fyne.Notification{
 		Title:   title,
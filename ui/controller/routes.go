@@ -8,6 +8,7 @@ const (
 	Albums
 	Artist
 	Artists
+	Composers
 	Genre
 	Genres
 	Favorites
@@ -15,6 +16,9 @@ const (
 	Playlist
 	Playlists
 	Tracks
+	AdvancedSearch
+	Stats
+	ServerAdmin
 )
 
 type Route struct {
@@ -34,6 +38,10 @@ func AlbumRoute(albumID string) Route {
 	return Route{Page: Album, Arg: albumID}
 }
 
+func ComposersRoute() Route {
+	return Route{Page: Composers}
+}
+
 func FavoritesRoute() Route {
 	return Route{Page: Favorites}
 }
@@ -57,6 +65,12 @@ func TracksRoute() Route {
 	return Route{Page: Tracks}
 }
 
+// ComposerTracksRoute is the route to the Tracks page,
+// filtered to the tracks of the given composer.
+func ComposerTracksRoute(composer string) Route {
+	return Route{Page: Tracks, Arg: composer}
+}
+
 func ArtistsRoute() Route {
 	return Route{Page: Artists}
 }
@@ -64,3 +78,17 @@ func ArtistsRoute() Route {
 func NowPlayingRoute(highlightedTrackID string) Route {
 	return Route{Page: NowPlaying, Arg: highlightedTrackID}
 }
+
+// AdvancedSearchRoute is the route to the Advanced Search page,
+// optionally with an initial query.
+func AdvancedSearchRoute(query string) Route {
+	return Route{Page: AdvancedSearch, Arg: query}
+}
+
+func StatsRoute() Route {
+	return Route{Page: Stats}
+}
+
+func ServerAdminRoute() Route {
+	return Route{Page: ServerAdmin}
+}
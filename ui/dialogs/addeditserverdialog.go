@@ -1,6 +1,10 @@
 package dialogs
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/dweymouth/supersonic/backend"
 
 	"fyne.io/fyne/v2"
@@ -21,13 +25,25 @@ type AddEditServerDialog struct {
 	Username   string
 	Password   string
 	LegacyAuth bool
-	OnSubmit   func()
-	OnCancel   func()
+	AuthMode   backend.AuthMode
+	HTTP       backend.HTTPOptions
+
+	// MergeLibrary connects to the server alongside the active
+	// server, merging their libraries (see backend.ServerConfig)
+	MergeLibrary bool
+
+	OnSubmit func()
+	OnCancel func()
 
-	passField  *widget.Entry
-	submitBtn  *widget.Button
-	promptText *widget.RichText
-	container  *fyne.Container
+	// OnQuickConnect is called to obtain a Jellyfin access token with Quick Connect
+	OnQuickConnect func()
+
+	userField    *widget.Entry
+	passField    *widget.Entry
+	headersField *widget.Entry
+	submitBtn    *widget.Button
+	promptText   *widget.RichText
+	container    *fyne.Container
 }
 
 var _ fyne.Widget = (*AddEditServerDialog)(nil)
@@ -42,18 +58,58 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 		a.AltHost = prefillServer.AltHostname
 		a.Username = prefillServer.Username
 		a.LegacyAuth = prefillServer.LegacyAuth
+		a.AuthMode = prefillServer.AuthMode
+		a.HTTP = prefillServer.HTTP
+		a.MergeLibrary = prefillServer.MergeLibrary
 	}
 
 	titleLabel := widget.NewLabel(title)
 	titleLabel.TextStyle.Bold = true
+	if a.AuthMode == "" {
+		a.AuthMode = backend.AuthModePassword
+	}
 	legacyAuthCheck := widget.NewCheckWithData("Use legacy authentication", binding.BindBool(&a.LegacyAuth))
-	serverTypeChoice := widget.NewRadioGroup([]string{"Subsonic", "Jellyfin"}, func(s string) {
-		a.ServerType = backend.ServerType(s)
-		if s == string(backend.ServerTypeSubsonic) {
-			legacyAuthCheck.Show()
+	passLabel := widget.NewLabel("Password")
+	quickConnectBtn := widget.NewButton("Quick Connect...", func() {
+		if a.OnQuickConnect != nil {
+			a.OnQuickConnect()
+		}
+	})
+	authModeChoice := widget.NewRadioGroup([]string{"Password", "API key"}, nil)
+	authModeChoice.Required = true
+	authModeChoice.Horizontal = true
+	updateAuthWidgets := func() {
+		jellyfin := a.ServerType == backend.ServerTypeJellyfin
+		apiKey := a.AuthMode == backend.AuthModeAPIKey
+		legacyAuthCheck.Hidden = jellyfin || apiKey
+		quickConnectBtn.Hidden = !(jellyfin && apiKey)
+		switch {
+		case !apiKey:
+			passLabel.SetText("Password")
+		case jellyfin:
+			passLabel.SetText("Access token")
+		default:
+			passLabel.SetText("API key")
+		}
+		if apiKey {
+			a.userField.SetPlaceHolder("(optional)")
 		} else {
-			legacyAuthCheck.Hide()
+			a.userField.SetPlaceHolder("")
 		}
+		legacyAuthCheck.Refresh()
+		quickConnectBtn.Refresh()
+	}
+	authModeChoice.OnChanged = func(s string) {
+		if s == "API key" {
+			a.AuthMode = backend.AuthModeAPIKey
+		} else {
+			a.AuthMode = backend.AuthModePassword
+		}
+		updateAuthWidgets()
+	}
+	serverTypeChoice := widget.NewRadioGroup([]string{"Subsonic", "Jellyfin"}, func(s string) {
+		a.ServerType = backend.ServerType(s)
+		updateAuthWidgets()
 	})
 	serverTypeChoice.Required = true
 	serverTypeChoice.Horizontal = true
@@ -62,13 +118,19 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 		selected = backend.ServerTypeJellyfin
 	}
 	serverTypeChoice.Selected = string(selected)
+	a.ServerType = selected
+	if a.AuthMode == backend.AuthModeAPIKey {
+		authModeChoice.Selected = "API key"
+	} else {
+		authModeChoice.Selected = "Password"
+	}
 	a.passField = widget.NewPasswordEntry()
 	a.passField.OnSubmitted = func(_ string) { a.doSubmit() }
-	userField := widget.NewEntryWithData(binding.BindString(&a.Username))
-	userField.OnSubmitted = func(_ string) { focusHandler(a.passField) }
+	a.userField = widget.NewEntryWithData(binding.BindString(&a.Username))
+	a.userField.OnSubmitted = func(_ string) { focusHandler(a.passField) }
 	altHostField := widget.NewEntryWithData(binding.BindString(&a.AltHost))
 	altHostField.SetPlaceHolder("(optional) https://my-external-domain.net/music")
-	altHostField.OnSubmitted = func(_ string) { focusHandler(userField) }
+	altHostField.OnSubmitted = func(_ string) { focusHandler(a.userField) }
 	hostField := widget.NewEntryWithData(binding.BindString(&a.Host))
 	hostField.SetPlaceHolder("http://localhost:4533")
 	hostField.OnSubmitted = func(_ string) { focusHandler(altHostField) }
@@ -94,6 +156,39 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 			a.submitBtn)
 	}
 
+	a.headersField = widget.NewMultiLineEntry()
+	a.headersField.SetPlaceHolder("Header-Name: value (one per line)")
+	a.headersField.SetMinRowsVisible(2)
+	a.headersField.Text = formatHeaders(a.HTTP.Headers)
+	clientCertField := widget.NewEntryWithData(binding.BindString(&a.HTTP.ClientCertFile))
+	clientCertField.SetPlaceHolder("(optional) PEM file path")
+	clientKeyField := widget.NewEntryWithData(binding.BindString(&a.HTTP.ClientKeyFile))
+	clientKeyField.SetPlaceHolder("(optional) if not in certificate file")
+	caCertField := widget.NewEntryWithData(binding.BindString(&a.HTTP.CACertFile))
+	caCertField.SetPlaceHolder("(optional) PEM file path")
+	proxyField := widget.NewEntryWithData(binding.BindString(&a.HTTP.ProxyURL))
+	proxyField.SetPlaceHolder("(optional) socks5://localhost:1080")
+	mergeLibraryCheck := widget.NewCheckWithData("Merge library with the active server", binding.BindBool(&a.MergeLibrary))
+	advanced := widget.NewAccordion(widget.NewAccordionItem("Advanced connection options",
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("HTTP headers"),
+			a.headersField,
+			widget.NewLabel("Client cert."),
+			clientCertField,
+			widget.NewLabel("Client key"),
+			clientKeyField,
+			widget.NewLabel("CA certificates"),
+			caCertField,
+			widget.NewLabel("Proxy"),
+			proxyField,
+			layout.NewSpacer(),
+			mergeLibraryCheck,
+		)))
+	if !a.HTTP.IsZero() || a.MergeLibrary {
+		advanced.Open(0)
+	}
+
+	updateAuthWidgets()
 	a.container = container.NewVBox(
 		container.NewHBox(layout.NewSpacer(), titleLabel, layout.NewSpacer()),
 		container.New(layout.NewFormLayout(),
@@ -105,12 +200,15 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 			hostField,
 			widget.NewLabel("Alt. Hostname"),
 			altHostField,
+			widget.NewLabel("Sign in with"),
+			authModeChoice,
 			widget.NewLabel("Username"),
-			userField,
-			widget.NewLabel("Password"),
+			a.userField,
+			passLabel,
 			a.passField,
 		),
-		container.NewHBox(layout.NewSpacer(), legacyAuthCheck),
+		container.NewHBox(layout.NewSpacer(), quickConnectBtn, legacyAuthCheck),
+		advanced,
 		widget.NewSeparator(),
 		bottomRow,
 	)
@@ -135,8 +233,45 @@ func (a *AddEditServerDialog) DisableSubmit() {
 	a.submitBtn.Refresh()
 }
 
+// Connection returns the server connection entered in the dialog.
+func (a *AddEditServerDialog) Connection() backend.ServerConnection {
+	return backend.ServerConnection{
+		ServerType:  a.ServerType,
+		Hostname:    a.Host,
+		AltHostname: a.AltHost,
+		Username:    a.Username,
+		LegacyAuth:  a.LegacyAuth,
+		AuthMode:    a.AuthMode,
+		HTTP:        a.HTTP,
+	}
+}
+
+// SetAccessToken fills in an access token obtained for the user,
+// such as by Jellyfin Quick Connect.
+func (a *AddEditServerDialog) SetAccessToken(token, username string) {
+	a.passField.SetText(token)
+	if username != "" {
+		a.userField.SetText(username)
+	}
+}
+
+// ParseHTTPOptions updates the HTTP options from the
+// dialog's fields, returning an error if they are invalid.
+func (a *AddEditServerDialog) ParseHTTPOptions() error {
+	headers, err := parseHeaders(a.headersField.Text)
+	if err != nil {
+		return err
+	}
+	a.HTTP.Headers = headers
+	return nil
+}
+
 func (a *AddEditServerDialog) doSubmit() {
 	a.Password = a.passField.Text
+	if err := a.ParseHTTPOptions(); err != nil {
+		a.SetErrorText(err.Error())
+		return
+	}
 	if a.OnSubmit != nil {
 		a.OnSubmit()
 	}
@@ -168,3 +303,37 @@ func (a *AddEditServerDialog) MinSize() fyne.Size {
 func (a *AddEditServerDialog) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(a.container)
 }
+
+// parseHeaders parses HTTP headers given one per line as "Name: value".
+func parseHeaders(text string) (map[string]string, error) {
+	var headers map[string]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("Invalid HTTP header: %q", line)
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+func formatHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = name + ": " + headers[name]
+	}
+	return strings.Join(lines, "\n")
+}
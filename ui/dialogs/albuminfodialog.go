@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"image"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/ui/layouts"
+	"github.com/dweymouth/supersonic/ui/util"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -68,14 +70,26 @@ func (a *AlbumInfoDialog) buildMainContainer(albumInfo *mediaprovider.AlbumInfo,
 		infoContent = a.infoLabel(albumInfo.Notes)
 	}
 
-	urlContainer := a.buildUrlContainer(albumInfo.LastFmUrl, albumInfo.MusicBrainzID)
+	urlContainer := a.buildUrlContainer(albumInfo)
+
+	content := container.NewVBox(infoContent)
+	if details := a.buildDetailsContainer(albumInfo); details != nil {
+		content.Add(details)
+	}
+	var mainContent fyne.CanvasObject = content
+	if len(albumInfo.TrackCredits) > 0 {
+		mainContent = container.NewAppTabs(
+			container.NewTabItem("Info", content),
+			container.NewTabItem("Credits", a.buildCreditsContainer(albumInfo.TrackCredits)),
+		)
+	}
 
 	return container.New(
 		&layouts.MaxPadLayout{PadLeft: 15, PadRight: 10, PadTop: 15, PadBottom: 10},
 		container.NewVBox(
 			iconImage,
 			title,
-			infoContent,
+			mainContent,
 			urlContainer,
 		),
 	)
@@ -96,19 +110,104 @@ func (a *AlbumInfoDialog) infoLabel(info string) *widget.Label {
 	return lbl
 }
 
-func (a *AlbumInfoDialog) buildUrlContainer(lastFm, musicBrainzID string) *fyne.Container {
-	urls := make([]*widget.Hyperlink, 0)
+func (a *AlbumInfoDialog) buildDetailsContainer(albumInfo *mediaprovider.AlbumInfo) *fyne.Container {
+	var items []fyne.CanvasObject
+	addRow := func(name, value string) {
+		if value == "" {
+			return
+		}
+		nameLbl := widget.NewLabelWithStyle(name, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true})
+		valueLbl := widget.NewLabel(value)
+		valueLbl.Wrapping = fyne.TextWrapWord
+		items = append(items, nameLbl, valueLbl)
+	}
+
+	addRow("Released", albumInfo.ReleaseDate)
+	if albumInfo.ReleaseTypes != 0 {
+		addRow("Type", util.DisplayReleaseType(albumInfo.ReleaseTypes))
+	}
+	labelTitle := "Label"
+	if len(albumInfo.RecordLabels) > 1 {
+		labelTitle = "Labels"
+	}
+	addRow(labelTitle, strings.Join(albumInfo.RecordLabels, ", "))
+	addRow("Catalog #", albumInfo.CatalogNumber)
 
-	if lastFm != "" {
-		if lastFmUrl, err := url.Parse(lastFm); err == nil {
-			urls = append(urls, widget.NewHyperlink("Last.fm", lastFmUrl))
+	if len(items) == 0 {
+		return nil
+	}
+	return container.New(layout.NewFormLayout(), items...)
+}
+
+func (a *AlbumInfoDialog) buildCreditsContainer(credits []*mediaprovider.TrackCredits) fyne.CanvasObject {
+	showDiscNumber := len(credits) > 0 && credits[0].DiscNumber != credits[len(credits)-1].DiscNumber
+	vbox := container.NewVBox()
+	for _, tr := range credits {
+		trackNum := strconv.Itoa(tr.TrackNumber)
+		if showDiscNumber {
+			trackNum = fmt.Sprintf("%d-%d", tr.DiscNumber, tr.TrackNumber)
+		}
+		segments := []widget.RichTextSegment{
+			&widget.TextSegment{Text: fmt.Sprintf("%s. %s", trackNum, tr.Name), Style: widget.RichTextStyleStrong},
+		}
+		if len(tr.Composers) > 0 {
+			segments = append(segments, &widget.TextSegment{
+				Text:  "Composed by " + strings.Join(tr.Composers, ", "),
+				Style: widget.RichTextStyleParagraph,
+			})
 		}
+		if len(tr.Performers) > 0 {
+			performers := make([]string, 0, len(tr.Performers))
+			for _, p := range tr.Performers {
+				performers = append(performers, fmt.Sprintf("%s (%s)", p.Name, contributorRoleDisplay(p)))
+			}
+			segments = append(segments, &widget.TextSegment{
+				Text:  strings.Join(performers, ", "),
+				Style: widget.RichTextStyleParagraph,
+			})
+		}
+		rt := widget.NewRichText(segments...)
+		rt.Wrapping = fyne.TextWrapWord
+		vbox.Add(rt)
 	}
+	scroll := container.NewVScroll(vbox)
+	scroll.SetMinSize(fyne.NewSize(0, 250))
+	return scroll
+}
 
-	if musicBrainzID != "" {
-		if musicBrainzUrl, err := url.Parse(fmt.Sprintf("%s/%s", musicBrainzReleaseUrl, musicBrainzID)); err == nil {
-			urls = append(urls, widget.NewHyperlink("MusicBrainz", musicBrainzUrl))
+func contributorRoleDisplay(c mediaprovider.Contributor) string {
+	if c.SubRole != "" {
+		return c.SubRole
+	}
+	if c.Role == "" {
+		return "performer"
+	}
+	return c.Role
+}
+
+func (a *AlbumInfoDialog) buildUrlContainer(albumInfo *mediaprovider.AlbumInfo) *fyne.Container {
+	urls := make([]*widget.Hyperlink, 0)
+	haveURLFor := make(map[string]bool)
+	addURL := func(name, u string) {
+		if haveURLFor[name] {
+			return
 		}
+		if parsed, err := url.Parse(u); err == nil {
+			urls = append(urls, widget.NewHyperlink(name, parsed))
+			haveURLFor[name] = true
+		}
+	}
+
+	if albumInfo.LastFmUrl != "" {
+		addURL("Last.fm", albumInfo.LastFmUrl)
+	}
+
+	if albumInfo.MusicBrainzID != "" {
+		addURL("MusicBrainz", fmt.Sprintf("%s/%s", musicBrainzReleaseUrl, albumInfo.MusicBrainzID))
+	}
+
+	for _, u := range albumInfo.ExternalURLs {
+		addURL(u.Name, u.URL)
 	}
 
 	urlContainer := container.New(&layouts.HboxCustomPadding{DisableThemePad: true, ExtraPad: -10})
@@ -25,6 +25,7 @@ type LoginDialog struct {
 	servers []*backend.ServerConfig
 
 	serverSelect *widget.Select
+	passLabel    *widget.Label
 	passField    *widget.Entry
 	promptText   *widget.RichText
 	submitBtn    *widget.Button
@@ -41,9 +42,11 @@ func NewLoginDialog(servers []*backend.ServerConfig, pwFetch PasswordFetchFunc)
 	titleLabel.TextStyle.Bold = true
 	l.passField = widget.NewPasswordEntry()
 	l.passField.OnSubmitted = func(_ string) { l.onSubmit() }
+	l.passLabel = widget.NewLabel("Password")
 
 	serverNames := sharedutil.MapSlice(servers, func(s *backend.ServerConfig) string { return s.Nickname })
 	l.serverSelect = widget.NewSelect(serverNames, func(_ string) {
+		l.passLabel.SetText(passwordLabel(l.servers[l.serverSelect.SelectedIndex()]))
 		if pwFetch != nil {
 			if pw, err := pwFetch(servers[l.serverSelect.SelectedIndex()].ID); err == nil {
 				l.passField.SetText(pw)
@@ -68,7 +71,7 @@ func NewLoginDialog(servers []*backend.ServerConfig, pwFetch PasswordFetchFunc)
 		container.New(layout.NewFormLayout(),
 			widget.NewLabel("Server"),
 			container.NewBorder(nil, nil, nil, container.NewHBox(editBtn, newBtn, deleteBtn), l.serverSelect),
-			widget.NewLabel("Password"),
+			l.passLabel,
 			l.passField),
 		widget.NewSeparator(),
 		container.NewHBox(l.promptText, layout.NewSpacer(), l.submitBtn),
@@ -76,6 +79,18 @@ func NewLoginDialog(servers []*backend.ServerConfig, pwFetch PasswordFetchFunc)
 	return l
 }
 
+// passwordLabel returns the label for the credential used to log in to the server.
+func passwordLabel(server *backend.ServerConfig) string {
+	switch {
+	case server.AuthMode != backend.AuthModeAPIKey:
+		return "Password"
+	case server.ServerType == backend.ServerTypeJellyfin:
+		return "Access token"
+	default:
+		return "API key"
+	}
+}
+
 func (l *LoginDialog) SetInfoText(text string) {
 	l.doSetPromptText(text, theme.ColorNameForeground)
 }
@@ -0,0 +1,115 @@
+package dialogs
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// MigrationDialog chooses the source and destination servers
+// and the data to copy for a cross-server migration.
+type MigrationDialog struct {
+	widget.BaseWidget
+
+	OnCanceled func()
+	OnSubmit   func(src, dst *backend.ServerConfig, opts backend.MigrationOptions)
+
+	servers      []*backend.ServerConfig
+	srcSelect    *widget.Select
+	dstSelect    *widget.Select
+	playlists    *widget.Check
+	favorites    *widget.Check
+	ratings      *widget.Check
+	dryRun       *widget.Check
+	okBtn        *widget.Button
+	container    *fyne.Container
+	sameSrvLabel *widget.Label
+}
+
+func NewMigrationDialog(servers []*backend.ServerConfig) *MigrationDialog {
+	m := &MigrationDialog{servers: servers}
+	m.ExtendBaseWidget(m)
+
+	titleLabel := widget.NewLabel("Migrate Between Servers")
+	titleLabel.TextStyle.Bold = true
+	names := sharedutil.MapSlice(servers, func(s *backend.ServerConfig) string {
+		return s.Nickname
+	})
+	m.srcSelect = widget.NewSelect(names, func(_ string) { m.validate() })
+	m.srcSelect.PlaceHolder = "(Choose server)"
+	m.dstSelect = widget.NewSelect(names, func(_ string) { m.validate() })
+	m.dstSelect.PlaceHolder = "(Choose server)"
+	m.sameSrvLabel = widget.NewLabel("Source and destination must be different servers")
+	m.sameSrvLabel.Importance = widget.WarningImportance
+	m.sameSrvLabel.Hidden = true
+
+	m.playlists = widget.NewCheck("Playlists", func(_ bool) { m.validate() })
+	m.playlists.Checked = true
+	m.favorites = widget.NewCheck("Favorites", func(_ bool) { m.validate() })
+	m.favorites.Checked = true
+	m.ratings = widget.NewCheck("Ratings", func(_ bool) { m.validate() })
+	m.ratings.Checked = true
+	m.dryRun = widget.NewCheck("Dry run (only report what would be migrated)", nil)
+	m.dryRun.Checked = true
+
+	m.okBtn = widget.NewButton("Start", m.onSubmit)
+	m.okBtn.Importance = widget.HighImportance
+	m.okBtn.Disable()
+	cancelBtn := widget.NewButton("Cancel", func() {
+		if m.OnCanceled != nil {
+			m.OnCanceled()
+		}
+	})
+
+	m.container = container.NewVBox(
+		container.NewHBox(layout.NewSpacer(), titleLabel, layout.NewSpacer()),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("From"), m.srcSelect,
+			widget.NewLabel("To"), m.dstSelect,
+		),
+		m.sameSrvLabel,
+		container.NewHBox(m.playlists, m.favorites, m.ratings),
+		m.dryRun,
+		widget.NewSeparator(),
+		container.NewHBox(layout.NewSpacer(), cancelBtn, m.okBtn),
+	)
+	return m
+}
+
+func (m *MigrationDialog) validate() {
+	src, dst := m.srcSelect.SelectedIndex(), m.dstSelect.SelectedIndex()
+	same := src >= 0 && src == dst
+	if same != !m.sameSrvLabel.Hidden {
+		m.sameSrvLabel.Hidden = !same
+		m.container.Refresh()
+	}
+	if src >= 0 && dst >= 0 && !same && (m.playlists.Checked || m.favorites.Checked || m.ratings.Checked) {
+		m.okBtn.Enable()
+	} else {
+		m.okBtn.Disable()
+	}
+}
+
+func (m *MigrationDialog) onSubmit() {
+	if m.OnSubmit == nil {
+		return
+	}
+	m.OnSubmit(m.servers[m.srcSelect.SelectedIndex()], m.servers[m.dstSelect.SelectedIndex()],
+		backend.MigrationOptions{
+			Playlists: m.playlists.Checked,
+			Favorites: m.favorites.Checked,
+			Ratings:   m.ratings.Checked,
+			DryRun:    m.dryRun.Checked,
+		})
+}
+
+func (m *MigrationDialog) MinSize() fyne.Size {
+	return fyne.NewSize(350, m.BaseWidget.MinSize().Height)
+}
+
+func (m *MigrationDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.container)
+}
@@ -21,14 +21,16 @@ import (
 type QuickSearch struct {
 	widget.BaseWidget
 
-	OnDismiss    func()
-	OnNavigateTo func(mediaprovider.ContentType, string)
+	OnDismiss        func()
+	OnNavigateTo     func(mediaprovider.ContentType, string)
+	OnAdvancedSearch func(query string)
 
 	SearchEntry fyne.Focusable // exported so it can be focused by the Controller
 
 	mp        mediaprovider.MediaProvider
 	imgSource util.ImageFetcher
 
+	query         string
 	resultsMutex  sync.RWMutex
 	searchResults []*mediaprovider.SearchResult
 	loadingDots   *widgets.LoadingDots
@@ -76,12 +78,17 @@ func NewQuickSearch(mp mediaprovider.MediaProvider, im util.ImageFetcher) *Quick
 	)
 
 	dismissBtn := widget.NewButton("Close", q.onDismiss)
+	advancedBtn := widget.NewButton("Advanced Search", func() {
+		if q.OnAdvancedSearch != nil {
+			q.OnAdvancedSearch(q.query)
+		}
+	})
 	title := widget.NewRichText(&widget.TextSegment{Text: "Quick Search", Style: util.BoldRichTextStyle})
 	title.Segments[0].(*widget.TextSegment).Style.Alignment = fyne.TextAlignCenter
 	q.content = container.NewStack(
 		container.NewBorder(
 			container.NewVBox(title, se),
-			container.NewVBox(widget.NewSeparator(), container.NewHBox(layout.NewSpacer(), dismissBtn)),
+			container.NewVBox(widget.NewSeparator(), container.NewHBox(advancedBtn, layout.NewSpacer(), dismissBtn)),
 			nil, nil, q.list),
 		container.NewCenter(q.loadingDots),
 	)
@@ -128,6 +135,7 @@ func (q *QuickSearch) moveSelectionUp() {
 }
 
 func (q *QuickSearch) onSearched(query string) {
+	q.query = query
 	q.loadingDots.Start()
 	var results []*mediaprovider.SearchResult
 	if query != "" {
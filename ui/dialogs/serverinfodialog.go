@@ -0,0 +1,140 @@
+package dialogs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type ServerInfoDialog struct {
+	widget.BaseWidget
+
+	OnDismiss func()
+
+	content fyne.CanvasObject
+}
+
+// NewServerInfoDialog creates a dialog showing the server type and version,
+// and which features are supported. mergedServers are the nicknames of the
+// servers whose libraries are merged with the server's, if any.
+func NewServerInfoDialog(nickname, hostname string, caps *mediaprovider.Capabilities, mergedServers []string) *ServerInfoDialog {
+	s := &ServerInfoDialog{}
+	s.ExtendBaseWidget(s)
+
+	title := widget.NewRichTextWithText(nickname)
+	title.Segments[0].(*widget.TextSegment).Style.TextStyle.Bold = true
+	title.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameSubHeadingText
+	title.Segments[0].(*widget.TextSegment).Style.Alignment = fyne.TextAlignCenter
+	title.Truncation = fyne.TextTruncateEllipsis
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Server", s.buildDetailsContainer(hostname, caps, mergedServers)),
+		container.NewTabItem("Features", s.buildFeaturesContainer(caps)),
+	)
+	if caps.OpenSubsonic {
+		tabs.Append(container.NewTabItem("Extensions", s.buildExtensionsContainer(caps)))
+	}
+
+	s.content = container.NewVBox(
+		title,
+		tabs,
+		widget.NewSeparator(),
+		container.NewHBox(
+			layout.NewSpacer(),
+			widget.NewButton("Close", func() {
+				if s.OnDismiss != nil {
+					s.OnDismiss()
+				}
+			}),
+		),
+	)
+	return s
+}
+
+func (s *ServerInfoDialog) MinSize() fyne.Size {
+	return fyne.NewSize(450, s.BaseWidget.MinSize().Height)
+}
+
+func (s *ServerInfoDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.content)
+}
+
+func (s *ServerInfoDialog) buildDetailsContainer(hostname string, caps *mediaprovider.Capabilities, mergedServers []string) fyne.CanvasObject {
+	var items []fyne.CanvasObject
+	addRow := func(name, value string) {
+		if value == "" {
+			return
+		}
+		nameLbl := widget.NewLabelWithStyle(name, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true})
+		valueLbl := widget.NewLabel(value)
+		valueLbl.Wrapping = fyne.TextWrapWord
+		items = append(items, nameLbl, valueLbl)
+	}
+
+	addRow("Hostname", hostname)
+	addRow("Server", caps.ServerType)
+	addRow("Version", caps.ServerVersion)
+	addRow("API version", caps.APIVersion)
+	if caps.APIVersion != "" {
+		addRow("OpenSubsonic", yesNo(caps.OpenSubsonic))
+	}
+	addRow("Merged with", strings.Join(mergedServers, ", "))
+
+	return container.New(layout.NewFormLayout(), items...)
+}
+
+func (s *ServerInfoDialog) buildFeaturesContainer(caps *mediaprovider.Capabilities) fyne.CanvasObject {
+	features := []struct {
+		name      string
+		supported bool
+	}{
+		{"Ratings", caps.Rating},
+		{"Sharing", caps.Sharing},
+		{"Lyrics", caps.Lyrics},
+		{"Synced lyrics", caps.SyncedLyrics},
+		{"Browse by composer", caps.Composers},
+		{"Filter by mood", caps.Moods},
+		{"Public playlists", caps.PublicPlaylists},
+		{"Transcoding", caps.Transcoding},
+		{"Jukebox", caps.Jukebox},
+		{"Podcasts", caps.Podcasts},
+	}
+	var items []fyne.CanvasObject
+	for _, f := range features {
+		icon := theme.CancelIcon()
+		if f.supported {
+			icon = theme.ConfirmIcon()
+		}
+		items = append(items, widget.NewIcon(icon), widget.NewLabel(f.name))
+	}
+	return container.New(layout.NewFormLayout(), items...)
+}
+
+func (s *ServerInfoDialog) buildExtensionsContainer(caps *mediaprovider.Capabilities) fyne.CanvasObject {
+	if len(caps.Extensions) == 0 {
+		return widget.NewLabel("The server reports no OpenSubsonic extensions")
+	}
+	lines := sharedutil.MapSlice(caps.Extensions, func(e mediaprovider.Extension) string {
+		versions := sharedutil.MapSlice(e.Versions, func(v int) string { return fmt.Sprint(v) })
+		return fmt.Sprintf("%s (version %s)", e.Name, strings.Join(versions, ", "))
+	})
+	lbl := widget.NewLabel(strings.Join(lines, "\n"))
+	scroll := container.NewVScroll(lbl)
+	scroll.SetMinSize(fyne.NewSize(0, 200))
+	return scroll
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
@@ -15,6 +15,7 @@ import (
 	myTheme "github.com/dweymouth/supersonic/ui/theme"
 	"github.com/dweymouth/supersonic/ui/util"
 	"github.com/dweymouth/supersonic/ui/widgets"
+	"github.com/google/uuid"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -35,11 +36,34 @@ type SettingsDialog struct {
 	OnThemeSettingChanged          func()
 	OnDismiss                      func()
 	OnEqualizerSettingsChanged     func()
+	OnLibraryIndexEnabledChanged   func(bool)
+	OnLibraryIndexResync           func()
+	OnOfflineImageCacheChanged     func(bool)
+	OnPrefetchCoverArt             func()
+	OnCancelPrefetchCoverArt       func()
+	OnClearImageCache              func(serverID uuid.UUID)
+	OnLocalArtworkDirChanged       func()
+	OnConnectLastFM                func()
+	OnDisconnectLastFM             func()
+	OnConnectListenBrainz          func()
+	OnDisconnectListenBrainz       func()
 
 	config       *backend.Config
 	audioDevices []mpv.AudioDevice
 	themeFiles   map[string]string // filename -> displayName
 	promptText   *widget.RichText
+	indexStatus  *widget.Label
+
+	prefetchStatus  *widget.Label
+	prefetchButton  *widget.Button
+	prefetchRunning bool
+	imageCacheSizes *fyne.Container
+
+	lastFMStatus             *widget.Label
+	lastFMButton             *widget.Button
+	listenBrainzStatus       *widget.Label
+	listenBrainzButton       *widget.Button
+	updateScrobbleThresholds func()
 
 	clientDecidesScrobble bool
 
@@ -125,6 +149,13 @@ func (s *SettingsDialog) createGeneralTab() *container.TabItem {
 			s.OnThemeSettingChanged()
 		}
 	}
+	dynamicAccent := widget.NewCheck("Accent color from album art", func(checked bool) {
+		s.config.Theme.DynamicAccentColor = checked
+		if s.OnThemeSettingChanged != nil {
+			s.OnThemeSettingChanged()
+		}
+	})
+	dynamicAccent.Checked = s.config.Theme.DynamicAccentColor
 	themeModeSelect.SetSelected(s.config.Theme.Appearance)
 	if themeModeSelect.Selected == "" {
 		themeModeSelect.SetSelectedIndex(0)
@@ -161,6 +192,8 @@ func (s *SettingsDialog) createGeneralTab() *container.TabItem {
 		binding.BindBool(&s.config.Application.SavePlayQueue))
 	trackNotif := widget.NewCheckWithData("Show notification on track change",
 		binding.BindBool(&s.config.Application.ShowTrackChangeNotification))
+	dupWarning := widget.NewCheckWithData("Warn when adding duplicate tracks to a playlist",
+		binding.BindBool(&s.config.Application.WarnOnDuplicatePlaylistAdd))
 
 	// Scrobble settings
 
@@ -176,9 +209,6 @@ func (s *SettingsDialog) createGeneralTab() *container.TabItem {
 		}
 	}
 	percentEntry.Text = strconv.Itoa(s.config.Scrobbling.ThresholdPercent)
-	if !s.config.Scrobbling.Enabled {
-		percentEntry.Disable()
-	}
 
 	durationEntry := widgets.NewTextRestrictedEntry(twoDigitValidator)
 	durationEntry.SetMinCharWidth(2)
@@ -191,67 +221,77 @@ func (s *SettingsDialog) createGeneralTab() *container.TabItem {
 		val := int(math.Round(float64(secs) / 60.))
 		durationEntry.Text = strconv.Itoa(val)
 	}
-	if !s.config.Scrobbling.Enabled || s.config.Scrobbling.ThresholdTimeSeconds < 0 {
-		durationEntry.Disable()
-	}
 
 	lastScrobbleText := durationEntry.Text
 	if lastScrobbleText == "" {
 		lastScrobbleText = "4" // default scrobble minutes
 	}
-	durationEnabled := widget.NewCheck("or when", func(checked bool) {
+	var durationEnabled *widget.Check
+	// the thresholds apply if scrobbling is enabled, and either the client
+	// decides when the server scrobbles, or scrobbling directly to any service
+	s.updateScrobbleThresholds = func() {
+		cfg := &s.config.Scrobbling
+		editable := cfg.Enabled && (s.clientDecidesScrobble || cfg.LastFM.Enabled || cfg.ListenBrainz.Enabled)
+		setEnabled(percentEntry, editable)
+		setEnabled(durationEnabled, editable)
+		setEnabled(durationEntry, editable && durationEnabled.Checked)
+	}
+	durationEnabled = widget.NewCheck("or when", func(checked bool) {
 		if !checked {
 			s.config.Scrobbling.ThresholdTimeSeconds = -1
 			lastScrobbleText = durationEntry.Text
 			durationEntry.Text = ""
-			durationEntry.Disable()
+			durationEntry.Refresh()
 		} else {
 			durationEntry.Text = lastScrobbleText
-			if s.clientDecidesScrobble {
-				durationEntry.Enable()
-			}
 			durationEntry.Refresh()
 			durationEntry.OnChanged(durationEntry.Text)
 		}
+		s.updateScrobbleThresholds()
 	})
 	durationEnabled.Checked = s.config.Scrobbling.ThresholdTimeSeconds >= 0
-	if !s.config.Scrobbling.Enabled {
-		durationEnabled.Disable()
-	}
-	if !s.clientDecidesScrobble {
-		percentEntry.Disable()
-		durationEnabled.Disable()
-	}
 
-	scrobbleEnabled := widget.NewCheck("Send playback statistics to server", func(checked bool) {
+	scrobbleEnabled := widget.NewCheck("Send playback statistics to server and scrobbling services", func(checked bool) {
 		s.config.Scrobbling.Enabled = checked
-		if !checked {
-			percentEntry.Disable()
-			durationEnabled.Disable()
-			durationEntry.Disable()
-		} else {
-			if s.clientDecidesScrobble {
-				percentEntry.Enable()
-				durationEnabled.Enable()
+		s.updateScrobbleThresholds()
+	})
+	scrobbleEnabled.Checked = s.config.Scrobbling.Enabled
+
+	s.lastFMStatus = widget.NewLabel("")
+	s.lastFMButton = widget.NewButton("", func() {
+		if s.config.Scrobbling.LastFM.Enabled {
+			if s.OnDisconnectLastFM != nil {
+				s.OnDisconnectLastFM()
 			}
-			if durationEnabled.Checked && s.clientDecidesScrobble {
-				durationEntry.Enable()
+		} else if s.OnConnectLastFM != nil {
+			s.OnConnectLastFM()
+		}
+	})
+	s.listenBrainzStatus = widget.NewLabel("")
+	s.listenBrainzButton = widget.NewButton("", func() {
+		if s.config.Scrobbling.ListenBrainz.Enabled {
+			if s.OnDisconnectListenBrainz != nil {
+				s.OnDisconnectListenBrainz()
 			}
+		} else if s.OnConnectListenBrainz != nil {
+			s.OnConnectListenBrainz()
 		}
 	})
-	scrobbleEnabled.Checked = s.config.Scrobbling.Enabled
+	s.RefreshScrobblingServices()
 
 	return container.NewTabItem("General", container.NewVBox(
 		container.NewBorder(nil, nil, widget.NewLabel("Theme"), /*left*/
 			container.NewHBox(widget.NewLabel("Mode"), themeModeSelect, util.NewHSpace(5)), // right
 			themeFileSelect, // center
 		),
+		dynamicAccent,
 		container.NewHBox(
 			widget.NewLabel("Startup page"), container.NewGridWithColumns(2, startupPage),
 		),
 		container.NewHBox(systemTrayEnable, closeToTray),
 		saveQueue,
 		trackNotif,
+		dupWarning,
 		s.newSectionSeparator(),
 
 		widget.NewRichText(&widget.TextSegment{Text: "Scrobbling", Style: util.BoldRichTextStyle}),
@@ -266,9 +306,37 @@ func (s *SettingsDialog) createGeneralTab() *container.TabItem {
 			durationEntry,
 			widget.NewLabel("minutes of track have been played"),
 		),
+		container.NewBorder(nil, nil, widget.NewLabel("Last.fm"), s.lastFMButton, s.lastFMStatus),
+		container.NewBorder(nil, nil, widget.NewLabel("ListenBrainz"), s.listenBrainzButton, s.listenBrainzStatus),
 	))
 }
 
+// RefreshScrobblingServices updates the dialog to show which
+// services are connected for direct scrobbling.
+func (s *SettingsDialog) RefreshScrobblingServices() {
+	updateService := func(status *widget.Label, button *widget.Button, enabled bool, username string) {
+		if enabled {
+			status.SetText("Scrobbling as " + username)
+			button.SetText("Disconnect")
+		} else {
+			status.SetText("Not connected")
+			button.SetText("Connect...")
+		}
+	}
+	cfg := &s.config.Scrobbling
+	updateService(s.lastFMStatus, s.lastFMButton, cfg.LastFM.Enabled, cfg.LastFM.Username)
+	updateService(s.listenBrainzStatus, s.listenBrainzButton, cfg.ListenBrainz.Enabled, cfg.ListenBrainz.Username)
+	s.updateScrobbleThresholds()
+}
+
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
+	} else {
+		w.Disable()
+	}
+}
+
 func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer bool) *container.TabItem {
 	disableTranscode := widget.NewCheckWithData("Disable server transcoding", binding.BindBool(&s.config.Transcoding.ForceRawFile))
 	deviceList := make([]string, len(s.audioDevices))
@@ -442,6 +510,73 @@ func (s *SettingsDialog) createExperimentalTab(window fyne.Window) *container.Ta
 		s.config.Application.UIScaleSize = choice
 		s.setRestartRequired()
 	})
+	s.indexStatus = widget.NewLabel("")
+	s.indexStatus.Wrapping = fyne.TextWrapWord
+	indexResync := widget.NewButton("Resync Now", func() {
+		if s.OnLibraryIndexResync != nil {
+			s.OnLibraryIndexResync()
+		}
+	})
+	if !s.config.LibraryIndex.Enabled {
+		indexResync.Disable()
+	}
+	indexEnabled := widget.NewCheck("Keep a local index of the library for faster search and filtering", func(checked bool) {
+		if checked {
+			indexResync.Enable()
+		} else {
+			indexResync.Disable()
+		}
+		if s.OnLibraryIndexEnabledChanged != nil {
+			s.OnLibraryIndexEnabledChanged(checked)
+		}
+	})
+	indexEnabled.Checked = s.config.LibraryIndex.Enabled
+
+	offlineImages := widget.NewCheck("Keep cached cover art for offline use (never expire or re-download)", func(checked bool) {
+		s.config.Application.OfflineImageCache = checked
+		if s.OnOfflineImageCacheChanged != nil {
+			s.OnOfflineImageCacheChanged(checked)
+		}
+	})
+	offlineImages.Checked = s.config.Application.OfflineImageCache
+	s.prefetchStatus = widget.NewLabel("")
+	s.prefetchStatus.Wrapping = fyne.TextWrapWord
+	s.prefetchButton = widget.NewButton("Download All Cover Art", func() {
+		if s.prefetchRunning {
+			if s.OnCancelPrefetchCoverArt != nil {
+				s.OnCancelPrefetchCoverArt()
+			}
+		} else if s.OnPrefetchCoverArt != nil {
+			s.OnPrefetchCoverArt()
+		}
+	})
+	s.imageCacheSizes = container.New(layout.NewFormLayout())
+
+	artworkDirEntry := widget.NewEntry()
+	artworkDirEntry.SetPlaceHolder("path to folder or empty to disable")
+	artworkDirEntry.Text = s.config.Application.LocalArtworkDir
+	artworkDirEntry.Validator = s.dirPathValidator
+	artworkDirEntry.OnChanged = func(path string) {
+		if artworkDirEntry.Validate() == nil {
+			s.config.Application.LocalArtworkDir = path
+			if s.OnLocalArtworkDirChanged != nil {
+				s.OnLocalArtworkDirChanged()
+			}
+		}
+	}
+	artworkDirBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				artworkDirEntry.SetText(uri.Path())
+			}
+		}, window)
+	})
+	artworkDirHint := widget.NewLabel("Artist images and album covers are read from " +
+		"<folder>/<artist>/artist.jpg and <folder>/<artist>/<album>/folder.jpg if present, " +
+		"and backdrops from <folder>/<artist>/fanart.jpg.")
+	artworkDirHint.Wrapping = fyne.TextWrapWord
+	artworkDirHint.Importance = widget.LowImportance
+
 	uiScaleRadio.Required = true
 	uiScaleRadio.Horizontal = true
 	if s.config.Application.UIScaleSize == "Smaller" || s.config.Application.UIScaleSize == "Larger" {
@@ -449,7 +584,7 @@ func (s *SettingsDialog) createExperimentalTab(window fyne.Window) *container.Ta
 	} else {
 		uiScaleRadio.Selected = "Normal"
 	}
-	return container.NewTabItem("Experimental", container.NewVBox(
+	content := container.NewVBox(
 		warningLabel,
 		s.newSectionSeparator(),
 		widget.NewRichText(&widget.TextSegment{Text: "UI Scaling", Style: util.BoldRichTextStyle}),
@@ -460,7 +595,63 @@ func (s *SettingsDialog) createExperimentalTab(window fyne.Window) *container.Ta
 			widget.NewLabel("Normal font"), container.NewBorder(nil, nil, nil, normalFontBrowse, normalFontEntry),
 			widget.NewLabel("Bold font"), container.NewBorder(nil, nil, nil, boldFontBrowse, boldFontEntry),
 		),
-	))
+		s.newSectionSeparator(),
+		widget.NewRichText(&widget.TextSegment{Text: "Library Index", Style: util.BoldRichTextStyle}),
+		indexEnabled,
+		container.NewBorder(nil, nil, nil, indexResync, s.indexStatus),
+		s.newSectionSeparator(),
+		widget.NewRichText(&widget.TextSegment{Text: "Local Artwork", Style: util.BoldRichTextStyle}),
+		container.NewBorder(nil, nil, widget.NewLabel("Artwork folder"), artworkDirBrowse, artworkDirEntry),
+		artworkDirHint,
+		s.newSectionSeparator(),
+		widget.NewRichText(&widget.TextSegment{Text: "Image Cache", Style: util.BoldRichTextStyle}),
+		offlineImages,
+		container.NewBorder(nil, nil, nil, s.prefetchButton, s.prefetchStatus),
+		s.imageCacheSizes,
+	)
+	// the tab is taller than the others, so scroll it rather than growing the dialog
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(0, 450))
+	return container.NewTabItem("Experimental", scroll)
+}
+
+// SetCoverArtPrefetchStatus sets the description of the cover art download
+// shown in the dialog, and whether the download can be cancelled.
+func (s *SettingsDialog) SetCoverArtPrefetchStatus(running bool, status string) {
+	s.prefetchRunning = running
+	if running {
+		s.prefetchButton.SetText("Cancel")
+	} else {
+		s.prefetchButton.SetText("Download All Cover Art")
+	}
+	s.prefetchStatus.SetText(status)
+}
+
+// SetImageCacheSizes sets the size in bytes of the image cache of each
+// configured server, shown in the dialog with a button to clear it.
+func (s *SettingsDialog) SetImageCacheSizes(sizes map[uuid.UUID]int64) {
+	var objs []fyne.CanvasObject
+	for _, server := range s.config.Servers {
+		id := server.ID
+		size := util.BytesToSizeString(sizes[id])
+		clear := widget.NewButton("Clear", func() {
+			if s.OnClearImageCache != nil {
+				s.OnClearImageCache(id)
+			}
+		})
+		if sizes[id] == 0 {
+			clear.Disable()
+		}
+		objs = append(objs, widget.NewLabel(server.Nickname),
+			container.NewBorder(nil, nil, nil, clear, widget.NewLabel(size)))
+	}
+	s.imageCacheSizes.Objects = objs
+	s.imageCacheSizes.Refresh()
+}
+
+// SetLibraryIndexStatus sets the description of the library index shown in the dialog.
+func (s *SettingsDialog) SetLibraryIndexStatus(status string) {
+	s.indexStatus.SetText(status)
 }
 
 func (s *SettingsDialog) doChooseTTFFile(window fyne.Window, entry *widget.Entry) {
@@ -474,6 +665,16 @@ func (s *SettingsDialog) doChooseTTFFile(window fyne.Window, entry *widget.Entry
 	dlg.Show()
 }
 
+func (s *SettingsDialog) dirPathValidator(path string) error {
+	if path == "" {
+		return nil
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return errors.New("not a folder")
+	}
+	return nil
+}
+
 func (s *SettingsDialog) ttfPathValidator(path string) error {
 	if path == "" {
 		return nil
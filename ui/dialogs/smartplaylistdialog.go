@@ -0,0 +1,225 @@
+package dialogs
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/widgets"
+)
+
+var smartPlaylistRatingOptions = []string{"Any", "1+", "2+", "3+", "4+", "5"}
+
+// SmartPlaylistDialog edits the name, rules and sorting of a smart playlist.
+type SmartPlaylistDialog struct {
+	widget.BaseWidget
+
+	OnCanceled func()
+	OnDelete   func()
+	OnSave     func()
+
+	playlist *backend.SmartPlaylist
+
+	nameEntry           *widget.Entry
+	descriptionEntry    *widget.Entry
+	genresEntry         *widget.Entry
+	artistsEntry        *widget.Entry
+	pathPrefixEntry     *widget.Entry
+	minYear             *widgets.TextRestrictedEntry
+	maxYear             *widgets.TextRestrictedEntry
+	minPlayCount        *widgets.TextRestrictedEntry
+	maxPlayCount        *widgets.TextRestrictedEntry
+	playedWithinDays    *widgets.TextRestrictedEntry
+	notPlayedWithinDays *widgets.TextRestrictedEntry
+	minDuration         *widgets.TextRestrictedEntry
+	maxDuration         *widgets.TextRestrictedEntry
+	minBitRate          *widgets.TextRestrictedEntry
+	maxBitRate          *widgets.TextRestrictedEntry
+	limit               *widgets.TextRestrictedEntry
+	minRating           *widget.Select
+	favoritesOnly       *widget.Check
+	sortOrder           *widget.Select
+	sortDescending      *widget.Check
+
+	container *fyne.Container
+}
+
+// NewSmartPlaylistDialog creates a dialog for editing the given smart playlist.
+// The Delete button is shown only if the playlist has already been saved.
+func NewSmartPlaylistDialog(playlist *backend.SmartPlaylist) *SmartPlaylistDialog {
+	s := &SmartPlaylistDialog{playlist: playlist}
+	s.ExtendBaseWidget(s)
+	rules := playlist.Rules
+
+	s.nameEntry = widget.NewEntry()
+	s.nameEntry.SetText(playlist.Name)
+	s.descriptionEntry = widget.NewEntry()
+	s.descriptionEntry.SetText(playlist.Description)
+	s.genresEntry = widget.NewEntry()
+	s.genresEntry.SetPlaceHolder("Any (comma-separated)")
+	s.genresEntry.SetText(strings.Join(rules.Genres, ", "))
+	s.artistsEntry = widget.NewEntry()
+	s.artistsEntry.SetPlaceHolder("Any (comma-separated)")
+	s.artistsEntry.SetText(strings.Join(rules.Artists, ", "))
+	s.pathPrefixEntry = widget.NewEntry()
+	s.pathPrefixEntry.SetPlaceHolder("Any")
+	s.pathPrefixEntry.SetText(rules.PathPrefix)
+
+	s.minYear = newNumberEntry(rules.MinYear, 4)
+	s.maxYear = newNumberEntry(rules.MaxYear, 4)
+	s.minPlayCount = newNumberEntry(rules.MinPlayCount, 5)
+	s.maxPlayCount = newNumberEntry(rules.MaxPlayCount, 5)
+	s.playedWithinDays = newNumberEntry(rules.PlayedWithinDays, 5)
+	s.notPlayedWithinDays = newNumberEntry(rules.NotPlayedWithinDays, 5)
+	s.minDuration = newNumberEntry(rules.MinDurationSecs/60, 4)
+	s.maxDuration = newNumberEntry(rules.MaxDurationSecs/60, 4)
+	s.minBitRate = newNumberEntry(rules.MinBitRate, 5)
+	s.maxBitRate = newNumberEntry(rules.MaxBitRate, 5)
+	s.limit = newNumberEntry(playlist.Limit, 5)
+
+	s.minRating = widget.NewSelect(smartPlaylistRatingOptions, nil)
+	// set Selected directly since calling the setters before the Select is shown can crash
+	s.minRating.Selected = smartPlaylistRatingOptions[min(max(rules.MinRating, 0), len(smartPlaylistRatingOptions)-1)]
+	s.favoritesOnly = widget.NewCheck("Favorites only", nil)
+	s.favoritesOnly.Checked = rules.FavoritesOnly
+
+	s.sortOrder = widget.NewSelect(backend.SmartPlaylistSortOrders, nil)
+	s.sortOrder.Selected = playlist.SortOrder
+	if s.sortOrder.Selected == "" {
+		s.sortOrder.Selected = backend.SmartPlaylistSortNone
+	}
+	s.sortDescending = widget.NewCheck("Descending", nil)
+	s.sortDescending.Checked = playlist.SortDescending
+
+	title := "Edit Smart Playlist"
+	if playlist.ID == "" {
+		title = "New Smart Playlist"
+	}
+	deleteBtn := widget.NewButton("Delete Playlist", func() {
+		if s.OnDelete != nil {
+			s.OnDelete()
+		}
+	})
+	deleteBtn.Hidden = playlist.ID == ""
+	saveBtn := widget.NewButton("Save", s.onSave)
+	saveBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton("Cancel", func() {
+		if s.OnCanceled != nil {
+			s.OnCanceled()
+		}
+	})
+
+	rangeRow := func(from, to fyne.CanvasObject, unit string) fyne.CanvasObject {
+		c := container.NewHBox(from, widget.NewLabel("to"), to)
+		if unit != "" {
+			c.Add(widget.NewLabel(unit))
+		}
+		return c
+	}
+	s.container = container.NewVBox(
+		container.NewHBox(layout.NewSpacer(), widget.NewLabel(title), layout.NewSpacer()),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Name"), s.nameEntry,
+			widget.NewLabel("Description"), s.descriptionEntry,
+		),
+		widget.NewSeparator(),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Genres"), s.genresEntry,
+			widget.NewLabel("Artists"), s.artistsEntry,
+			widget.NewLabel("Year"), rangeRow(s.minYear, s.maxYear, ""),
+			widget.NewLabel("Play count"), rangeRow(s.minPlayCount, s.maxPlayCount, ""),
+			widget.NewLabel("Minimum rating"), container.NewHBox(s.minRating, s.favoritesOnly),
+			widget.NewLabel("Played in last"), container.NewHBox(s.playedWithinDays, widget.NewLabel("days")),
+			widget.NewLabel("Not played in last"), container.NewHBox(s.notPlayedWithinDays, widget.NewLabel("days")),
+			widget.NewLabel("Duration"), rangeRow(s.minDuration, s.maxDuration, "minutes"),
+			widget.NewLabel("Bit rate"), rangeRow(s.minBitRate, s.maxBitRate, "kbps"),
+			widget.NewLabel("File path starts with"), s.pathPrefixEntry,
+		),
+		widget.NewSeparator(),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("Sort by"), container.NewHBox(s.sortOrder, s.sortDescending),
+			widget.NewLabel("Limit to"), container.NewHBox(s.limit, widget.NewLabel("tracks")),
+		),
+		widget.NewSeparator(),
+		container.NewHBox(deleteBtn, layout.NewSpacer(), cancelBtn, saveBtn),
+	)
+	return s
+}
+
+// Playlist returns the smart playlist being edited,
+// updated with the values entered in the dialog when saved.
+func (s *SmartPlaylistDialog) Playlist() *backend.SmartPlaylist {
+	return s.playlist
+}
+
+func (s *SmartPlaylistDialog) onSave() {
+	name := strings.TrimSpace(s.nameEntry.Text)
+	if name == "" {
+		s.nameEntry.SetPlaceHolder("Name is required")
+		return
+	}
+	s.playlist.Name = name
+	s.playlist.Description = s.descriptionEntry.Text
+	s.playlist.Rules = backend.SmartPlaylistRules{
+		Genres:              splitCommaList(s.genresEntry.Text),
+		Artists:             splitCommaList(s.artistsEntry.Text),
+		MinYear:             entryInt(s.minYear),
+		MaxYear:             entryInt(s.maxYear),
+		MinPlayCount:        entryInt(s.minPlayCount),
+		MaxPlayCount:        entryInt(s.maxPlayCount),
+		MinRating:           s.minRating.SelectedIndex(),
+		FavoritesOnly:       s.favoritesOnly.Checked,
+		PlayedWithinDays:    entryInt(s.playedWithinDays),
+		NotPlayedWithinDays: entryInt(s.notPlayedWithinDays),
+		MinDurationSecs:     entryInt(s.minDuration) * 60,
+		MaxDurationSecs:     entryInt(s.maxDuration) * 60,
+		MinBitRate:          entryInt(s.minBitRate),
+		MaxBitRate:          entryInt(s.maxBitRate),
+		PathPrefix:          strings.TrimSpace(s.pathPrefixEntry.Text),
+	}
+	s.playlist.SortOrder = s.sortOrder.Selected
+	s.playlist.SortDescending = s.sortDescending.Checked
+	s.playlist.Limit = entryInt(s.limit)
+	if s.OnSave != nil {
+		s.OnSave()
+	}
+}
+
+func (s *SmartPlaylistDialog) MinSize() fyne.Size {
+	return fyne.NewSize(450, s.BaseWidget.MinSize().Height)
+}
+
+func (s *SmartPlaylistDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
+
+func newNumberEntry(initial, maxDigits int) *widgets.TextRestrictedEntry {
+	e := widgets.NewTextRestrictedEntry(func(curText, selText string, r rune) bool {
+		return unicode.IsDigit(r) && len(curText)-len(selText) < maxDigits
+	})
+	e.SetMinCharWidth(maxDigits)
+	if initial > 0 {
+		e.Text = strconv.Itoa(initial)
+	}
+	return e
+}
+
+func entryInt(e *widgets.TextRestrictedEntry) int {
+	i, _ := strconv.Atoi(e.Text)
+	return i
+}
+
+func splitCommaList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -36,9 +36,10 @@ var (
 	ShortcutNavFive  = desktop.CustomShortcut{KeyName: fyne.Key5, Modifier: os.ControlModifier}
 	ShortcutNavSix   = desktop.CustomShortcut{KeyName: fyne.Key6, Modifier: os.ControlModifier}
 	ShortcutNavSeven = desktop.CustomShortcut{KeyName: fyne.Key7, Modifier: os.ControlModifier}
+	ShortcutNavEight = desktop.CustomShortcut{KeyName: fyne.Key8, Modifier: os.ControlModifier}
 
 	NavShortcuts = []desktop.CustomShortcut{ShortcutNavOne, ShortcutNavTwo, ShortcutNavThree,
-		ShortcutNavFour, ShortcutNavFive, ShortcutNavSix, ShortcutNavSeven}
+		ShortcutNavFour, ShortcutNavFive, ShortcutNavSix, ShortcutNavSeven, ShortcutNavEight}
 )
 
 type MainWindow struct {
@@ -50,9 +51,11 @@ type MainWindow struct {
 	BrowsingPane *browsing.BrowsingPane
 	BottomPanel  *BottomPanel
 
-	theme          *theme.MyTheme
-	haveSystemTray bool
-	container      *fyne.Container
+	theme           *theme.MyTheme
+	accentColor     *accentColorUpdater
+	haveSystemTray  bool
+	serverAdminItem *fyne.MenuItem
+	container       *fyne.Container
 }
 
 func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string, app *backend.App, size fyne.Size) MainWindow {
@@ -65,6 +68,7 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.theme.NormalFont = app.Config.Application.FontNormalTTF
 	m.theme.BoldFont = app.Config.Application.FontBoldTTF
 	fyneApp.Settings().SetTheme(m.theme)
+	m.accentColor = &accentColorUpdater{theme: m.theme, config: &app.Config.Theme, im: app.ImageManager}
 
 	if app.Config.Application.EnableSystemTray {
 		m.SetupSystemTrayMenu(displayAppName, fyneApp)
@@ -80,6 +84,7 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.Controller.NavHandler = m.Router.NavigateTo
 	m.Controller.ReloadFunc = m.BrowsingPane.Reload
 	m.Controller.CurPageFunc = m.BrowsingPane.CurrentPage
+	m.Controller.OnPinnedPlaylistsChanged = m.refreshPinnedPlaylists
 
 	m.BottomPanel = NewBottomPanel(app.PlaybackManager, m.Controller)
 	m.BottomPanel.ImageManager = app.ImageManager
@@ -87,6 +92,7 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.Window.SetContent(m.container)
 	m.Window.Resize(size)
 	app.PlaybackManager.OnSongChange(func(track, _ *mediaprovider.Track) {
+		m.accentColor.OnSongChange(track)
 		if track == nil {
 			m.Window.SetTitle(displayAppName)
 			return
@@ -106,13 +112,28 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	})
 	app.ServerManager.OnLogout(func() {
 		m.BrowsingPane.DisableNavigationButtons()
+		m.BrowsingPane.SetPinnedPlaylists(nil)
 		m.BrowsingPane.SetPage(nil)
 		m.BrowsingPane.ClearHistory()
+		m.BrowsingPane.SetSettingsMenuItemHidden(m.serverAdminItem, true)
 		m.Controller.PromptForLoginAndConnect()
 	})
 	m.BrowsingPane.AddSettingsMenuItem("Log Out", func() { app.ServerManager.Logout(true) })
 	m.BrowsingPane.AddSettingsMenuItem("Switch Servers", func() { app.ServerManager.Logout(false) })
 	m.BrowsingPane.AddSettingsMenuItem("Rescan Library", func() { app.ServerManager.Server.RescanLibrary() })
+	m.BrowsingPane.AddSettingsMenuItem("Server Info...", m.Controller.ShowServerInfoDialog)
+	m.serverAdminItem = m.BrowsingPane.AddSettingsMenuItem("Server Admin", func() {
+		m.Controller.NavigateTo(controller.ServerAdminRoute())
+	})
+	// only shown to admin users once connected
+	m.BrowsingPane.SetSettingsMenuItemHidden(m.serverAdminItem, true)
+	m.BrowsingPane.AddSettingsMenuSeparator()
+	m.BrowsingPane.AddSettingsMenuItem("Import Playlist...", m.Controller.DoImportPlaylistWorkflow)
+	m.BrowsingPane.AddSettingsMenuItem("Export Play Queue...", func() {
+		m.Controller.ShowExportPlaylistDialog(app.PlaybackManager.GetPlayQueue(), "Play Queue")
+	})
+	m.BrowsingPane.AddSettingsMenuItem("Migrate Between Servers...", m.Controller.DoMigrateServerWorkflow)
+	m.BrowsingPane.AddSettingsMenuItem("Listening Stats", func() { m.Controller.NavigateTo(controller.StatsRoute()) })
 	m.BrowsingPane.AddSettingsMenuSeparator()
 	m.BrowsingPane.AddSettingsMenuItem("Check for Updates", func() {
 		go func() {
@@ -133,6 +154,12 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	return m
 }
 
+func (m *MainWindow) refreshPinnedPlaylists() {
+	if org := m.Controller.PlaylistOrganization(); org != nil {
+		m.BrowsingPane.SetPinnedPlaylists(org.Pinned)
+	}
+}
+
 func (m *MainWindow) StartupPage() controller.Route {
 	switch m.App.Config.Application.StartupPage {
 	case "Favorites":
@@ -146,9 +173,10 @@ func (m *MainWindow) StartupPage() controller.Route {
 
 func (m *MainWindow) RunOnServerConnectedTasks(app *backend.App, displayAppName string) {
 	m.BrowsingPane.EnableNavigationButtons()
+	m.refreshPinnedPlaylists()
 	m.Router.NavigateTo(m.StartupPage())
-	_, canRate := m.App.ServerManager.Server.(mediaprovider.SupportsRating)
-	m.BottomPanel.NowPlaying.DisableRating = !canRate
+	m.BottomPanel.NowPlaying.DisableRating = !m.App.ServerManager.Capabilities().Rating
+	m.BrowsingPane.SetSettingsMenuItemHidden(m.serverAdminItem, m.App.ServerManager.Admin() == nil)
 
 	if app.Config.Application.SavePlayQueue {
 		go func() {
@@ -260,6 +288,9 @@ func (m *MainWindow) addNavigationButtons() {
 	m.BrowsingPane.AddNavigationButton(theme.TracksIcon, controller.Tracks, func() {
 		m.Router.NavigateTo(controller.TracksRoute())
 	})
+	m.BrowsingPane.AddNavigationButton(theme.ComposerIcon, controller.Composers, func() {
+		m.Router.NavigateTo(controller.ComposersRoute())
+	})
 }
 
 func (m *MainWindow) addShortcuts() {
@@ -340,6 +371,8 @@ func (m *MainWindow) addShortcuts() {
 func (m *MainWindow) showSettingsDialog() {
 	m.Controller.ShowSettingsDialog(func() {
 		fyne.CurrentApp().Settings().SetTheme(m.theme)
+		// dynamic accent color may have been enabled, or the background changed
+		m.accentColor.OnSongChange(m.App.PlaybackManager.NowPlaying())
 	}, m.theme.ListThemeFiles())
 }
 
@@ -0,0 +1,177 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2/theme"
+)
+
+const (
+	// minimum WCAG contrast ratios against the background
+	minPrimaryContrast   = 3.0
+	minHyperlinkContrast = 4.5
+
+	// how strongly the accent tints the page header background
+	pageHeaderAccentTint = 0.2
+
+	accentHueBins = 12
+)
+
+// SetAccentColor sets the color from which the Primary, PageHeader and Hyperlink
+// colors are derived when the dynamic accent color is enabled in the theme config.
+// If nil, the theme's own colors are used.
+func (m *MyTheme) SetAccentColor(c color.Color) {
+	m.accentMutex.Lock()
+	defer m.accentMutex.Unlock()
+	m.accentColor = c
+}
+
+// AccentColor returns the current accent color, or the theme's
+// Primary color if no accent color is set.
+func (m *MyTheme) AccentColor() color.Color {
+	if c := m.dynamicAccentColor(); c != nil {
+		return c
+	}
+	return m.themeColor(theme.ColorNamePrimary)
+}
+
+func (m *MyTheme) dynamicAccentColor() color.Color {
+	if !m.config.DynamicAccentColor {
+		return nil
+	}
+	m.accentMutex.RLock()
+	defer m.accentMutex.RUnlock()
+	return m.accentColor
+}
+
+// AccentColorFromImage returns the most prominent vivid color of the image,
+// or false if the image has no such color (e.g. it is grayscale).
+func AccentColorFromImage(img image.Image) (color.Color, bool) {
+	type bin struct {
+		weight  float64
+		r, g, b float64
+	}
+	var bins [accentHueBins]bin
+
+	bounds := img.Bounds()
+	// sample around 64x64 pixels regardless of image size
+	step := max(1, max(bounds.Dx(), bounds.Dy())/64)
+	var totalWeight float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			h, s, v := rgbToHSV(c)
+			// ignore grays and near-black pixels, and weight
+			// the rest to prefer saturated, bright colors
+			if s < 0.25 || v < 0.2 {
+				continue
+			}
+			w := s * v
+			b := &bins[int(h/360*accentHueBins)%accentHueBins]
+			b.weight += w
+			b.r += w * float64(c.R)
+			b.g += w * float64(c.G)
+			b.b += w * float64(c.B)
+			totalWeight += w
+		}
+	}
+
+	best := 0
+	for i := range bins {
+		if bins[i].weight > bins[best].weight {
+			best = i
+		}
+	}
+	b := bins[best]
+	samples := float64((bounds.Dx()/step + 1) * (bounds.Dy()/step + 1))
+	if b.weight == 0 || totalWeight/samples < 0.02 {
+		return nil, false
+	}
+	return color.NRGBA{
+		R: uint8(math.Round(b.r / b.weight)),
+		G: uint8(math.Round(b.g / b.weight)),
+		B: uint8(math.Round(b.b / b.weight)),
+		A: 255,
+	}, true
+}
+
+// BlendColors returns the color frac of the way from a to b.
+func BlendColors(a, b color.Color, frac float64) color.Color {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	blend := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*frac))
+	}
+	return color.NRGBA{
+		R: blend(ca.R, cb.R),
+		G: blend(ca.G, cb.G),
+		B: blend(ca.B, cb.B),
+		A: blend(ca.A, cb.A),
+	}
+}
+
+// ensureContrast lightens or darkens c, whichever is away from bg,
+// until it has at least the given contrast ratio against bg.
+func ensureContrast(c, bg color.Color, minRatio float64) color.Color {
+	target := color.Color(color.White)
+	if relativeLuminance(bg) > 0.5 {
+		target = color.Black
+	}
+	adjusted := c
+	for i := 1; i <= 10 && contrastRatio(adjusted, bg) < minRatio; i++ {
+		adjusted = BlendColors(c, target, float64(i)/10)
+	}
+	return adjusted
+}
+
+// contrastRatio returns the WCAG contrast ratio of the two colors.
+func contrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// relativeLuminance returns the WCAG relative luminance of the color.
+func relativeLuminance(c color.Color) float64 {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	linear := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(nc.R) + 0.7152*linear(nc.G) + 0.0722*linear(nc.B)
+}
+
+func rgbToHSV(c color.NRGBA) (h, s, v float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	maxC := max(r, g, b)
+	minC := min(r, g, b)
+	delta := maxC - minC
+	v = maxC
+	if maxC > 0 {
+		s = delta / maxC
+	}
+	switch {
+	case delta == 0:
+		h = 0
+	case maxC == r:
+		h = 60 * math.Mod((g-b)/delta, 6)
+	case maxC == g:
+		h = 60 * ((b-r)/delta + 2)
+	default:
+		h = 60 * ((r-g)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
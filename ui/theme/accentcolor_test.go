@@ -0,0 +1,99 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func Test_AccentColorFromImage(t *testing.T) {
+	red := color.NRGBA{R: 200, G: 30, B: 30, A: 255}
+	blue := color.NRGBA{R: 30, G: 60, B: 220, A: 255}
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want color.Color // nil if no accent color
+	}{
+		{"solid", solidImage(100, 100, red), red},
+		{"grayscale", solidImage(100, 100, gray), nil},
+		{"black", solidImage(100, 100, color.NRGBA{A: 255}), nil},
+		{"transparent", solidImage(100, 100, color.NRGBA{R: 200}), nil},
+		{"mostly blue", splitImage(300, 300, blue, red, 0.75), blue},
+		// a small vivid area on a gray image is too little to be the accent
+		{"speck of color", splitImage(300, 300, red, gray, 0.01), nil},
+	}
+	for _, tt := range tests {
+		got, ok := AccentColorFromImage(tt.img)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("%s: got accent color %v, want none", tt.name, got)
+			}
+			continue
+		}
+		if !ok || color.NRGBAModel.Convert(got) != tt.want {
+			t.Errorf("%s: got %v (%v), want %v", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func Test_EnsureContrast(t *testing.T) {
+	dark := color.NRGBA{R: 20, G: 20, B: 24, A: 255}
+	light := color.NRGBA{R: 250, G: 250, B: 250, A: 255}
+	tests := []struct {
+		name  string
+		c, bg color.Color
+		ratio float64
+	}{
+		{"dark color on dark background", color.NRGBA{R: 60, G: 20, B: 20, A: 255}, dark, minPrimaryContrast},
+		{"light color on light background", color.NRGBA{R: 255, G: 230, B: 120, A: 255}, light, minHyperlinkContrast},
+		{"black on black", color.NRGBA{A: 255}, color.NRGBA{A: 255}, minHyperlinkContrast},
+		{"white on white", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, light, minPrimaryContrast},
+	}
+	for _, tt := range tests {
+		got := ensureContrast(tt.c, tt.bg, tt.ratio)
+		if r := contrastRatio(got, tt.bg); r < tt.ratio {
+			t.Errorf("%s: got contrast %.2f, want at least %.2f", tt.name, r, tt.ratio)
+		}
+	}
+
+	// colors with enough contrast are unchanged
+	c := color.NRGBA{R: 255, G: 200, B: 0, A: 255}
+	if got := ensureContrast(c, dark, minPrimaryContrast); color.NRGBAModel.Convert(got) != c {
+		t.Errorf("got %v, want unchanged %v", got, c)
+	}
+}
+
+func Test_ContrastRatio(t *testing.T) {
+	black, white := color.NRGBA{A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	if r := contrastRatio(black, white); r < 20.99 || r > 21.01 {
+		t.Errorf("black on white: got %.3f, want 21", r)
+	}
+	if r := contrastRatio(white, black); r < 20.99 || r > 21.01 {
+		t.Errorf("white on black: got %.3f, want 21", r)
+	}
+	if r := contrastRatio(white, white); r != 1 {
+		t.Errorf("white on white: got %.3f, want 1", r)
+	}
+}
+
+func solidImage(w, h int, c color.Color) image.Image {
+	return splitImage(w, h, c, c, 1)
+}
+
+// splitImage returns an image with the top frac of its rows in color a,
+// and the rest in color b.
+func splitImage(w, h int, a, b color.Color, frac float64) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		c := b
+		if float64(y) < frac*float64(h) {
+			c = a
+		}
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
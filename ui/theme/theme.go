@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/res"
@@ -37,6 +38,7 @@ var (
 	TracksIcon      fyne.Resource = theme.NewThemedResource(res.ResMusicnotesSvg)
 	GenreIcon       fyne.Resource = theme.NewThemedResource(res.ResTheatermasksSvg)
 	FilterIcon      fyne.Resource = theme.NewThemedResource(res.ResFilterSvg)
+	ComposerIcon    fyne.Resource = theme.NewThemedResource(res.ResScoreSvg)
 	RepeatIcon      fyne.Resource = theme.NewThemedResource(res.ResRepeatSvg)
 	RepeatOneIcon   fyne.Resource = theme.NewThemedResource(res.ResRepeatoneSvg)
 	SortIcon        fyne.Resource = theme.NewThemedResource(res.ResUpdownarrowSvg)
@@ -66,6 +68,9 @@ type MyTheme struct {
 	loadedThemeFilename string
 	loadedThemeFile     *ThemeFile
 	defaultThemeFile    *ThemeFile
+
+	accentMutex sync.RWMutex
+	accentColor color.Color // set from the cover art if dynamic accent color is enabled
 }
 
 var _ fyne.Theme = (*MyTheme)(nil)
@@ -80,6 +85,21 @@ func NewMyTheme(config *backend.ThemeConfig, themeFileDir string) *MyTheme {
 }
 
 func (m *MyTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	if accent := m.dynamicAccentColor(); accent != nil {
+		switch name {
+		case theme.ColorNamePrimary:
+			return ensureContrast(accent, m.themeColor(theme.ColorNameBackground), minPrimaryContrast)
+		case theme.ColorNameHyperlink:
+			return ensureContrast(accent, m.themeColor(ColorNamePageBackground), minHyperlinkContrast)
+		case ColorNamePageHeader:
+			return BlendColors(m.themeColor(ColorNamePageHeader), accent, pageHeaderAccentTint)
+		}
+	}
+	return m.themeColor(name)
+}
+
+// themeColor returns the color from the theme file, ignoring any accent color.
+func (m *MyTheme) themeColor(name fyne.ThemeColorName) color.Color {
 	// load theme file if necessary
 	if m.loadedThemeFile == nil || m.config.ThemeFile != m.loadedThemeFilename {
 		t, err := ReadThemeFile(path.Join(m.themeFileDir, m.config.ThemeFile))
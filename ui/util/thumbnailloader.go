@@ -27,26 +27,50 @@ type ImageFetcher interface {
 	GetCoverThumbnailAsync(string, func(image.Image, error)) context.CancelFunc
 }
 
+// ArtworkFetcher is optionally implemented by an ImageFetcher which can
+// look up local artwork for an album (or artist, if album is empty) by name.
+// impl: backend.ImageManager
+type ArtworkFetcher interface {
+	GetArtworkThumbnailFromCache(coverID, artist, album string) (image.Image, bool)
+	GetArtworkThumbnailAsync(coverID, artist, album string, cb func(image.Image, error)) context.CancelFunc
+}
+
 func NewThumbnailLoader(im ImageFetcher, onLoaded func(image.Image)) ThumbnailLoader {
 	return ThumbnailLoader{im: im, OnLoaded: onLoaded}
 }
 
 func (i *ThumbnailLoader) Load(coverID string) {
+	i.LoadArtwork(coverID, "", "")
+}
+
+// LoadArtwork loads the local artwork for the album (or artist, if album
+// is empty) if the ImageFetcher supports it and the artwork exists,
+// and otherwise the cover thumbnail with the given ID.
+func (i *ThumbnailLoader) LoadArtwork(coverID, artist, album string) {
 	if i.prevLoadCancel != nil {
 		i.prevLoadCancel()
 	}
-	if coverID == "" {
+	fromCache := i.im.GetCoverThumbnailFromCache
+	fetchAsync := i.im.GetCoverThumbnailAsync
+	if af, ok := i.im.(ArtworkFetcher); ok && artist != "" {
+		fromCache = func(id string) (image.Image, bool) {
+			return af.GetArtworkThumbnailFromCache(id, artist, album)
+		}
+		fetchAsync = func(id string, cb func(image.Image, error)) context.CancelFunc {
+			return af.GetArtworkThumbnailAsync(id, artist, album, cb)
+		}
+	} else if coverID == "" {
 		i.callOnLoaded(nil)
 		return
 	}
-	if img, ok := i.im.GetCoverThumbnailFromCache(coverID); ok {
+	if img, ok := fromCache(coverID); ok {
 		i.callOnLoaded(img)
 		return
 	}
 	if i.OnBeforeLoad != nil {
 		i.OnBeforeLoad()
 	}
-	i.prevLoadCancel = i.im.GetCoverThumbnailAsync(coverID, func(img image.Image, err error) {
+	i.prevLoadCancel = fetchAsync(coverID, func(img image.Image, err error) {
 		if err != nil {
 			log.Printf("Error loading cover image: %s", err.Error())
 		} else {
@@ -30,6 +30,16 @@ func SelectedTrackIDs(tracks []*TrackListModel) []string {
 	})
 }
 
+func SelectedIndexes(tracks []*TrackListModel) []int {
+	var idxs []int
+	for i, tm := range tracks {
+		if tm.Selected {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
+
 func SelectTrack(tracks []*TrackListModel, idx int) {
 	if tracks[idx].Selected {
 		return
@@ -0,0 +1,89 @@
+package widgets
+
+import (
+	"strconv"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+var _ FilterButton[mediaprovider.Artist, mediaprovider.ArtistFilterOptions] = (*ArtistFilterButton)(nil)
+
+type ArtistFilterButton struct {
+	filterButtonBase[mediaprovider.Artist, mediaprovider.ArtistFilterOptions]
+
+	// HasImageDisabled hides the "Has image" filter, for
+	// servers which don't report whether artists have images
+	HasImageDisabled bool
+}
+
+func NewArtistFilterButton(filter mediaprovider.ArtistFilter, fetchGenresFunc func() ([]*mediaprovider.Genre, error)) *ArtistFilterButton {
+	a := &ArtistFilterButton{}
+	a.init(a, filter, fetchGenresFunc)
+	a.newPopup = func() *filterPopup { return &newArtistFilterPopup(a).filterPopup }
+	return a
+}
+
+type artistFilterPopup struct {
+	filterPopup
+
+	filterBtn *ArtistFilterButton
+}
+
+func newArtistFilterPopup(filterBtn *ArtistFilterButton) *artistFilterPopup {
+	a := &artistFilterPopup{filterBtn: filterBtn}
+
+	debounceOnChanged := util.NewDebouncer(350*time.Millisecond, a.emitOnChanged)
+	updateOptions := func(update func(*mediaprovider.ArtistFilterOptions)) {
+		a.filterBtn.updateOptions(update, debounceOnChanged)
+	}
+	filterOptions := a.filterBtn.filter.Options()
+
+	// setup min album count filter
+	minAlbums := NewTextRestrictedEntry(func(curText, selText string, r rune) bool {
+		l := len(curText) - len(selText)
+		return unicode.IsDigit(r) && l <= 3
+	})
+	minAlbums.SetMinCharWidth(3)
+	if filterOptions.MinAlbumCount > 0 {
+		minAlbums.Text = strconv.Itoa(filterOptions.MinAlbumCount)
+	}
+	minAlbums.OnChanged = func(countStr string) {
+		count, _ := strconv.Atoi(countStr)
+		updateOptions(func(o *mediaprovider.ArtistFilterOptions) { o.MinAlbumCount = count })
+	}
+
+	// setup is favorite/not favorite and has image filters
+	isFavorite, isNotFavorite := newFavoriteChecks(
+		filterOptions.ExcludeUnfavorited, filterOptions.ExcludeFavorited,
+		func(fav bool) {
+			updateOptions(func(o *mediaprovider.ArtistFilterOptions) { o.ExcludeUnfavorited = fav })
+		},
+		func(fav bool) {
+			updateOptions(func(o *mediaprovider.ArtistFilterOptions) { o.ExcludeFavorited = fav })
+		})
+	hasImage := widget.NewCheck("Has image", func(b bool) {
+		updateOptions(func(o *mediaprovider.ArtistFilterOptions) { o.ExcludeWithoutImage = b })
+	})
+	hasImage.Checked = filterOptions.ExcludeWithoutImage
+	hasImage.Hidden = a.filterBtn.HasImageDisabled
+
+	// create genre filter subsection
+	genreFilter := NewGenreFilterSubsection(func(genres []string, mode mediaprovider.TagMatchMode, excluded []string) {
+		updateOptions(func(o *mediaprovider.ArtistFilterOptions) {
+			o.Genres, o.GenreMatch, o.ExcludeGenres = genres, mode, excluded
+		})
+	}, filterOptions.Genres, filterOptions.GenreMatch, filterOptions.ExcludeGenres)
+
+	a.init(a, "Artist filters",
+		container.NewHBox(widget.NewLabel("Minimum albums"), minAlbums),
+		container.NewHBox(isFavorite, isNotFavorite, hasImage),
+		genreFilter,
+	)
+	a.filterBtn.loadGenres(genreFilter)
+	return a
+}
@@ -0,0 +1,91 @@
+package widgets
+
+import (
+	"image"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Backdrop displays an image behind page content, scaled and cropped
+// to fill its size and faded into the background color. It is intended
+// for small, blurred images, which are smoothly scaled up.
+type Backdrop struct {
+	widget.BaseWidget
+
+	src     image.Image
+	image   *canvas.Image
+	overlay *myTheme.ThemedRectangle
+
+	container *fyne.Container
+}
+
+func NewBackdrop() *Backdrop {
+	b := &Backdrop{
+		image:   canvas.NewImageFromImage(nil),
+		overlay: myTheme.NewThemedRectangle(theme.ColorNameBackground),
+	}
+	b.ExtendBaseWidget(b)
+	b.image.FillMode = canvas.ImageFillStretch
+	b.image.ScaleMode = canvas.ImageScaleSmooth
+	b.overlay.Translucent = true
+	b.container = container.NewStack(b.image, b.overlay)
+	b.Hide()
+	return b
+}
+
+// SetImage sets the backdrop image. The backdrop is hidden if img is nil.
+func (b *Backdrop) SetImage(img image.Image) {
+	b.src = img
+	if img == nil {
+		b.image.Image = nil
+		b.Hide()
+		return
+	}
+	b.cropImage(b.Size())
+	b.Show()
+	b.Refresh()
+}
+
+func (b *Backdrop) Resize(size fyne.Size) {
+	if size != b.Size() {
+		b.cropImage(size)
+	}
+	b.BaseWidget.Resize(size)
+}
+
+// cropImage crops the source image to the aspect ratio of size,
+// keeping the center, so that it fills the backdrop without distortion.
+func (b *Backdrop) cropImage(size fyne.Size) {
+	if b.src == nil || size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	bounds := b.src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	aspect := size.Width / size.Height
+	if float32(w)/float32(h) > aspect {
+		w = int(float32(h) * aspect)
+	} else {
+		h = int(float32(w) / aspect)
+	}
+	w, h = max(w, 1), max(h, 1)
+	x := bounds.Min.X + (bounds.Dx()-w)/2
+	y := bounds.Min.Y + (bounds.Dy()-h)/2
+	if sub, ok := b.src.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		b.image.Image = sub.SubImage(image.Rect(x, y, x+w, y+h))
+	} else {
+		b.image.Image = b.src
+	}
+	b.image.Refresh()
+}
+
+func (b *Backdrop) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.container)
+}
@@ -0,0 +1,77 @@
+package widgets
+
+import (
+	"fmt"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DiscHeaderRow is the row shown in the Tracklist above the tracks
+// of each disc of a multi-disc album when grouping by disc.
+// Tapping the row collapses or expands the disc.
+type DiscHeaderRow struct {
+	FocusListRowBase
+
+	tracklist *Tracklist
+	discNum   int
+	collapsed bool
+
+	collapseIcon *widget.Icon
+	title        *widget.RichText
+	info         *widget.Label
+	actions      *fyne.Container
+}
+
+func NewDiscHeaderRow(tracklist *Tracklist) *DiscHeaderRow {
+	d := &DiscHeaderRow{tracklist: tracklist, discNum: -1}
+	d.ExtendBaseWidget(d)
+	d.collapseIcon = widget.NewIcon(theme.MenuDropDownIcon())
+	d.title = util.NewTruncatingRichText()
+	d.title.Segments[0].(*widget.TextSegment).Style.TextStyle.Bold = true
+	d.info = util.NewTrailingAlignLabel()
+
+	play := NewTappableIcon(theme.MediaPlayIcon())
+	play.OnTapped = func() { d.tracklist.onPlayDisc(d.discNum, false) }
+	shuffle := NewTappableIcon(myTheme.ShuffleIcon)
+	shuffle.OnTapped = func() { d.tracklist.onPlayDisc(d.discNum, true) }
+	add := NewTappableIcon(theme.ContentAddIcon())
+	add.OnTapped = func() { d.tracklist.onAddDiscToQueue(d.discNum) }
+	d.actions = container.NewHBox(play, shuffle, add, util.NewHSpace(5))
+
+	d.OnTapped = func() { d.tracklist.toggleDiscCollapsed(d.discNum) }
+	d.Content = container.NewBorder(nil, nil,
+		container.NewHBox(util.NewHSpace(5), d.collapseIcon),
+		container.NewHBox(d.info, d.actions),
+		d.title)
+	return d
+}
+
+func (d *DiscHeaderRow) Update(discNum int, title string, numTracks, durationSecs int, collapsed bool) {
+	d.EnsureUnfocused()
+	d.discNum = discNum
+	d.collapsed = collapsed
+
+	label := fmt.Sprintf("Disc %d", discNum)
+	if title != "" {
+		label += ": " + title
+	}
+	d.title.Segments[0].(*widget.TextSegment).Text = label
+	tracksWord := "tracks"
+	if numTracks == 1 {
+		tracksWord = "track"
+	}
+	d.info.Text = fmt.Sprintf("%d %s · %s", numTracks, tracksWord, util.SecondsToTimeString(float64(durationSecs)))
+	if collapsed {
+		d.collapseIcon.Resource = theme.MenuExpandIcon()
+	} else {
+		d.collapseIcon.Resource = theme.MenuDropDownIcon()
+	}
+	d.actions.Hidden = d.tracklist.Options.DisablePlaybackMenu
+	d.Refresh()
+}
@@ -0,0 +1,249 @@
+package widgets
+
+import (
+	"fmt"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// DragData is the payload of a drag-and-drop operation of tracks,
+// or of an item (album, artist, playlist) whose tracks are loaded on drop.
+type DragData struct {
+	// Source is the widget the drag started from.
+	Source fyne.CanvasObject
+
+	// Label is a short description of what is being dragged.
+	Label string
+
+	tracks     []*mediaprovider.Track
+	loadTracks func() ([]*mediaprovider.Track, error)
+}
+
+func NewTracksDragData(source fyne.CanvasObject, tracks []*mediaprovider.Track) *DragData {
+	label := fmt.Sprintf("%d tracks", len(tracks))
+	if len(tracks) == 1 {
+		label = tracks[0].Name
+	}
+	return &DragData{Source: source, Label: label, tracks: tracks}
+}
+
+// NewItemDragData creates the payload for dragging an item whose tracks
+// will be loaded with the loadTracks function when dropped.
+func NewItemDragData(source fyne.CanvasObject, label string, loadTracks func() ([]*mediaprovider.Track, error)) *DragData {
+	return &DragData{Source: source, Label: label, loadTracks: loadTracks}
+}
+
+// Tracks returns the dragged tracks, loading them if needed.
+// May block on network requests so should not be called on the UI thread.
+func (d *DragData) Tracks() ([]*mediaprovider.Track, error) {
+	if d.tracks == nil && d.loadTracks != nil {
+		tracks, err := d.loadTracks()
+		if err != nil {
+			return nil, err
+		}
+		d.tracks = tracks
+	}
+	return d.tracks, nil
+}
+
+// DropTarget is implemented by widgets that tracks can be dropped onto.
+type DropTarget interface {
+	fyne.CanvasObject
+
+	// DragOver is called as a drag moves over the target, with the position
+	// relative to the target. It returns a short description of what dropping
+	// at that position would do, or "" if the drop would not be accepted.
+	DragOver(data *DragData, pos fyne.Position) string
+
+	// DragLeave is called when a drag leaves the target without dropping.
+	DragLeave()
+
+	// Drop is called when the data is dropped onto the target at a
+	// position where the last call to DragOver accepted it.
+	Drop(data *DragData, pos fyne.Position)
+}
+
+var dropTargets []DropTarget
+
+// RegisterDropTarget registers a drop target which can receive drags from
+// any widget. It must live for the rest of the app's lifetime. A widget
+// that is the source of a drag receives its own drops without registering.
+func RegisterDropTarget(target DropTarget) {
+	dropTargets = append(dropTargets, target)
+}
+
+// how far, in total X and Y movement, the pointer must move before a drag begins
+const dragStartThreshold = 8
+
+// the in-progress drag operation, if any
+var curDrag *dragOperation
+
+type dragOperation struct {
+	moved     float32 // total X and Y movement so far
+	started   bool
+	data      *DragData
+	target    DropTarget
+	targetPos fyne.Position
+	accepted  bool
+	indicator *widget.PopUp
+	label     *widget.Label
+}
+
+// handleDragged handles a drag event from a draggable item.
+// newData is called to create the payload when the drag begins,
+// and may return nil if the item cannot be dragged.
+func handleDragged(e *fyne.DragEvent, source fyne.CanvasObject, newData func() *DragData) {
+	if curDrag == nil {
+		curDrag = &dragOperation{}
+	}
+	if !curDrag.started {
+		// don't begin the drag until the pointer has moved far enough
+		// to be sure it is not just a jittery click
+		curDrag.moved += float32(math.Abs(float64(e.Dragged.DX)) + math.Abs(float64(e.Dragged.DY)))
+		if curDrag.moved < dragStartThreshold {
+			return
+		}
+		curDrag.started = true
+		curDrag.data = newData()
+		if curDrag.data != nil {
+			curDrag.label = widget.NewLabel(curDrag.data.Label)
+			c := fyne.CurrentApp().Driver().CanvasForObject(source)
+			curDrag.indicator = widget.NewPopUp(curDrag.label, c)
+		}
+	}
+	if curDrag.data == nil {
+		return
+	}
+
+	target, pos := findDropTarget(curDrag.data.Source, e.AbsolutePosition)
+	if target != curDrag.target && curDrag.target != nil {
+		curDrag.target.DragLeave()
+	}
+	curDrag.target = target
+	curDrag.targetPos = pos
+	action := ""
+	if target != nil {
+		action = target.DragOver(curDrag.data, pos)
+	}
+	curDrag.accepted = action != ""
+	label := curDrag.data.Label
+	if curDrag.accepted {
+		label = fmt.Sprintf("%s: %s", action, label)
+	}
+	if curDrag.label.Text != label {
+		curDrag.label.SetText(label)
+	}
+	curDrag.indicator.ShowAtPosition(e.AbsolutePosition.Add(fyne.NewPos(12, 12)))
+}
+
+// handleDragEnd ends the drag in progress, dropping it onto
+// the target under the pointer if it accepts the drop.
+func handleDragEnd() {
+	d := curDrag
+	curDrag = nil
+	if d == nil || d.data == nil {
+		return
+	}
+	d.indicator.Hide()
+	if d.target == nil {
+		return
+	}
+	if d.accepted {
+		d.target.Drop(d.data, d.targetPos)
+	} else {
+		d.target.DragLeave()
+	}
+}
+
+// findDropTarget returns the drop target under the absolute position,
+// if any, and the position relative to it.
+func findDropTarget(source fyne.CanvasObject, absPos fyne.Position) (DropTarget, fyne.Position) {
+	if t, ok := source.(DropTarget); ok {
+		if pos, ok := positionInside(t, absPos); ok {
+			return t, pos
+		}
+	}
+	for _, t := range dropTargets {
+		if pos, ok := positionInside(t, absPos); ok {
+			return t, pos
+		}
+	}
+	return nil, fyne.Position{}
+}
+
+func positionInside(obj fyne.CanvasObject, absPos fyne.Position) (fyne.Position, bool) {
+	if !obj.Visible() {
+		return fyne.Position{}, false
+	}
+	d := fyne.CurrentApp().Driver()
+	if d.CanvasForObject(obj) == nil {
+		return fyne.Position{}, false
+	}
+	// the zero position is returned for objects not in the visible
+	// object tree, such as those in hidden containers
+	origin := d.AbsolutePositionForObject(obj)
+	if origin.IsZero() {
+		return fyne.Position{}, false
+	}
+	pos := absPos.Subtract(origin)
+	size := obj.Size()
+	return pos, pos.X >= 0 && pos.Y >= 0 && pos.X < size.Width && pos.Y < size.Height
+}
+
+// isNoopMove returns whether moving the items at idxs
+// to insertIdx would leave the list in the same order.
+func isNoopMove(idxs []int, insertIdx int) bool {
+	if len(idxs) == 0 {
+		return true
+	}
+	for i := 1; i < len(idxs); i++ {
+		if idxs[i] != idxs[i-1]+1 {
+			return false
+		}
+	}
+	return insertIdx >= idxs[0] && insertIdx <= idxs[len(idxs)-1]+1
+}
+
+// dropIndicator is a horizontal line showing where
+// dragged items would be inserted into a list.
+type dropIndicator struct {
+	line  *canvas.Rectangle
+	layer *fyne.Container
+}
+
+func newDropIndicator() *dropIndicator {
+	d := &dropIndicator{line: canvas.NewRectangle(theme.PrimaryColor())}
+	d.line.Hidden = true
+	d.layer = container.NewWithoutLayout(d.line)
+	return d
+}
+
+// ShowAt shows the indicator line centered at y in the coordinate space of its layer.
+func (d *dropIndicator) ShowAt(y, width float32) {
+	d.line.FillColor = theme.PrimaryColor()
+	d.line.Move(fyne.NewPos(0, y-1))
+	d.line.Resize(fyne.NewSize(width, 2))
+	d.line.Show()
+	d.line.Refresh()
+}
+
+func (d *dropIndicator) Hide() {
+	d.line.Hide()
+}
+
+// autoScrollList scrolls the list if y (relative to the list)
+// is near its top or bottom edge, during a drag.
+func autoScrollList(list *FocusList, y float32) {
+	const edge, amount = 30, 15
+	if y < edge {
+		list.ScrollToOffset(list.GetScrollOffset() - amount)
+	} else if y > list.Size().Height-edge {
+		list.ScrollToOffset(list.GetScrollOffset() + amount)
+	}
+}
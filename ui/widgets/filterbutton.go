@@ -1,8 +1,16 @@
 package widgets
 
 import (
+	"slices"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
 )
 
 type FilterButton[M, F any] interface {
@@ -11,3 +19,156 @@ type FilterButton[M, F any] interface {
 	Filter() mediaprovider.MediaFilter[M, F]
 	SetOnChanged(func())
 }
+
+// a widget which extends a base widget
+type extendedWidget interface {
+	fyne.Widget
+	ExtendBaseWidget(fyne.Widget)
+}
+
+// filterButtonBase is the common implementation of the filter buttons,
+// which show a popup to edit the filter when tapped.
+type filterButtonBase[M, F any] struct {
+	widget.Button
+
+	OnChanged func()
+
+	self          fyne.Widget
+	filter        mediaprovider.MediaFilter[M, F]
+	genreListChan chan []string
+	dialog        *widget.PopUp
+
+	// filterEmpty returns true if the button should be shown as inactive
+	filterEmpty func() bool
+	// newPopup creates the popup to edit the filter
+	newPopup func() *filterPopup
+}
+
+// init initializes the button and starts fetching the genre list.
+// Must be called by the embedding button's constructor.
+func (f *filterButtonBase[M, F]) init(self extendedWidget, filter mediaprovider.MediaFilter[M, F], fetchGenresFunc func() ([]*mediaprovider.Genre, error)) {
+	f.self = self
+	f.filter = filter
+	f.filterEmpty = filter.IsNil
+	f.Icon = theme.NewThemedResource(myTheme.FilterIcon)
+	f.OnTapped = f.showFilterDialog
+	self.ExtendBaseWidget(self)
+	f.genreListChan = make(chan []string)
+	go func() {
+		if genres, err := fetchGenresFunc(); err == nil {
+			genreNames := sharedutil.MapSlice(genres, func(g *mediaprovider.Genre) string {
+				return g.Name
+			})
+			slices.Sort(genreNames)
+			f.genreListChan <- genreNames
+		}
+	}()
+}
+
+func (f *filterButtonBase[M, F]) Refresh() {
+	themedIcon := f.Icon.(*theme.ThemedResource)
+	if f.filterEmpty() {
+		themedIcon.ColorName = theme.ColorNameForeground
+	} else {
+		themedIcon.ColorName = theme.ColorNamePrimary
+	}
+	f.Button.Refresh()
+}
+
+func (f *filterButtonBase[M, F]) Filter() mediaprovider.MediaFilter[M, F] {
+	return f.filter
+}
+
+func (f *filterButtonBase[M, F]) SetOnChanged(fn func()) {
+	f.OnChanged = fn
+}
+
+func (f *filterButtonBase[M, F]) onFilterChanged() {
+	f.Refresh()
+	if f.OnChanged != nil {
+		f.OnChanged()
+	}
+}
+
+func (f *filterButtonBase[M, F]) showFilterDialog() {
+	if f.dialog == nil {
+		filterDlg := f.newPopup()
+		filterDlg.OnChanged = f.onFilterChanged
+		f.dialog = widget.NewPopUp(filterDlg.self, fyne.CurrentApp().Driver().CanvasForObject(f.self))
+	}
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(f.self)
+	f.dialog.ShowAtPosition(fyne.NewPos(pos.X+f.Size().Width/2-f.dialog.MinSize().Width/2, pos.Y+f.Size().Height))
+}
+
+// updateOptions applies update to the filter's options
+// and calls onChanged (usually a debouncer).
+func (f *filterButtonBase[M, F]) updateOptions(update func(*F), onChanged func()) {
+	filterOptions := f.filter.Options()
+	update(&filterOptions)
+	f.filter.SetOptions(filterOptions)
+	onChanged()
+}
+
+// loadGenres sets the genre list of the genre filter once it has been fetched.
+func (f *filterButtonBase[M, F]) loadGenres(genreFilter *TagFilterSubsection) {
+	go func() {
+		genreFilter.SetTagList(<-f.genreListChan)
+	}()
+}
+
+// filterPopup is the common implementation of the filter popups.
+type filterPopup struct {
+	widget.BaseWidget
+
+	OnChanged func()
+
+	self      fyne.Widget
+	container *fyne.Container
+}
+
+// init initializes the popup with a title and the given rows of controls.
+// Must be called by the embedding popup's constructor.
+func (f *filterPopup) init(self extendedWidget, title string, rows ...fyne.CanvasObject) {
+	f.self = self
+	self.ExtendBaseWidget(self)
+	titleLabel := widget.NewLabel(title)
+	titleLabel.TextStyle.Bold = true
+	f.container = container.NewVBox(container.NewHBox(layout.NewSpacer(), titleLabel, layout.NewSpacer()))
+	f.container.Objects = append(f.container.Objects, rows...)
+}
+
+func (f *filterPopup) Tapped(_ *fyne.PointEvent) {
+	// swallow the Tapped event so that the popup is
+	// only dismissed by clicking outside of it
+}
+
+func (f *filterPopup) emitOnChanged() {
+	if f.OnChanged != nil {
+		f.OnChanged()
+	}
+}
+
+func (f *filterPopup) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(f.container)
+}
+
+// newFavoriteChecks creates the mutually exclusive "Is favorite"
+// and "Is not favorite" checks of a filter popup.
+func newFavoriteChecks(isFav, isNotFav bool, onFavChanged, onNotFavChanged func(bool)) (*widget.Check, *widget.Check) {
+	var isFavorite, isNotFavorite *widget.Check
+	isFavorite = widget.NewCheck("Is favorite", func(fav bool) {
+		if fav {
+			isNotFavorite.SetChecked(false)
+		}
+		onFavChanged(fav)
+	})
+	isFavorite.Checked = isFav
+	isNotFavorite = widget.NewCheck("Is not favorite", func(fav bool) {
+		if fav {
+			isFavorite.SetChecked(false)
+		}
+		onNotFavChanged(fav)
+	})
+	isNotFavorite.Checked = isNotFav
+	return isFavorite, isNotFavorite
+}
@@ -22,6 +22,7 @@ type FocusList struct {
 }
 
 type FocusListRow interface {
+	fyne.CanvasObject
 	fyne.Focusable
 	ItemID() widget.ListItemID
 }
@@ -78,9 +79,40 @@ func (g *FocusList) FocusNeighbor(curItem widget.ListItemID, up bool) {
 	}
 }
 
+// InsertIndexAtPosition returns the index at which items dropped at
+// the given position (relative to the list) would be inserted.
+// Assumes all rows have the same height.
+func (g *FocusList) InsertIndexAtPosition(pos fyne.Position) int {
+	pitch := g.rowPitch()
+	if pitch <= 0 {
+		return g.Length()
+	}
+	idx := int((pos.Y + g.GetScrollOffset() + pitch/2) / pitch)
+	return max(0, min(idx, g.Length()))
+}
+
+// InsertLineY returns the Y coordinate (relative to the list) of the
+// gap between rows before which items would be inserted at idx.
+func (g *FocusList) InsertLineY(idx int) float32 {
+	return float32(idx)*g.rowPitch() - g.GetScrollOffset() - theme.Padding()/2
+}
+
+// height of a row plus the separator between rows
+func (g *FocusList) rowPitch() float32 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, item := range g.itemForIndex {
+		if h := item.Size().Height; h > 0 {
+			return h + theme.Padding()
+		}
+	}
+	return 0
+}
+
 var _ fyne.Tappable = (*FocusListRowBase)(nil)
 var _ fyne.Widget = (*FocusListRowBase)(nil)
 var _ fyne.Focusable = (*FocusListRowBase)(nil)
+var _ fyne.Draggable = (*FocusListRowBase)(nil)
 
 // Base type used for all list rows in widgets such as Tracklist, etc.
 type FocusListRowBase struct {
@@ -94,6 +126,8 @@ type FocusListRowBase struct {
 	OnTapped        func()
 	OnDoubleTapped  func()
 	OnFocusNeighbor func(up bool) //TODO: func(up, selecting bool)
+	OnDragged       func(*fyne.DragEvent)
+	OnDragEnd       func()
 
 	tappedAt      int64 // unixMillis
 	focusedRect   *canvas.Rectangle
@@ -127,6 +161,18 @@ func (l *FocusListRowBase) Tapped(*fyne.PointEvent) {
 	}
 }
 
+func (l *FocusListRowBase) Dragged(e *fyne.DragEvent) {
+	if l.OnDragged != nil {
+		l.OnDragged(e)
+	}
+}
+
+func (l *FocusListRowBase) DragEnd() {
+	if l.OnDragEnd != nil {
+		l.OnDragEnd()
+	}
+}
+
 func (l *FocusListRowBase) FocusGained() {
 	l.Focused = true
 	l.Refresh()
@@ -57,6 +57,9 @@ func (g gridViewAlbumIterator) NextN(n int) []GridViewItemModel {
 			CoverArtID:   al.CoverArtID,
 			Secondary:    al.ArtistNames,
 			SecondaryIDs: al.ArtistIDs,
+
+			ArtworkArtist: sharedutil.FirstOrEmptyStr(al.ArtistNames),
+			ArtworkAlbum:  al.Name,
 		}
 	})
 }
@@ -81,6 +84,8 @@ func (g gridViewArtistIterator) NextN(n int) []GridViewItemModel {
 			ID:         ar.ID,
 			CoverArtID: ar.CoverArtID,
 			Secondary:  []string{fmt.Sprintf("%d %s", ar.AlbumCount, albumsLabel)},
+
+			ArtworkArtist: ar.Name,
 		}
 	})
 }
@@ -124,6 +129,10 @@ type GridViewState struct {
 	OnShowItemPage      func(id string)
 	OnShowSecondaryPage func(id string)
 
+	// TracksLoader loads the tracks of an item when it is dropped
+	// after being dragged. Items can be dragged only if it is set.
+	TracksLoader func(id string) ([]*mediaprovider.Track, error)
+
 	scrollPos float32
 }
 
@@ -279,6 +288,10 @@ func (g *GridView) createNewItemCard() fyne.CanvasObject {
 	card.OnShowContextMenu = func(p fyne.Position) {
 		g.showContextMenu(card, p)
 	}
+	card.OnDragged = func(e *fyne.DragEvent) {
+		handleDragged(e, g, func() *DragData { return g.newDragData(card) })
+	}
+	card.OnDragEnd = handleDragEnd
 	card.OnFocusNeighbor = func(neighbor int) {
 		focusIndex := -1
 		switch neighbor {
@@ -328,7 +341,7 @@ func (g *GridView) doUpdateItemCard(itemIdx int, card *GridViewItem) {
 	}
 	g.stateMutex.Unlock()
 	card.Update(item)
-	card.ImgLoader.Load(item.CoverArtID)
+	card.ImgLoader.LoadArtwork(item.CoverArtID, item.ArtworkArtist, item.ArtworkAlbum)
 
 	// if user has scrolled near the bottom, fetch more
 	if itemIdx > g.lenItems()-10 {
@@ -396,6 +409,17 @@ func (g *GridView) cancelFetch() {
 	}
 }
 
+func (g *GridView) newDragData(card *GridViewItem) *DragData {
+	loader := g.TracksLoader
+	if loader == nil {
+		return nil
+	}
+	id := card.ItemID()
+	return NewItemDragData(g, card.primaryText.Text, func() ([]*mediaprovider.Track, error) {
+		return loader(id)
+	})
+}
+
 func (g *GridView) showContextMenu(card *GridViewItem, pos fyne.Position) {
 	g.menuGridViewItemId = card.ItemID()
 	if g.menu == nil {
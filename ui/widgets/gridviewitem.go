@@ -19,6 +19,7 @@ import (
 
 var _ fyne.Widget = (*GridViewItem)(nil)
 var _ fyne.Focusable = (*GridViewItem)(nil)
+var _ fyne.Draggable = (*GridViewItem)(nil)
 
 var _ fyne.Widget = (*coverImage)(nil)
 
@@ -131,6 +132,11 @@ type GridViewItemModel struct {
 	CoverArtID   string
 	Secondary    []string
 	SecondaryIDs []string
+
+	// names used to look up local artwork for the item;
+	// ArtworkAlbum is empty for artists
+	ArtworkArtist string
+	ArtworkAlbum  string
 }
 
 type GridViewItem struct {
@@ -153,6 +159,8 @@ type GridViewItem struct {
 	OnShowContextMenu   func(fyne.Position)
 	OnShowItemPage      func()
 	OnShowSecondaryPage func(string)
+	OnDragged           func(*fyne.DragEvent)
+	OnDragEnd           func()
 
 	// Invoked with arg 0-3 when left, right, up, or down neighbor should be focused, respectively
 	OnFocusNeighbor func(int)
@@ -227,6 +235,18 @@ func (g *GridViewItem) Refresh() {
 	g.BaseWidget.Refresh()
 }
 
+func (g *GridViewItem) Dragged(e *fyne.DragEvent) {
+	if g.OnDragged != nil {
+		g.OnDragged(e)
+	}
+}
+
+func (g *GridViewItem) DragEnd() {
+	if g.OnDragEnd != nil {
+		g.OnDragEnd()
+	}
+}
+
 func (g *GridViewItem) ItemID() string {
 	return g.itemID
 }
@@ -40,6 +40,10 @@ type PlayQueueList struct {
 	OnPlayTrackAt     func(idx int)
 	OnReorderTracks   func(trackIDs []string, op sharedutil.TrackReorderOp)
 
+	// OnMoveTracks is called when tracks are reordered by drag-and-drop,
+	// with their indexes and the index to insert them before.
+	OnMoveTracks func(trackIdxs []int, insertIdx int)
+
 	list          *FocusList
 	menu          *widget.PopUpMenu
 	ratingSubmenu *fyne.MenuItem
@@ -48,6 +52,11 @@ type PlayQueueList struct {
 	nowPlayingID string
 	colLayout    *layouts.ColumnsLayout
 
+	// drag-and-drop reordering state
+	dragTrackIdxs []int
+	dropInsertIdx int
+	dropIndicator *dropIndicator
+
 	tracksMutex sync.RWMutex
 	tracks      []*util.TrackListModel
 }
@@ -93,6 +102,7 @@ func NewPlayQueueList(im *backend.ImageManager) *PlayQueueList {
 			tr.Update(model, itemID+1)
 		},
 	)
+	p.dropIndicator = newDropIndicator()
 
 	return p
 }
@@ -271,8 +281,47 @@ func (t *PlayQueueList) selectedTrackIDs() []string {
 	return util.SelectedTrackIDs(t.tracks)
 }
 
+// creates the payload for dragging the selected tracks, first
+// selecting only the track at idx if it is not already selected
+func (p *PlayQueueList) newDragData(idx int) *DragData {
+	p.tracksMutex.RLock()
+	if idx < 0 || idx >= len(p.tracks) {
+		p.tracksMutex.RUnlock()
+		return nil
+	}
+	util.SelectTrack(p.tracks, idx)
+	p.dragTrackIdxs = util.SelectedIndexes(p.tracks)
+	tracks := util.SelectedTracks(p.tracks)
+	p.tracksMutex.RUnlock()
+	p.list.Refresh()
+	return NewTracksDragData(p, tracks)
+}
+
+var _ DropTarget = (*PlayQueueList)(nil)
+
+func (p *PlayQueueList) DragOver(data *DragData, pos fyne.Position) string {
+	if data.Source != p || p.OnMoveTracks == nil {
+		return ""
+	}
+	autoScrollList(p.list, pos.Y)
+	p.dropInsertIdx = p.list.InsertIndexAtPosition(pos)
+	p.dropIndicator.ShowAt(p.list.InsertLineY(p.dropInsertIdx), p.Size().Width)
+	return "Move"
+}
+
+func (p *PlayQueueList) DragLeave() {
+	p.dropIndicator.Hide()
+}
+
+func (p *PlayQueueList) Drop(data *DragData, pos fyne.Position) {
+	p.dropIndicator.Hide()
+	if data.Source == p && p.OnMoveTracks != nil && !isNoopMove(p.dragTrackIdxs, p.dropInsertIdx) {
+		p.OnMoveTracks(p.dragTrackIdxs, p.dropInsertIdx)
+	}
+}
+
 func (p *PlayQueueList) CreateRenderer() fyne.WidgetRenderer {
-	return widget.NewSimpleRenderer(p.list)
+	return widget.NewSimpleRenderer(container.NewStack(p.list, p.dropIndicator.layer))
 }
 
 type PlayQueueListRow struct {
@@ -317,6 +366,12 @@ func NewPlayQueueListRow(playQueueList *PlayQueueList, im *backend.ImageManager,
 	p.OnFocusNeighbor = func(up bool) {
 		playQueueList.list.FocusNeighbor(p.ItemID(), up)
 	}
+	p.OnDragged = func(e *fyne.DragEvent) {
+		handleDragged(e, playQueueList, func() *DragData {
+			return playQueueList.newDragData(p.ItemID())
+		})
+	}
+	p.OnDragEnd = handleDragEnd
 
 	p.imageLoader = util.NewThumbnailLoader(im, func(i image.Image) {
 		p.cover.SetImage(i, false)
@@ -0,0 +1,331 @@
+package widgets
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/layouts"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+type tagState int
+
+const (
+	tagIncluded tagState = iota
+	tagExcluded
+)
+
+// TagFilterSubsection is a section of a filter popup for selecting
+// tags (e.g. genres or moods) which items must (or must not) have.
+type TagFilterSubsection struct {
+	widget.BaseWidget
+
+	// If true, tags not in the list can be added by typing them
+	// in the filter entry and pressing Enter.
+	AllowCustomTags bool
+
+	tagList   []string
+	onChanged func(include []string, mode mediaprovider.TagMatchMode, exclude []string)
+
+	tagStates      map[string]tagState
+	tagStatesMutex sync.RWMutex
+	matchMode      mediaprovider.TagMatchMode
+
+	filterText       *widget.Entry
+	numSelectedText  *widget.Label
+	matchModeSelect  *widget.Select
+	allBtn           *widget.Button
+	noneBtn          *widget.Button
+	listModelMutex   sync.RWMutex
+	tagListViewModel []string
+	tagListView      *FocusList
+
+	container *fyne.Container
+}
+
+const (
+	matchAnyText = "Match any"
+	matchAllText = "Match all"
+)
+
+func NewGenreFilterSubsection(onChanged func([]string, mediaprovider.TagMatchMode, []string), include []string, mode mediaprovider.TagMatchMode, exclude []string) *TagFilterSubsection {
+	return NewTagFilterSubsection("Genres", "Filter genres", onChanged, include, mode, exclude)
+}
+
+func NewMoodFilterSubsection(onChanged func([]string, mediaprovider.TagMatchMode, []string), include []string, mode mediaprovider.TagMatchMode, exclude []string) *TagFilterSubsection {
+	t := NewTagFilterSubsection("Moods", "Filter or add moods", onChanged, include, mode, exclude)
+	t.AllowCustomTags = true
+	return t
+}
+
+func NewTagFilterSubsection(title, placeholder string, onChanged func([]string, mediaprovider.TagMatchMode, []string), include []string, mode mediaprovider.TagMatchMode, exclude []string) *TagFilterSubsection {
+	g := &TagFilterSubsection{
+		onChanged: onChanged,
+		tagStates: make(map[string]tagState),
+		matchMode: mode,
+	}
+	g.ExtendBaseWidget(g)
+
+	for _, tag := range include {
+		g.tagStates[tag] = tagIncluded
+	}
+	for _, tag := range exclude {
+		g.tagStates[tag] = tagExcluded
+	}
+
+	g.tagListView = NewFocusList(
+		func() int {
+			g.listModelMutex.RLock()
+			defer g.listModelMutex.RUnlock()
+			return len(g.tagListViewModel)
+		},
+		func() fyne.CanvasObject {
+			row := newTagListViewRow(g.onTagIncluded, g.onTagExcludeToggled)
+			row.OnFocusNeighbor = func(up bool) {
+				g.tagListView.FocusNeighbor(row.ItemID(), up)
+			}
+			return row
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			g.listModelMutex.RLock()
+			defer g.listModelMutex.RUnlock()
+			tag := g.tagListViewModel[id]
+			g.tagStatesMutex.RLock()
+			state, selected := g.tagStates[tag]
+			g.tagStatesMutex.RUnlock()
+			row := obj.(*tagListViewRow)
+			g.tagListView.SetItemForID(id, row)
+			row.ListItemID = id
+			row.Update(tag, selected && state == tagIncluded, selected && state == tagExcluded)
+		},
+	)
+	g.filterText = widget.NewEntry()
+	g.filterText.SetPlaceHolder(placeholder)
+	i := NewTappableIcon(theme.ContentClearIcon())
+	i.NoPointerCursor = true
+	i.OnTapped = func() { g.filterText.SetText("") }
+	g.filterText.ActionItem = i
+	debouncer := util.NewDebouncer(300*time.Millisecond, g.updateTagListView)
+	g.filterText.OnChanged = func(_ string) {
+		debouncer()
+	}
+	g.filterText.OnSubmitted = g.onFilterTextSubmitted
+	g.allBtn = widget.NewButton("All", func() { g.selectAllOrNoneInView(false) })
+	g.noneBtn = widget.NewButton("None", func() { g.selectAllOrNoneInView(true) })
+	g.matchModeSelect = widget.NewSelect([]string{matchAnyText, matchAllText}, g.onMatchModeChanged)
+	if mode == mediaprovider.TagMatchAll {
+		g.matchModeSelect.Selected = matchAllText
+	} else {
+		g.matchModeSelect.Selected = matchAnyText
+	}
+
+	titleText := widget.NewRichTextWithText(title)
+	titleText.Segments[0].(*widget.TextSegment).Style.TextStyle.Bold = true
+	g.numSelectedText = widget.NewLabel("(none selected)")
+	g.updateNumSelectedText()
+	titleRow := container.NewBorder(nil, nil,
+		container.New(&layouts.HboxCustomPadding{ExtraPad: -10}, titleText, g.numSelectedText),
+		g.matchModeSelect)
+
+	filterRow := container.NewBorder(nil, nil, nil, container.NewHBox(g.allBtn, g.noneBtn), g.filterText)
+	g.container = container.NewBorder(titleRow, nil, nil, nil,
+		container.New(&layouts.MaxPadLayout{PadLeft: 5, PadRight: 5},
+			container.NewBorder(filterRow, nil, nil, nil, g.tagListView),
+		),
+	)
+	return g
+}
+
+func (g *TagFilterSubsection) MinSize() fyne.Size {
+	return fyne.NewSize(g.BaseWidget.MinSize().Width, 250)
+}
+
+// SetTagList sets the list of tags to choose from.
+// Any currently selected tags not in the list are added to it.
+func (g *TagFilterSubsection) SetTagList(tags []string) {
+	g.tagStatesMutex.RLock()
+	for tag := range g.tagStates {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	g.tagStatesMutex.RUnlock()
+	slices.Sort(tags)
+	g.tagList = tags
+	g.updateTagListView()
+}
+
+func (g *TagFilterSubsection) updateTagListView() {
+	g.listModelMutex.Lock()
+	if g.filterText.Text == "" {
+		g.tagListViewModel = g.tagList
+	} else {
+		filterText := strings.ToLower(g.filterText.Text)
+		g.tagListViewModel = sharedutil.FilterSlice(g.tagList, func(tag string) bool {
+			return strings.Contains(strings.ToLower(tag), filterText)
+		})
+	}
+	g.listModelMutex.Unlock()
+	g.tagListView.Refresh()
+}
+
+func (g *TagFilterSubsection) onFilterTextSubmitted(text string) {
+	text = strings.TrimSpace(text)
+	if !g.AllowCustomTags || text == "" {
+		return
+	}
+	if !slices.ContainsFunc(g.tagList, func(tag string) bool { return strings.EqualFold(tag, text) }) {
+		g.tagList = append(g.tagList, text)
+		slices.Sort(g.tagList)
+	}
+	g.tagStatesMutex.Lock()
+	g.tagStates[text] = tagIncluded
+	g.tagStatesMutex.Unlock()
+	g.filterText.SetText("")
+	g.updateTagListView()
+	g.invokeOnChanged()
+}
+
+func (g *TagFilterSubsection) onMatchModeChanged(mode string) {
+	newMode := mediaprovider.TagMatchAny
+	if mode == matchAllText {
+		newMode = mediaprovider.TagMatchAll
+	}
+	if newMode == g.matchMode {
+		return
+	}
+	g.matchMode = newMode
+	g.invokeOnChanged()
+}
+
+func (g *TagFilterSubsection) onTagIncluded(row widget.ListItemID, included bool) {
+	g.listModelMutex.RLock()
+	tag := g.tagListViewModel[row]
+	g.listModelMutex.RUnlock()
+	g.tagStatesMutex.Lock()
+	if included {
+		g.tagStates[tag] = tagIncluded
+	} else if g.tagStates[tag] == tagIncluded {
+		delete(g.tagStates, tag)
+	}
+	g.tagStatesMutex.Unlock()
+	g.tagListView.RefreshItem(row)
+	g.invokeOnChanged()
+}
+
+func (g *TagFilterSubsection) onTagExcludeToggled(row widget.ListItemID) {
+	g.listModelMutex.RLock()
+	tag := g.tagListViewModel[row]
+	g.listModelMutex.RUnlock()
+	g.tagStatesMutex.Lock()
+	if state, ok := g.tagStates[tag]; ok && state == tagExcluded {
+		delete(g.tagStates, tag)
+	} else {
+		g.tagStates[tag] = tagExcluded
+	}
+	g.tagStatesMutex.Unlock()
+	g.tagListView.RefreshItem(row)
+	g.invokeOnChanged()
+}
+
+func (g *TagFilterSubsection) selectAllOrNoneInView(none bool) {
+	g.listModelMutex.RLock()
+	g.tagStatesMutex.Lock()
+	for _, tag := range g.tagListViewModel {
+		if none {
+			delete(g.tagStates, tag)
+		} else {
+			g.tagStates[tag] = tagIncluded
+		}
+	}
+	g.tagStatesMutex.Unlock()
+	g.listModelMutex.RUnlock()
+	g.tagListView.Refresh()
+	g.invokeOnChanged()
+}
+
+func (g *TagFilterSubsection) invokeOnChanged() {
+	g.tagStatesMutex.RLock()
+	g.updateNumSelectedText()
+	var include, exclude []string
+	for tag, state := range g.tagStates {
+		if state == tagIncluded {
+			include = append(include, tag)
+		} else {
+			exclude = append(exclude, tag)
+		}
+	}
+	g.tagStatesMutex.RUnlock()
+	g.onChanged(include, g.matchMode, exclude)
+}
+
+func (g *TagFilterSubsection) updateNumSelectedText() {
+	var numIncluded, numExcluded int
+	for _, state := range g.tagStates {
+		if state == tagIncluded {
+			numIncluded++
+		} else {
+			numExcluded++
+		}
+	}
+	numText := "none"
+	if numIncluded > 0 {
+		numText = strconv.Itoa(numIncluded)
+	}
+	text := fmt.Sprintf("(%s selected", numText)
+	if numExcluded > 0 {
+		text += fmt.Sprintf(", %d excluded", numExcluded)
+	}
+	g.numSelectedText.SetText(text + ")")
+}
+
+func (g *TagFilterSubsection) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(g.container)
+}
+
+type tagListViewRow struct {
+	FocusListRowBase
+
+	check       *widget.Check
+	excludeIcon *theme.ThemedResource
+	excludeBtn  *TappableIcon
+}
+
+func newTagListViewRow(onIncluded func(widget.ListItemID, bool), onExcludeToggled func(widget.ListItemID)) *tagListViewRow {
+	g := &tagListViewRow{}
+	g.ExtendBaseWidget(g)
+	g.check = widget.NewCheck("", func(b bool) {
+		onIncluded(g.ItemID(), b)
+	})
+	g.excludeIcon = theme.NewThemedResource(theme.ContentRemoveIcon())
+	g.excludeBtn = NewTappableIcon(g.excludeIcon)
+	g.excludeBtn.OnTapped = func() { onExcludeToggled(g.ItemID()) }
+	g.Content = container.NewBorder(nil, nil, nil, g.excludeBtn, g.check)
+	g.OnTapped = func() {
+		g.check.SetChecked(!g.check.Checked)
+	}
+	return g
+}
+
+func (g *tagListViewRow) Update(tag string, included, excluded bool) {
+	g.check.Text = tag
+	g.check.Checked = included
+	if excluded {
+		g.excludeIcon.ColorName = theme.ColorNameError
+		g.check.Text = tag + " (excluded)"
+	} else {
+		g.excludeIcon.ColorName = theme.ColorNameDisabled
+	}
+	g.excludeBtn.Refresh()
+	g.Refresh()
+}
@@ -0,0 +1,79 @@
+package widgets
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+var _ FilterButton[mediaprovider.Track, mediaprovider.TrackFilterOptions] = (*TrackFilterButton)(nil)
+
+type TrackFilterButton struct {
+	filterButtonBase[mediaprovider.Track, mediaprovider.TrackFilterOptions]
+
+	MoodDisabled bool
+}
+
+func NewTrackFilterButton(filter mediaprovider.TrackFilter, fetchGenresFunc func() ([]*mediaprovider.Genre, error)) *TrackFilterButton {
+	t := &TrackFilterButton{}
+	t.init(t, filter, fetchGenresFunc)
+	t.newPopup = func() *filterPopup { return &newTrackFilterPopup(t).filterPopup }
+	return t
+}
+
+type trackFilterPopup struct {
+	filterPopup
+
+	filterBtn *TrackFilterButton
+}
+
+func newTrackFilterPopup(filterBtn *TrackFilterButton) *trackFilterPopup {
+	t := &trackFilterPopup{filterBtn: filterBtn}
+
+	debounceOnChanged := util.NewDebouncer(350*time.Millisecond, t.emitOnChanged)
+	updateOptions := func(update func(*mediaprovider.TrackFilterOptions)) {
+		t.filterBtn.updateOptions(update, debounceOnChanged)
+	}
+
+	filterOptions := t.filterBtn.filter.Options()
+	minYear := newYearFilterEntry(filterOptions.MinYear, func(year int) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.MinYear = year })
+	})
+	maxYear := newYearFilterEntry(filterOptions.MaxYear, func(year int) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.MaxYear = year })
+	})
+
+	isFavorite, isNotFavorite := newFavoriteChecks(
+		filterOptions.ExcludeUnfavorited, filterOptions.ExcludeFavorited,
+		func(fav bool) {
+			updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ExcludeUnfavorited = fav })
+		},
+		func(fav bool) {
+			updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ExcludeFavorited = fav })
+		})
+
+	genreFilter := NewGenreFilterSubsection(func(genres []string, mode mediaprovider.TagMatchMode, excluded []string) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) {
+			o.Genres, o.GenreMatch, o.ExcludeGenres = genres, mode, excluded
+		})
+	}, filterOptions.Genres, filterOptions.GenreMatch, filterOptions.ExcludeGenres)
+	moodFilter := NewMoodFilterSubsection(func(moods []string, mode mediaprovider.TagMatchMode, excluded []string) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) {
+			o.Moods, o.MoodMatch, o.ExcludeMoods = moods, mode, excluded
+		})
+	}, filterOptions.Moods, filterOptions.MoodMatch, filterOptions.ExcludeMoods)
+	moodFilter.SetTagList(nil)
+	moodFilter.Hidden = t.filterBtn.MoodDisabled
+
+	t.init(t, "Track filters",
+		container.NewHBox(widget.NewLabel("Year from"), minYear, widget.NewLabel("to"), maxYear),
+		container.NewHBox(isFavorite, isNotFavorite),
+		genreFilter,
+		moodFilter,
+	)
+	t.filterBtn.loadGenres(genreFilter)
+	return t
+}
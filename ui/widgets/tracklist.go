@@ -27,6 +27,7 @@ const (
 	ColumnNum      = "Num"
 	ColumnTitle    = "Title"
 	ColumnArtist   = "Artist"
+	ColumnComposer = "Composer"
 	ColumnAlbum    = "Album"
 	ColumnTime     = "Time"
 	ColumnYear     = "Year"
@@ -38,11 +39,11 @@ const (
 	ColumnSize     = "Size"
 	ColumnPath     = "Path"
 
-	numColumns = 13
+	numColumns = 14
 )
 
 var columns = []string{
-	ColumnNum, ColumnTitle, ColumnArtist, ColumnAlbum, ColumnTime, ColumnYear, ColumnFavorite,
+	ColumnNum, ColumnTitle, ColumnArtist, ColumnComposer, ColumnAlbum, ColumnTime, ColumnYear, ColumnFavorite,
 	ColumnRating, ColumnPlays, ColumnComment, ColumnBitrate, ColumnSize, ColumnPath,
 }
 
@@ -76,6 +77,15 @@ type TracklistOptions struct {
 
 	// Disables the sharing option.
 	DisableSharing bool
+
+	// GroupByDisc sets whether to show a collapsible header row above
+	// the tracks of each disc, if the tracks span multiple discs.
+	// Grouping only applies while the tracklist is in its original order.
+	GroupByDisc bool
+
+	// DiscTitles sets the (optional) titles to show in the disc headers
+	// when grouping by disc.
+	DiscTitles map[int]string
 }
 
 type Tracklist struct {
@@ -94,6 +104,12 @@ type Tracklist struct {
 	OnShare         func(trackID string)
 	OnPlaySongRadio func(track *mediaprovider.Track)
 
+	// OnMoveTracks is called when tracks are reordered by drag-and-drop,
+	// with their indexes and the index to insert them before.
+	// Drag reordering is enabled only if set, and only while the
+	// tracklist is in its original order and not grouped by disc.
+	OnMoveTracks func(trackIdxs []int, insertIdx int)
+
 	OnShowArtistPage func(artistID string)
 	OnShowAlbumPage  func(albumID string)
 
@@ -108,6 +124,16 @@ type Tracklist struct {
 	tracks          []*util.TrackListModel
 	tracksOrigOrder []*util.TrackListModel
 
+	// display rows when grouping by disc; nil if not grouped,
+	// in which case list items map 1:1 to tracks
+	rows           []tracklistRow
+	collapsedDiscs map[int]bool
+
+	// drag-and-drop reordering state
+	dragTrackIdxs []int
+	dropInsertIdx int
+	dropIndicator *dropIndicator
+
 	nowPlayingID      string
 	colLayout         *layouts.ColumnsLayout
 	hdr               *ListHeader
@@ -119,6 +145,13 @@ type Tracklist struct {
 	container         *fyne.Container
 }
 
+// tracklistRow is an entry in the displayed list when grouping by disc,
+// which is either a track or the header of a disc.
+type tracklistRow struct {
+	trackIdx int // index into tracks, or -1 for disc headers
+	discNum  int
+}
+
 func NewTracklist(tracks []*mediaprovider.Track) *Tracklist {
 	t := &Tracklist{visibleColumns: make([]bool, numColumns)}
 	t.ExtendBaseWidget(t)
@@ -127,8 +160,8 @@ func NewTracklist(tracks []*mediaprovider.Track) *Tracklist {
 		t._setTracks(tracks)
 	}
 
-	// #, Title, Artist, Album, Time, Year, Favorite, Rating, Plays, Comment, Bitrate, Size, Path
-	t.colLayout = layouts.NewColumnsLayout([]float32{40, -1, -1, -1, 60, 60, 55, 100, 65, -1, 75, 75, -1})
+	// #, Title, Artist, Composer, Album, Time, Year, Favorite, Rating, Plays, Comment, Bitrate, Size, Path
+	t.colLayout = layouts.NewColumnsLayout([]float32{40, -1, -1, -1, -1, 60, 60, 55, 100, 65, -1, 75, 75, -1})
 	t.buildHeader()
 	t.hdr.OnColumnSortChanged = t.onSorted
 	t.hdr.OnColumnVisibilityChanged = t.setColumnVisible
@@ -143,55 +176,98 @@ func NewTracklist(tracks []*mediaprovider.Track) *Tracklist {
 	playingIcon := container.NewCenter(container.NewHBox(util.NewHSpace(2), widget.NewIcon(playIcon)))
 
 	t.list = NewFocusList(
-		t.lenTracks,
+		t.lenRows,
 		func() fyne.CanvasObject {
 			tr := NewTrackRow(t, playingIcon)
 			tr.OnTapped = func() {
-				t.onSelectTrack(tr.ListItemID)
+				t.onSelectTrack(t.trackIdxForRow(tr.ListItemID))
+			}
+			tr.OnTappedSecondary = func(e *fyne.PointEvent, rowIdx int) {
+				t.onShowContextMenu(e, t.trackIdxForRow(rowIdx))
 			}
-			tr.OnTappedSecondary = t.onShowContextMenu
 			tr.OnDoubleTapped = func() {
-				t.onPlayTrackAt(tr.ListItemID)
+				t.onPlayTrackAt(t.trackIdxForRow(tr.ListItemID))
 			}
 			tr.OnFocusNeighbor = func(up bool) {
 				t.list.FocusNeighbor(tr.ListItemID, up)
 			}
-			return tr
+			tr.OnDragged = func(e *fyne.DragEvent) {
+				handleDragged(e, t, func() *DragData {
+					return t.newDragData(t.trackIdxForRow(tr.ListItemID))
+				})
+			}
+			tr.OnDragEnd = handleDragEnd
+			dh := NewDiscHeaderRow(t)
+			dh.OnFocusNeighbor = func(up bool) {
+				t.list.FocusNeighbor(dh.ListItemID, up)
+			}
+			dh.Hide()
+			return container.NewStack(tr, dh)
 		},
 		func(itemID widget.ListItemID, item fyne.CanvasObject) {
 			t.tracksMutex.RLock()
 			// we could have removed tracks from the list in between
 			// Fyne calling the length callback and this update callback
 			// so the itemID may be out of bounds. if so, do nothing.
-			if itemID >= len(t.tracks) {
+			row, ok := t.rowAt(itemID)
+			if !ok {
 				t.tracksMutex.RUnlock()
 				return
 			}
-			model := t.tracks[itemID]
+			var model *util.TrackListModel
+			var discTracks []*mediaprovider.Track
+			if row.trackIdx >= 0 {
+				model = t.tracks[row.trackIdx]
+			} else {
+				discTracks = t.discTracks(row.discNum)
+			}
 			t.tracksMutex.RUnlock()
 
-			tr := item.(*TrackRow)
+			objs := item.(*fyne.Container).Objects
+			tr, dh := objs[0].(*TrackRow), objs[1].(*DiscHeaderRow)
+			if model == nil {
+				tr.Hide()
+				tr.EnsureUnfocused()
+				t.list.SetItemForID(itemID, dh)
+				dh.ListItemID = itemID
+				dur := 0
+				for _, track := range discTracks {
+					dur += track.Duration
+				}
+				dh.Update(row.discNum, t.Options.DiscTitles[row.discNum], len(discTracks), dur, t.collapsedDiscs[row.discNum])
+				dh.Show()
+				return
+			}
+			dh.Hide()
+			dh.EnsureUnfocused()
+			tr.Show()
+
 			t.list.SetItemForID(itemID, tr)
 			if tr.trackID != model.Track.ID || tr.ListItemID != itemID {
 				tr.ListItemID = itemID
 			}
 			i := -1 // signal that we want to display the actual track num.
 			if t.Options.AutoNumber {
-				i = itemID + 1
+				i = row.trackIdx + 1
 			}
 			tr.Update(model, i)
 			if t.OnTrackShown != nil {
-				t.OnTrackShown(itemID)
+				t.OnTrackShown(row.trackIdx)
 			}
 		})
-	t.container = container.NewBorder(t.hdr, nil, nil, nil, t.list)
+	t.dropIndicator = newDropIndicator()
+	t.container = container.NewStack(
+		container.NewBorder(t.hdr, nil, nil, nil, t.list),
+		t.dropIndicator.layer)
 	return t
 }
 
 func (t *Tracklist) Reset() {
 	t.Clear()
 	t.Options = TracklistOptions{}
+	t.collapsedDiscs = nil
 	t.ctxMenu = nil
+	t.OnMoveTracks = nil
 	t.SetSorting(TracklistSort{})
 }
 
@@ -204,6 +280,7 @@ func (t *Tracklist) buildHeader() {
 		{Text: "#", Alignment: fyne.TextAlignTrailing, CanToggleVisible: false},
 		{Text: "Title", Alignment: fyne.TextAlignLeading, CanToggleVisible: false},
 		{Text: "Artist", Alignment: fyne.TextAlignLeading, CanToggleVisible: true},
+		{Text: "Composer", Alignment: fyne.TextAlignLeading, CanToggleVisible: true},
 		{Text: "Album", Alignment: fyne.TextAlignLeading, CanToggleVisible: true},
 		{Text: "Time", Alignment: fyne.TextAlignTrailing, CanToggleVisible: true},
 		{Text: "Year", Alignment: fyne.TextAlignTrailing, CanToggleVisible: true},
@@ -289,13 +366,14 @@ func (t *Tracklist) SetNowPlaying(trackID string) {
 	t.tracksMutex.RLock()
 	trPrev, idxPrev := util.FindTrackByID(t.tracks, prevNowPlaying)
 	tr, idx := util.FindTrackByID(t.tracks, trackID)
+	rowPrev, row := t.rowForTrackIdx(idxPrev), t.rowForTrackIdx(idx)
 	t.tracksMutex.RUnlock()
 	t.nowPlayingID = trackID
-	if trPrev != nil {
-		t.list.RefreshItem(idxPrev)
+	if trPrev != nil && rowPrev >= 0 {
+		t.list.RefreshItem(rowPrev)
 	}
-	if tr != nil {
-		t.list.RefreshItem(idx)
+	if tr != nil && row >= 0 {
+		t.list.RefreshItem(row)
 	}
 }
 
@@ -303,10 +381,13 @@ func (t *Tracklist) SetNowPlaying(trackID string) {
 func (t *Tracklist) IncrementPlayCount(trackID string) {
 	t.tracksMutex.RLock()
 	tr, idx := util.FindTrackByID(t.tracks, trackID)
+	row := t.rowForTrackIdx(idx)
 	t.tracksMutex.RUnlock()
 	if tr != nil {
 		tr.PlayCount += 1
-		t.list.RefreshItem(idx)
+		if row >= 0 {
+			t.list.RefreshItem(row)
+		}
 	}
 }
 
@@ -316,6 +397,7 @@ func (t *Tracklist) Clear() {
 	defer t.tracksMutex.Unlock()
 	t.tracks = nil
 	t.tracksOrigOrder = nil
+	t.rows = nil
 	t.list.ClearItemForIDMap()
 }
 
@@ -371,7 +453,7 @@ func (t *Tracklist) unselectAll() {
 }
 
 func (t *Tracklist) SelectAndScrollToTrack(trackID string) {
-	t.tracksMutex.RLock()
+	t.tracksMutex.Lock()
 	idx := -1
 	for i, tr := range t.tracks {
 		if tr.Track.ID == trackID {
@@ -381,9 +463,15 @@ func (t *Tracklist) SelectAndScrollToTrack(trackID string) {
 			tr.Selected = false
 		}
 	}
-	t.tracksMutex.RUnlock()
-	if idx >= 0 {
-		t.list.ScrollTo(idx)
+	if idx >= 0 && t.rows != nil && t.collapsedDiscs[t.tracks[idx].Track.DiscNumber] {
+		// expand the disc containing the track so it can be shown
+		delete(t.collapsedDiscs, t.tracks[idx].Track.DiscNumber)
+		t.buildRows()
+	}
+	row := t.rowForTrackIdx(idx)
+	t.tracksMutex.Unlock()
+	if row >= 0 {
+		t.list.ScrollTo(row)
 	}
 }
 
@@ -426,6 +514,7 @@ func (t *Tracklist) intSort(fieldFn func(*util.TrackListModel) int64) {
 }
 
 func (t *Tracklist) doSortTracks() {
+	defer t.buildRows()
 	if t.sorting.SortOrder == SortNone {
 		t.tracks = t.tracksOrigOrder
 		return
@@ -441,6 +530,8 @@ func (t *Tracklist) doSortTracks() {
 		t.stringSort(func(tr *util.TrackListModel) string { return tr.Track.Name })
 	case ColumnArtist:
 		t.stringSort(func(tr *util.TrackListModel) string { return strings.Join(tr.Track.ArtistNames, ", ") })
+	case ColumnComposer:
+		t.stringSort(func(tr *util.TrackListModel) string { return strings.Join(tr.Track.ComposerNames, ", ") })
 	case ColumnAlbum:
 		t.stringSort(func(tr *util.TrackListModel) string { return tr.Track.Album })
 	case ColumnPath:
@@ -478,12 +569,15 @@ func (t *Tracklist) onSorted(sort ListHeaderSort) {
 }
 
 func (t *Tracklist) onPlayTrackAt(idx int) {
-	if t.OnPlayTrackAt != nil {
+	if t.OnPlayTrackAt != nil && idx >= 0 {
 		t.OnPlayTrackAt(idx)
 	}
 }
 
 func (t *Tracklist) onSelectTrack(idx int) {
+	if idx < 0 {
+		return
+	}
 	if d, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
 		mod := d.CurrentKeyModifiers()
 		if mod&os.ControlModifier != 0 {
@@ -518,6 +612,9 @@ func (t *Tracklist) selectRange(idx int) {
 }
 
 func (t *Tracklist) onShowContextMenu(e *fyne.PointEvent, trackIdx int) {
+	if trackIdx < 0 {
+		return
+	}
 	t.selectTrack(trackIdx)
 	t.list.Refresh()
 	if t.ctxMenu == nil {
@@ -588,6 +685,50 @@ func (t *Tracklist) onShowContextMenu(e *fyne.PointEvent, trackIdx int) {
 	widget.ShowPopUpMenuAtPosition(t.ctxMenu, fyne.CurrentApp().Driver().CanvasForObject(t), e.AbsolutePosition)
 }
 
+// creates the payload for dragging the selected tracks, first
+// selecting only the track at idx if it is not already selected
+func (t *Tracklist) newDragData(idx int) *DragData {
+	if idx < 0 {
+		return nil
+	}
+	t.tracksMutex.RLock()
+	util.SelectTrack(t.tracks, idx)
+	t.dragTrackIdxs = util.SelectedIndexes(t.tracks)
+	tracks := util.SelectedTracks(t.tracks)
+	t.tracksMutex.RUnlock()
+	t.list.Refresh()
+	return NewTracksDragData(t, tracks)
+}
+
+func (t *Tracklist) canDragReorder(data *DragData) bool {
+	return data.Source == t && t.OnMoveTracks != nil &&
+		t.sorting.SortOrder == SortNone && !t.isGroupedByDisc()
+}
+
+var _ DropTarget = (*Tracklist)(nil)
+
+func (t *Tracklist) DragOver(data *DragData, pos fyne.Position) string {
+	if !t.canDragReorder(data) {
+		return ""
+	}
+	listPos := pos.Subtract(t.list.Position())
+	autoScrollList(t.list, listPos.Y)
+	t.dropInsertIdx = t.list.InsertIndexAtPosition(listPos)
+	t.dropIndicator.ShowAt(t.list.Position().Y+t.list.InsertLineY(t.dropInsertIdx), t.Size().Width)
+	return "Move"
+}
+
+func (t *Tracklist) DragLeave() {
+	t.dropIndicator.Hide()
+}
+
+func (t *Tracklist) Drop(data *DragData, pos fyne.Position) {
+	t.dropIndicator.Hide()
+	if t.canDragReorder(data) && !isNoopMove(t.dragTrackIdxs, t.dropInsertIdx) {
+		t.OnMoveTracks(t.dragTrackIdxs, t.dropInsertIdx)
+	}
+}
+
 func (t *Tracklist) onSetFavorite(trackID string, fav bool) {
 	t.tracksMutex.RLock()
 	tr, _ := util.FindTrackByID(t.tracks, trackID)
@@ -675,12 +816,124 @@ func (t *Tracklist) SelectedTrackIDs() []string {
 	return util.SelectedTrackIDs(t.tracks)
 }
 
-func (t *Tracklist) lenTracks() int {
+func (t *Tracklist) lenRows() int {
 	t.tracksMutex.RLock()
 	defer t.tracksMutex.RUnlock()
+	if t.rows != nil {
+		return len(t.rows)
+	}
 	return len(t.tracks)
 }
 
+// buildRows rebuilds the disc-grouped display rows, or clears them
+// if the tracks should not be grouped. Must be called with tracksMutex held.
+func (t *Tracklist) buildRows() {
+	t.rows = nil
+	origOrder := t.sorting.SortOrder == SortNone ||
+		(t.sorting.ColumnName == ColumnNum && t.sorting.SortOrder == SortAscending)
+	if !t.Options.GroupByDisc || !origOrder || len(t.tracks) == 0 ||
+		t.tracks[0].Track.DiscNumber == t.tracks[len(t.tracks)-1].Track.DiscNumber {
+		return
+	}
+	rows := make([]tracklistRow, 0, len(t.tracks)+4)
+	curDisc := -1
+	for i, tm := range t.tracks {
+		disc := tm.Track.DiscNumber
+		if disc != curDisc {
+			curDisc = disc
+			rows = append(rows, tracklistRow{trackIdx: -1, discNum: disc})
+		}
+		if !t.collapsedDiscs[disc] {
+			rows = append(rows, tracklistRow{trackIdx: i, discNum: disc})
+		}
+	}
+	t.rows = rows
+}
+
+func (t *Tracklist) isGroupedByDisc() bool {
+	t.tracksMutex.RLock()
+	defer t.tracksMutex.RUnlock()
+	return t.rows != nil
+}
+
+// Must be called with tracksMutex held.
+func (t *Tracklist) rowAt(rowIdx int) (tracklistRow, bool) {
+	if t.rows == nil {
+		if rowIdx >= len(t.tracks) {
+			return tracklistRow{}, false
+		}
+		return tracklistRow{trackIdx: rowIdx, discNum: t.tracks[rowIdx].Track.DiscNumber}, true
+	}
+	if rowIdx >= len(t.rows) {
+		return tracklistRow{}, false
+	}
+	return t.rows[rowIdx], true
+}
+
+// Returns the index into tracks of the track shown at the given list row,
+// or -1 if the row is a disc header.
+func (t *Tracklist) trackIdxForRow(rowIdx int) int {
+	t.tracksMutex.RLock()
+	defer t.tracksMutex.RUnlock()
+	row, ok := t.rowAt(rowIdx)
+	if !ok {
+		return -1
+	}
+	return row.trackIdx
+}
+
+// Returns the list row showing the track at the given index,
+// or -1 if not shown (in a collapsed disc). Must be called with tracksMutex held.
+func (t *Tracklist) rowForTrackIdx(trackIdx int) int {
+	if t.rows == nil || trackIdx < 0 {
+		return trackIdx
+	}
+	return slices.IndexFunc(t.rows, func(r tracklistRow) bool {
+		return r.trackIdx == trackIdx
+	})
+}
+
+// Must be called with tracksMutex held.
+func (t *Tracklist) discTracks(discNum int) []*mediaprovider.Track {
+	return sharedutil.FilterMapSlice(t.tracks, func(tm *util.TrackListModel) (*mediaprovider.Track, bool) {
+		return tm.Track, tm.Track.DiscNumber == discNum
+	})
+}
+
+func (t *Tracklist) toggleDiscCollapsed(discNum int) {
+	t.tracksMutex.Lock()
+	if t.collapsedDiscs == nil {
+		t.collapsedDiscs = make(map[int]bool)
+	}
+	if t.collapsedDiscs[discNum] {
+		delete(t.collapsedDiscs, discNum)
+	} else {
+		t.collapsedDiscs[discNum] = true
+	}
+	t.buildRows()
+	t.list.ClearItemForIDMap()
+	t.tracksMutex.Unlock()
+	t.list.Refresh()
+}
+
+func (t *Tracklist) onPlayDisc(discNum int, shuffle bool) {
+	t.tracksMutex.RLock()
+	tracks := t.discTracks(discNum)
+	t.tracksMutex.RUnlock()
+	if t.OnPlaySelection != nil && len(tracks) > 0 {
+		t.OnPlaySelection(tracks, shuffle)
+	}
+}
+
+func (t *Tracklist) onAddDiscToQueue(discNum int) {
+	t.tracksMutex.RLock()
+	tracks := t.discTracks(discNum)
+	t.tracksMutex.RUnlock()
+	if t.OnAddToQueue != nil && len(tracks) > 0 {
+		t.OnAddToQueue(tracks)
+	}
+}
+
 func ColNumber(colName string) int {
 	i := slices.Index(columns, colName)
 	if i < 0 {
@@ -703,6 +956,7 @@ type TrackRow struct {
 	// internal state
 	tracklist  *Tracklist
 	trackNum   int
+	showDisc   bool
 	trackID    string
 	isPlaying  bool
 	isFavorite bool
@@ -711,6 +965,7 @@ type TrackRow struct {
 	num      *widget.Label
 	name     *widget.RichText // for bold support
 	artist   *MultiHyperlink
+	composer *widget.Label
 	album    *MultiHyperlink // for disabled support, if albumID is ""
 	dur      *widget.Label
 	year     *widget.Label
@@ -734,6 +989,7 @@ func NewTrackRow(tracklist *Tracklist, playingIcon fyne.CanvasObject) *TrackRow
 	t.name = util.NewTruncatingRichText()
 	t.artist = NewMultiHyperlink()
 	t.artist.OnTapped = tracklist.onArtistTapped
+	t.composer = util.NewTruncatingLabel()
 	t.album = NewMultiHyperlink()
 	t.album.OnTapped = func(id string) { tracklist.onAlbumTapped(id) }
 	t.dur = util.NewTrailingAlignLabel()
@@ -752,7 +1008,7 @@ func NewTrackRow(tracklist *Tracklist, playingIcon fyne.CanvasObject) *TrackRow
 	t.path = util.NewTruncatingLabel()
 
 	t.Content = container.New(tracklist.colLayout,
-		t.num, t.name, t.artist, t.album, t.dur, t.year, t.favorite, t.rating, t.plays, t.comment, t.bitrate, t.size, t.path)
+		t.num, t.name, t.artist, t.composer, t.album, t.dur, t.year, t.favorite, t.rating, t.plays, t.comment, t.bitrate, t.size, t.path)
 	return t
 }
 
@@ -772,6 +1028,7 @@ func (t *TrackRow) Update(tm *util.TrackListModel, rowNum int) {
 
 		t.name.Segments[0].(*widget.TextSegment).Text = tr.Name
 		t.artist.BuildSegments(tr.ArtistNames, tr.ArtistIDs)
+		t.composer.Text = strings.Join(tr.ComposerNames, ", ")
 		t.album.BuildSegments([]string{tr.Album}, []string{tr.AlbumID})
 		t.dur.Text = util.SecondsToTimeString(float64(tr.Duration))
 		t.year.Text = strconv.Itoa(tr.Year)
@@ -785,16 +1042,19 @@ func (t *TrackRow) Update(tm *util.TrackListModel, rowNum int) {
 
 	// Update track num if needed
 	// (which can change based on bound *mediaprovider.Track or tracklist.AutoNumber)
-	if t.trackNum != rowNum {
+	// (the disc number is redundant when shown under a disc header)
+	showDisc := t.tracklist.Options.ShowDiscNumber && !t.tracklist.isGroupedByDisc()
+	if t.trackNum != rowNum || t.showDisc != showDisc {
 		discNum := -1
 		var str string
 		if rowNum < 0 {
 			rowNum = tr.TrackNumber
-			if t.tracklist.Options.ShowDiscNumber {
+			if showDisc {
 				discNum = tr.DiscNumber
 			}
 		}
 		t.trackNum = rowNum
+		t.showDisc = showDisc
 		if discNum >= 0 {
 			str = fmt.Sprintf("%d.%02d", discNum, rowNum)
 		} else {
@@ -850,6 +1110,7 @@ func (t *TrackRow) Update(tm *util.TrackListModel, rowNum int) {
 		}
 	}
 	updateHidden(&t.artist.Hidden, ColumnArtist)
+	updateHidden(&t.composer.Hidden, ColumnComposer)
 	updateHidden(&t.album.Hidden, ColumnAlbum)
 	updateHidden(&t.dur.Hidden, ColumnTime)
 	updateHidden(&t.year.Hidden, ColumnYear)